@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package analytics computes per-conversation statistics from stored histories and traces,
+// eg. turns, tool calls, latency, prompt token growth and where users abandon conversations.
+//
+//	trace, err := chat.ReadTrace(f)
+//	stats := analytics.Analyze(id, conv.Messages, trace)
+//	summary := analytics.Summarize(allStats)
+//	err = analytics.WriteCSV(os.Stdout, allStats)
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Stats is the statistics of a conversation.
+type Stats struct {
+	ConversationID string `json:"conversation_id"`
+	// Turns is the number of human messages.
+	Turns    int `json:"turns"`
+	Messages int `json:"messages"`
+	// ToolCalls is the number of tool calls, and Tools counts them by tool name.
+	ToolCalls int            `json:"tool_calls"`
+	Tools     map[string]int `json:"tools,omitempty"`
+	// Generations and Errors are the numbers of generations and failed generations in the trace.
+	Generations int `json:"generations"`
+	Errors      int `json:"errors"`
+	// AverageLatency of the generations in the trace.
+	AverageLatency time.Duration `json:"average_latency"`
+	// TokenGrowth is the prompt tokens of each generation by the trace usage,
+	// or estimated at each AI turn of the history without the trace usage.
+	TokenGrowth []int `json:"token_growth"`
+	// AbandonedAt is the human turn the conversation ended at.
+	AbandonedAt int `json:"abandoned_at"`
+	// Unanswered is true if the conversation ended with a human message without a response
+	// or with a failed generation.
+	Unanswered bool `json:"unanswered,omitempty"`
+}
+
+// Analyze computes the statistics of the conversation from the history and the trace.
+// Either may be nil. The trace should have the events of the conversation only.
+func Analyze(id string, messages []chat.Message, trace *chat.Trace) *Stats {
+	s := &Stats{ConversationID: id, Messages: len(messages), Tools: map[string]int{}}
+	for i, msg := range messages {
+		switch {
+		case msg.Role == chat.MessageRoleHuman:
+			s.Turns++
+		case msg.IsToolCall():
+			s.countTool(msg.ToolCall.Name)
+		case msg.Role == chat.MessageRoleAI:
+			s.TokenGrowth = append(s.TokenGrowth, chat.EstimateTokens(&chat.Request{Messages: messages[:i]}))
+		}
+	}
+	s.AbandonedAt = s.Turns
+	s.Unanswered = len(messages) > 0 && messages[len(messages)-1].Role == chat.MessageRoleHuman
+
+	if trace != nil {
+		s.analyzeTrace(trace, len(messages) == 0)
+	}
+	if len(s.Tools) == 0 {
+		s.Tools = nil
+	}
+	return s
+}
+
+func (s *Stats) countTool(name string) {
+	s.ToolCalls++
+	s.Tools[name]++
+}
+
+// analyzeTrace adds the generations, latency and usage of the trace.
+// Tool calls are counted from the trace if the history is empty.
+func (s *Stats) analyzeTrace(trace *chat.Trace, countTools bool) {
+	var (
+		start   time.Time
+		total   time.Duration
+		growth  []int
+		lastErr bool
+	)
+	trace.Replay(func(ev *chat.Event) {
+		switch ev.Type {
+		case chat.EventRequest:
+			start = ev.Time
+		case chat.EventFinal, chat.EventError:
+			s.Generations++
+			if !start.IsZero() {
+				total += ev.Time.Sub(start)
+				start = time.Time{}
+			}
+			lastErr = ev.Type == chat.EventError
+			if lastErr {
+				s.Errors++
+			} else if ev.Response != nil && ev.Response.Usage != nil {
+				growth = append(growth, ev.Response.Usage.InputTokens)
+			}
+		case chat.EventToolCall:
+			if countTools && ev.ToolCall != nil {
+				s.countTool(ev.ToolCall.Name)
+			}
+		}
+	})
+	if s.Generations > 0 {
+		s.AverageLatency = total / time.Duration(s.Generations)
+	}
+	if len(growth) > 0 {
+		s.TokenGrowth = growth
+	}
+	s.Unanswered = s.Unanswered || lastErr
+}
+
+// Summary is the aggregated statistics of conversations.
+type Summary struct {
+	Conversations    int           `json:"conversations"`
+	AverageTurns     float64       `json:"average_turns"`
+	AverageToolCalls float64       `json:"average_tool_calls"`
+	AverageLatency   time.Duration `json:"average_latency"`
+	// Unanswered is the number of the unanswered conversations.
+	Unanswered int `json:"unanswered"`
+	// Abandonment counts the conversations by the human turn they ended at.
+	Abandonment map[int]int `json:"abandonment"`
+	// TokenGrowth is the average prompt tokens by generation over the conversations reaching it.
+	TokenGrowth []float64 `json:"token_growth"`
+}
+
+// Summarize aggregates the statistics of the conversations.
+// AverageLatency is weighted by the generations.
+func Summarize(stats []*Stats) *Summary {
+	sum := &Summary{Conversations: len(stats), Abandonment: map[int]int{}}
+	if len(stats) == 0 {
+		return sum
+	}
+	var (
+		turns, toolCalls, generations int
+		latency                       time.Duration
+		growth                        []int
+		reached                       []int
+	)
+	for _, s := range stats {
+		turns += s.Turns
+		toolCalls += s.ToolCalls
+		generations += s.Generations
+		latency += s.AverageLatency * time.Duration(s.Generations)
+		sum.Abandonment[s.AbandonedAt]++
+		if s.Unanswered {
+			sum.Unanswered++
+		}
+		for i, tokens := range s.TokenGrowth {
+			if i == len(growth) {
+				growth = append(growth, 0)
+				reached = append(reached, 0)
+			}
+			growth[i] += tokens
+			reached[i]++
+		}
+	}
+	sum.AverageTurns = float64(turns) / float64(len(stats))
+	sum.AverageToolCalls = float64(toolCalls) / float64(len(stats))
+	if generations > 0 {
+		sum.AverageLatency = latency / time.Duration(generations)
+	}
+	sum.TokenGrowth = make([]float64, len(growth))
+	for i := range growth {
+		sum.TokenGrowth[i] = float64(growth[i]) / float64(reached[i])
+	}
+	return sum
+}
+
+// WriteCSV writes the statistics as CSV with a header row, one conversation per row.
+// The token growth is joined with ";" and the latency is in milliseconds.
+func WriteCSV(w io.Writer, stats []*Stats) error {
+	header := []string{
+		"conversation_id", "turns", "messages", "tool_calls", "generations", "errors",
+		"average_latency_ms", "token_growth", "abandoned_at", "unanswered",
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	for _, s := range stats {
+		growth := make([]string, len(s.TokenGrowth))
+		for i, tokens := range s.TokenGrowth {
+			growth[i] = strconv.Itoa(tokens)
+		}
+		row := []string{
+			s.ConversationID,
+			strconv.Itoa(s.Turns),
+			strconv.Itoa(s.Messages),
+			strconv.Itoa(s.ToolCalls),
+			strconv.Itoa(s.Generations),
+			strconv.Itoa(s.Errors),
+			strconv.FormatInt(s.AverageLatency.Milliseconds(), 10),
+			strings.Join(growth, ";"),
+			strconv.Itoa(s.AbandonedAt),
+			strconv.FormatBool(s.Unanswered),
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package analytics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func history() []chat.Message {
+	return []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleHuman, "What's the weather in Tokyo?"),
+		chat.NewToolCallMessage("weather", "1", `{"city":"Tokyo"}`),
+		chat.NewToolResponseMessage("weather", "1", "sunny"),
+		chat.NewTextMessage(chat.MessageRoleAI, "It's sunny."),
+		chat.NewTextMessage(chat.MessageRoleHuman, "And tomorrow?"),
+	}
+}
+
+func TestAnalyzeHistory(t *testing.T) {
+	s := Analyze("c1", history(), nil)
+	if s.Turns != 2 || s.Messages != 5 || s.ToolCalls != 1 || s.Tools["weather"] != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+	if s.AbandonedAt != 2 || !s.Unanswered || len(s.TokenGrowth) != 1 || s.TokenGrowth[0] == 0 {
+		t.Errorf("abandonment = %d, unanswered = %v, growth = %v", s.AbandonedAt, s.Unanswered, s.TokenGrowth)
+	}
+}
+
+func TestAnalyzeTrace(t *testing.T) {
+	start := time.Unix(0, 0)
+	trace := chat.NewTrace()
+	emit := trace.Handler()
+	emit(&chat.Event{Type: chat.EventRequest, Time: start})
+	emit(&chat.Event{Type: chat.EventToolCall, Time: start, ToolCall: &chat.ToolCall{Name: "weather"}})
+	emit(&chat.Event{Type: chat.EventFinal, Time: start.Add(time.Second), Response: &chat.Response{Usage: &chat.Usage{InputTokens: 100}}})
+	emit(&chat.Event{Type: chat.EventRequest, Time: start.Add(5 * time.Second)})
+	emit(&chat.Event{Type: chat.EventFinal, Time: start.Add(8 * time.Second), Response: &chat.Response{Usage: &chat.Usage{InputTokens: 180}}})
+
+	s := Analyze("c1", history()[:4], trace)
+	if s.Generations != 2 || s.Errors != 0 || s.AverageLatency != 2*time.Second || s.Unanswered {
+		t.Errorf("stats = %+v", s)
+	}
+	if len(s.TokenGrowth) != 2 || s.TokenGrowth[1] != 180 {
+		t.Errorf("growth = %v", s.TokenGrowth)
+	}
+	// tool calls are counted from the history, not twice from the trace.
+	if s.ToolCalls != 1 {
+		t.Errorf("tool calls = %d", s.ToolCalls)
+	}
+
+	emit(&chat.Event{Type: chat.EventRequest, Time: start.Add(10 * time.Second)})
+	emit(&chat.Event{Type: chat.EventError, Time: start.Add(11 * time.Second)})
+	s = Analyze("c1", nil, trace)
+	if s.Generations != 3 || s.Errors != 1 || !s.Unanswered || s.ToolCalls != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	stats := []*Stats{
+		{Turns: 2, ToolCalls: 1, Generations: 1, AverageLatency: time.Second, AbandonedAt: 2, TokenGrowth: []int{100, 200}},
+		{Turns: 4, ToolCalls: 3, Generations: 3, AverageLatency: 3 * time.Second, AbandonedAt: 4, Unanswered: true, TokenGrowth: []int{300}},
+	}
+	sum := Summarize(stats)
+	if sum.Conversations != 2 || sum.AverageTurns != 3 || sum.AverageToolCalls != 2 || sum.Unanswered != 1 {
+		t.Errorf("summary = %+v", sum)
+	}
+	if sum.AverageLatency != 2500*time.Millisecond {
+		t.Errorf("latency = %v", sum.AverageLatency)
+	}
+	if sum.Abandonment[2] != 1 || sum.Abandonment[4] != 1 {
+		t.Errorf("abandonment = %v", sum.Abandonment)
+	}
+	if len(sum.TokenGrowth) != 2 || sum.TokenGrowth[0] != 200 || sum.TokenGrowth[1] != 200 {
+		t.Errorf("growth = %v", sum.TokenGrowth)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	stats := []*Stats{{ConversationID: "c1", Turns: 2, AverageLatency: 1500 * time.Millisecond, TokenGrowth: []int{100, 200}, AbandonedAt: 2}}
+	if err := WriteCSV(&buf, stats); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[1] != "c1,2,0,0,0,0,1500,100;200,2,false" {
+		t.Errorf("csv = %q", buf.String())
+	}
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+const (
+	cacheTTL5m = "5m"
+	cacheTTL1h = "1h"
+	// extendedCacheTTLBeta is the beta flag required for 1 hour cache TTL.
+	extendedCacheTTLBeta = "extended-cache-ttl-2025-04-11"
+	// maxCacheBreakpoints is the maximum number of cache breakpoints in a request.
+	maxCacheBreakpoints = 4
+)
+
+// setCacheBreakpoint sets cache control to the last content block,
+// so the whole conversation prefix is cached.
+func setCacheBreakpoint(messages []anthropic.MessageParam, system []anthropic.TextBlockParam, ttl string) {
+	if ttl == "" {
+		return
+	}
+	switch {
+	case len(messages) > 0:
+		markCacheBreakpoint(&messages[len(messages)-1], ttl)
+	case len(system) > 0:
+		setCacheControl(&system[len(system)-1].CacheControl, ttl)
+	}
+}
+
+// setCacheBreakpoints sets cache control to the last content blocks of the request messages of the indexes.
+// positions are the indexes of the request messages in messages, or -1 for system messages,
+// which set cache control to the system blocks as they precede the messages.
+func setCacheBreakpoints(messages []anthropic.MessageParam, system []anthropic.TextBlockParam, positions, indexes []int, ttl string) error {
+	if len(indexes) > maxCacheBreakpoints {
+		return fmt.Errorf("too many cache breakpoints: %d > %d", len(indexes), maxCacheBreakpoints)
+	}
+	if ttl == "" {
+		ttl = cacheTTL5m
+	}
+	n := len(positions)
+	for _, i := range indexes {
+		if i < 0 {
+			i += n
+		}
+		if i < 0 || i >= n {
+			return fmt.Errorf("cache breakpoint out of range: %d", i)
+		}
+		if positions[i] < 0 {
+			setCacheControl(&system[len(system)-1].CacheControl, ttl)
+			continue
+		}
+		markCacheBreakpoint(&messages[positions[i]], ttl)
+	}
+	return nil
+}
+
+func markCacheBreakpoint(message *anthropic.MessageParam, ttl string) {
+	content := message.Content
+	if len(content) == 0 {
+		return
+	}
+	cacheControl := content[len(content)-1].GetCacheControl()
+	if cacheControl == nil {
+		return
+	}
+	setCacheControl(cacheControl, ttl)
+}
+
+func setCacheControl(cacheControl *anthropic.CacheControlEphemeralParam, ttl string) {
+	// the zero value is omitted, so the type is set explicitly.
+	*cacheControl = anthropic.CacheControlEphemeralParam{Type: "ephemeral"}
+	if ttl == cacheTTL1h {
+		cacheControl.WithExtraFields(map[string]any{"ttl": ttl})
+	}
+}
+
+// convertUsage converts usage with cache tokens included in input tokens.
+func convertUsage(usage anthropic.Usage, ttl string) *chat.Usage {
+	input := int(usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens)
+	u := &chat.Usage{
+		InputTokens:         input,
+		OutputTokens:        int(usage.OutputTokens),
+		CachedTokens:        int(usage.CacheReadInputTokens),
+		CacheCreationTokens: int(usage.CacheCreationInputTokens),
+		TotalTokens:         input + int(usage.OutputTokens),
+	}
+	if ttl == cacheTTL1h {
+		u.CacheCreation1hTokens = u.CacheCreationTokens
+	}
+	return u
+}
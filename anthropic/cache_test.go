@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestSetCacheBreakpoint(t *testing.T) {
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("long document")),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("question"), anthropic.NewTextBlock("details")),
+	}
+	setCacheBreakpoint(messages, nil, "1h")
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), `"cache_control"`) != 1 {
+		t.Errorf("expected one cache breakpoint: %s", data)
+	}
+	if !strings.Contains(string(data), `"text":"details","cache_control":{"type":"ephemeral","ttl":"1h"}`) {
+		t.Errorf("cache breakpoint should be on the last block with ttl: %s", data)
+	}
+}
+
+func TestSetCacheBreakpoints(t *testing.T) {
+	messages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("schema prompt")),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("example")),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("answer")),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("question")),
+	}
+	system := convertSystem([]string{"long instructions"})
+	// the request messages are system, example, answer and question.
+	positions := []int{-1, 1, 2, 3}
+	if err := setCacheBreakpoints(messages, system, positions, []int{0, -2}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(map[string]any{"system": system, "messages": messages})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), `"cache_control":{"type":"ephemeral"}`) != 2 {
+		t.Errorf("expected two cache breakpoints: %s", data)
+	}
+	for _, text := range []string{"long instructions", "answer"} {
+		if !strings.Contains(string(data), `"text":"`+text+`","cache_control"`) {
+			t.Errorf("cache breakpoint should be on %q: %s", text, data)
+		}
+	}
+
+	if err := setCacheBreakpoints(messages, system, positions, []int{4}, ""); err == nil {
+		t.Error("expected out of range error")
+	}
+	if err := setCacheBreakpoints(messages, system, positions, []int{0, 1, 2, 3, -1}, ""); err == nil {
+		t.Error("expected too many breakpoints error")
+	}
+}
+
+func TestConvertUsage(t *testing.T) {
+	usage := convertUsage(anthropic.Usage{
+		InputTokens:              100,
+		CacheCreationInputTokens: 500,
+		CacheReadInputTokens:     400,
+		OutputTokens:             50,
+	}, "1h")
+
+	if usage.InputTokens != 1000 || usage.CachedTokens != 400 || usage.CacheCreationTokens != 500 {
+		t.Errorf("usage mismatch: %+v", usage)
+	}
+	if usage.CacheCreation1hTokens != 500 || usage.TotalTokens != 1050 {
+		t.Errorf("usage mismatch: %+v", usage)
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// convertDocumentPart converts a file part, a PDF or plain text data URL or a PDF URL, to a document block.
+func convertDocumentPart(part *chat.ContentPart) (anthropic.ContentBlockParamUnion, error) {
+	doc := &anthropic.DocumentBlockParam{}
+	switch {
+	case part.URL != "":
+		if !strings.HasPrefix(part.URL, "https://") {
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("URL source must be https: %s", part.URL)
+		}
+		doc.Source.OfUrlpdfSource = &anthropic.URLPDFSourceParam{URL: part.URL}
+	default:
+		data, mimeType, err := chat.DecodeDataURL(part.DataURL)
+		if err != nil {
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("decode document data URL: %w", err)
+		}
+		switch {
+		case mimeType == "application/pdf":
+			_, encoded, _ := chat.SplitDataURL(part.DataURL)
+			doc.Source.OfBase64PDFSource = &anthropic.Base64PDFSourceParam{Data: encoded}
+		case strings.HasPrefix(mimeType, "text/"):
+			doc.Source.OfPlainTextSource = &anthropic.PlainTextSourceParam{Data: string(data)}
+		default:
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("document type is not supported: %s", mimeType)
+		}
+	}
+	if part.Title != "" {
+		doc.Title = anthropic.String(part.Title)
+	}
+	if part.EnableCitations {
+		doc.Citations = anthropic.CitationsConfigParam{Enabled: anthropic.Bool(true)}
+	}
+	return anthropic.ContentBlockParamUnion{OfRequestDocumentBlock: doc}, nil
+}
+
+func convertCitation(c anthropic.TextCitationUnion) chat.Citation {
+	citation := chat.Citation{
+		Type:          c.Type,
+		CitedText:     c.CitedText,
+		DocumentIndex: int(c.DocumentIndex),
+		DocumentTitle: c.DocumentTitle,
+	}
+	switch c.Type {
+	case "char_location":
+		citation.Start, citation.End = int(c.StartCharIndex), int(c.EndCharIndex)
+	case "page_location":
+		citation.Start, citation.End = int(c.StartPageNumber), int(c.EndPageNumber)
+	case "content_block_location":
+		citation.Start, citation.End = int(c.StartBlockIndex), int(c.EndBlockIndex)
+	}
+	return citation
+}
+
+func convertCitations(citations []anthropic.TextCitationUnion) []chat.Citation {
+	if len(citations) == 0 {
+		return nil
+	}
+	out := make([]chat.Citation, len(citations))
+	for i, c := range citations {
+		out[i] = convertCitation(c)
+	}
+	return out
+}
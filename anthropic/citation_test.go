@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestConvertDocumentPart(t *testing.T) {
+	part := chat.NewTextDocumentPart("Manual", "The grass is green.")
+	block, err := convertDocumentPart(&part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := block.OfRequestDocumentBlock
+	if doc == nil || doc.Source.OfPlainTextSource == nil || doc.Source.OfPlainTextSource.Data != "The grass is green." {
+		t.Fatalf("document mismatch: %+v", block)
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(js, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["title"] != "Manual" || got["citations"].(map[string]any)["enabled"] != true {
+		t.Errorf("document params mismatch: %s", js)
+	}
+
+	part = chat.ContentPart{Type: "file", DataURL: chat.EncodeDataURL("image/png", []byte("png"))}
+	if _, err := convertDocumentPart(&part); err == nil {
+		t.Error("expected error for unsupported document type")
+	}
+}
+
+func TestMessageToResponseCitations(t *testing.T) {
+	var message anthropic.Message
+	err := json.Unmarshal([]byte(`{
+		"content": [
+			{"type": "text", "text": "According to the manual, "},
+			{"type": "text", "text": "the grass is green", "citations": [{
+				"type": "char_location", "cited_text": "The grass is green.",
+				"document_index": 0, "document_title": "Manual",
+				"start_char_index": 0, "end_char_index": 20
+			}]}
+		],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 10, "output_tokens": 5}
+	}`), &message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := messageToResponse(&message, "")
+	citations := resp.Citations()
+	if len(citations) != 1 {
+		t.Fatalf("citations mismatch: %+v", citations)
+	}
+	want := chat.Citation{Type: "char_location", CitedText: "The grass is green.", DocumentTitle: "Manual", Start: 0, End: 20}
+	if citations[0] != want {
+		t.Errorf("citation = %+v, want %+v", citations[0], want)
+	}
+	if resp.Messages[1].Content[0].Citations == nil || resp.Messages[0].Content[0].Citations != nil {
+		t.Error("citations should be attached to the cited text")
+	}
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// PartConverter converts a content part to an Anthropic content block.
+type PartConverter func(part *chat.ContentPart) (anthropic.ContentBlockParamUnion, error)
+
+var (
+	partConvertersMu sync.RWMutex
+	partConverters   = map[string]PartConverter{}
+)
+
+// RegisterPartConverter registers a converter for the content part type, eg. "latex".
+// It overrides the built-in conversion of the type.
+func RegisterPartConverter(partType string, fn PartConverter) {
+	partConvertersMu.Lock()
+	defer partConvertersMu.Unlock()
+	partConverters[partType] = fn
+}
+
+func lookupPartConverter(partType string) (PartConverter, bool) {
+	partConvertersMu.RLock()
+	defer partConvertersMu.RUnlock()
+	fn, ok := partConverters[partType]
+	return fn, ok
+}
@@ -0,0 +1,29 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestRegisterPartConverter(t *testing.T) {
+	RegisterPartConverter("latex", func(part *chat.ContentPart) (anthropic.ContentBlockParamUnion, error) {
+		return anthropic.NewTextBlock("$" + part.Text + "$"), nil
+	})
+	defer delete(partConverters, "latex")
+
+	blocks, err := convertContentPart(&chat.Message{
+		Role:    chat.MessageRoleHuman,
+		Content: []chat.ContentPart{{Type: "latex", Text: "x^2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].OfRequestTextBlock.Text != "$x^2$" {
+		t.Errorf("converted block mismatch: %+v", blocks)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
@@ -22,39 +22,41 @@ Please respond with json in the following json_schema:
 Make sure to return an instance of the JSON, not the schema itself.
 `
 
+const jsonOutputPrompt = `Please respond with a single JSON object only, without any other text.`
+
 func Generate(ctx context.Context, r *chat.Request, opts ...chat.Option) (*chat.Response, error) {
 	opt := chat.NewOptions(opts...)
 
-	options := []option.RequestOption{option.WithAPIKey(os.Getenv("ANTHROPIC_API_KEY"))}
+	apiKey, err := opt.ResolveAPIKey(ctx, "anthropic")
+	if err != nil {
+		return nil, err
+	}
+	// Retries are handled by gengo.Generate with the provider profile.
+	options := []option.RequestOption{option.WithAPIKey(apiKey), option.WithMaxRetries(0)}
 	if opt.BaseURL != "" {
 		options = append(options, option.WithBaseURL(opt.BaseURL))
 	}
+	if opt.AnthropicCacheTTL == cacheTTL1h {
+		options = append(options, option.WithHeaderAdd("anthropic-beta", extendedCacheTTLBeta))
+	}
+	if httpClient := opt.HTTPClient("anthropic"); httpClient != nil {
+		options = append(options, option.WithHTTPClient(httpClient))
+	}
 
 	client := anthropic.NewClient(options...)
 
-	messages := []anthropic.MessageParam{}
-	if r.ResponseSchema != nil {
-		messages = append(messages,
-			anthropic.NewUserMessage(anthropic.NewTextBlock(fmt.Sprintf(structuredOutputPrompt, string(r.ResponseSchema.JSON())))))
-	}
-	for _, msg := range r.Messages {
-		param, err := convertMessage(&msg)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert message: %w", err)
-		}
-		messages = append(messages, param)
+	params, err := buildParams(r, opt)
+	if err != nil {
+		return nil, err
 	}
 
-	params := convertChatRequest(r, messages)
-
-	// tool call will not use stream for simplicity
-	if opt.Streamer != nil && len(params.Tools) == 0 {
-		resp, err := handleStreaming(ctx, client, params, opt.Streamer)
+	if opt.Streamer != nil {
+		resp, err := handleStreaming(ctx, client, params, opt)
 		if err != nil {
 			return nil, fmt.Errorf("streaming error: %w", err)
 		}
-		opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
-		return resp, nil
+		opt.CalculateCost(r.Model, resp.Usage)
+		return structuredResponse(r, &params, resp)
 	}
 
 	message, err := client.Messages.New(ctx, params)
@@ -62,9 +64,20 @@ func Generate(ctx context.Context, r *chat.Request, opts ...chat.Option) (*chat.
 		return nil, fmt.Errorf("anthropic message creation error: %w", err)
 	}
 
-	resp := messageToResponse(message)
+	resp := messageToResponse(message, opt.AnthropicCacheTTL)
 	resp.Model = r.Model
-	opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
+	opt.CalculateCost(r.Model, resp.Usage)
+	return structuredResponse(r, &params, resp)
+}
+
+// structuredResponse returns the response with the output of the response tool if forced.
+func structuredResponse(r *chat.Request, params *anthropic.MessageNewParams, resp *chat.Response) (*chat.Response, error) {
+	if !forcesResponseTool(params) {
+		return resp, nil
+	}
+	if err := responseToolOutput(r, resp); err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
 
@@ -134,7 +147,9 @@ func convertMessage(msg *chat.Message) (anthropic.MessageParam, error) {
 		}
 		blocks = append(blocks, anthropic.ContentBlockParamUnion{OfRequestToolUseBlock: &block})
 	default:
-		blks, err := convertContentPart(msg)
+		named := *msg
+		named.Content = msg.NamedContent()
+		blks, err := convertContentPart(&named)
 		if err != nil {
 			return anthropic.MessageParam{}, fmt.Errorf("convert content part: %w", err)
 		}
@@ -147,7 +162,7 @@ func convertMessage(msg *chat.Message) (anthropic.MessageParam, error) {
 
 	switch msg.Role {
 	case chat.MessageRoleSystem:
-		return anthropic.NewUserMessage(anthropic.NewTextBlock("system: " + msg.Content[0].Text)), nil
+		return anthropic.MessageParam{}, fmt.Errorf("system message must be converted to the system parameter")
 	case chat.MessageRoleHuman:
 		return anthropic.NewUserMessage(blocks...), nil
 	case chat.MessageRoleAI:
@@ -162,10 +177,32 @@ func convertMessage(msg *chat.Message) (anthropic.MessageParam, error) {
 func convertContentPart(msg *chat.Message) ([]anthropic.ContentBlockParamUnion, error) {
 	blocks := []anthropic.ContentBlockParamUnion{}
 	for _, part := range msg.Content {
+		if fn, ok := lookupPartConverter(part.Type); ok {
+			block, err := fn(&part)
+			if err != nil {
+				return nil, fmt.Errorf("convert %s part: %w", part.Type, err)
+			}
+			blocks = append(blocks, block)
+			continue
+		}
 		switch part.Type {
 		case "text":
 			blocks = append(blocks, anthropic.NewTextBlock(part.Text))
+		case "file":
+			block, err := convertDocumentPart(&part)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
 		case "image":
+			if part.URL != "" {
+				block, err := convertURLPart(&part)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, block)
+				continue
+			}
 			if !chat.IsDataURL(part.DataURL) {
 				return nil, fmt.Errorf("invalid image data URL: %s", part.DataURL)
 			}
@@ -175,11 +212,27 @@ func convertContentPart(msg *chat.Message) ([]anthropic.ContentBlockParamUnion,
 				return nil, fmt.Errorf("split image data URL: %w", err)
 			}
 			blocks = append(blocks, anthropic.NewImageBlockBase64(mimeType, encodedData))
+		case "uri":
+			return nil, fmt.Errorf("uri part is not supported: %s", part.URL)
 		}
 	}
 	return blocks, nil
 }
 
+// convertURLPart converts an image part with an HTTPS URL to a URL source block.
+func convertURLPart(part *chat.ContentPart) (anthropic.ContentBlockParamUnion, error) {
+	if !strings.HasPrefix(part.URL, "https://") {
+		return anthropic.ContentBlockParamUnion{}, fmt.Errorf("URL source must be https: %s", part.URL)
+	}
+	return anthropic.ContentBlockParamUnion{
+		OfRequestImageBlock: &anthropic.ImageBlockParam{
+			Source: anthropic.ImageBlockParamSourceUnion{
+				OfURLImageSource: &anthropic.URLImageSourceParam{URL: part.URL},
+			},
+		},
+	}, nil
+}
+
 func convertFinishReason(reason anthropic.MessageStopReason) chat.FinishReason {
 	switch reason {
 	case anthropic.MessageStopReasonEndTurn,
@@ -194,13 +247,15 @@ func convertFinishReason(reason anthropic.MessageStopReason) chat.FinishReason {
 	}
 }
 
-func messageToResponse(message *anthropic.Message) *chat.Response {
+func messageToResponse(message *anthropic.Message, ttl string) *chat.Response {
 	messages := []chat.Message{}
 
 	for _, block := range message.Content {
 		switch block := block.AsAny().(type) {
 		case anthropic.TextBlock:
-			messages = append(messages, chat.NewTextMessage(chat.MessageRoleAI, block.Text))
+			msg := chat.NewTextMessage(chat.MessageRoleAI, block.Text)
+			msg.Content[0].Citations = convertCitations(block.Citations)
+			messages = append(messages, msg)
 		case anthropic.ToolUseBlock:
 			toolCall := chat.NewToolCallMessage(block.Name, block.ID, string(block.Input))
 			messages = append(messages, toolCall)
@@ -210,28 +265,37 @@ func messageToResponse(message *anthropic.Message) *chat.Response {
 	return &chat.Response{
 		Messages:     messages,
 		FinishReason: convertFinishReason(message.StopReason),
-		Usage: &chat.Usage{
-			InputTokens:  int(message.Usage.InputTokens),
-			OutputTokens: int(message.Usage.OutputTokens),
-			TotalTokens:  int(message.Usage.InputTokens + message.Usage.OutputTokens),
-		},
+		StopSequence: message.StopSequence,
+		Usage:        convertUsage(message.Usage, ttl),
 	}
 }
 
-func handleStreaming(ctx context.Context, client anthropic.Client, params anthropic.MessageNewParams, streamer chat.Streamer) (*chat.Response, error) {
+func handleStreaming(ctx context.Context, client anthropic.Client, params anthropic.MessageNewParams, opt *chat.Options) (*chat.Response, error) {
 	stream := client.Messages.NewStreaming(ctx, params)
 	defer stream.Close()
 
 	content := ""
+	var citations []chat.Citation
+	var toolCalls []*chat.ToolCall
+	toolIndexes := map[int64]int{} // content block index -> tool call index
+	responseTool := forcesResponseTool(&params)
 	usage := &chat.Usage{}
+	stopReason := anthropic.MessageStopReasonEndTurn
+	stopSequence := ""
 	for stream.Next() {
 		event := stream.Current()
 
 		switch eventVariant := event.AsAny().(type) {
 		case anthropic.ContentBlockDeltaEvent:
+			if citationsDelta, ok := eventVariant.Delta.AsAny().(anthropic.CitationsDelta); ok {
+				var c anthropic.TextCitationUnion
+				if err := json.Unmarshal([]byte(citationsDelta.Citation.RawJSON()), &c); err == nil {
+					citations = append(citations, convertCitation(c))
+				}
+			}
 			if textDelta, ok := eventVariant.Delta.AsAny().(anthropic.TextDelta); ok {
 				content += textDelta.Text
-				err := streamer(&chat.StreamResponse{
+				err := opt.Streamer(&chat.StreamResponse{
 					Type:    "text",
 					Content: textDelta.Text,
 				})
@@ -239,10 +303,46 @@ func handleStreaming(ctx context.Context, client anthropic.Client, params anthro
 					return nil, fmt.Errorf("stream: %w", err)
 				}
 			}
+			if jsonDelta, ok := eventVariant.Delta.AsAny().(anthropic.InputJSONDelta); ok {
+				index, ok := toolIndexes[eventVariant.Index]
+				if !ok {
+					continue
+				}
+				toolCalls[index].Arguments += jsonDelta.PartialJSON
+				delta := chat.NewToolCallDelta(index, "", "", jsonDelta.PartialJSON)
+				if responseTool && toolCalls[index].Name == ResponseToolName {
+					// the structured output is streamed as text.
+					delta = &chat.StreamResponse{Type: "text", Content: jsonDelta.PartialJSON}
+				}
+				err := opt.Streamer(delta)
+				if err != nil {
+					return nil, fmt.Errorf("stream: %w", err)
+				}
+			}
+		case anthropic.ContentBlockStartEvent:
+			if eventVariant.ContentBlock.Type != "tool_use" {
+				continue
+			}
+			index := len(toolCalls)
+			toolIndexes[eventVariant.Index] = index
+			toolCalls = append(toolCalls, &chat.ToolCall{ID: eventVariant.ContentBlock.ID, Name: eventVariant.ContentBlock.Name})
+			if responseTool && eventVariant.ContentBlock.Name == ResponseToolName {
+				continue
+			}
+			err := opt.Streamer(chat.NewToolCallDelta(index, eventVariant.ContentBlock.ID, eventVariant.ContentBlock.Name, ""))
+			if err != nil {
+				return nil, fmt.Errorf("stream: %w", err)
+			}
 		case anthropic.MessageStartEvent:
-			usage.InputTokens = int(eventVariant.Message.Usage.InputTokens)
+			usage = convertUsage(eventVariant.Message.Usage, opt.AnthropicCacheTTL)
+			// output tokens are counted by message delta events.
+			usage.OutputTokens = 0
 		case anthropic.MessageDeltaEvent:
 			usage.OutputTokens += int(eventVariant.Usage.OutputTokens)
+			if eventVariant.Delta.StopReason != "" {
+				stopReason = anthropic.MessageStopReason(eventVariant.Delta.StopReason)
+				stopSequence = eventVariant.Delta.StopSequence
+			}
 		}
 	}
 
@@ -251,9 +351,23 @@ func handleStreaming(ctx context.Context, client anthropic.Client, params anthro
 	}
 
 	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	messages := []chat.Message{}
+	if content != "" || len(toolCalls) == 0 {
+		msg := chat.NewTextMessage(chat.MessageRoleAI, content)
+		msg.Content[0].Citations = citations
+		messages = append(messages, msg)
+	}
+	for _, call := range toolCalls {
+		arguments := call.Arguments
+		if arguments == "" {
+			arguments = "{}"
+		}
+		messages = append(messages, chat.NewToolCallMessage(call.Name, call.ID, arguments))
+	}
 	return &chat.Response{
-		Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, content)},
-		FinishReason: "stop",
+		Messages:     messages,
+		FinishReason: convertFinishReason(stopReason),
+		StopSequence: stopSequence,
 		Usage:        usage,
 	}, nil
 }
@@ -4,13 +4,19 @@
 package anthropic
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
 )
 
 func TestConvertChatRequest(t *testing.T) {
@@ -59,3 +65,186 @@ func TestConvertChatRequest(t *testing.T) {
 		t.Errorf("MaxTokens mismatch: expected %d, got %d", 2048, params.MaxTokens)
 	}
 }
+
+func TestConvertURLPart(t *testing.T) {
+	msg := &chat.Message{
+		Role: chat.MessageRoleHuman,
+		Content: []chat.ContentPart{
+			chat.NewURLPart("image", "https://example.com/image.png"),
+			chat.NewURLPart("file", "https://example.com/doc.pdf"),
+		},
+	}
+
+	blocks, err := convertContentPart(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("blocks mismatch: %d", len(blocks))
+	}
+	if src := blocks[0].OfRequestImageBlock.Source.OfURLImageSource; src == nil || src.URL != "https://example.com/image.png" {
+		t.Errorf("image URL source mismatch: %+v", blocks[0])
+	}
+	if src := blocks[1].OfRequestDocumentBlock.Source.OfUrlpdfSource; src == nil || src.URL != "https://example.com/doc.pdf" {
+		t.Errorf("document URL source mismatch: %+v", blocks[1])
+	}
+
+	msg.Content = []chat.ContentPart{chat.NewURLPart("image", "http://example.com/image.png")}
+	if _, err := convertContentPart(msg); err == nil {
+		t.Error("expected error for non https URL")
+	}
+}
+
+func TestMessageToResponseStopSequence(t *testing.T) {
+	message := &anthropic.Message{
+		Content:      []anthropic.ContentBlockUnion{},
+		StopReason:   anthropic.MessageStopReasonStopSequence,
+		StopSequence: "</answer>",
+	}
+	resp := messageToResponse(message, "")
+	if resp.StopSequence != "</answer>" || resp.FinishReason != chat.FinishReasonStop {
+		t.Errorf("stop sequence mismatch: %q, %s", resp.StopSequence, resp.FinishReason)
+	}
+}
+
+func TestGenerateUserID(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type": "message", "role": "assistant", "content": [{"type": "text", "text": "hi"}],
+			"stop_reason": "end_turn", "usage": {"input_tokens": 1, "output_tokens": 1}}`))
+	}))
+	defer server.Close()
+
+	r := &chat.Request{Model: "claude-3-5-haiku-latest", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+	_, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"), chat.WithUserID("user-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata, _ := body["metadata"].(map[string]any); metadata["user_id"] != "user-1" {
+		t.Errorf("metadata mismatch: %v", body["metadata"])
+	}
+}
+
+func TestGenerateBetaHeaders(t *testing.T) {
+	var beta string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type": "message", "role": "assistant", "content": [{"type": "text", "text": "hi"}],
+			"stop_reason": "end_turn", "usage": {"input_tokens": 1, "output_tokens": 1}}`))
+	}))
+	defer server.Close()
+
+	r := &chat.Request{Model: "claude-3-5-haiku-latest", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+	_, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"),
+		chat.WithAnthropicCacheTTL("1h"), chat.WithAnthropicBeta("pdfs-2024-09-25"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if beta != extendedCacheTTLBeta+",pdfs-2024-09-25" {
+		t.Errorf("beta header mismatch: %q", beta)
+	}
+}
+
+func TestGenerateStreamToolCall(t *testing.T) {
+	events := []string{
+		`{"type": "message_start", "message": {"type": "message", "role": "assistant", "content": [], "usage": {"input_tokens": 10, "output_tokens": 1}}}`,
+		`{"type": "content_block_start", "index": 0, "content_block": {"type": "text", "text": ""}}`,
+		`{"type": "content_block_delta", "index": 0, "delta": {"type": "text_delta", "text": "Checking."}}`,
+		`{"type": "content_block_stop", "index": 0}`,
+		`{"type": "content_block_start", "index": 1, "content_block": {"type": "tool_use", "id": "toolu_1", "name": "weather", "input": {}}}`,
+		`{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": "{\"city\":"}}`,
+		`{"type": "content_block_delta", "index": 1, "delta": {"type": "input_json_delta", "partial_json": " \"Tokyo\"}"}}`,
+		`{"type": "content_block_stop", "index": 1}`,
+		`{"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 20}}`,
+		`{"type": "message_stop"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			var event struct{ Type string }
+			_ = json.Unmarshal([]byte(e), &event)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, e)
+		}
+	}))
+	defer server.Close()
+
+	var deltas []chat.ToolCallDelta
+	streamer := func(resp *chat.StreamResponse) error {
+		if resp.Type == chat.StreamTypeToolCallDelta {
+			deltas = append(deltas, *resp.ToolCall)
+		}
+		return nil
+	}
+	r := &chat.Request{
+		Model:    "claude-3-5-haiku-latest",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+		Tools:    []chat.Tool{{Name: "weather", InputSchema: jsonschema.MustParseJSONString(`{"type": "object"}`)}},
+	}
+	resp, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"), chat.WithStream(streamer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 3 || deltas[0].Name != "weather" || deltas[0].ID != "toolu_1" || deltas[2].Arguments != ` "Tokyo"}` {
+		t.Errorf("deltas mismatch: %+v", deltas)
+	}
+	call := resp.FirstToolCall()
+	if call == nil || call.Arguments != `{"city": "Tokyo"}` || resp.Text() != "Checking." || resp.FinishReason != chat.FinishReasonToolUse {
+		t.Errorf("response mismatch: %+v", resp)
+	}
+}
+
+func TestPayloadSystem(t *testing.T) {
+	r := &chat.Request{
+		Model: "claude-3-5-haiku-latest",
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleSystem, "You are a poet."),
+			chat.NewTextMessage(chat.MessageRoleHuman, "Write a haiku."),
+			chat.NewTextMessage(chat.MessageRoleAI, "Old pond, a frog jumps."),
+			chat.NewTextMessage(chat.MessageRoleSystem, "Answer in Japanese."),
+			chat.NewTextMessage(chat.MessageRoleHuman, "Another one."),
+		},
+	}
+	body, err := Payload(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		System []struct {
+			Text string `json:"text"`
+		} `json:"system"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.System) != 1 || got.System[0].Text != "You are a poet.\n\nAnswer in Japanese." {
+		t.Errorf("system mismatch: %s", body)
+	}
+	var roles []string
+	for _, m := range got.Messages {
+		roles = append(roles, m.Role+": "+m.Content[0].Text)
+	}
+	want := []string{"user: Write a haiku.", "assistant: Old pond, a frog jumps.", "user: Another one."}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("messages mismatch: %v", roles)
+	}
+
+	// the cache breakpoint of a system message is set to the system parameter.
+	body, err = Payload(r, chat.WithPromptPrefixCache(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"system":[{"text":"You are a poet.\n\nAnswer in Japanese.","cache_control":{"type":"ephemeral"}`) {
+		t.Errorf("system cache breakpoint mismatch: %s", body)
+	}
+}
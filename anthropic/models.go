@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// ListModels returns the model IDs available to the API key.
+func ListModels(ctx context.Context, opts ...chat.Option) ([]string, error) {
+	opt := chat.NewOptions(opts...)
+
+	apiKey, err := opt.ResolveAPIKey(ctx, "anthropic")
+	if err != nil {
+		return nil, err
+	}
+	options := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if opt.BaseURL != "" {
+		options = append(options, option.WithBaseURL(opt.BaseURL))
+	}
+	if httpClient := opt.HTTPClient("anthropic"); httpClient != nil {
+		options = append(options, option.WithHTTPClient(httpClient))
+	}
+	client := anthropic.NewClient(options...)
+
+	models := []string{}
+	pager := client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{Limit: anthropic.Int(1000)})
+	for pager.Next() {
+		models = append(models, pager.Current().ID)
+	}
+	if err := pager.Err(); err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	return models, nil
+}
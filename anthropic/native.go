@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// nativeConversation is the conversation of a Messages API request body.
+type nativeConversation struct {
+	// System is a string or text blocks.
+	System   json.RawMessage `json:"system,omitempty"`
+	Messages []nativeMessage `json:"messages"`
+}
+
+type nativeMessage struct {
+	Role string `json:"role"`
+	// Content is a string or content blocks.
+	Content json.RawMessage `json:"content"`
+}
+
+type nativeBlock struct {
+	Type   string        `json:"type"`
+	Text   string        `json:"text"`
+	Title  string        `json:"title"`
+	Source *nativeSource `json:"source"`
+	// ID, Name and Input of tool_use.
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+	// ToolUseID and Content of tool_result. Content is a string or text blocks.
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+type nativeSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+	URL       string `json:"url"`
+}
+
+// ImportMessages converts Anthropic Messages API JSON into messages, eg. logged conversations.
+// The data is a request body with system and messages, or a messages array.
+// The system prompt is the first system message, and tool_use and tool_result blocks
+// are tool call and tool response messages. Thinking blocks are skipped.
+func ImportMessages(data []byte) ([]chat.Message, error) {
+	var conv nativeConversation
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &conv.Messages); err != nil {
+			return nil, fmt.Errorf("unmarshal messages: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("unmarshal conversation: %w", err)
+	}
+
+	messages := []chat.Message{}
+	if len(conv.System) > 0 {
+		system, err := blocksText(conv.System)
+		if err != nil {
+			return nil, fmt.Errorf("system: %w", err)
+		}
+		if system != "" {
+			messages = append(messages, chat.NewTextMessage(chat.MessageRoleSystem, system))
+		}
+	}
+	toolNames := map[string]string{} // tool_use ID -> name
+	for i, m := range conv.Messages {
+		msgs, err := importMessage(m, toolNames)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		messages = append(messages, msgs...)
+	}
+	return messages, nil
+}
+
+func importMessage(m nativeMessage, toolNames map[string]string) ([]chat.Message, error) {
+	var role chat.MessageRole
+	switch m.Role {
+	case "user":
+		role = chat.MessageRoleHuman
+	case "assistant":
+		role = chat.MessageRoleAI
+	default:
+		return nil, fmt.Errorf("unknown role: %s", m.Role)
+	}
+
+	var text string
+	if json.Unmarshal(m.Content, &text) == nil {
+		return []chat.Message{chat.NewTextMessage(role, text)}, nil
+	}
+	var blocks []nativeBlock
+	if err := json.Unmarshal(m.Content, &blocks); err != nil {
+		return nil, fmt.Errorf("unmarshal content: %w", err)
+	}
+
+	messages := []chat.Message{}
+	var content []chat.ContentPart
+	flush := func() {
+		if len(content) > 0 {
+			messages = append(messages, chat.Message{Role: role, Content: content})
+			content = nil
+		}
+	}
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			content = append(content, chat.ContentPart{Type: "text", Text: b.Text})
+		case "image", "document":
+			part, err := importSource(b)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, part)
+		case "tool_use":
+			flush()
+			input := string(b.Input)
+			if input == "" {
+				input = "{}"
+			}
+			toolNames[b.ID] = b.Name
+			messages = append(messages, chat.NewToolCallMessage(b.Name, b.ID, input))
+		case "tool_result":
+			flush()
+			result, err := blocksText(b.Content)
+			if err != nil {
+				return nil, fmt.Errorf("tool result %s: %w", b.ToolUseID, err)
+			}
+			messages = append(messages, chat.NewToolResponseMessage(toolNames[b.ToolUseID], b.ToolUseID, result))
+		case "thinking", "redacted_thinking":
+		default:
+			return nil, fmt.Errorf("content block type is not supported: %s", b.Type)
+		}
+	}
+	flush()
+	return messages, nil
+}
+
+func importSource(b nativeBlock) (chat.ContentPart, error) {
+	partType := "image"
+	if b.Type == "document" {
+		partType = "file"
+	}
+	if b.Source == nil {
+		return chat.ContentPart{}, fmt.Errorf("%s block without source", b.Type)
+	}
+	var part chat.ContentPart
+	switch b.Source.Type {
+	case "base64":
+		part = chat.ContentPart{Type: partType, DataURL: "data:" + b.Source.MediaType + ";base64," + b.Source.Data}
+	case "text":
+		part = chat.ContentPart{Type: partType, DataURL: chat.EncodeDataURL(b.Source.MediaType, []byte(b.Source.Data))}
+	case "url":
+		part = chat.NewURLPart(partType, b.Source.URL)
+	default:
+		return chat.ContentPart{}, fmt.Errorf("%s source type is not supported: %s", b.Type, b.Source.Type)
+	}
+	part.Title = b.Title
+	return part, nil
+}
+
+// blocksText returns the text of a string or text blocks.
+func blocksText(data json.RawMessage) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		return text, nil
+	}
+	var blocks []nativeBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return "", fmt.Errorf("unmarshal text blocks: %w", err)
+	}
+	texts := []string{}
+	for _, b := range blocks {
+		if b.Type == "text" {
+			texts = append(texts, b.Text)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// ExportMessages converts messages into Anthropic Messages API JSON with system and messages,
+// eg. to replay a conversation with the Anthropic SDK. System messages are joined into system,
+// and consecutive tool calls and tool responses are grouped into a message as the API requires.
+func ExportMessages(messages []chat.Message) ([]byte, error) {
+	var system []string
+	params := []anthropic.MessageParam{}
+	var prev *chat.Message
+	for i := range messages {
+		msg := &messages[i]
+		if msg.Role == chat.MessageRoleSystem {
+			system = append(system, msg.ContentString())
+			continue
+		}
+		param, err := convertMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if prev != nil && (prev.IsToolCall() && msg.IsToolCall() || prev.IsToolResponse() && msg.IsToolResponse()) {
+			last := &params[len(params)-1]
+			last.Content = append(last.Content, param.Content...)
+		} else {
+			params = append(params, param)
+		}
+		prev = msg
+	}
+
+	data, err := json.Marshal(struct {
+		System   string                   `json:"system,omitempty"`
+		Messages []anthropic.MessageParam `json:"messages"`
+	}{
+		System:   strings.Join(system, "\n\n"),
+		Messages: params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal messages: %w", err)
+	}
+	return data, nil
+}
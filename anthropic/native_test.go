@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestImportMessages(t *testing.T) {
+	data := `{
+		"model": "claude-3-5-haiku-latest",
+		"system": [{"type": "text", "text": "Be brief."}],
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is the weather?"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "AA=="}}
+			]},
+			{"role": "assistant", "content": [
+				{"type": "thinking", "thinking": "..."},
+				{"type": "tool_use", "id": "t1", "name": "weather", "input": {"city": "Tokyo"}},
+				{"type": "tool_use", "id": "t2", "name": "weather", "input": {"city": "Osaka"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "t1", "content": "Rainy"},
+				{"type": "tool_result", "tool_use_id": "t2", "content": [{"type": "text", "text": "Sunny"}]}
+			]},
+			{"role": "assistant", "content": "Rainy in Tokyo, sunny in Osaka."}
+		]
+	}`
+	messages, err := ImportMessages([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 7 {
+		t.Fatalf("messages mismatch: %+v", messages)
+	}
+	if messages[0].Role != chat.MessageRoleSystem || messages[0].ContentString() != "Be brief." {
+		t.Errorf("system mismatch: %+v", messages[0])
+	}
+	if len(messages[1].Content) != 2 || messages[1].Content[1].DataURL != "data:image/png;base64,AA==" {
+		t.Errorf("user content mismatch: %+v", messages[1])
+	}
+	if call := messages[2].ToolCall; call == nil || call.ID != "t1" || call.Arguments != `{"city": "Tokyo"}` {
+		t.Errorf("tool call mismatch: %+v", messages[2])
+	}
+	if resp := messages[5].ToolResponse; resp == nil || resp.Name != "weather" || resp.Result != "Sunny" {
+		t.Errorf("tool response mismatch: %+v", messages[5])
+	}
+
+	exported, err := ExportMessages(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"system":"Be brief."`, `"tool_use_id":"t2"`, `"media_type":"image/png"`} {
+		if !strings.Contains(string(exported), want) {
+			t.Errorf("exported should contain %s: %s", want, exported)
+		}
+	}
+	roundtrip, err := ImportMessages(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roundtrip) != len(messages) {
+		t.Errorf("roundtrip mismatch: %+v", roundtrip)
+	}
+
+	if _, err := ImportMessages([]byte(`[{"role": "user", "content": "hello"}]`)); err != nil {
+		t.Errorf("messages array should be imported: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Payload returns the JSON body of the messages request without sending it.
+func Payload(r *chat.Request, opts ...chat.Option) (json.RawMessage, error) {
+	params, err := buildParams(r, chat.NewOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return data, nil
+}
+
+// buildParams converts the request with the options to the messages request parameters.
+func buildParams(r *chat.Request, opt *chat.Options) (anthropic.MessageNewParams, error) {
+	messages := []anthropic.MessageParam{}
+	responseTool := useResponseTool(r)
+	if r.ResponseSchema != nil && !responseTool {
+		messages = append(messages,
+			anthropic.NewUserMessage(anthropic.NewTextBlock(fmt.Sprintf(structuredOutputPrompt, string(r.ResponseSchema.JSON())))))
+	} else if r.ResponseType == chat.ResponseTypeJSON {
+		messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(jsonOutputPrompt)))
+	}
+	// positions are the indexes of the request messages in messages, or -1 for system messages.
+	positions := make([]int, len(r.Messages))
+	var system []string
+	for i, msg := range r.Messages {
+		if msg.Role == chat.MessageRoleSystem {
+			system = append(system, msg.ContentString())
+			positions[i] = -1
+			continue
+		}
+		param, err := convertMessage(&msg)
+		if err != nil {
+			return anthropic.MessageNewParams{}, fmt.Errorf("failed to convert message: %w", err)
+		}
+		positions[i] = len(messages)
+		messages = append(messages, param)
+	}
+	systemBlocks := convertSystem(system)
+	if len(opt.PromptCacheBreakpoints) > 0 {
+		if err := setCacheBreakpoints(messages, systemBlocks, positions, opt.PromptCacheBreakpoints, opt.AnthropicCacheTTL); err != nil {
+			return anthropic.MessageNewParams{}, err
+		}
+	} else {
+		setCacheBreakpoint(messages, systemBlocks, opt.AnthropicCacheTTL)
+	}
+
+	params := convertChatRequest(r, messages)
+	params.System = systemBlocks
+	if responseTool {
+		setResponseTool(&params, r.ResponseSchema)
+	}
+	if opt.UserID != "" {
+		params.Metadata.UserID = anthropic.String(opt.UserID)
+	}
+	return params, nil
+}
+
+// convertSystem joins the system messages into the system parameter, or returns nil if there is none.
+func convertSystem(system []string) []anthropic.TextBlockParam {
+	if len(system) == 0 {
+		return nil
+	}
+	return []anthropic.TextBlockParam{{Text: strings.Join(system, "\n\n")}}
+}
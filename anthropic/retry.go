@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// statusOverloaded is the status code of the Anthropic overloaded_error.
+const statusOverloaded = 529
+
+// IsRetryable reports whether the error is a rate limit, overloaded or server error.
+// Overloaded errors sent as stream events are retryable too.
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// Classify returns the retry class of the error.
+// overloaded_error (529), also sent as a stream event, is overloaded.
+func Classify(err error) chat.RetryClass {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == statusOverloaded:
+			return chat.RetryClassOverloaded
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return chat.RetryClassRateLimit
+		case apiErr.StatusCode == http.StatusRequestTimeout, apiErr.StatusCode == http.StatusConflict,
+			apiErr.StatusCode >= http.StatusInternalServerError:
+			return chat.RetryClassTransient
+		}
+		return chat.RetryClassNone
+	}
+	if err != nil && strings.Contains(err.Error(), "overloaded_error") {
+		return chat.RetryClassOverloaded
+	}
+	return chat.RetryClassNone
+}
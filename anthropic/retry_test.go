@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("generate: %w", &anthropic.Error{StatusCode: 529}), true},
+		{&anthropic.Error{StatusCode: 429}, true},
+		{&anthropic.Error{StatusCode: 500}, true},
+		{&anthropic.Error{StatusCode: 400}, false},
+		{errors.New(`stream error: {"type":"overloaded_error"}`), true},
+		{errors.New("invalid request"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want chat.RetryClass
+	}{
+		{&anthropic.Error{StatusCode: 529}, chat.RetryClassOverloaded},
+		{errors.New(`stream error: {"type":"overloaded_error"}`), chat.RetryClassOverloaded},
+		{&anthropic.Error{StatusCode: 429}, chat.RetryClassRateLimit},
+		{&anthropic.Error{StatusCode: 500}, chat.RetryClassTransient},
+		{&anthropic.Error{StatusCode: 400}, chat.RetryClassNone},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// ResponseToolName is the name of the forced tool whose input is the structured output of ResponseSchema.
+const ResponseToolName = "structured_output"
+
+// useResponseTool reports whether the ResponseSchema of the request is generated as the input of
+// the forced response tool. Requests with tools, schemas not of an object and models without
+// tool use (Claude 2 and Instant) fall back to the schema in the prompt.
+func useResponseTool(r *chat.Request) bool {
+	if r.ResponseSchema == nil || len(r.Tools) > 0 || r.ResponseSchema["type"] != "object" {
+		return false
+	}
+	return !strings.HasPrefix(r.Model, "claude-2") && !strings.HasPrefix(r.Model, "claude-instant")
+}
+
+// setResponseTool sets the response tool with the schema as the input schema, and forces its use.
+func setResponseTool(params *anthropic.MessageNewParams, schema jsonschema.Schema) {
+	extra := map[string]any{}
+	for k, v := range schema {
+		if k != "type" && k != "properties" {
+			extra[k] = v
+		}
+	}
+	params.Tools = []anthropic.ToolUnionParam{{OfTool: &anthropic.ToolParam{
+		Name:        ResponseToolName,
+		Description: anthropic.String("Respond with the structured output."),
+		InputSchema: anthropic.ToolInputSchemaParam{Properties: schema["properties"], ExtraFields: extra},
+	}}}
+	params.ToolChoice = anthropic.ToolChoiceUnionParam{
+		OfToolChoiceTool: &anthropic.ToolChoiceToolParam{Name: ResponseToolName},
+	}
+}
+
+// forcesResponseTool reports whether the params force the response tool.
+func forcesResponseTool(params *anthropic.MessageNewParams) bool {
+	return params.ToolChoice.OfToolChoiceTool != nil && params.ToolChoice.OfToolChoiceTool.Name == ResponseToolName
+}
+
+// responseToolOutput replaces the response tool call of the response with the AI text of its input,
+// and validates it against ResponseSchema. Returns *chat.SchemaMismatchError if it does not match.
+func responseToolOutput(r *chat.Request, resp *chat.Response) error {
+	for _, msg := range resp.Messages {
+		if msg.IsToolCall() && msg.ToolCall.Name == ResponseToolName {
+			resp.Messages = []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, msg.ToolCall.Arguments)}
+			if resp.FinishReason == chat.FinishReasonToolUse {
+				resp.FinishReason = chat.FinishReasonStop
+			}
+			break
+		}
+	}
+	return chat.ValidateResponseSchema(r, resp)
+}
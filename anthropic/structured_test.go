@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+var citySchema = jsonschema.MustParseJSONString(`{
+	"type": "object",
+	"properties": {"city": {"type": "string"}},
+	"required": ["city"],
+	"additionalProperties": false
+}`)
+
+func TestPayloadResponseTool(t *testing.T) {
+	hello := []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "The capital of Japan?")}
+	tests := []struct {
+		name string
+		req  *chat.Request
+		tool bool
+	}{
+		{"object schema", &chat.Request{Model: "claude-3-5-haiku-latest", Messages: hello, ResponseSchema: citySchema}, true},
+		{"with tools", &chat.Request{Model: "claude-3-5-haiku-latest", Messages: hello, ResponseSchema: citySchema,
+			Tools: []chat.Tool{{Name: "search", InputSchema: jsonschema.MustParseJSONString(`{"type": "object"}`)}}}, false},
+		{"array schema", &chat.Request{Model: "claude-3-5-haiku-latest", Messages: hello,
+			ResponseSchema: jsonschema.MustParseJSONString(`{"type": "array", "items": {"type": "string"}}`)}, false},
+		{"old model", &chat.Request{Model: "claude-2.1", Messages: hello, ResponseSchema: citySchema}, false},
+	}
+	for _, tt := range tests {
+		body, err := Payload(tt.req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		s := string(body)
+		forced := strings.Contains(s, `"tool_choice":{"name":"structured_output","type":"tool"}`) &&
+			strings.Contains(s, `"required":["city"]`)
+		prompt := strings.Contains(s, "Please respond with json")
+		if forced != tt.tool || prompt == tt.tool {
+			t.Errorf("%s: forced = %v, prompt = %v: %s", tt.name, forced, prompt, body)
+		}
+	}
+}
+
+func TestGenerateResponseTool(t *testing.T) {
+	input := `{"city":"Tokyo"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"type": "message", "role": "assistant", "stop_reason": "tool_use",
+			"content": []map[string]any{{"type": "tool_use", "id": "toolu_1", "name": ResponseToolName, "input": json.RawMessage(input)}},
+			"usage":   map[string]any{"input_tokens": 1, "output_tokens": 1},
+		})
+	}))
+	defer server.Close()
+
+	r := &chat.Request{
+		Model:          "claude-3-5-haiku-latest",
+		Messages:       []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "The capital of Japan?")},
+		ResponseSchema: citySchema,
+	}
+	resp, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != input || len(resp.ToolCalls()) != 0 || resp.FinishReason != chat.FinishReasonStop {
+		t.Errorf("response mismatch: %+v", resp)
+	}
+
+	input = `{"town":"Tokyo"}`
+	_, err = Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"))
+	var mismatch *chat.SchemaMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Response == nil {
+		t.Errorf("expected schema mismatch: %v", err)
+	}
+}
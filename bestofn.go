@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Score is the reward of a candidate response. Higher is better.
+type Score struct {
+	Value float64
+	// Usage of the scoring, eg. a judge model call.
+	Usage *chat.Usage
+}
+
+// Scorer scores a candidate response of the request, eg. JudgeScorer or ScoreFunc.
+type Scorer func(ctx context.Context, req *chat.Request, resp *chat.Response, opts ...chat.Option) (*Score, error)
+
+// ScoreFunc returns a scorer of a heuristic or user function, eg. rewarding valid JSON or brevity.
+func ScoreFunc(fn func(resp *chat.Response) float64) Scorer {
+	return func(_ context.Context, _ *chat.Request, resp *chat.Response, _ ...chat.Option) (*Score, error) {
+		return &Score{Value: fn(resp)}, nil
+	}
+}
+
+// Candidate is a scored candidate response.
+type Candidate struct {
+	Response *chat.Response `json:"response"`
+	Score    float64        `json:"score"`
+}
+
+// BestOfNResponse is the best of the candidate responses.
+type BestOfNResponse struct {
+	// Response is the candidate with the highest score.
+	Response *chat.Response `json:"response"`
+	Score    float64        `json:"score"`
+	// Candidates are all scored candidates in generation order.
+	Candidates []Candidate `json:"candidates"`
+	// Usage is the combined usage of the candidates and the scoring.
+	Usage *chat.Usage `json:"usage"`
+}
+
+// GenerateBestOfN generates n candidate responses of the request in parallel and returns
+// the one with the highest score by the scorer. Ties go to the earlier candidate.
+// Requests without a temperature are sampled with DefaultConsistencyTemperature if the model supports it.
+// Failed candidates are dropped, and an error is returned only if all candidates fail.
+func GenerateBestOfN(ctx context.Context, req *chat.Request, n int, score Scorer, opts ...chat.Option,
+) (*BestOfNResponse, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of candidates: %d", n)
+	}
+	r := samplingRequest(req)
+	samples, usage, err := sample(ctx, &r, n, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]*Score, len(samples))
+	errs := make([]error, len(samples))
+	var wg sync.WaitGroup
+	for i, resp := range samples {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scores[i], errs[i] = score(ctx, &r, resp, opts...)
+		}()
+	}
+	wg.Wait()
+
+	result := &BestOfNResponse{Candidates: make([]Candidate, len(samples)), Usage: usage}
+	for i, resp := range samples {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("score candidate %d: %w", i, errs[i])
+		}
+		result.Candidates[i] = Candidate{Response: resp, Score: scores[i].Value}
+		result.Usage.Add(scores[i].Usage)
+		if i == 0 || scores[i].Value > result.Score {
+			result.Response, result.Score = resp, scores[i].Value
+		}
+	}
+	return result, nil
+}
+
+// JudgeScorer returns a scorer asking the model to score candidates by the rubric from 0 to 1 with Judge.
+func JudgeScorer(model, rubric string) Scorer {
+	return func(ctx context.Context, req *chat.Request, resp *chat.Response, opts ...chat.Option) (*Score, error) {
+		j, err := Judge(ctx, model, rubric, lastHumanText(req), resp.Text(), opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &Score{Value: j.Score, Usage: j.Usage}, nil
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerateBestOfN(t *testing.T) {
+	answers := []string{"A short answer.", "A much longer and more detailed answer.", "Mid answer."}
+	var calls atomic.Int32
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		i := calls.Add(1) - 1
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, answers[i])},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	})
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "explain")}}
+	length := ScoreFunc(func(resp *chat.Response) float64 { return float64(len(resp.Text())) })
+	result, err := GenerateBestOfN(context.Background(), req, 3, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Response.Text() != answers[1] || result.Score != float64(len(answers[1])) {
+		t.Errorf("best = %q, score = %v", result.Response.Text(), result.Score)
+	}
+	if len(result.Candidates) != 3 || result.Usage.TotalTokens != 30 {
+		t.Errorf("candidates = %d, usage = %+v", len(result.Candidates), result.Usage)
+	}
+}
+
+func TestJudgeScorer(t *testing.T) {
+	var judged *chat.Request
+	setGenerate(t, fakeJSONModel(`{"score": 0.8}`, &judged))
+
+	req := &chat.Request{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "capital of Japan?")}}
+	resp := &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Tokyo")}}
+	score, err := JudgeScorer("judge", "Names the capital.")(context.Background(), req, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score.Value != 0.8 || score.Usage.TotalTokens != 10 {
+		t.Errorf("score mismatch: %+v", score)
+	}
+	prompt := judged.Messages[0].ContentString()
+	if judged.Model != "judge" || !strings.Contains(prompt, "<rubric>Names the capital.</rubric>") ||
+		!strings.Contains(prompt, "<question>capital of Japan?</question>") || !strings.Contains(prompt, "<answer>Tokyo</answer>") {
+		t.Errorf("judge request mismatch: %s", prompt)
+	}
+}
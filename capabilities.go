@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import "github.com/jumonmd/gengo/chat"
+
+// Capabilities returns the features supported by the model, eg. to build adaptive UIs.
+func Capabilities(model string, opts ...chat.Option) (chat.Capabilities, error) {
+	info, err := chat.NewOptions(opts...).LookupModel(model)
+	if err != nil {
+		return chat.Capabilities{}, err
+	}
+	return chat.ModelCapabilities(info), nil
+}
@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import "testing"
+
+func TestCapabilities(t *testing.T) {
+	c, err := Capabilities("gemini-2.0-flash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Streaming || !c.Vision || !c.StructuredOutput || !c.Search {
+		t.Errorf("capabilities mismatch: %+v", c)
+	}
+	if _, err := Capabilities("unknown-model"); err == nil {
+		t.Error("expected error for unknown model")
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "os"
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version if neither WithAzure nor AZURE_OPENAI_API_VERSION sets it.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureConfig is the Azure OpenAI resource of the openai provider.
+type AzureConfig struct {
+	// Endpoint of the resource, eg. https://my-resource.openai.azure.com.
+	Endpoint   string
+	APIVersion string
+	// Deployments maps model names to deployment names.
+	// Unmapped models are deployed as the model name without dots, eg. gpt-35-turbo.
+	Deployments map[string]string
+
+	enabled bool
+}
+
+// Enabled reports whether OpenAI requests are sent to Azure, by WithAzure or with an endpoint.
+// The config of WithAzureDeployment alone is not enabled.
+func (c *AzureConfig) Enabled() bool {
+	return c != nil && (c.enabled || c.Endpoint != "")
+}
+
+// Deployment returns the deployment name of the model.
+func (c *AzureConfig) Deployment(model string) (string, bool) {
+	deployment, ok := c.Deployments[model]
+	return deployment, ok
+}
+
+// WithAzure sends OpenAI requests to the Azure OpenAI resource.
+// Empty endpoint and apiVersion are read from AZURE_OPENAI_ENDPOINT and AZURE_OPENAI_API_VERSION,
+// and the API key is read from AZURE_OPENAI_API_KEY by default.
+// Models not in the catalog, eg. deployment names, are routed to the openai provider,
+// and catalog models of the other providers are not affected.
+// Requests fail if no endpoint is set.
+func WithAzure(endpoint, apiVersion string) Option {
+	return func(o *Options) {
+		if endpoint == "" {
+			endpoint = os.Getenv("AZURE_OPENAI_ENDPOINT")
+		}
+		if apiVersion == "" {
+			apiVersion = os.Getenv("AZURE_OPENAI_API_VERSION")
+		}
+		if apiVersion == "" {
+			apiVersion = DefaultAzureAPIVersion
+		}
+		deployments := map[string]string{}
+		if o.Azure != nil {
+			deployments = o.Azure.Deployments
+		}
+		o.Azure = &AzureConfig{Endpoint: endpoint, APIVersion: apiVersion, Deployments: deployments, enabled: true}
+	}
+}
+
+// WithAzureDeployment maps the model to the Azure OpenAI deployment name.
+// Use with WithAzure, as it does not enable Azure by itself.
+func WithAzureDeployment(model, deployment string) Option {
+	return func(o *Options) {
+		if o.Azure == nil {
+			o.Azure = &AzureConfig{}
+		}
+		if o.Azure.Deployments == nil {
+			o.Azure.Deployments = map[string]string{}
+		}
+		o.Azure.Deployments[model] = deployment
+	}
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "testing"
+
+func TestWithAzure(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://example.openai.azure.com")
+	t.Setenv("AZURE_OPENAI_API_VERSION", "2025-01-01-preview")
+	o := NewOptions(WithAzureDeployment("gpt-4o", "prod-4o"), WithAzure("", ""))
+	if o.Azure.Endpoint != "https://example.openai.azure.com" || o.Azure.APIVersion != "2025-01-01-preview" {
+		t.Errorf("azure config mismatch: %+v", o.Azure)
+	}
+	if deployment, ok := o.Azure.Deployment("gpt-4o"); !ok || deployment != "prod-4o" {
+		t.Errorf("deployment mismatch: %s", deployment)
+	}
+	info, err := o.LookupModel("my-deployment")
+	if err != nil || info.Provider != "openai" {
+		t.Errorf("deployment names should route to openai: %+v, %v", info, err)
+	}
+	info, err = o.LookupModel("claude-3-5-sonnet-latest")
+	if err != nil || info.Provider != "anthropic" {
+		t.Errorf("catalog models should keep the provider: %+v, %v", info, err)
+	}
+
+	o = NewOptions(WithAzureDeployment("gpt-4o", "prod-4o"))
+	if o.Azure.Enabled() {
+		t.Error("deployments alone should not enable azure")
+	}
+	if _, err := o.LookupModel("my-deployment"); err == nil {
+		t.Error("expected error of unknown model without azure")
+	}
+}
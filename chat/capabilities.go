@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "strings"
+
+// Capabilities are the features supported by a model.
+type Capabilities struct {
+	Streaming     bool `json:"streaming"`
+	ToolCalls     bool `json:"tool_calls"`
+	ParallelTools bool `json:"parallel_tools"`
+	Vision        bool `json:"vision"`
+	PDF           bool `json:"pdf"`
+	AudioIn       bool `json:"audio_in"`
+	// StructuredOutput is native JSON schema output. Other models follow the schema by prompt.
+	StructuredOutput bool `json:"structured_output"`
+	Search           bool `json:"search"`
+	Caching          bool `json:"caching"`
+}
+
+// providerCapabilities are the features supported by all chat models of the provider.
+// The other features are of the catalog, so models not in the catalog do not have them.
+var providerCapabilities = map[string]Capabilities{
+	"openai":    {Streaming: true},
+	"anthropic": {Streaming: true},
+	"gemini":    {Streaming: true, Search: true},
+}
+
+// ModelCapabilities returns the capabilities of the model composed from the catalog
+// and the features of the provider.
+func ModelCapabilities(info *ModelInfo) Capabilities {
+	c := providerCapabilities[info.Provider]
+	c.ToolCalls = c.ToolCalls || info.SupportsFunctionCalling
+	c.ParallelTools = c.ParallelTools || info.SupportsParallelFunctionCalling
+	c.Vision = info.SupportsVision
+	c.PDF = info.SupportsPDFInput
+	c.AudioIn = c.AudioIn || info.SupportsAudioInput || strings.Contains(info.Model, "audio")
+	c.StructuredOutput = c.StructuredOutput || info.SupportsResponseSchema
+	c.Search = c.Search || info.SupportsWebSearch
+	c.Caching = c.Caching || info.SupportsPromptCaching || info.CacheReadTokenCost > 0
+	return c
+}
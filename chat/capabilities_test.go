@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "testing"
+
+func TestModelCapabilities(t *testing.T) {
+	tests := []struct {
+		info *ModelInfo
+		want Capabilities
+	}{
+		{
+			info: &ModelInfo{Model: "gpt-4o-audio-preview", Provider: "openai", CacheReadTokenCost: 1e-6, SupportsFunctionCalling: true},
+			want: Capabilities{Streaming: true, ToolCalls: true, AudioIn: true, Caching: true},
+		},
+		{
+			info: NewOptions().ModelCatalog.GetModel("claude-3-5-sonnet-latest"),
+			want: Capabilities{Streaming: true, ToolCalls: true, ParallelTools: true, Vision: true, PDF: true, Caching: true},
+		},
+		{
+			info: NewOptions().ModelCatalog.GetModel("o1-mini"),
+			want: Capabilities{Streaming: true, Vision: true, Caching: true},
+		},
+		{
+			info: &ModelInfo{Model: "local", Provider: "unknown", SupportsFunctionCalling: true, SupportsResponseSchema: true},
+			want: Capabilities{ToolCalls: true, StructuredOutput: true},
+		},
+	}
+	for _, tt := range tests {
+		if got := ModelCapabilities(tt.info); got != tt.want {
+			t.Errorf("ModelCapabilities(%s) = %+v, want %+v", tt.info.Model, got, tt.want)
+		}
+	}
+}
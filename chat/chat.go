@@ -6,6 +6,9 @@ package chat
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/jumonmd/gengo/jsonschema"
@@ -25,12 +28,22 @@ type Request struct {
 	Config   ModelConfig `json:"config,omitempty"`
 	Metadata Metadata    `json:"metadata,omitempty"`
 	Messages []Message   `json:"messages"`
-	Tools    []Tool      `json:"tools,omitempty"`
+	// PromptRef references a stored prompt, resolved as the system prompt, eg. by promptstore.Middleware.
+	PromptRef *PromptRef `json:"prompt_ref,omitempty"`
+	Tools     []Tool     `json:"tools,omitempty"`
 	// MustCallTool is true if forced to call tool.
-	MustCallTool   bool              `json:"must_call_tool,omitempty"`
+	MustCallTool bool `json:"must_call_tool,omitempty"`
+	// ResponseType is the format of the response text. ResponseSchema takes precedence.
+	ResponseType   ResponseType      `json:"response_type,omitempty"`
 	ResponseSchema jsonschema.Schema `json:"response_schema,omitempty"`
 }
 
+// PromptRef references a version of a stored prompt. An empty version is the current version.
+type PromptRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
 type ModelConfig struct {
 	MaxTokens        int32    `json:"max_tokens,omitempty"`
 	Temperature      float32  `json:"temperature,omitempty"`
@@ -38,6 +51,20 @@ type ModelConfig struct {
 	PresencePenalty  float32  `json:"presence_penalty,omitempty"`
 	FrequencyPenalty float32  `json:"frequency_penalty,omitempty"`
 	StopWords        []string `json:"stop_words,omitempty"`
+	// Grammar constrains the output with a grammar, eg. GBNF, on OpenAI-compatible servers.
+	Grammar string `json:"grammar,omitempty"`
+	// ResponseRegex constrains the output text to match the regular expression.
+	// Backends without constrained decoding validate the output instead.
+	ResponseRegex string `json:"response_regex,omitempty"`
+}
+
+// SupportsTemperature reports whether the model accepts a sampling temperature.
+// OpenAI reasoning models, the o-series and gpt-5 except gpt-5-chat, reject it.
+func SupportsTemperature(model string) bool {
+	if len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9' {
+		return false
+	}
+	return !strings.HasPrefix(model, "gpt-5") || strings.HasPrefix(model, "gpt-5-chat")
 }
 
 type Tool struct {
@@ -52,8 +79,10 @@ type Metadata map[string]string
 type Message struct {
 	// Type for extension. Default type is message.
 	//   possible values: web_search_call, file_search_call...
-	Type    string        `json:"type,omitempty"`
-	Role    MessageRole   `json:"role"`
+	Type string      `json:"type,omitempty"`
+	Role MessageRole `json:"role"`
+	// Name of the participant for multi-speaker transcripts.
+	Name    string        `json:"name,omitempty"`
 	Content []ContentPart `json:"content,omitempty"`
 	// ToolCall by AI. Role should be AI.
 	ToolCall *ToolCall `json:"tool_call,omitempty"`
@@ -69,13 +98,40 @@ func (m *Message) IsToolResponse() bool {
 	return m.ToolResponse != nil && m.Role == MessageRoleTool
 }
 
+// NamedContent returns the content with the participant name prefixed to the first text part,
+// for providers without a message name field. The content is returned as is if Name is empty.
+func (m *Message) NamedContent() []ContentPart {
+	if m.Name == "" {
+		return m.Content
+	}
+	content := slices.Clone(m.Content)
+	for i, part := range content {
+		if part.Type == "text" {
+			content[i].Text = m.Name + ": " + part.Text
+			return content
+		}
+	}
+	return append([]ContentPart{{Type: "text", Text: m.Name + ":"}}, content...)
+}
+
 type ContentPart struct {
-	// Type is the content part type. text, image or file.
+	// Type is the content part type. text, image, file or uri.
 	Type string `json:"type"`
 	// Text for text type.
 	Text string `json:"text,omitempty"`
 	// DataURL for image or file type.
 	DataURL string `json:"data_url,omitempty"`
+	// URL for image or file type hosted remotely, instead of DataURL.
+	// URI for uri type, eg. gs:// URI or YouTube URL.
+	URL string `json:"url,omitempty"`
+	// MIMEType of the uri type content, or the file type content hosted remotely, PDF by default.
+	MIMEType string `json:"mime_type,omitempty"`
+	// Title of the file type document.
+	Title string `json:"title,omitempty"`
+	// EnableCitations enables citations of the file type document (Anthropic).
+	EnableCitations bool `json:"enable_citations,omitempty"`
+	// Citations supporting the text of the response.
+	Citations []Citation `json:"citations,omitempty"`
 }
 
 type ToolCall struct {
@@ -98,6 +154,13 @@ type Response struct {
 	Messages     []Message    `json:"messages"`
 	Metadata     Metadata     `json:"metadata,omitempty"`
 	Usage        *Usage       `json:"usage,omitempty"`
+	// StopSequence is the stop sequence that terminated generation.
+	// Only set by providers reporting it (Anthropic).
+	StopSequence string `json:"stop_sequence,omitempty"`
+	// Variant is the name of the response variant chosen by the model. See ResponseVariant.
+	Variant string `json:"variant,omitempty"`
+	// Timing of the generation set by gengo.Generate.
+	Timing *Timing `json:"timing,omitempty"`
 }
 
 type FinishReason string
@@ -112,22 +175,120 @@ const (
 )
 
 type Usage struct {
-	InputTokens         int     `json:"input_tokens"`
-	OutputTokens        int     `json:"output_tokens"`
-	ReasoningTokens     int     `json:"reasoning_tokens"`
-	CacheCreationTokens int     `json:"cache_creation_tokens"`
-	CachedTokens        int     `json:"cached_tokens"`
-	TotalTokens         int     `json:"total_tokens"`
-	Cost                float64 `json:"cost"`
+	InputTokens     int `json:"input_tokens"`
+	OutputTokens    int `json:"output_tokens"`
+	ReasoningTokens int `json:"reasoning_tokens"`
+	// CacheCreationTokens is the cache write tokens included in InputTokens.
+	CacheCreationTokens int `json:"cache_creation_tokens"`
+	// CacheCreation1hTokens is the 1 hour TTL cache write tokens included in CacheCreationTokens.
+	CacheCreation1hTokens int `json:"cache_creation_1h_tokens,omitempty"`
+	// CachedTokens is the cache read tokens included in InputTokens.
+	CachedTokens int     `json:"cached_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost"`
+	// Currency of the cost. Empty means USD.
+	Currency string `json:"currency,omitempty"`
+	// Costs is the cost per currency, set instead of Cost and Currency
+	// when usages with costs in different currencies are added.
+	Costs map[string]float64 `json:"costs,omitempty"`
+	// Requests is the number of provider calls of the usage.
+	Requests int `json:"requests,omitempty"`
+}
+
+// Add adds the other usage to the usage.
+func (u *Usage) Add(other *Usage) {
+	if other == nil {
+		return
+	}
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.CacheCreationTokens += other.CacheCreationTokens
+	u.CacheCreation1hTokens += other.CacheCreation1hTokens
+	u.CachedTokens += other.CachedTokens
+	u.TotalTokens += other.TotalTokens
+	u.Requests += other.Requests
+	u.addCost(other)
+}
+
+// addCost adds the cost of the other usage. Costs in different currencies are kept per currency in Costs.
+func (u *Usage) addCost(other *Usage) {
+	switch {
+	case other.Cost == 0 && len(other.Costs) == 0:
+	case u.Cost == 0 && len(u.Costs) == 0:
+		u.Cost, u.Currency, u.Costs = other.Cost, other.Currency, maps.Clone(other.Costs)
+	case len(u.Costs) == 0 && len(other.Costs) == 0 && costCurrency(u.Currency) == costCurrency(other.Currency):
+		u.Cost += other.Cost
+	default:
+		costs := u.costs()
+		for currency, cost := range other.costs() {
+			costs[currency] += cost
+		}
+		u.Cost, u.Currency, u.Costs = 0, "", costs
+	}
+}
+
+// costs returns a copy of the costs per currency.
+func (u *Usage) costs() map[string]float64 {
+	if len(u.Costs) > 0 {
+		return maps.Clone(u.Costs)
+	}
+	return map[string]float64{costCurrency(u.Currency): u.Cost}
+}
+
+func costCurrency(currency string) string {
+	if currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// SumUsage returns the total usage of the usages. nil usages are skipped.
+func SumUsage(usages ...*Usage) *Usage {
+	total := &Usage{}
+	for _, u := range usages {
+		total.Add(u)
+	}
+	return total
 }
 
 type Streamer func(resp *StreamResponse) error
 
 type StreamResponse struct {
 	// Type is the type of the stream response for extension.
-	//   possible values: chat.completion.chunk, chat.thinking.chunk...
+	//   possible values: text, tool_call.delta, reset...
 	Type    string `json:"type"`
 	Content string `json:"content"`
+	// ToolCall is the tool call delta of the tool_call.delta type.
+	// Content is the partial arguments of the delta.
+	ToolCall *ToolCallDelta `json:"tool_call,omitempty"`
+}
+
+// StreamTypeToolCallDelta is the stream response type of a tool call delta.
+const StreamTypeToolCallDelta = "tool_call.delta"
+
+// StreamTypeReset is the stream response type streamed before a regeneration,
+// eg. of a response failing the schema validation or the response language check.
+// Streamers should discard the output streamed before it, which is replaced by the regenerated response.
+const StreamTypeReset = "reset"
+
+// ToolCallDelta is a part of a streamed tool call.
+// The first delta of a tool call has the ID and the name, and the arguments arrive in the following deltas.
+type ToolCallDelta struct {
+	// Index is the index of the tool call in the response.
+	Index     int    `json:"index"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// NewToolCallDelta creates a tool call delta stream response.
+func NewToolCallDelta(index int, id, name, arguments string) *StreamResponse {
+	return &StreamResponse{
+		Type:     StreamTypeToolCallDelta,
+		Content:  arguments,
+		ToolCall: &ToolCallDelta{Index: index, ID: id, Name: name, Arguments: arguments},
+	}
 }
 
 func (s *StreamResponse) JSON() []byte {
@@ -148,6 +309,28 @@ func NewTextMessage(role MessageRole, text string) Message {
 	}
 }
 
+// NewNamedTextMessage creates a text message from the named participant.
+func NewNamedTextMessage(role MessageRole, name, text string) Message {
+	msg := NewTextMessage(role, text)
+	msg.Name = name
+	return msg
+}
+
+// AppendSystemPrompt returns a copy of the request with the prompt appended to the first system message,
+// or prepended as a new system message if there is none.
+func AppendSystemPrompt(req *Request, prompt string) *Request {
+	r := *req
+	if len(req.Messages) > 0 && req.Messages[0].Role == MessageRoleSystem {
+		r.Messages = slices.Clone(req.Messages)
+		system := r.Messages[0]
+		system.Content = append(slices.Clone(system.Content), ContentPart{Type: "text", Text: "\n\n" + prompt})
+		r.Messages[0] = system
+		return &r
+	}
+	r.Messages = append([]Message{NewTextMessage(MessageRoleSystem, prompt)}, req.Messages...)
+	return &r
+}
+
 // NewTextImageMessage creates a message with text and image.
 // If text is empty, image only content is returned.
 func NewTextImageMessage(role MessageRole, text, path string) (Message, error) {
@@ -180,6 +363,48 @@ func NewTextImageMessage(role MessageRole, text, path string) (Message, error) {
 	}, nil
 }
 
+// NewTextFileMessage creates a message with text and a file, eg. a PDF, titled by the file name.
+// If text is empty, file only content is returned.
+func NewTextFileMessage(role MessageRole, text, path string) (Message, error) {
+	dataurl, _, err := EncodeDataURLFromPath(path)
+	if err != nil {
+		return Message{}, err
+	}
+
+	content := []ContentPart{}
+	if text != "" {
+		content = append(content, ContentPart{Type: "text", Text: text})
+	}
+	content = append(content, ContentPart{
+		Type:    "file",
+		DataURL: dataurl,
+		Title:   filepath.Base(path),
+	})
+
+	return Message{
+		Role:    role,
+		Content: content,
+	}, nil
+}
+
+// NewURLPart creates an image or file content part referring to a remote URL.
+func NewURLPart(partType, url string) ContentPart {
+	return ContentPart{
+		Type: partType,
+		URL:  url,
+	}
+}
+
+// NewURIPart creates a uri part referencing content without downloading,
+// eg. gs:// URI or YouTube URL (Gemini).
+func NewURIPart(uri, mimeType string) ContentPart {
+	return ContentPart{
+		Type:     "uri",
+		URL:      uri,
+		MIMEType: mimeType,
+	}
+}
+
 // NewToolCallMessage creates a AI tool call message with name, callID and arguments(stringified json).
 func NewToolCallMessage(name, callID, arguments string) Message {
 	return Message{
@@ -192,6 +417,12 @@ func NewToolCallMessage(name, callID, arguments string) Message {
 	}
 }
 
+// ToolErrorResult returns the JSON tool result of the error message, {"error": msg}.
+func ToolErrorResult(msg string) string {
+	data, _ := json.Marshal(map[string]string{"error": msg})
+	return string(data)
+}
+
 // NewToolResponseMessage creates a tool response message with name, callID and result.
 func NewToolResponseMessage(name, callID, result string) Message {
 	return Message{
@@ -226,6 +457,39 @@ func (r *Response) ToolResponses() []Message {
 	return toolresponses
 }
 
+// Text returns the concatenated text content of AI messages.
+func (r *Response) Text() string {
+	text := ""
+	for _, m := range r.Messages {
+		if m.Role == MessageRoleAI {
+			text += m.ContentString()
+		}
+	}
+	return text
+}
+
+// FirstToolCall returns the first tool call from AI, or nil if there is no tool call.
+func (r *Response) FirstToolCall() *ToolCall {
+	for _, m := range r.Messages {
+		if m.IsToolCall() {
+			return m.ToolCall
+		}
+	}
+	return nil
+}
+
+// JSON unmarshals the text content (structured output) into v.
+func (r *Response) JSON(v any) error {
+	text := r.Text()
+	if text == "" {
+		return fmt.Errorf("no text content in response")
+	}
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
 func (r *Response) String() string {
 	parts := []string{}
 	for _, m := range r.Messages {
@@ -252,7 +516,11 @@ func (m *Message) ContentString() string {
 func (m *Message) String() string {
 	parts := []string{}
 	if m.ContentString() != "" {
-		parts = append(parts, fmt.Sprintf("%s: %s", strings.ToUpper(string(m.Role)), m.ContentString()))
+		role := strings.ToUpper(string(m.Role))
+		if m.Name != "" {
+			role += " (" + m.Name + ")"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", role, m.ContentString()))
 	}
 	if m.ToolCall != nil {
 		parts = append(parts, fmt.Sprintf("tool_calls: [CallID: %s, Name: %s, Arguments: %s]", m.ToolCall.ID, m.ToolCall.Name, m.ToolCall.Arguments))
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResponseAccessors(t *testing.T) {
+	resp := &Response{
+		Messages: []Message{
+			NewTextMessage(MessageRoleAI, `{"name": `),
+			NewToolCallMessage("get_weather", "call-1", `{"location": "Tokyo"}`),
+			NewTextMessage(MessageRoleAI, `"Gengo"}`),
+		},
+	}
+
+	if got := resp.Text(); got != `{"name": "Gengo"}` {
+		t.Errorf("Text() = %s", got)
+	}
+
+	call := resp.FirstToolCall()
+	if call == nil || call.Name != "get_weather" {
+		t.Errorf("FirstToolCall() = %+v", call)
+	}
+
+	var v struct {
+		Name string `json:"name"`
+	}
+	if err := resp.JSON(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "Gengo" {
+		t.Errorf("JSON() name = %s", v.Name)
+	}
+
+	empty := &Response{}
+	if empty.Text() != "" || empty.FirstToolCall() != nil {
+		t.Error("empty response accessors should return zero values")
+	}
+	if err := empty.JSON(&v); err == nil {
+		t.Error("JSON() should fail on empty response")
+	}
+}
+
+func TestSumUsage(t *testing.T) {
+	total := SumUsage(
+		&Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, Cost: 0.5, Requests: 1},
+		nil,
+		&Usage{InputTokens: 20, OutputTokens: 10, TotalTokens: 30, Cost: 0.25, Requests: 2},
+	)
+	if total.TotalTokens != 45 || total.Cost != 0.75 || total.Requests != 3 {
+		t.Errorf("total usage mismatch: %+v", total)
+	}
+}
+
+func TestSumUsageCurrencies(t *testing.T) {
+	total := SumUsage(
+		&Usage{Cost: 0.5, Currency: "EUR"},
+		&Usage{Cost: 0.25, Currency: "EUR"},
+	)
+	if total.Cost != 0.75 || total.Currency != "EUR" || total.Costs != nil {
+		t.Errorf("same currency should be summed: %+v", total)
+	}
+
+	total = SumUsage(
+		&Usage{Cost: 0.5, Currency: "EUR"},
+		&Usage{Cost: 0.25},
+		&Usage{Cost: 0.25, Currency: "EUR"},
+		&Usage{Cost: 10, Currency: "JPY"},
+	)
+	want := map[string]float64{"EUR": 0.75, "USD": 0.25, "JPY": 10}
+	if total.Cost != 0 || total.Currency != "" || !maps.Equal(total.Costs, want) {
+		t.Errorf("costs should be kept per currency: %+v", total)
+	}
+}
+
+func TestNamedContent(t *testing.T) {
+	msg := NewNamedTextMessage(MessageRoleHuman, "Alice", "hello")
+	if got := msg.NamedContent()[0].Text; got != "Alice: hello" {
+		t.Errorf("NamedContent() = %q", got)
+	}
+	if msg.Content[0].Text != "hello" {
+		t.Error("original content modified")
+	}
+	if got := msg.String(); got != "HUMAN (Alice): hello" {
+		t.Errorf("String() = %q", got)
+	}
+
+	image := Message{Role: MessageRoleHuman, Name: "Bob", Content: []ContentPart{NewURLPart("image", "https://example.com/a.png")}}
+	if content := image.NamedContent(); len(content) != 2 || content[0].Text != "Bob:" {
+		t.Errorf("NamedContent() = %+v", content)
+	}
+	unnamed := NewTextMessage(MessageRoleHuman, "hi")
+	if got := unnamed.NamedContent()[0].Text; got != "hi" {
+		t.Errorf("NamedContent() = %q", got)
+	}
+}
+
+func TestNewTextFileMessage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := NewTextFileMessage(MessageRoleHuman, "Summarize this report", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.Content) != 2 || msg.Content[0].Text != "Summarize this report" {
+		t.Fatalf("content mismatch: %+v", msg.Content)
+	}
+	file := msg.Content[1]
+	if file.Type != "file" || file.Title != "report.pdf" || file.DataURL != EncodeDataURL("application/pdf", []byte("%PDF-1.4")) {
+		t.Errorf("file part mismatch: %+v", file)
+	}
+
+	if _, err := NewTextFileMessage(MessageRoleHuman, "", filepath.Join(t.TempDir(), "missing.pdf")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestSupportsTemperature(t *testing.T) {
+	for model, want := range map[string]bool{"gpt-4o": true, "gpt-5-chat-latest": true, "claude-sonnet-4-0": true,
+		"o1": false, "o3-mini": false, "o4-mini": false, "gpt-5": false, "gpt-5-mini": false} {
+		if got := SupportsTemperature(model); got != want {
+			t.Errorf("SupportsTemperature(%s) = %v", model, got)
+		}
+	}
+}
+
+func TestToolErrorResult(t *testing.T) {
+	msg := "bad \"input\" \x00 é"
+	var got map[string]string
+	if err := json.Unmarshal([]byte(ToolErrorResult(msg)), &got); err != nil || got["error"] != msg {
+		t.Errorf("result should be valid JSON: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+// Citation locates the source of a response text in a document of the request.
+type Citation struct {
+	// Type is "char_location", "page_location" or "content_block_location".
+	Type      string `json:"type"`
+	CitedText string `json:"cited_text"`
+	// DocumentIndex is the index of the document in the request, counted across messages.
+	DocumentIndex int    `json:"document_index"`
+	DocumentTitle string `json:"document_title,omitempty"`
+	// Start and End are the range of the cited text: a character range (end exclusive)
+	// for char_location, a page range for page_location or a block range for content_block_location.
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// NewTextDocumentPart creates a plain text document part with citations enabled.
+func NewTextDocumentPart(title, text string) ContentPart {
+	return ContentPart{
+		Type:            "file",
+		DataURL:         EncodeDataURL("text/plain", []byte(text)),
+		Title:           title,
+		EnableCitations: true,
+	}
+}
+
+// Citations returns the citations of the AI messages.
+func (r *Response) Citations() []Citation {
+	var citations []Citation
+	for _, m := range r.Messages {
+		if m.Role != MessageRoleAI {
+			continue
+		}
+		for _, part := range m.Content {
+			citations = append(citations, part.Citations...)
+		}
+	}
+	return citations
+}
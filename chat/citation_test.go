@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "testing"
+
+func TestNewTextDocumentPart(t *testing.T) {
+	part := NewTextDocumentPart("Manual", "The grass is green.")
+	data, mimeType, err := DecodeDataURL(part.DataURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != "file" || mimeType != "text/plain" || string(data) != "The grass is green." || !part.EnableCitations {
+		t.Errorf("part mismatch: %+v", part)
+	}
+}
+
+func TestResponseCitations(t *testing.T) {
+	msg := NewTextMessage(MessageRoleAI, "green")
+	msg.Content[0].Citations = []Citation{{Type: "char_location", CitedText: "The grass is green.", End: 20}}
+	resp := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, "It is "), msg}}
+	if c := resp.Citations(); len(c) != 1 || c[0].End != 20 {
+		t.Errorf("citations mismatch: %+v", c)
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ConstraintError is returned when the response does not match ResponseRegex.
+type ConstraintError struct {
+	Pattern string
+	Text    string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("response does not match %q", e.Pattern)
+}
+
+// ValidateResponseRegex checks that the response text fully matches the request ResponseRegex.
+func ValidateResponseRegex(req *Request, resp *Response) error {
+	if req.Config.ResponseRegex == "" || len(resp.ToolCalls()) > 0 {
+		return nil
+	}
+	re, err := regexp.Compile(`^(?:` + req.Config.ResponseRegex + `)$`)
+	if err != nil {
+		return fmt.Errorf("compile response regex: %w", err)
+	}
+	text := resp.Text()
+	if !re.MatchString(text) {
+		return &ConstraintError{Pattern: req.Config.ResponseRegex, Text: text}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateResponseRegex(t *testing.T) {
+	req := &Request{Config: ModelConfig{ResponseRegex: `SELECT .+ FROM \w+;`}}
+
+	ok := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, "SELECT id FROM users;")}}
+	if err := ValidateResponseRegex(req, ok); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ng := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, "Here is the query: SELECT id FROM users;")}}
+	var constraintErr *ConstraintError
+	if err := ValidateResponseRegex(req, ng); !errors.As(err, &constraintErr) {
+		t.Errorf("expected ConstraintError, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"slices"
+)
+
+type optionsKey struct{}
+
+// ContextWithOptions returns a context with the options appended to the options of the parent context.
+// gengo.Generate applies the context options before its own options, so frameworks and middlewares
+// can set request-scoped options, eg. tenant metadata, logging or budgets, without threading them
+// through every call.
+func ContextWithOptions(ctx context.Context, opts ...Option) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	merged := slices.Concat(OptionsFromContext(ctx), opts)
+	return context.WithValue(ctx, optionsKey{}, merged)
+}
+
+// OptionsFromContext returns the options attached to the context, nil if none.
+func OptionsFromContext(ctx context.Context) []Option {
+	opts, _ := ctx.Value(optionsKey{}).([]Option)
+	return opts
+}
+
+// MergeContextOptions returns the options of the context followed by opts,
+// so the explicit options override the context options.
+func MergeContextOptions(ctx context.Context, opts []Option) []Option {
+	ctxOpts := OptionsFromContext(ctx)
+	if len(ctxOpts) == 0 {
+		return opts
+	}
+	return slices.Concat(ctxOpts, opts)
+}
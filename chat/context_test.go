@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContextWithOptions(t *testing.T) {
+	ctx := context.Background()
+	if opts := OptionsFromContext(ctx); opts != nil {
+		t.Errorf("expected no options: %v", opts)
+	}
+	if ContextWithOptions(ctx) != ctx {
+		t.Error("context without options should be returned as is")
+	}
+
+	ctx = ContextWithOptions(ctx, WithPriority(PriorityBatch), WithMaxCost(1))
+	ctx = ContextWithOptions(ctx, WithPriority(PriorityHigh))
+	if n := len(OptionsFromContext(ctx)); n != 3 {
+		t.Fatalf("options should be appended: %d", n)
+	}
+
+	o := NewOptions(MergeContextOptions(ctx, nil)...)
+	if o.Priority != PriorityHigh || o.MaxCost != 1 {
+		t.Errorf("context options mismatch: %+v", o)
+	}
+	o = NewOptions(MergeContextOptions(ctx, []Option{WithPriority(PriorityNormal)})...)
+	if o.Priority != PriorityNormal {
+		t.Errorf("explicit options should override: %s", o.Priority)
+	}
+}
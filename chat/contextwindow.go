@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrContextTooLarge is matched by errors.Is for all ContextTooLargeError.
+var ErrContextTooLarge = errors.New("context too large")
+
+// ContextTooLargeError is returned when the estimated prompt tokens and MaxTokens exceed the context window.
+type ContextTooLargeError struct {
+	Model string
+	// Needed is the estimated prompt tokens plus MaxTokens.
+	Needed       int
+	PromptTokens int
+	MaxTokens    int
+	// Limit is the context window of the model.
+	Limit int
+}
+
+func (e *ContextTooLargeError) Error() string {
+	return fmt.Sprintf("context too large for %s: needs %d tokens (prompt %d + max tokens %d), limit %d; reduce the prompt by %d tokens",
+		e.Model, e.Needed, e.PromptTokens, e.MaxTokens, e.Limit, e.Needed-e.Limit)
+}
+
+func (e *ContextTooLargeError) Unwrap() error {
+	return ErrContextTooLarge
+}
+
+// WithSkipContextCheck disables the context window pre-check, eg. when the estimate is too conservative.
+func WithSkipContextCheck() Option {
+	return func(o *Options) {
+		o.SkipContextCheck = true
+	}
+}
+
+// ContextEstimateTolerance is the overestimate of the prompt tokens tolerated by CheckContextWindow.
+const ContextEstimateTolerance = 0.15
+
+// CheckContextWindow compares the estimated prompt tokens plus MaxTokens with the context window of the model.
+// The estimate may be off, so requests are rejected only if they exceed the window
+// even with the prompt reduced by ContextEstimateTolerance, and the others are left to the provider.
+// Gemini limits input and output separately, so only the prompt is compared.
+func (o *Options) CheckContextWindow(req *Request, info *ModelInfo) error {
+	if o.SkipContextCheck || info.MaxInputTokens == 0 {
+		return nil
+	}
+	prompt := o.EstimateModelTokens(req, info)
+	maxTokens := int(req.Config.MaxTokens)
+	if info.Provider == "gemini" {
+		maxTokens = 0
+	}
+	needed := prompt + maxTokens
+	if int(float64(prompt)*(1-ContextEstimateTolerance))+maxTokens <= info.MaxInputTokens {
+		return nil
+	}
+	return &ContextTooLargeError{
+		Model:        req.Model,
+		Needed:       needed,
+		PromptTokens: prompt,
+		MaxTokens:    maxTokens,
+		Limit:        info.MaxInputTokens,
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckContextWindow(t *testing.T) {
+	info := &ModelInfo{Model: "test", MaxInputTokens: 1000}
+	req := &Request{
+		Model:    "test",
+		Config:   ModelConfig{MaxTokens: 500},
+		Messages: []Message{NewTextMessage(MessageRoleHuman, strings.Repeat("a", 2400))},
+	}
+
+	err := NewOptions().CheckContextWindow(req, info)
+	var tooLarge *ContextTooLargeError
+	if !errors.Is(err, ErrContextTooLarge) || !errors.As(err, &tooLarge) {
+		t.Fatalf("expected ContextTooLargeError, got %v", err)
+	}
+	if tooLarge.Needed != 604+500 || tooLarge.Limit != 1000 {
+		t.Errorf("error mismatch: %+v", tooLarge)
+	}
+
+	if err := NewOptions(WithSkipContextCheck()).CheckContextWindow(req, info); err != nil {
+		t.Errorf("check should be skipped: %v", err)
+	}
+	gemini := &ModelInfo{Model: "test", Provider: "gemini", MaxInputTokens: 1000}
+	if err := NewOptions(WithTokenCorrection("test", 1)).CheckContextWindow(req, gemini); err != nil {
+		t.Errorf("gemini output should not count: %v", err)
+	}
+	req.Config.MaxTokens = 450
+	if err := NewOptions().CheckContextWindow(req, info); err != nil {
+		t.Errorf("estimate near the limit should be left to the provider: %v", err)
+	}
+}
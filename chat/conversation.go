@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Conversation is a message history with accumulated usage.
+type Conversation struct {
+	Messages []Message `json:"messages"`
+	Usage    Usage     `json:"usage"`
+}
+
+// NewConversation creates a conversation with the messages.
+func NewConversation(messages ...Message) *Conversation {
+	return &Conversation{Messages: messages}
+}
+
+// Append adds messages to the conversation.
+func (c *Conversation) Append(messages ...Message) {
+	c.Messages = append(c.Messages, messages...)
+}
+
+// AppendResponse adds the response messages and usage to the conversation.
+func (c *Conversation) AppendResponse(resp *Response) {
+	c.Messages = append(c.Messages, resp.Messages...)
+	c.Usage.Add(resp.Usage)
+}
+
+// Request creates a request of the model with the conversation messages.
+func (c *Conversation) Request(model string) *Request {
+	return &Request{
+		Model:    model,
+		Messages: slices.Clone(c.Messages),
+	}
+}
+
+// Fork returns a copy of the conversation. Changes to the fork do not affect the original.
+func (c *Conversation) Fork() *Conversation {
+	messages := make([]Message, len(c.Messages))
+	for i, msg := range c.Messages {
+		messages[i] = cloneMessage(msg)
+	}
+	return &Conversation{Messages: messages, Usage: c.Usage}
+}
+
+// EditMessage replaces the content of the human or system message at i
+// and removes the following messages, so the conversation can be resent.
+func (c *Conversation) EditMessage(i int, content []ContentPart) error {
+	if i < 0 || i >= len(c.Messages) {
+		return fmt.Errorf("message index out of range: %d", i)
+	}
+	msg := c.Messages[i]
+	if msg.Role != MessageRoleHuman && msg.Role != MessageRoleSystem {
+		return fmt.Errorf("message %d is not editable: role %s", i, msg.Role)
+	}
+	msg.Content = slices.Clone(content)
+	c.Messages = append(c.Messages[:i:i], msg)
+	return nil
+}
+
+// RegenerateFrom removes the AI message at i and the following messages,
+// so a new response can be generated from the preceding history.
+// The tool call and response pairs before i are kept intact.
+func (c *Conversation) RegenerateFrom(i int) error {
+	if i < 0 || i >= len(c.Messages) {
+		return fmt.Errorf("message index out of range: %d", i)
+	}
+	if c.Messages[i].Role != MessageRoleAI {
+		return fmt.Errorf("message %d is not an AI message: role %s", i, c.Messages[i].Role)
+	}
+	// tool calls of a turn must be removed together with the responses.
+	for i > 0 && c.Messages[i-1].IsToolCall() {
+		i--
+	}
+	c.Messages = c.Messages[:i:i]
+	return nil
+}
+
+// Validate checks that every tool call is followed by its tool response and
+// every tool response has a preceding tool call.
+func (c *Conversation) Validate() error {
+	pending := map[string]bool{}
+	for i, msg := range c.Messages {
+		switch {
+		case msg.IsToolCall():
+			pending[msg.ToolCall.ID] = true
+		case msg.IsToolResponse():
+			if !pending[msg.ToolResponse.ID] {
+				return fmt.Errorf("message %d: tool response without tool call: %s", i, msg.ToolResponse.ID)
+			}
+			delete(pending, msg.ToolResponse.ID)
+		default:
+			if len(pending) > 0 {
+				return fmt.Errorf("message %d: tool calls without response before message", i)
+			}
+		}
+	}
+	return nil
+}
+
+func cloneMessage(msg Message) Message {
+	msg.Content = slices.Clone(msg.Content)
+	if msg.ToolCall != nil {
+		call := *msg.ToolCall
+		msg.ToolCall = &call
+	}
+	if msg.ToolResponse != nil {
+		resp := *msg.ToolResponse
+		msg.ToolResponse = &resp
+	}
+	return msg
+}
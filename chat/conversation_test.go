@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+)
+
+func newToolConversation() *Conversation {
+	c := NewConversation(
+		NewTextMessage(MessageRoleSystem, "You are a helpful assistant."),
+		NewTextMessage(MessageRoleHuman, "weather in Tokyo?"),
+	)
+	c.AppendResponse(&Response{
+		Messages: []Message{NewToolCallMessage("weather", "call_1", `{"city":"Tokyo"}`)},
+		Usage:    &Usage{TotalTokens: 10},
+	})
+	c.Append(NewToolResponseMessage("weather", "call_1", `{"weather":"sunny"}`))
+	c.AppendResponse(&Response{
+		Messages: []Message{NewTextMessage(MessageRoleAI, "It is sunny.")},
+		Usage:    &Usage{TotalTokens: 20},
+	})
+	return c
+}
+
+func TestConversationFork(t *testing.T) {
+	c := newToolConversation()
+	if c.Usage.TotalTokens != 30 {
+		t.Errorf("usage mismatch: %d", c.Usage.TotalTokens)
+	}
+
+	fork := c.Fork()
+	fork.Messages[1].Content[0].Text = "weather in Osaka?"
+	fork.Messages[2].ToolCall.Arguments = `{"city":"Osaka"}`
+	if c.Messages[1].Content[0].Text != "weather in Tokyo?" || c.Messages[2].ToolCall.Arguments != `{"city":"Tokyo"}` {
+		t.Error("fork should not change the original")
+	}
+}
+
+func TestConversationEditMessage(t *testing.T) {
+	c := newToolConversation()
+	if err := c.EditMessage(1, []ContentPart{{Type: "text", Text: "weather in Osaka?"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Messages) != 2 || c.Messages[1].Content[0].Text != "weather in Osaka?" {
+		t.Errorf("edit mismatch: %+v", c.Messages)
+	}
+	if err := newToolConversation().EditMessage(2, nil); err == nil {
+		t.Error("tool call should not be editable")
+	}
+}
+
+func TestConversationRegenerateFrom(t *testing.T) {
+	c := newToolConversation()
+	if err := c.RegenerateFrom(4); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Messages) != 4 || c.Validate() != nil {
+		t.Errorf("regenerate final answer: %d messages, %v", len(c.Messages), c.Validate())
+	}
+
+	c = newToolConversation()
+	if err := c.RegenerateFrom(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Messages) != 2 || c.Validate() != nil {
+		t.Errorf("regenerate tool call: %d messages, %v", len(c.Messages), c.Validate())
+	}
+
+	if err := newToolConversation().RegenerateFrom(1); err == nil {
+		t.Error("human message should not be regenerated")
+	}
+}
+
+func TestConversationValidate(t *testing.T) {
+	c := newToolConversation()
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c.Messages = append(c.Messages[:3:3], NewTextMessage(MessageRoleHuman, "hi"))
+	if err := c.Validate(); err == nil {
+		t.Error("expected error for tool call without response")
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModelPrice is a per-token price override in USD.
+// Zero values fall back to the catalog prices.
+type ModelPrice struct {
+	InputTokenCost         float64 `json:"input_cost_per_token,omitempty"`
+	OutputTokenCost        float64 `json:"output_cost_per_token,omitempty"`
+	CacheCreationTokenCost float64 `json:"cache_creation_input_token_cost,omitempty"`
+	CacheReadTokenCost     float64 `json:"cache_read_input_token_cost,omitempty"`
+	// CacheCreation1hTokenCost is the cache write cost with 1 hour TTL.
+	CacheCreation1hTokenCost float64 `json:"cache_creation_input_token_cost_above_1hr,omitempty"`
+}
+
+// RateProvider returns the exchange rate from USD to the currency.
+type RateProvider interface {
+	Rate(currency string) (float64, error)
+}
+
+// RateProviderFunc is a function adapter for RateProvider.
+type RateProviderFunc func(currency string) (float64, error)
+
+func (f RateProviderFunc) Rate(currency string) (float64, error) {
+	return f(currency)
+}
+
+// FixedRates is a RateProvider with static exchange rates from USD.
+// eg. FixedRates{"EUR": 0.92, "JPY": 150}
+type FixedRates map[string]float64
+
+func (r FixedRates) Rate(currency string) (float64, error) {
+	rate, ok := r[strings.ToUpper(currency)]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate for currency: %s", currency)
+	}
+	return rate, nil
+}
+
+// WithPriceOverride overrides the catalog price of the model.
+// Useful for negotiated rates.
+func WithPriceOverride(model string, price ModelPrice) Option {
+	return func(o *Options) {
+		if o.PriceOverrides == nil {
+			o.PriceOverrides = map[string]ModelPrice{}
+		}
+		o.PriceOverrides[model] = price
+	}
+}
+
+// WithCostCurrency reports Usage.Cost in the currency converted by the rate provider.
+func WithCostCurrency(currency string, rates RateProvider) Option {
+	return func(o *Options) {
+		o.Currency = strings.ToUpper(currency)
+		o.RateProvider = rates
+	}
+}
+
+// CalculateCost put cost into the usage with price overrides and currency conversion.
+// Returns true if the cost is calculated. If the cost cannot be converted to the currency,
+// the cost is kept in USD with empty Usage.Currency, and EventCostError is emitted.
+func (o *Options) CalculateCost(model string, usage *Usage) bool {
+	if usage == nil {
+		return false
+	}
+	info := o.ModelCatalog.GetModel(model)
+	if info == nil {
+		return false
+	}
+
+	priced := o.pricedModel(model, info)
+	usage.Cost = calculateCost(&priced, usage)
+	usage.Currency = ""
+	if o.Currency == "" || o.Currency == "USD" {
+		return true
+	}
+	if o.RateProvider == nil {
+		o.Emit(&Event{Type: EventCostError, Model: model, Error: "no rate provider for currency: " + o.Currency})
+		return true
+	}
+	rate, err := o.RateProvider.Rate(o.Currency)
+	if err != nil {
+		o.Emit(&Event{Type: EventCostError, Model: model, Error: err.Error()})
+		return true
+	}
+	usage.Cost *= rate
+	usage.Currency = o.Currency
+	return true
+}
+
+// pricedModel returns a copy of the model info with the price override of the model name or the catalog name.
+func (o *Options) pricedModel(model string, info *ModelInfo) ModelInfo {
+	priced := *info
+	if price, ok := o.PriceOverrides[model]; ok {
+		applyPrice(&priced, price)
+	} else if price, ok := o.PriceOverrides[info.Model]; ok {
+		applyPrice(&priced, price)
+	}
+	return priced
+}
+
+func applyPrice(info *ModelInfo, price ModelPrice) {
+	if price.InputTokenCost != 0 {
+		info.InputTokenCost = price.InputTokenCost
+	}
+	if price.OutputTokenCost != 0 {
+		info.OutputTokenCost = price.OutputTokenCost
+	}
+	if price.CacheCreationTokenCost != 0 {
+		info.CacheCreationTokenCost = price.CacheCreationTokenCost
+	}
+	if price.CacheReadTokenCost != 0 {
+		info.CacheReadTokenCost = price.CacheReadTokenCost
+	}
+	if price.CacheCreation1hTokenCost != 0 {
+		info.CacheCreation1hTokenCost = price.CacheCreation1hTokenCost
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOptionsCalculateCost(t *testing.T) {
+	catalog := ModelCatalog{
+		{Model: "test-model", Provider: "openai", InputTokenCost: 1e-6, OutputTokenCost: 2e-6},
+	}
+
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantCost     float64
+		wantCurrency string
+		wantOK       bool
+		wantError    bool
+	}{
+		{"catalog price", nil, 0.003, "", true, false},
+		{"price override", []Option{WithPriceOverride("test-model", ModelPrice{InputTokenCost: 5e-7})}, 0.0025, "", true, false},
+		{"currency", []Option{WithCostCurrency("eur", FixedRates{"EUR": 0.5})}, 0.0015, "EUR", true, false},
+		{"unknown currency", []Option{WithCostCurrency("JPY", FixedRates{"EUR": 0.5})}, 0.003, "", true, true},
+		{"no rate provider", []Option{WithCostCurrency("JPY", nil)}, 0.003, "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			costErrors := 0
+			handler := WithEventHandler(func(ev *Event) {
+				if ev.Type == EventCostError {
+					costErrors++
+				}
+			})
+			o := NewOptions(append([]Option{WithModelCatalog(catalog), handler}, tt.opts...)...)
+			usage := &Usage{InputTokens: 1000, OutputTokens: 1000}
+			ok := o.CalculateCost("test-model", usage)
+			if ok != tt.wantOK {
+				t.Fatalf("CalculateCost() = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(usage.Cost-tt.wantCost) > 1e-12 {
+				t.Errorf("cost is not expected: %f, want %f", usage.Cost, tt.wantCost)
+			}
+			if usage.Currency != tt.wantCurrency {
+				t.Errorf("currency is not expected: %s, want %s", usage.Currency, tt.wantCurrency)
+			}
+			if (costErrors > 0) != tt.wantError {
+				t.Errorf("cost error events: %d", costErrors)
+			}
+		})
+	}
+}
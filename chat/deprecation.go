@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DeprecationHandler is called when a deprecated model is requested.
+type DeprecationHandler func(info *ModelInfo)
+
+// ModelShutdownError is returned when the requested model is past its shutdown date.
+type ModelShutdownError struct {
+	Model        string
+	ShutdownDate string
+}
+
+func (e *ModelShutdownError) Error() string {
+	return fmt.Sprintf("model %s was shut down on %s", e.Model, e.ShutdownDate)
+}
+
+// WithDeprecationHandler sets the handler called for deprecated models.
+// Default handler logs a warning with slog.
+func WithDeprecationHandler(handler DeprecationHandler) Option {
+	return func(o *Options) {
+		o.DeprecationHandler = handler
+	}
+}
+
+// IsShutdown returns true if the model is past its shutdown date.
+func (m *ModelInfo) IsShutdown(now time.Time) bool {
+	if m.ShutdownDate == "" {
+		return false
+	}
+	date, err := time.Parse(time.DateOnly, m.ShutdownDate)
+	if err != nil {
+		return false
+	}
+	return !now.Before(date)
+}
+
+// CheckDeprecation returns ModelShutdownError if the model is past its shutdown date,
+// and calls the deprecation handler if the model is deprecated.
+func (o *Options) CheckDeprecation(info *ModelInfo, now time.Time) error {
+	if info.IsShutdown(now) {
+		return &ModelShutdownError{Model: info.Model, ShutdownDate: info.ShutdownDate}
+	}
+	if !info.Deprecated {
+		return nil
+	}
+	if o.DeprecationHandler != nil {
+		o.DeprecationHandler(info)
+		return nil
+	}
+	slog.Warn("model is deprecated", "model", info.Model, "shutdown_date", info.ShutdownDate)
+	return nil
+}
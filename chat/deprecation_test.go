@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckDeprecation(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	called := false
+	o := NewOptions(WithDeprecationHandler(func(info *ModelInfo) {
+		called = true
+	}))
+
+	if err := o.CheckDeprecation(&ModelInfo{Model: "active"}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called {
+		t.Fatal("handler should not be called for active model")
+	}
+
+	if err := o.CheckDeprecation(&ModelInfo{Model: "deprecated", Deprecated: true, ShutdownDate: "2025-12-01"}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Fatal("handler should be called for deprecated model")
+	}
+
+	err := o.CheckDeprecation(&ModelInfo{Model: "shutdown", Deprecated: true, ShutdownDate: "2025-06-01"}, now)
+	var shutdownErr *ModelShutdownError
+	if !errors.As(err, &shutdownErr) {
+		t.Fatalf("expected ModelShutdownError, got %v", err)
+	}
+}
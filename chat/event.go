@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	// EventRequest is emitted when a request is accepted.
+	EventRequest EventType = "request"
+	// EventAttempt is emitted before each provider call.
+	EventAttempt EventType = "provider_attempt"
+	// EventChunk is emitted for each stream chunk.
+	EventChunk EventType = "chunk"
+	// EventToolCall is emitted before a tool is executed.
+	EventToolCall EventType = "tool_call"
+	// EventToolResult is emitted after a tool is executed.
+	EventToolResult EventType = "tool_result"
+	// EventRetry is emitted when a failed attempt is retried.
+	EventRetry EventType = "retry"
+	// EventResume is emitted when a dropped stream is resumed from the streamed text.
+	EventResume EventType = "resume"
+	// EventFinal is emitted with the final response.
+	EventFinal EventType = "final"
+	// EventError is emitted when generation fails.
+	EventError EventType = "error"
+	// EventMiddlewareError is emitted by middlewares failing open, eg. on errors of a cache or usage store,
+	// without failing the generation.
+	EventMiddlewareError EventType = "middleware_error"
+	// EventCostError is emitted when the cost cannot be converted to the currency and is reported in USD.
+	EventCostError EventType = "cost_error"
+)
+
+// Event is a structured event of a generation.
+type Event struct {
+	Type         EventType       `json:"type"`
+	Time         time.Time       `json:"time"`
+	Model        string          `json:"model,omitempty"`
+	Provider     string          `json:"provider,omitempty"`
+	Attempt      int             `json:"attempt,omitempty"`
+	Request      *Request        `json:"request,omitempty"`
+	Response     *Response       `json:"response,omitempty"`
+	Chunk        *StreamResponse `json:"chunk,omitempty"`
+	ToolCall     *ToolCall       `json:"tool_call,omitempty"`
+	ToolResponse *ToolResponse   `json:"tool_response,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// EventHandler handles generation events.
+type EventHandler func(ev *Event)
+
+// WithEventHandler adds an event handler.
+func WithEventHandler(handler EventHandler) Option {
+	return func(o *Options) {
+		o.EventHandlers = append(o.EventHandlers, handler)
+	}
+}
+
+// Emit sends the event to the event handlers.
+func (o *Options) Emit(ev *Event) {
+	if len(o.EventHandlers) == 0 {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = o.Now()
+	}
+	for _, h := range o.EventHandlers {
+		h(ev)
+	}
+}
+
+// Trace collects generation events for debugging and replay.
+type Trace struct {
+	mu     sync.Mutex
+	Events []*Event `json:"events"`
+}
+
+// NewTrace creates an empty trace.
+func NewTrace() *Trace {
+	return &Trace{Events: []*Event{}}
+}
+
+// ReadTrace reads a trace serialized by WriteJSON.
+func ReadTrace(r io.Reader) (*Trace, error) {
+	t := NewTrace()
+	if err := json.NewDecoder(r).Decode(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Handler returns an event handler collecting events into the trace.
+func (t *Trace) Handler() EventHandler {
+	return func(ev *Event) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.Events = append(t.Events, ev)
+	}
+}
+
+// Option returns an option collecting events into the trace.
+func (t *Trace) Option() Option {
+	return WithEventHandler(t.Handler())
+}
+
+// WriteJSON writes the trace as JSON.
+func (t *Trace) WriteJSON(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return json.NewEncoder(w).Encode(t)
+}
+
+// Replay sends the collected events to the handler in order.
+func (t *Trace) Replay(handler EventHandler) {
+	t.mu.Lock()
+	events := append([]*Event{}, t.Events...)
+	t.mu.Unlock()
+	for _, ev := range events {
+		handler(ev)
+	}
+}
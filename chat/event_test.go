@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTrace(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trace := NewTrace()
+	o := NewOptions(trace.Option(), WithClock(func() time.Time { return now }))
+
+	o.Emit(&Event{Type: EventRequest, Model: "test-model"})
+	o.Emit(&Event{Type: EventChunk, Chunk: &StreamResponse{Type: "text", Content: "hi"}})
+	o.Emit(&Event{Type: EventFinal, Response: &Response{Messages: []Message{NewTextMessage(MessageRoleAI, "hi")}}})
+
+	buf := &bytes.Buffer{}
+	if err := trace.WriteJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := ReadTrace(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := []EventType{}
+	read.Replay(func(ev *Event) {
+		types = append(types, ev.Type)
+		if !ev.Time.Equal(now) {
+			t.Errorf("time mismatch: %v", ev.Time)
+		}
+	})
+	if len(types) != 3 || types[0] != EventRequest || types[1] != EventChunk || types[2] != EventFinal {
+		t.Errorf("events mismatch: %v", types)
+	}
+	if read.Events[1].Chunk.Content != "hi" {
+		t.Errorf("chunk mismatch: %+v", read.Events[1].Chunk)
+	}
+}
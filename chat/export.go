@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WriteMarkdown renders the conversation to self-contained markdown.
+// Images are embedded as data URLs.
+func (c *Conversation) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Conversation\n\n")
+	for _, msg := range c.Messages {
+		fmt.Fprintf(&b, "## %s\n\n", roleTitle(msg))
+		switch {
+		case msg.ToolCall != nil:
+			fmt.Fprintf(&b, "`%s` (%s)\n\n```json\n%s\n```\n\n", msg.ToolCall.Name, msg.ToolCall.ID, msg.ToolCall.Arguments)
+		case msg.ToolResponse != nil:
+			fmt.Fprintf(&b, "`%s` (%s)\n\n```json\n%s\n```\n\n", msg.ToolResponse.Name, msg.ToolResponse.ID, msg.ToolResponse.Result)
+		default:
+			for _, part := range msg.Content {
+				b.WriteString(markdownPart(part))
+				b.WriteString("\n\n")
+			}
+		}
+	}
+	b.WriteString(usageSummary(&c.Usage))
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownPart(part ContentPart) string {
+	src := part.DataURL
+	if src == "" {
+		src = part.URL
+	}
+	switch part.Type {
+	case "text":
+		return part.Text
+	case "image":
+		return fmt.Sprintf("![image](%s)", src)
+	}
+	return fmt.Sprintf("[%s](%s)", part.Type, src)
+}
+
+// WriteHTML renders the conversation to a self-contained HTML page.
+// Images are embedded as data URLs.
+func (c *Conversation) WriteHTML(w io.Writer) error {
+	type message struct {
+		Role         string
+		Class        string
+		Content      []ContentPart
+		ToolCall     *ToolCall
+		ToolResponse *ToolResponse
+	}
+	data := struct {
+		Messages []message
+		Usage    string
+	}{Usage: usageSummary(&c.Usage)}
+	for _, msg := range c.Messages {
+		data.Messages = append(data.Messages, message{
+			Role:         roleTitle(msg),
+			Class:        string(msg.Role),
+			Content:      msg.Content,
+			ToolCall:     msg.ToolCall,
+			ToolResponse: msg.ToolResponse,
+		})
+	}
+	return conversationHTML.Execute(w, data)
+}
+
+func roleTitle(msg Message) string {
+	switch {
+	case msg.ToolCall != nil:
+		return "Tool Call"
+	case msg.ToolResponse != nil:
+		return "Tool Result"
+	}
+	if msg.Name != "" {
+		return msg.Name
+	}
+	switch msg.Role {
+	case MessageRoleSystem:
+		return "System"
+	case MessageRoleHuman:
+		return "Human"
+	case MessageRoleAI:
+		return "AI"
+	}
+	return string(msg.Role)
+}
+
+func usageSummary(u *Usage) string {
+	costs := u.costs()
+	parts := []string{}
+	for _, currency := range slices.Sorted(maps.Keys(costs)) {
+		parts = append(parts, fmt.Sprintf("%.6f %s", costs[currency], currency))
+	}
+	return fmt.Sprintf("Tokens: %d input, %d output, %d total. Cost: %s",
+		u.InputTokens, u.OutputTokens, u.TotalTokens, strings.Join(parts, " + "))
+}
+
+var conversationHTML = template.Must(template.New("conversation").Funcs(template.FuncMap{
+	// data URLs are escaped by html/template unless marked safe.
+	"src": func(p ContentPart) any {
+		if IsDataURL(p.DataURL) {
+			return template.URL(p.DataURL)
+		}
+		return p.URL
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conversation</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; }
+.message { border-radius: 8px; padding: 0.5rem 1rem; margin: 1rem 0; background: #f5f5f5; }
+.human { background: #e8f0fe; }
+.system { background: #fff8e1; }
+.tool { background: #eeeeee; }
+pre { white-space: pre-wrap; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+{{range .Messages}}<div class="message {{.Class}}">
+<h3>{{.Role}}</h3>
+{{if .ToolCall}}<p><code>{{.ToolCall.Name}}</code> ({{.ToolCall.ID}})</p><pre>{{.ToolCall.Arguments}}</pre>
+{{else if .ToolResponse}}<p><code>{{.ToolResponse.Name}}</code> ({{.ToolResponse.ID}})</p><pre>{{.ToolResponse.Result}}</pre>
+{{else}}{{range .Content}}{{if eq .Type "text"}}<pre>{{.Text}}</pre>
+{{else if eq .Type "image"}}<img src="{{src .}}" alt="image">
+{{else}}<p><a href="{{src .}}">{{.Type}}</a></p>
+{{end}}{{end}}{{end}}</div>
+{{end}}<p class="usage">{{.Usage}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func exportConversation() *Conversation {
+	c := newToolConversation()
+	c.Append(Message{Role: MessageRoleHuman, Content: []ContentPart{
+		{Type: "text", Text: "what is <this>?"},
+		{Type: "image", DataURL: "data:image/png;base64,iVBORw0KGgo="},
+	}})
+	c.Usage.Cost = 0.0015
+	return c
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := exportConversation().WriteMarkdown(buf); err != nil {
+		t.Fatal(err)
+	}
+	md := buf.String()
+	for _, want := range []string{
+		"## Tool Call\n\n`weather` (call_1)\n\n```json\n{\"city\":\"Tokyo\"}\n```",
+		"![image](data:image/png;base64,iVBORw0KGgo=)",
+		"Cost: 0.001500 USD",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown should contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := exportConversation().WriteHTML(buf); err != nil {
+		t.Fatal(err)
+	}
+	html := buf.String()
+	for _, want := range []string{
+		`<img src="data:image/png;base64,iVBORw0KGgo=" alt="image">`,
+		"what is &lt;this&gt;?",
+		`<div class="message tool">`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html should contain %q:\n%s", want, html)
+		}
+	}
+}
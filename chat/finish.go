@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// FinishPolicy decides the retry of a response finished by FinishReasonSafety or FinishReasonError.
+// It returns the request to retry, eg. with the flagged content removed, a softened prompt
+// or another model, or nil to return the response as is.
+// req is the request of the response, the rewritten request on retries.
+type FinishPolicy func(ctx context.Context, req *Request, resp *Response) (*Request, error)
+
+// WithFinishPolicy sets the policy of responses finished by safety or error, retried up to maxRetries.
+func WithFinishPolicy(policy FinishPolicy, maxRetries int) Option {
+	return func(o *Options) {
+		o.FinishPolicy = policy
+		o.FinishPolicyRetries = maxRetries
+	}
+}
+
+// PolicyFinishReason reports whether the finish reason is handled by FinishPolicy.
+func PolicyFinishReason(reason FinishReason) bool {
+	return reason == FinishReasonSafety || reason == FinishReasonError
+}
+
+// SoftenPrompt is the system instruction added by SoftenPolicy.
+const SoftenPrompt = "Respond helpfully while avoiding any explicit, violent or otherwise unsafe content. " +
+	"If part of the request cannot be answered safely, answer the rest and briefly decline that part."
+
+// SoftenPolicy retries responses finished by safety with SoftenPrompt added to the system message.
+func SoftenPolicy(ctx context.Context, req *Request, resp *Response) (*Request, error) {
+	if resp.FinishReason != FinishReasonSafety {
+		return nil, nil
+	}
+	r := *req
+	r.Messages = slices.Clone(req.Messages)
+	for i, msg := range r.Messages {
+		if msg.Role != MessageRoleSystem {
+			continue
+		}
+		text := msg.ContentString()
+		if strings.Contains(text, SoftenPrompt) {
+			return nil, nil
+		}
+		r.Messages[i] = NewTextMessage(MessageRoleSystem, text+"\n\n"+SoftenPrompt)
+		return &r, nil
+	}
+	r.Messages = append([]Message{NewTextMessage(MessageRoleSystem, SoftenPrompt)}, r.Messages...)
+	return &r, nil
+}
+
+// FallbackModelPolicy retries the request with the model, eg. of another provider.
+func FallbackModelPolicy(model string) FinishPolicy {
+	return func(ctx context.Context, req *Request, resp *Response) (*Request, error) {
+		if req.Model == model {
+			return nil, nil
+		}
+		r := *req
+		r.Model = model
+		return &r, nil
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSoftenPolicy(t *testing.T) {
+	ctx := context.Background()
+	req := &Request{Messages: []Message{
+		NewTextMessage(MessageRoleSystem, "You are a novelist."),
+		NewTextMessage(MessageRoleHuman, "Write a battle scene."),
+	}}
+	if r, _ := SoftenPolicy(ctx, req, &Response{FinishReason: FinishReasonError}); r != nil {
+		t.Error("only safety should be retried")
+	}
+	r, err := SoftenPolicy(ctx, req, &Response{FinishReason: FinishReasonSafety})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Messages) != 2 || !strings.HasSuffix(r.Messages[0].ContentString(), SoftenPrompt) {
+		t.Errorf("system message mismatch: %+v", r.Messages)
+	}
+	if req.Messages[0].ContentString() != "You are a novelist." {
+		t.Error("original request should not be modified")
+	}
+	if r, _ := SoftenPolicy(ctx, r, &Response{FinishReason: FinishReasonSafety}); r != nil {
+		t.Error("softened request should not be retried again")
+	}
+}
+
+func TestFallbackModelPolicy(t *testing.T) {
+	policy := FallbackModelPolicy("claude-3-5-haiku-latest")
+	r, _ := policy(context.Background(), &Request{Model: "gemini-2.0-flash"}, &Response{FinishReason: FinishReasonSafety})
+	if r == nil || r.Model != "claude-3-5-haiku-latest" {
+		t.Errorf("request mismatch: %+v", r)
+	}
+	if r, _ := policy(context.Background(), r, &Response{FinishReason: FinishReasonSafety}); r != nil {
+		t.Error("fallback model should not be retried again")
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	// DefaultForecastPercentile is the percentile of the past output tokens used as the expected output.
+	DefaultForecastPercentile = 0.9
+	// DefaultForecastOutputTokens is the expected output tokens of a conversation without AI turns.
+	DefaultForecastOutputTokens = 500
+)
+
+// Forecast is the estimated tokens and cost of the next call.
+type Forecast struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+	// Cost is zero if the model has no price.
+	Cost     float64 `json:"cost"`
+	Currency string  `json:"currency,omitempty"`
+	// ExceedsContext is true if the prompt exceeds the model context window.
+	ExceedsContext bool `json:"exceeds_context,omitempty"`
+}
+
+// WithForecastPercentile sets the percentile (0-1) of the past output tokens used by Conversation.Forecast.
+func WithForecastPercentile(p float64) Option {
+	return func(o *Options) {
+		o.ForecastPercentile = p
+	}
+}
+
+// Forecast estimates the tokens and cost of sending the next message with the model.
+// The prompt is estimated by EstimateModelTokens and the output is a percentile of the past AI turns,
+// so UIs can warn users before expensive turns.
+func (c *Conversation) Forecast(next Message, model string, opts ...Option) (*Forecast, error) {
+	o := NewOptions(opts...)
+	info := o.ModelCatalog.GetModel(model)
+	if info == nil {
+		return nil, fmt.Errorf("model not found: %s", model)
+	}
+
+	req := c.Request(model)
+	req.Messages = append(req.Messages, next)
+	input := o.EstimateModelTokens(req, info)
+
+	p := o.ForecastPercentile
+	if p == 0 {
+		p = DefaultForecastPercentile
+	}
+	output := percentile(c.turnOutputTokens(), p)
+	if output == 0 {
+		output = DefaultForecastOutputTokens
+	}
+	maxOutput := info.MaxOutputTokens
+	if maxOutput == 0 {
+		maxOutput = info.MaxTokens
+	}
+	if maxOutput > 0 {
+		output = min(output, maxOutput)
+	}
+
+	usage := &Usage{InputTokens: input, OutputTokens: output, TotalTokens: input + output}
+	o.CalculateCost(model, usage)
+	return &Forecast{
+		InputTokens:    input,
+		OutputTokens:   output,
+		TotalTokens:    usage.TotalTokens,
+		Cost:           usage.Cost,
+		Currency:       usage.Currency,
+		ExceedsContext: info.MaxInputTokens > 0 && input > info.MaxInputTokens,
+	}, nil
+}
+
+// turnOutputTokens returns the estimated output tokens of each AI turn, consecutive AI messages.
+func (c *Conversation) turnOutputTokens() []int {
+	var turns []int
+	start := -1
+	for i := 0; i <= len(c.Messages); i++ {
+		isAI := i < len(c.Messages) && c.Messages[i].Role == MessageRoleAI
+		switch {
+		case isAI && start < 0:
+			start = i
+		case !isAI && start >= 0:
+			turns = append(turns, EstimateTokens(&Request{Messages: c.Messages[start:i]}))
+			start = -1
+		}
+	}
+	return turns
+}
+
+// percentile returns the nearest-rank percentile of the values, or 0 if empty.
+func percentile[T cmp.Ordered](values []T, p float64) T {
+	if len(values) == 0 {
+		var zero T
+		return zero
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConversationForecast(t *testing.T) {
+	c := NewConversation(NewTextMessage(MessageRoleSystem, "Be helpful."))
+	for _, n := range []int{100, 400, 200} {
+		c.Append(
+			NewTextMessage(MessageRoleHuman, "question"),
+			// 4 Latin characters per token.
+			NewTextMessage(MessageRoleAI, strings.Repeat("a", n*4)),
+		)
+	}
+	next := NewTextMessage(MessageRoleHuman, "next question")
+
+	f, err := c.Forecast(next, "gpt-4o-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := c.Request("gpt-4o-mini")
+	req.Messages = append(req.Messages, next)
+	if want := NewOptions().EstimateModelTokens(req, NewOptions().ModelCatalog.GetModel("gpt-4o-mini")); f.InputTokens != want {
+		t.Errorf("input tokens = %d, want %d", f.InputTokens, want)
+	}
+	// p90 of 104, 204 and 404 tokens including the message overhead.
+	if f.OutputTokens != 404 || f.TotalTokens != f.InputTokens+f.OutputTokens {
+		t.Errorf("output tokens = %d", f.OutputTokens)
+	}
+	if f.Cost <= 0 || f.ExceedsContext {
+		t.Errorf("forecast mismatch: %+v", f)
+	}
+
+	f, err = c.Forecast(next, "gpt-4o-mini", WithForecastPercentile(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.OutputTokens != 204 {
+		t.Errorf("p50 output tokens = %d", f.OutputTokens)
+	}
+
+	f, err = NewConversation().Forecast(next, "gpt-4o-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.OutputTokens != DefaultForecastOutputTokens {
+		t.Errorf("default output tokens = %d", f.OutputTokens)
+	}
+
+	if _, err := c.Forecast(next, "unknown-model"); err == nil {
+		t.Error("expected error for unknown model")
+	}
+}
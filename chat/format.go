@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ResponseType is the format of the response text.
+type ResponseType string
+
+const (
+	// ResponseTypeText is free-form text. It is the default.
+	ResponseTypeText ResponseType = "text"
+	// ResponseTypeJSON is a JSON object without a schema.
+	ResponseTypeJSON ResponseType = "json"
+	// ResponseTypeJSONSchema is a JSON instance of Request.ResponseSchema.
+	ResponseTypeJSONSchema ResponseType = "json_schema"
+)
+
+// ErrResponseFormatConflict is returned when the response format fields of a request contradict each other.
+var ErrResponseFormatConflict = errors.New("response format conflict")
+
+// UnsupportedFormatError is returned when the provider does not support the response format combination.
+type UnsupportedFormatError struct {
+	Provider     string
+	ResponseType ResponseType
+	Reason       string
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("response type %s is not supported by %s: %s", e.ResponseType, e.Provider, e.Reason)
+}
+
+// EffectiveResponseType returns the response type applied to the request.
+// ResponseSchema takes precedence over ResponseType, and ResponseType over the text default.
+func (r *Request) EffectiveResponseType() ResponseType {
+	switch {
+	case r.ResponseSchema != nil:
+		return ResponseTypeJSONSchema
+	case r.ResponseType != "":
+		return r.ResponseType
+	}
+	return ResponseTypeText
+}
+
+// ValidateResponseFormat validates the response format of the request for the provider.
+//
+// The rules are:
+//   - ResponseSchema implies json_schema; ResponseType "json" is allowed with it, "text" is a conflict.
+//   - ResponseType json_schema requires ResponseSchema.
+//   - ResponseRegex only applies to text responses.
+//   - Tools are allowed with any response type; the format applies to the final answer,
+//     except on Gemini which does not support function calling with JSON responses.
+func ValidateResponseFormat(provider string, req *Request) error {
+	switch req.ResponseType {
+	case "", ResponseTypeText, ResponseTypeJSON, ResponseTypeJSONSchema:
+	default:
+		return fmt.Errorf("%w: unknown response type %q", ErrResponseFormatConflict, req.ResponseType)
+	}
+	if req.ResponseType == ResponseTypeText && req.ResponseSchema != nil {
+		return fmt.Errorf("%w: response type text with response schema", ErrResponseFormatConflict)
+	}
+	if req.ResponseType == ResponseTypeJSONSchema && req.ResponseSchema == nil {
+		return fmt.Errorf("%w: response type json_schema without response schema", ErrResponseFormatConflict)
+	}
+
+	typ := req.EffectiveResponseType()
+	if typ != ResponseTypeText && req.Config.ResponseRegex != "" {
+		return fmt.Errorf("%w: response regex with response type %s", ErrResponseFormatConflict, typ)
+	}
+	if provider == "gemini" && typ != ResponseTypeText && len(req.Tools) > 0 {
+		return &UnsupportedFormatError{Provider: provider, ResponseType: typ, Reason: "tools with JSON response"}
+	}
+	return nil
+}
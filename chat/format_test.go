@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestValidateResponseFormat(t *testing.T) {
+	schema := jsonschema.Schema{"type": "object"}
+	tools := []Tool{{Name: "search"}}
+
+	tests := []struct {
+		name     string
+		provider string
+		req      *Request
+		want     ResponseType
+		conflict bool
+		unsupp   bool
+	}{
+		{name: "default", req: &Request{}, want: ResponseTypeText},
+		{name: "schema", req: &Request{ResponseSchema: schema}, want: ResponseTypeJSONSchema},
+		{name: "json with schema", req: &Request{ResponseType: ResponseTypeJSON, ResponseSchema: schema}, want: ResponseTypeJSONSchema},
+		{name: "text with schema", req: &Request{ResponseType: ResponseTypeText, ResponseSchema: schema}, conflict: true},
+		{name: "json_schema without schema", req: &Request{ResponseType: ResponseTypeJSONSchema}, conflict: true},
+		{name: "unknown", req: &Request{ResponseType: "yaml"}, conflict: true},
+		{name: "regex with json", req: &Request{ResponseType: ResponseTypeJSON, Config: ModelConfig{ResponseRegex: `\d+`}}, conflict: true},
+		{name: "schema and tools", provider: "openai", req: &Request{ResponseSchema: schema, Tools: tools}, want: ResponseTypeJSONSchema},
+		{name: "gemini schema and tools", provider: "gemini", req: &Request{ResponseSchema: schema, Tools: tools}, unsupp: true},
+		{name: "gemini text and tools", provider: "gemini", req: &Request{Tools: tools}, want: ResponseTypeText},
+	}
+	for _, tt := range tests {
+		err := ValidateResponseFormat(tt.provider, tt.req)
+		var unsupp *UnsupportedFormatError
+		switch {
+		case tt.conflict:
+			if !errors.Is(err, ErrResponseFormatConflict) {
+				t.Errorf("%s: want conflict, got %v", tt.name, err)
+			}
+		case tt.unsupp:
+			if !errors.As(err, &unsupp) {
+				t.Errorf("%s: want unsupported, got %v", tt.name, err)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		case tt.req.EffectiveResponseType() != tt.want:
+			t.Errorf("%s: response type = %s, want %s", tt.name, tt.req.EffectiveResponseType(), tt.want)
+		}
+	}
+}
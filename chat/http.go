@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// WithHeader adds a header to the provider API requests.
+func WithHeader(key, value string) Option {
+	return WithProviderHeader("", key, value)
+}
+
+// WithProviderHeader adds a header to the API requests of the provider only.
+// eg. WithProviderHeader("anthropic", "anthropic-beta", "...")
+func WithProviderHeader(provider, key, value string) Option {
+	return func(o *Options) {
+		if o.Headers == nil {
+			o.Headers = map[string]http.Header{}
+		}
+		if o.Headers[provider] == nil {
+			o.Headers[provider] = http.Header{}
+		}
+		o.Headers[provider].Add(key, value)
+	}
+}
+
+// WithQueryParam adds a query parameter to the provider API requests.
+func WithQueryParam(key, value string) Option {
+	return WithProviderQueryParam("", key, value)
+}
+
+// WithProviderQueryParam adds a query parameter to the API requests of the provider only.
+func WithProviderQueryParam(provider, key, value string) Option {
+	return func(o *Options) {
+		if o.QueryParams == nil {
+			o.QueryParams = map[string]url.Values{}
+		}
+		if o.QueryParams[provider] == nil {
+			o.QueryParams[provider] = url.Values{}
+		}
+		o.QueryParams[provider].Add(key, value)
+	}
+}
+
+// WithOpenAIOrganization sets the OpenAI organization for multi-org accounts.
+func WithOpenAIOrganization(org string) Option {
+	return WithProviderHeader("openai", "OpenAI-Organization", org)
+}
+
+// WithOpenAIProject sets the OpenAI project.
+func WithOpenAIProject(project string) Option {
+	return WithProviderHeader("openai", "OpenAI-Project", project)
+}
+
+// WithAnthropicBeta enables Anthropic beta features, eg. "prompt-caching-2024-07-31".
+func WithAnthropicBeta(features ...string) Option {
+	return WithProviderHeader("anthropic", "anthropic-beta", strings.Join(features, ","))
+}
+
+// HTTPClient returns an HTTP client adding the headers and query parameters for the provider.
+// Returns nil if there is nothing to add, so the SDK default client is used.
+// Provider API traffic is recorded or replayed if WithRecording or WithReplay is set.
+// With WithRetry, the Retry-After of failed responses is recorded in the RetryAfter of the request context.
+func (o *Options) HTTPClient(provider string) *http.Client {
+	header := http.Header{}
+	for _, p := range []string{"", provider} {
+		for k, vs := range o.Headers[p] {
+			header[k] = append(header[k], vs...)
+		}
+	}
+	query := url.Values{}
+	for _, p := range []string{"", provider} {
+		for k, vs := range o.QueryParams[p] {
+			query[k] = append(query[k], vs...)
+		}
+	}
+	if len(header) == 0 && len(query) == 0 && o.RecordDir == "" && o.ReplayDir == "" && o.Retry == nil {
+		return nil
+	}
+
+	var base http.RoundTripper = http.DefaultTransport
+	if o.RecordDir != "" || o.ReplayDir != "" {
+		base = &recordTransport{
+			base:      http.DefaultTransport,
+			recordDir: o.RecordDir,
+			replayDir: o.ReplayDir,
+		}
+	}
+	return &http.Client{
+		Transport: &headerTransport{
+			base:   base,
+			header: header,
+			query:  query,
+		},
+	}
+}
+
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+	query  url.Values
+}
+
+// listHeaders are comma separated lists merged with the values set by the SDKs,
+// eg. the anthropic-beta of the 1h cache TTL, instead of replacing them.
+var listHeaders = map[string]bool{"Anthropic-Beta": true, "Openai-Beta": true}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, vs := range t.header {
+		if listHeaders[http.CanonicalHeaderKey(k)] {
+			req.Header.Set(k, mergeList(req.Header.Values(k), vs))
+			continue
+		}
+		req.Header.Del(k)
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if len(t.query) > 0 {
+		q := req.URL.Query()
+		for k, vs := range t.query {
+			q[k] = vs
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+	resp, err := t.base.RoundTrip(req)
+	if r, ok := req.Context().Value(retryAfterKey{}).(*RetryAfter); ok && resp != nil {
+		r.record(resp)
+	}
+	return resp, err
+}
+
+// mergeList joins the comma separated values without duplicates.
+func mergeList(values ...[]string) string {
+	items := []string{}
+	for _, vs := range values {
+		for _, v := range vs {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" && !slices.Contains(items, item) {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return strings.Join(items, ",")
+}
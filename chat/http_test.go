@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClient(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer server.Close()
+
+	if NewOptions().HTTPClient("openai") != nil {
+		t.Fatal("HTTP client should be nil without headers")
+	}
+
+	o := NewOptions(
+		WithHeader("X-Common", "common"),
+		WithProviderHeader("openai", "OpenAI-Beta", "assistants=v2"),
+		WithProviderHeader("anthropic", "anthropic-beta", "prompt-caching"),
+		WithQueryParam("api-version", "2025-01-01"),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+"/v1/models?limit=10", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := o.HTTPClient("openai").Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Header.Get("X-Common") != "common" || got.Header.Get("OpenAI-Beta") != "assistants=v2" {
+		t.Errorf("headers mismatch: %v", got.Header)
+	}
+	if got.Header.Get("anthropic-beta") != "" {
+		t.Errorf("other provider header should not be added: %v", got.Header)
+	}
+	if got.URL.Query().Get("api-version") != "2025-01-01" || got.URL.Query().Get("limit") != "10" {
+		t.Errorf("query mismatch: %v", got.URL.RawQuery)
+	}
+}
+
+func TestProviderOptions(t *testing.T) {
+	o := NewOptions(
+		WithOpenAIOrganization("org-1"),
+		WithOpenAIProject("proj-1"),
+		WithAnthropicBeta("prompt-caching-2024-07-31", "pdfs-2024-09-25"),
+	)
+
+	if o.Headers["openai"].Get("OpenAI-Organization") != "org-1" {
+		t.Errorf("organization mismatch: %v", o.Headers["openai"])
+	}
+	if o.Headers["openai"].Get("OpenAI-Project") != "proj-1" {
+		t.Errorf("project mismatch: %v", o.Headers["openai"])
+	}
+	if o.Headers["anthropic"].Get("anthropic-beta") != "prompt-caching-2024-07-31,pdfs-2024-09-25" {
+		t.Errorf("beta mismatch: %v", o.Headers["anthropic"])
+	}
+}
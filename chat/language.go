@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// LanguageMismatchError is returned when the response is not in the requested language after a retry.
+type LanguageMismatchError struct {
+	Want string
+	// Got is the detected language of the response.
+	Got  string
+	Text string
+	// Response is the last response.
+	Response *Response
+}
+
+func (e *LanguageMismatchError) Error() string {
+	return fmt.Sprintf("response language %s does not match %s", e.Got, e.Want)
+}
+
+// MetadataLanguageMismatch is the response metadata key of the detected language
+// of a response not in the response language after the retry.
+const MetadataLanguageMismatch = "language_mismatch"
+
+// WithResponseLanguage instructs the model to respond in the language, eg. "ja" or "pt-BR",
+// and validates the language of text responses with DetectLanguage, retrying once on mismatch.
+// A response still not in the language is returned with MetadataLanguageMismatch.
+func WithResponseLanguage(lang string) Option {
+	return func(o *Options) {
+		o.ResponseLanguage = lang
+	}
+}
+
+// WithStrictResponseLanguage returns *LanguageMismatchError instead of a response
+// still not in the response language after the retry.
+func WithStrictResponseLanguage() Option {
+	return func(o *Options) {
+		o.StrictResponseLanguage = true
+	}
+}
+
+// languageNames are the English names of languages in the instruction.
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "el": "Greek", "en": "English", "es": "Spanish", "fr": "French",
+	"he": "Hebrew", "hi": "Hindi", "it": "Italian", "ja": "Japanese", "ko": "Korean", "nl": "Dutch",
+	"pt": "Portuguese", "ru": "Russian", "th": "Thai", "zh": "Chinese",
+}
+
+const responseLanguagePrompt = "Always respond in %s, regardless of the language of the input."
+
+// LanguageName returns the English name of the language, or lang if unknown.
+func LanguageName(lang string) string {
+	if name, ok := languageNames[baseLanguage(lang)]; ok {
+		return name
+	}
+	return lang
+}
+
+// ResponseLanguagePrompt returns the instruction to respond in the language.
+func ResponseLanguagePrompt(lang string) string {
+	return fmt.Sprintf(responseLanguagePrompt, LanguageName(lang))
+}
+
+// ApplyResponseLanguage returns a copy of the request with the response language instruction
+// appended to the system message, or a new system message if there is none.
+func (o *Options) ApplyResponseLanguage(req *Request) *Request {
+	if o.ResponseLanguage == "" {
+		return req
+	}
+	return AppendSystemPrompt(req, ResponseLanguagePrompt(o.ResponseLanguage))
+}
+
+// CheckResponseLanguage returns *LanguageMismatchError if the detected language of the text response
+// differs from lang. Structured outputs, tool calls, undetectable text and languages DetectLanguage
+// does not detect are not checked. Code and URLs in the text are ignored.
+func CheckResponseLanguage(lang string, req *Request, resp *Response) error {
+	if lang == "" || !detectable(baseLanguage(lang)) ||
+		req.EffectiveResponseType() != ResponseTypeText || len(resp.ToolCalls()) > 0 {
+		return nil
+	}
+	text := resp.Text()
+	got := DetectLanguage(proseText(text))
+	if got == "" || got == baseLanguage(lang) {
+		return nil
+	}
+	return &LanguageMismatchError{Want: lang, Got: got, Text: text, Response: resp}
+}
+
+var (
+	codePattern = regexp.MustCompile("(?s)```.*?(?:```|$)|`[^`\n]*`")
+	urlPattern  = regexp.MustCompile(`(?:https?://|www\.)\S+`)
+)
+
+// proseText returns the text without code and URLs, which are not in the language of the prose.
+func proseText(text string) string {
+	text = codePattern.ReplaceAllString(text, " ")
+	return urlPattern.ReplaceAllString(text, " ")
+}
+
+// detectable reports whether DetectLanguage detects the language,
+// as other languages of the same script are detected as another language, eg. Ukrainian as Russian.
+func detectable(lang string) bool {
+	if lang == "ja" || lang == "zh" {
+		return true
+	}
+	if _, ok := latinWords[lang]; ok {
+		return true
+	}
+	for _, s := range scriptLanguages {
+		if s.lang == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func baseLanguage(lang string) string {
+	base, _, _ := strings.Cut(strings.ToLower(lang), "-")
+	base, _, _ = strings.Cut(base, "_")
+	return base
+}
+
+// cjkWeight weights CJK characters against letters of alphabets, as a character is about a word.
+const cjkWeight = 3
+
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// DetectLanguage returns the ISO 639-1 code of the dominant language of the text,
+// or "" if unknown. It is a fast heuristic by script, with common words for Latin script languages.
+// Japanese is distinguished from Chinese by kana.
+func DetectLanguage(text string) string {
+	counts := map[string]int{}
+	han, kana, latin := 0, 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					counts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if kana > 0 && kana*20 >= han+kana {
+		counts["ja"] = (han + kana) * cjkWeight
+	} else if han > 0 {
+		counts["zh"] = han * cjkWeight
+	}
+	counts["ko"] *= cjkWeight
+
+	best, bestCount := "", 0
+	for lang, n := range counts {
+		if n > bestCount || n == bestCount && lang < best {
+			best, bestCount = lang, n
+		}
+	}
+	if latin > bestCount {
+		return detectLatinLanguage(text)
+	}
+	return best
+}
+
+// latinWords are frequent words of Latin script languages.
+var latinWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "with", "for", "this", "you"},
+	"es": {"el", "la", "los", "las", "y", "es", "de", "que", "en", "un", "una", "por", "con", "para"},
+	"fr": {"le", "la", "les", "et", "est", "de", "des", "que", "un", "une", "pour", "dans", "avec", "vous"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "für", "auf", "sie", "ich"},
+	"pt": {"o", "os", "as", "e", "é", "de", "que", "um", "uma", "para", "com", "não", "em", "do", "da"},
+	"it": {"il", "lo", "gli", "e", "è", "di", "che", "un", "una", "per", "con", "non", "sono", "della"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "met", "voor", "op", "zijn", "ik"},
+}
+
+// detectLatinLanguage returns the language with the most frequent words,
+// or "" if no language clearly wins.
+func detectLatinLanguage(text string) string {
+	scores := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for lang, words := range latinWords {
+			if slices.Contains(words, word) {
+				scores[lang]++
+			}
+		}
+	}
+	best, bestScore, second := "", 0, 0
+	for lang, n := range scores {
+		switch {
+		case n > bestScore:
+			best, bestScore, second = lang, n, bestScore
+		case n > second:
+			second = n
+		}
+	}
+	if bestScore < 2 || bestScore*2 < second*3 {
+		return ""
+	}
+	return best
+}
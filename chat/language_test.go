@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"今日はいい天気ですね。GPT-4oのモデルを使います。", "ja"},
+		{"今天天气很好，我们去公园吧。", "zh"},
+		{"오늘 날씨가 좋네요.", "ko"},
+		{"Сегодня хорошая погода.", "ru"},
+		{"The weather is nice today and it is sunny.", "en"},
+		{"El tiempo es bueno y la gente está en el parque.", "es"},
+		{"Der Himmel ist blau und die Sonne scheint.", "de"},
+		{"OK", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestApplyResponseLanguage(t *testing.T) {
+	req := &Request{Messages: []Message{
+		NewTextMessage(MessageRoleSystem, "You are helpful."),
+		NewTextMessage(MessageRoleHuman, "Hello"),
+	}}
+	got := NewOptions(WithResponseLanguage("ja-JP")).ApplyResponseLanguage(req)
+	if len(got.Messages) != 2 || got.Messages[0].ContentString() != "You are helpful.\n\nAlways respond in Japanese, regardless of the language of the input." {
+		t.Errorf("system message mismatch: %q", got.Messages[0].ContentString())
+	}
+	if len(req.Messages[0].Content) != 1 {
+		t.Error("original request modified")
+	}
+
+	got = NewOptions(WithResponseLanguage("fr")).ApplyResponseLanguage(&Request{Messages: req.Messages[1:]})
+	if len(got.Messages) != 2 || got.Messages[0].Role != MessageRoleSystem {
+		t.Errorf("system message should be added: %+v", got.Messages)
+	}
+}
+
+func TestCheckResponseLanguage(t *testing.T) {
+	resp := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, "The answer is in the file.")}}
+	var mismatch *LanguageMismatchError
+	if err := CheckResponseLanguage("ja", &Request{}, resp); !errors.As(err, &mismatch) || mismatch.Got != "en" {
+		t.Errorf("expected LanguageMismatchError, got %v", err)
+	}
+	if err := CheckResponseLanguage("en-US", &Request{}, resp); err != nil {
+		t.Errorf("language should match: %v", err)
+	}
+	if err := CheckResponseLanguage("ja", &Request{ResponseType: ResponseTypeJSON}, resp); err != nil {
+		t.Errorf("JSON response should not be checked: %v", err)
+	}
+	if err := CheckResponseLanguage("uk", &Request{}, resp); err != nil {
+		t.Errorf("undetectable language should not be checked: %v", err)
+	}
+
+	code := &Response{Messages: []Message{NewTextMessage(MessageRoleAI,
+		"次のコードを使います。\n```go\nfor i := 0; i < len(items); i++ {\n\t// print the item and the index of the item\n}\n```\n"+
+			"詳しくは https://go.dev/doc/effective_go#for-the-loop-and-the-range-of-the-items を見てください。")}}
+	if err := CheckResponseLanguage("ja", &Request{}, code); err != nil {
+		t.Errorf("code and URLs should be ignored: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/jumonmd/gengo/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// RequestFile is the declarative format of a request in YAML or JSON,
+// eg. for generation tasks of cron jobs and pipelines:
+//
+//	model: gpt-4o-mini
+//	config:
+//	  temperature: 0.2
+//	  max_tokens: 1000
+//	prompt:
+//	  name: daily-report
+//	system: You are a news reporter.
+//	messages:
+//	  - role: human
+//	    content: Summarize the news of today.
+//	tools: [search_news]
+//	response_schema:
+//	  type: object
+//	  properties:
+//	    summary: {type: string}
+//	metadata:
+//	  job: daily-report
+//
+// The prompt references a stored prompt, resolved by promptstore.Middleware.
+// Tools are referenced by name and resolved from the tools passed to ParseRequest.
+type RequestFile struct {
+	Model          string            `json:"model"`
+	Config         ModelConfig       `json:"config,omitempty"`
+	Prompt         *PromptRef        `json:"prompt,omitempty"`
+	System         string            `json:"system,omitempty"`
+	Messages       []FileMessage     `json:"messages,omitempty"`
+	Tools          []string          `json:"tools,omitempty"`
+	MustCallTool   bool              `json:"must_call_tool,omitempty"`
+	ResponseType   ResponseType      `json:"response_type,omitempty"`
+	ResponseSchema jsonschema.Schema `json:"response_schema,omitempty"`
+	Metadata       Metadata          `json:"metadata,omitempty"`
+}
+
+// FileMessage is a text message of RequestFile.
+type FileMessage struct {
+	Role    MessageRole `json:"role"`
+	Name    string      `json:"name,omitempty"`
+	Content string      `json:"content"`
+}
+
+// RequestFileSchema is the JSON schema of RequestFile. Unknown keys are rejected to catch typos.
+var RequestFileSchema = jsonschema.MustParseJSONString(`{
+	"type": "object",
+	"properties": {
+		"model": {"type": "string", "minLength": 1},
+		"config": {"type": "object", "properties": {
+			"max_tokens": {"type": "integer", "minimum": 0},
+			"temperature": {"type": "number", "minimum": 0},
+			"top_p": {"type": "number", "minimum": 0, "maximum": 1},
+			"presence_penalty": {"type": "number"},
+			"frequency_penalty": {"type": "number"},
+			"stop_words": {"type": "array", "items": {"type": "string"}},
+			"grammar": {"type": "string"},
+			"response_regex": {"type": "string"}
+		}, "additionalProperties": false},
+		"prompt": {"type": "object", "properties": {
+			"name": {"type": "string", "minLength": 1},
+			"version": {"type": "string"}
+		}, "required": ["name"], "additionalProperties": false},
+		"system": {"type": "string"},
+		"messages": {"type": "array", "items": {"type": "object", "properties": {
+			"role": {"enum": ["system", "human", "ai"]},
+			"name": {"type": "string"},
+			"content": {"type": "string"}
+		}, "required": ["role", "content"], "additionalProperties": false}},
+		"tools": {"type": "array", "items": {"type": "string"}},
+		"must_call_tool": {"type": "boolean"},
+		"response_type": {"enum": ["text", "json", "json_schema"]},
+		"response_schema": {"type": "object"},
+		"metadata": {"type": "object", "additionalProperties": {"type": "string"}}
+	},
+	"required": ["model"],
+	"additionalProperties": false
+}`)
+
+// LoadRequest reads the RequestFile at the path and returns the request.
+func LoadRequest(path string, tools ...Tool) (*Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read request file: %w", err)
+	}
+	req, err := ParseRequest(data, tools...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return req, nil
+}
+
+// ParseRequest parses the RequestFile in YAML or JSON, validates it against RequestFileSchema,
+// and returns the request with the tools of the names.
+func ParseRequest(data []byte, tools ...Tool) (*Request, error) {
+	// YAML is a superset of JSON.
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("parse request file: %w", err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("convert request file: %w", err)
+	}
+	if err := RequestFileSchema.Validate(data); err != nil {
+		return nil, fmt.Errorf("invalid request file: %w", err)
+	}
+	f := &RequestFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("decode request file: %w", err)
+	}
+	if f.ResponseSchema != nil && !f.ResponseSchema.IsValid() {
+		return nil, fmt.Errorf("invalid response schema")
+	}
+
+	req := &Request{
+		Model:          f.Model,
+		Config:         f.Config,
+		Metadata:       f.Metadata,
+		PromptRef:      f.Prompt,
+		MustCallTool:   f.MustCallTool,
+		ResponseType:   f.ResponseType,
+		ResponseSchema: f.ResponseSchema,
+	}
+	if f.System != "" {
+		req.Messages = append(req.Messages, NewTextMessage(MessageRoleSystem, f.System))
+	}
+	for _, m := range f.Messages {
+		req.Messages = append(req.Messages, NewNamedTextMessage(m.Role, m.Name, m.Content))
+	}
+	for _, name := range f.Tools {
+		i := slices.IndexFunc(tools, func(t Tool) bool { return t.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("tool not found: %s", name)
+		}
+		req.Tools = append(req.Tools, tools[i])
+	}
+	return req, nil
+}
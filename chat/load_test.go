@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	data := `
+model: gpt-4o-mini
+config:
+  temperature: 0.2
+  max_tokens: 1000
+prompt:
+  name: daily-report
+system: You are a news reporter.
+messages:
+  - role: human
+    content: Summarize the news of today.
+tools: [search_news]
+response_schema:
+  type: object
+  properties:
+    summary: {type: string}
+metadata:
+  job: daily-report
+`
+	search := Tool{Name: "search_news", Description: "Search news."}
+	req, err := ParseRequest([]byte(data), Tool{Name: "other"}, search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Model != "gpt-4o-mini" || req.Config.Temperature != 0.2 || req.Config.MaxTokens != 1000 {
+		t.Errorf("request mismatch: %+v", req)
+	}
+	if req.PromptRef == nil || req.PromptRef.Name != "daily-report" || req.Metadata["job"] != "daily-report" {
+		t.Errorf("prompt or metadata mismatch: %+v", req)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != MessageRoleSystem || req.Messages[1].ContentString() != "Summarize the news of today." {
+		t.Errorf("messages mismatch: %+v", req.Messages)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Description != "Search news." || req.ResponseSchema["type"] != "object" {
+		t.Errorf("tools or schema mismatch: %+v", req)
+	}
+
+	// JSON is also accepted.
+	path := filepath.Join(t.TempDir(), "request.json")
+	if err := os.WriteFile(path, []byte(`{"model": "gpt-4o-mini", "messages": [{"role": "human", "content": "hi"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if req, err := LoadRequest(path); err != nil || req.Messages[0].ContentString() != "hi" {
+		t.Errorf("load mismatch: %+v %v", req, err)
+	}
+}
+
+func TestParseRequestInvalid(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"model: gpt-4o-mini\ntemprature: 0.2", "invalid request file"},
+		{"config: {max_tokens: 10}", "invalid request file"},
+		{"model: gpt-4o-mini\nmessages: [{role: user, content: hi}]", "invalid request file"},
+		{"model: gpt-4o-mini\ntools: [unknown]", "tool not found"},
+		{"model: [", "parse request file"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRequest([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error mismatch: %v", tt.data, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer. Message contents are redacted.
+func (r *Request) LogValue() slog.Value {
+	return r.logValue(false)
+}
+
+// LogValue implements slog.LogValuer. Message contents are redacted.
+func (r *Response) LogValue() slog.Value {
+	return r.logValue(false)
+}
+
+// LogValue implements slog.LogValuer. Contents are redacted to lengths and hashes.
+func (m *Message) LogValue() slog.Value {
+	return m.logValue(false)
+}
+
+// LogUnredacted returns a slog.LogValuer of Request, Response or Message including the contents.
+// Use it only when the logs are allowed to contain user data.
+func LogUnredacted(v any) slog.LogValuer {
+	return unredacted{v: v}
+}
+
+type unredacted struct {
+	v any
+}
+
+func (u unredacted) LogValue() slog.Value {
+	switch v := u.v.(type) {
+	case *Request:
+		return v.logValue(true)
+	case *Response:
+		return v.logValue(true)
+	case *Message:
+		return v.logValue(true)
+	case Message:
+		return v.logValue(true)
+	}
+	return slog.AnyValue(u.v)
+}
+
+func (r *Request) logValue(content bool) slog.Value {
+	if r == nil {
+		return slog.Value{}
+	}
+	tools := make([]string, len(r.Tools))
+	for i, t := range r.Tools {
+		tools[i] = t.Name
+	}
+	attrs := []slog.Attr{
+		slog.String("model", r.Model),
+		slog.Any("tools", tools),
+		messagesAttr(r.Messages, content),
+	}
+	if len(r.Metadata) > 0 {
+		attrs = append(attrs, slog.Any("metadata", r.Metadata))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (r *Response) logValue(content bool) slog.Value {
+	if r == nil {
+		return slog.Value{}
+	}
+	attrs := []slog.Attr{
+		slog.String("model", r.Model),
+		slog.String("finish_reason", string(r.FinishReason)),
+		messagesAttr(r.Messages, content),
+	}
+	if r.Usage != nil {
+		attrs = append(attrs,
+			slog.Int("input_tokens", r.Usage.InputTokens),
+			slog.Int("output_tokens", r.Usage.OutputTokens),
+			slog.Float64("cost", r.Usage.Cost))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func messagesAttr(messages []Message, content bool) slog.Attr {
+	attrs := make([]slog.Attr, len(messages))
+	for i := range messages {
+		attrs[i] = slog.Attr{Key: strconv.Itoa(i), Value: messages[i].logValue(content)}
+	}
+	return slog.Attr{Key: "messages", Value: slog.GroupValue(attrs...)}
+}
+
+func (m *Message) logValue(content bool) slog.Value {
+	attrs := []slog.Attr{slog.String("role", string(m.Role))}
+	for i, p := range m.Content {
+		attrs = append(attrs, slog.Attr{Key: "content." + strconv.Itoa(i), Value: p.logValue(content)})
+	}
+	if m.ToolCall != nil {
+		attrs = append(attrs,
+			slog.String("tool_call.name", m.ToolCall.Name),
+			slog.String("tool_call.id", m.ToolCall.ID),
+			redactedAttr("tool_call.arguments", m.ToolCall.Arguments, content))
+	}
+	if m.ToolResponse != nil {
+		attrs = append(attrs,
+			slog.String("tool_response.name", m.ToolResponse.Name),
+			slog.String("tool_response.id", m.ToolResponse.ID),
+			redactedAttr("tool_response.result", m.ToolResponse.Result, content))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (p ContentPart) logValue(content bool) slog.Value {
+	attrs := []slog.Attr{slog.String("type", p.Type)}
+	if p.Text != "" {
+		attrs = append(attrs, redactedAttr("text", p.Text, content))
+	}
+	if p.DataURL != "" {
+		// data URLs are never logged as is.
+		mimeType, data, err := SplitDataURL(p.DataURL)
+		if err != nil {
+			mimeType = "unknown"
+			data = p.DataURL
+		}
+		attrs = append(attrs,
+			slog.String("mime_type", mimeType),
+			slog.Int("data_length", len(data)),
+			slog.String("data_sha256", shortHash(data)))
+	}
+	if p.URL != "" {
+		// URLs may contain signed query parameters.
+		attrs = append(attrs, redactedAttr("url", p.URL, content))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func redactedAttr(key, value string, content bool) slog.Attr {
+	if content {
+		return slog.String(key, value)
+	}
+	return slog.Group(key, slog.Int("length", len(value)), slog.String("sha256", shortHash(value)))
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValueRedaction(t *testing.T) {
+	req := &Request{
+		Model: "test-model",
+		Messages: []Message{
+			NewTextMessage(MessageRoleHuman, "my secret is 1234"),
+			{Role: MessageRoleHuman, Content: []ContentPart{{Type: "image", DataURL: EncodeDataURL("image/png", []byte("png-bytes"))}}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+
+	logger.Info("request", "req", req)
+	out := buf.String()
+	if strings.Contains(out, "secret") {
+		t.Errorf("content should be redacted: %s", out)
+	}
+	if strings.Contains(out, "base64") {
+		t.Errorf("data URL should be redacted: %s", out)
+	}
+	if !strings.Contains(out, "req.messages.0.content.0.text.length=17") {
+		t.Errorf("content length should be logged: %s", out)
+	}
+	if !strings.Contains(out, "mime_type=image/png") {
+		t.Errorf("mime type should be logged: %s", out)
+	}
+
+	buf.Reset()
+	logger.Info("request", "req", LogUnredacted(req))
+	if !strings.Contains(buf.String(), "my secret is 1234") {
+		t.Errorf("unredacted content should be logged: %s", buf.String())
+	}
+}
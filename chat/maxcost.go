@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoPrice is returned by WithMaxCost for models without an output price.
+var ErrNoPrice = errors.New("no model price")
+
+// MaxCostError is returned when the estimated prompt cost leaves no output within the max cost.
+type MaxCostError struct {
+	Model string
+	// PromptCost is the estimated cost of the prompt tokens in USD.
+	PromptCost float64
+	MaxCost    float64
+}
+
+func (e *MaxCostError) Error() string {
+	return fmt.Sprintf("%s: estimated prompt cost $%.6f leaves no output within max cost $%.6f",
+		e.Model, e.PromptCost, e.MaxCost)
+}
+
+// WithMaxCost caps MaxTokens of requests so that a single call does not exceed the cost in USD.
+// The cap is computed from the estimated prompt tokens and the catalog prices with price overrides.
+// Prompts of Anthropic prompt caching are priced at the cache write rate, as the prompt may be written
+// to the cache. The prompt tokens are estimated, so the actual cost may slightly differ.
+func WithMaxCost(usd float64) Option {
+	return func(o *Options) {
+		o.MaxCost = usd
+	}
+}
+
+// MaxCostTokens returns the max output tokens within MaxCost after the estimated prompt cost.
+// Returns ErrNoPrice if the model has no output price, or *MaxCostError if the prompt alone
+// reaches MaxCost.
+func (o *Options) MaxCostTokens(req *Request, info *ModelInfo) (int32, error) {
+	priced := o.pricedModel(req.Model, info)
+	if priced.OutputTokenCost <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoPrice, req.Model)
+	}
+	promptCost := float64(o.EstimateModelTokens(req, info)) * o.promptTokenCost(&priced)
+	tokens := math.Floor((o.MaxCost - promptCost) / priced.OutputTokenCost)
+	if tokens < 1 {
+		return 0, &MaxCostError{Model: req.Model, PromptCost: promptCost, MaxCost: o.MaxCost}
+	}
+	return int32(min(tokens, math.MaxInt32)), nil
+}
+
+// promptTokenCost returns the price of a prompt token: the cache write price if it is higher
+// and Anthropic prompt caching is enabled, or the input price.
+func (o *Options) promptTokenCost(info *ModelInfo) float64 {
+	if info.Provider != "anthropic" || o.AnthropicCacheTTL == "" && len(o.PromptCacheBreakpoints) == 0 {
+		return info.InputTokenCost
+	}
+	write := info.CacheCreationTokenCost
+	if o.AnthropicCacheTTL == "1h" && info.CacheCreation1hTokenCost > 0 {
+		write = info.CacheCreation1hTokenCost
+	}
+	return max(info.InputTokenCost, write)
+}
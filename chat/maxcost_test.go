@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxCostTokens(t *testing.T) {
+	info := &ModelInfo{InputTokenCost: 0.000001, OutputTokenCost: 0.000004}
+	o := NewOptions(WithMaxCost(0.01))
+
+	long := &Request{Model: "m", Messages: []Message{NewTextMessage(MessageRoleHuman, strings.Repeat("a", 8000))}}
+	prompt := 2000 + messageOverheadTokens
+	if got, err := o.MaxCostTokens(long, info); err != nil || got != int32((10000-prompt)/4) {
+		t.Errorf("max tokens: %d, %v", got, err)
+	}
+
+	o = NewOptions(WithMaxCost(0.01), WithPriceOverride("m", ModelPrice{OutputTokenCost: 0.00001}))
+	if got, err := o.MaxCostTokens(long, info); err != nil || got != int32((10000-prompt)/10) {
+		t.Errorf("price override: %d, %v", got, err)
+	}
+
+	o = NewOptions(WithMaxCost(0.001))
+	var costErr *MaxCostError
+	if _, err := o.MaxCostTokens(long, info); !errors.As(err, &costErr) || costErr.MaxCost != 0.001 {
+		t.Errorf("expected MaxCostError, got %v", err)
+	}
+
+	cached := &ModelInfo{Provider: "anthropic", InputTokenCost: 0.000001, OutputTokenCost: 0.000004, CacheCreationTokenCost: 0.00000125, CacheCreation1hTokenCost: 0.000002}
+	o = NewOptions(WithMaxCost(0.01), WithAnthropicCacheTTL("1h"))
+	anthropicPrompt := o.EstimateModelTokens(long, cached)
+	if got, err := o.MaxCostTokens(long, cached); err != nil || got != int32((10000-2*anthropicPrompt)/4) {
+		t.Errorf("cache write: %d, %v", got, err)
+	}
+
+	if _, err := o.MaxCostTokens(long, &ModelInfo{}); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("expected ErrNoPrice, got %v", err)
+	}
+}
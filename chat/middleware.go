@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "context"
+
+// GenerateFunc generates a response for the request.
+type GenerateFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a provider call, eg. for quota, caching or logging.
+type Middleware func(next GenerateFunc) GenerateFunc
+
+// WithMiddleware adds middlewares. The first middleware is the outermost.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(o *Options) {
+		o.Middlewares = append(o.Middlewares, middlewares...)
+	}
+}
+
+// Wrap wraps the generate function with the middlewares.
+func (o *Options) Wrap(generate GenerateFunc) GenerateFunc {
+	for i := len(o.Middlewares) - 1; i >= 0; i-- {
+		generate = o.Middlewares[i](generate)
+	}
+	return generate
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	order := []string{}
+	mw := func(name string) Middleware {
+		return func(next GenerateFunc) GenerateFunc {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				order = append(order, name)
+				return next(ctx, req)
+			}
+		}
+	}
+
+	o := NewOptions(WithMiddleware(mw("a"), mw("b")), WithMiddleware(mw("c")))
+	generate := o.Wrap(func(ctx context.Context, req *Request) (*Response, error) {
+		order = append(order, "provider")
+		return &Response{}, nil
+	})
+	if _, err := generate(t.Context(), &Request{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "a,b,c,provider" {
+		t.Errorf("order mismatch: %s", got)
+	}
+}
@@ -23,9 +23,21 @@ type ModelInfo struct {
 	OutputTokenCost        float64 `json:"output_cost_per_token"`
 	CacheCreationTokenCost float64 `json:"cache_creation_input_token_cost"`
 	CacheReadTokenCost     float64 `json:"cache_read_input_token_cost"`
-	SupportsWebSearch      bool    `json:"supports_web_search"`
-	SupportsVision         bool    `json:"supports_vision"`
-	SupportsPDFInput       bool    `json:"supports_pdf_input"`
+	// CacheCreation1hTokenCost is the cache write cost with 1 hour TTL (Anthropic).
+	CacheCreation1hTokenCost float64 `json:"cache_creation_input_token_cost_above_1hr,omitempty"`
+	SupportsWebSearch        bool    `json:"supports_web_search"`
+	SupportsVision           bool    `json:"supports_vision"`
+	SupportsPDFInput         bool    `json:"supports_pdf_input"`
+	// SupportsFunctionCalling and the following capabilities are false if unknown by the catalog.
+	SupportsFunctionCalling         bool `json:"supports_function_calling,omitempty"`
+	SupportsParallelFunctionCalling bool `json:"supports_parallel_function_calling,omitempty"`
+	SupportsAudioInput              bool `json:"supports_audio_input,omitempty"`
+	SupportsResponseSchema          bool `json:"supports_response_schema,omitempty"`
+	SupportsPromptCaching           bool `json:"supports_prompt_caching,omitempty"`
+	// Deprecated is true if the provider announced the model deprecation.
+	Deprecated bool `json:"deprecated,omitempty"`
+	// ShutdownDate is the date the model stops serving in YYYY-MM-DD format.
+	ShutdownDate string `json:"shutdown_date,omitempty"`
 }
 
 // NewModelCatalog creates a new model catalog from a JSON reader input.
@@ -67,7 +79,21 @@ func (c ModelCatalog) CalculateCost(model string, usage *Usage) bool {
 
 func calculateCost(model *ModelInfo, usage *Usage) float64 {
 	cost := 0.0
-	cost += model.InputTokenCost * float64(usage.InputTokens)
+	input := usage.InputTokens
+	if model.CacheReadTokenCost > 0 && usage.CachedTokens > 0 {
+		input -= usage.CachedTokens
+		cost += model.CacheReadTokenCost * float64(usage.CachedTokens)
+	}
+	if model.CacheCreationTokenCost > 0 && usage.CacheCreationTokens > 0 {
+		input -= usage.CacheCreationTokens
+		price1h := model.CacheCreation1hTokenCost
+		if price1h == 0 {
+			price1h = model.CacheCreationTokenCost
+		}
+		cost += model.CacheCreationTokenCost * float64(usage.CacheCreationTokens-usage.CacheCreation1hTokens)
+		cost += price1h * float64(usage.CacheCreation1hTokens)
+	}
+	cost += model.InputTokenCost * float64(input)
 	cost += model.OutputTokenCost * float64(usage.OutputTokens)
 
 	return cost
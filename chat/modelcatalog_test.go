@@ -4,6 +4,7 @@
 package chat
 
 import (
+	"math"
 	"os"
 	"strings"
 	"testing"
@@ -55,3 +56,40 @@ func TestDefaultModelCatalog(t *testing.T) {
 		t.Fatal("default model catalog is nil")
 	}
 }
+
+func TestCalculateCostCachedTokens(t *testing.T) {
+	m := &ModelInfo{
+		InputTokenCost:     1e-6,
+		CacheReadTokenCost: 1e-7,
+	}
+
+	usage := &Usage{
+		InputTokens:  1000,
+		CachedTokens: 800,
+	}
+
+	cost := calculateCost(m, usage)
+	if math.Abs(cost-0.00028) > 1e-12 {
+		t.Fatalf("cost is not expected: %f", cost)
+	}
+}
+
+func TestCalculateCostCacheCreationTokens(t *testing.T) {
+	m := &ModelInfo{
+		InputTokenCost:           3e-6,
+		CacheCreationTokenCost:   3.75e-6,
+		CacheCreation1hTokenCost: 6e-6,
+	}
+
+	// 100 uncached, 400 written with 5m TTL and 500 written with 1h TTL.
+	usage := &Usage{
+		InputTokens:           1000,
+		CacheCreationTokens:   900,
+		CacheCreation1hTokens: 500,
+	}
+
+	cost := calculateCost(m, usage)
+	if math.Abs(cost-(100*3e-6+400*3.75e-6+500*6e-6)) > 1e-12 {
+		t.Fatalf("cost is not expected: %f", cost)
+	}
+}
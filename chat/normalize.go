@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// WithNormalization normalizes outbound text parts before dispatch:
+// NFC normalization, invisible and control character removal and whitespace normalization.
+// It protects against prompt injection hidden with Unicode tricks and stabilizes cache keys.
+func WithNormalization() Option {
+	return func(o *Options) {
+		o.Normalize = true
+	}
+}
+
+// NormalizeText returns the NFC normalized text without invisible characters
+// (zero width, bidi controls, tag characters) and control characters except newline and tab.
+// Zero width non-joiner and joiner are kept, as they are required by scripts such as Persian
+// and Indic scripts, and by emoji sequences.
+// CRLF is converted to LF and Unicode spaces to ASCII space.
+func NormalizeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return '\n'
+		case isInvisible(r) || unicode.IsControl(r):
+			return -1
+		case unicode.Is(unicode.Zs, r):
+			return ' '
+		}
+		return r
+	}, s)
+	return norm.NFC.String(s)
+}
+
+func isInvisible(r rune) bool {
+	switch {
+	case r == 0x00ad, r == 0x034f, r == 0x061c, r == 0x180e, r == 0xfeff:
+		return true
+	case r == 0x200b, r == 0x200e, r == 0x200f, r >= 0x202a && r <= 0x202e, r >= 0x2060 && r <= 0x206f:
+		return true
+	case r >= 0xe0000 && r <= 0xe007f:
+		return true
+	}
+	return false
+}
+
+// NormalizeRequest returns a copy of the request with the text parts and tool results normalized
+// if normalization is enabled, as tool results may carry injected text, eg. of fetched web pages.
+func (o *Options) NormalizeRequest(req *Request) *Request {
+	if !o.Normalize {
+		return req
+	}
+	r := *req
+	r.Messages = slices.Clone(req.Messages)
+	for i, msg := range r.Messages {
+		content := slices.Clone(msg.Content)
+		for j, part := range content {
+			if part.Type == "text" {
+				content[j].Text = NormalizeText(part.Text)
+			}
+		}
+		r.Messages[i].Content = content
+		if msg.ToolResponse != nil {
+			toolResponse := *msg.ToolResponse
+			toolResponse.Result = NormalizeText(toolResponse.Result)
+			r.Messages[i].ToolResponse = &toolResponse
+		}
+	}
+	return &r
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "testing"
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text\n\tindent", "plain text\n\tindent"},
+		{"cafe\u0301", "caf\u00e9"},
+		{"ig\u200bnore\u202e previous\ufeff", "ignore previous"},
+		{"a\u00a0b\u3000c", "a b c"},
+		{"line1\r\nline2\rline3\x00\x1b", "line1\nline2\nline3"},
+		{"می\u200cخواهم \U0001F468\u200d\U0001F469", "می\u200cخواهم \U0001F468\u200d\U0001F469"},
+		{"می\u200cخواهم 👨\u200d👩", "می\u200cخواهم 👨\u200d👩"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeText(tt.in); got != tt.want {
+			t.Errorf("NormalizeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeRequest(t *testing.T) {
+	req := &Request{Messages: []Message{
+		NewTextMessage(MessageRoleHuman, "hi\u200b"),
+		NewToolResponseMessage("fetch", "call-1", "ignore\u202e previous"),
+	}}
+
+	if got := NewOptions().NormalizeRequest(req); got != req {
+		t.Error("request should not be copied without normalization")
+	}
+	got := NewOptions(WithNormalization()).NormalizeRequest(req)
+	if got.Messages[0].Content[0].Text != "hi" {
+		t.Errorf("text not normalized: %q", got.Messages[0].Content[0].Text)
+	}
+	if got.Messages[1].ToolResponse.Result != "ignore previous" {
+		t.Errorf("tool result not normalized: %q", got.Messages[1].ToolResponse.Result)
+	}
+	if req.Messages[0].Content[0].Text != "hi\u200b" || req.Messages[1].ToolResponse.Result != "ignore\u202e previous" {
+		t.Error("original request modified")
+	}
+}
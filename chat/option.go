@@ -7,16 +7,113 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 )
 
 //go:embed modelcatalog.json
 var modelCatalog []byte
 
 type Options struct {
-	Streamer     Streamer
-	BaseURL      string
+	Streamer Streamer
+	BaseURL  string
+	// Provider overrides the provider of the model in the catalog.
+	Provider string
+	// Azure sends OpenAI requests to an Azure OpenAI resource.
+	Azure *AzureConfig
+	// APIKey overrides the provider API key environment variable.
+	APIKey string
+	// UserID is the end-user ID sent to the provider for abuse attribution.
+	UserID string
+	// Priority is the scheduling priority of the request.
+	Priority Priority
+	// Secrets provides the API keys if APIKey is not set.
+	Secrets      SecretsProvider
 	ModelCatalog ModelCatalog
 	UseSearch    bool
+	// PriceOverrides is a map of model name to price overrides.
+	PriceOverrides map[string]ModelPrice
+	// Currency of Usage.Cost. Empty means USD.
+	Currency     string
+	RateProvider RateProvider
+	// DeprecationHandler is called when a deprecated model is requested.
+	DeprecationHandler DeprecationHandler
+	// RunStore checkpoints agent runs with RunID.
+	RunStore RunStore
+	RunID    string
+	// MaxIterations is the maximum number of Generate calls in an agent run.
+	MaxIterations int
+	// ToolLoopThreshold and ToolLoopAction handle repeated identical tool calls in an agent run.
+	ToolLoopThreshold int
+	ToolLoopAction    ToolLoopAction
+	// Clarification lets the model pause agent runs to ask the user with the ask_user tool.
+	Clarification bool
+	// ToolResultLimit and ToolResultLimits by tool name truncate tool results in agent runs.
+	ToolResultLimit  int
+	ToolResultLimits map[string]int
+	// Clock returns the current time. time.Now by default.
+	Clock func() time.Time
+	// EventHandlers receive generation events.
+	EventHandlers []EventHandler
+	// MaxPartBytes and MaxRequestBytes limit content sizes.
+	MaxPartBytes    int
+	MaxRequestBytes int
+	// AllowedMIMETypes of data URL parts. Derived from the model capabilities if nil.
+	AllowedMIMETypes []string
+	// CachedContent is the name of the provider cached content (Gemini).
+	CachedContent string
+	// AnthropicCacheTTL enables Anthropic prompt caching with the TTL, "5m" or "1h".
+	AnthropicCacheTTL string
+	// PromptCacheBreakpoints are the indexes of the messages ending the cached prefixes (Anthropic).
+	PromptCacheBreakpoints []int
+	// Headers and QueryParams added to provider API requests, keyed by provider. "" is for all providers.
+	Headers     map[string]http.Header
+	QueryParams map[string]url.Values
+	// RecordDir records provider API traffic, ReplayDir replays it.
+	RecordDir string
+	ReplayDir string
+	// AutoMaxTokens computes MaxTokens from the remaining context.
+	AutoMaxTokens       bool
+	AutoMaxTokensMargin int
+	// MaxCost caps MaxTokens so a request does not exceed the cost in USD.
+	MaxCost float64
+	// ProviderProfiles override the default provider timeout and retry profiles.
+	ProviderProfiles map[string]ProviderProfile
+	// Retry overrides the retries of all provider profiles.
+	Retry *RetryPolicy
+	// FinishPolicy retries responses finished by safety or error up to FinishPolicyRetries.
+	FinishPolicy        FinishPolicy
+	FinishPolicyRetries int
+	// SchemaValidation validates the response against ResponseSchema.
+	SchemaValidation bool
+	// SchemaRetries is the number of regenerations on schema mismatch.
+	SchemaRetries int
+	// StreamSchemaValidation aborts streams diverging from ResponseSchema.
+	StreamSchemaValidation bool
+	// SchemaFieldOrder reorders the response JSON keys by the ResponseSchema property order.
+	SchemaFieldOrder bool
+	// ForecastPercentile is the percentile of the past output tokens in Conversation.Forecast.
+	ForecastPercentile float64
+	// TokenCorrections are the multipliers of the token estimates by model.
+	TokenCorrections map[string]float64
+	// DeadlineMargin suspends agent runs when the context deadline is near.
+	DeadlineMargin time.Duration
+	// SkipContextCheck disables the context window pre-check.
+	SkipContextCheck bool
+	// ResponseLanguage is the language the model responds in.
+	ResponseLanguage string
+	// StrictResponseLanguage fails responses not in ResponseLanguage after the retry.
+	StrictResponseLanguage bool
+	// MaxOutputChars limits text responses to the characters, instructed in OutputCharsLocale.
+	MaxOutputChars    int
+	OutputCharsLocale string
+	// OutputCharsRetries is the number of regenerations on exceeding MaxOutputChars before trimming.
+	OutputCharsRetries int
+	// Normalize normalizes outbound text parts.
+	Normalize bool
+	// Middlewares wrap provider calls.
+	Middlewares []Middleware
 }
 
 type Option func(o *Options)
@@ -44,6 +141,41 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithAnthropicCacheTTL enables Anthropic prompt caching of the conversation prefix
+// with the cache TTL, "5m" or "1h". 1 hour cache writes are priced higher.
+func WithAnthropicCacheTTL(ttl string) Option {
+	return func(o *Options) {
+		o.AnthropicCacheTTL = ttl
+	}
+}
+
+// WithPromptPrefixCache sets Anthropic cache breakpoints at the messages of the indexes,
+// eg. the last system message and the last message of a stable few-shot prefix,
+// instead of the last message. Negative indexes count from the end, eg. -2 for the message before the last.
+// The tool definitions and the messages up to each breakpoint are cached.
+// Up to 4 breakpoints are allowed. The TTL is set by WithAnthropicCacheTTL, "5m" by default.
+func WithPromptPrefixCache(indexes ...int) Option {
+	return func(o *Options) {
+		o.PromptCacheBreakpoints = indexes
+	}
+}
+
+// WithUserID sets a stable end-user ID for provider abuse monitoring and audits:
+// OpenAI user, Anthropic metadata user_id and Vertex AI labels.
+// Use an opaque ID or hash, not personal information.
+func WithUserID(id string) Option {
+	return func(o *Options) {
+		o.UserID = id
+	}
+}
+
+// WithAPIKey sets the provider API key instead of the environment variable.
+func WithAPIKey(key string) Option {
+	return func(o *Options) {
+		o.APIKey = key
+	}
+}
+
 func WithModelCatalog(catalog ModelCatalog) Option {
 	return func(o *Options) {
 		o.ModelCatalog = catalog
@@ -56,6 +188,28 @@ func WithSearch() Option {
 	}
 }
 
+// WithCachedContent refers to the provider cached content by name in the request.
+func WithCachedContent(name string) Option {
+	return func(o *Options) {
+		o.CachedContent = name
+	}
+}
+
+// WithClock sets the clock used instead of time.Now for deterministic execution.
+func WithClock(clock func() time.Time) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+// Now returns the current time from the clock.
+func (o *Options) Now() time.Time {
+	if o.Clock != nil {
+		return o.Clock()
+	}
+	return time.Now()
+}
+
 func defaultModelCatalog() ModelCatalog {
 	var catalog ModelCatalog
 	if err := json.Unmarshal(modelCatalog, &catalog); err != nil {
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MetadataTrimmedChars is the response metadata key of the number of characters trimmed by MaxOutputChars.
+const MetadataTrimmedChars = "trimmed_chars"
+
+// OutputCharsExceededError reports a text response longer than MaxOutputChars.
+type OutputCharsExceededError struct {
+	Max int
+	// Got is the number of characters of the response.
+	Got  int
+	Text string
+	// Response is the last response.
+	Response *Response
+}
+
+func (e *OutputCharsExceededError) Error() string {
+	return fmt.Sprintf("response of %d characters exceeds %d", e.Got, e.Max)
+}
+
+// WithMaxOutputChars limits the text response to n characters counted by CountChars,
+// eg. 140 for tweet length. The model is instructed in the locale, eg. "ja" or "en",
+// and longer responses are trimmed at a character boundary.
+// Streamed chunks are not trimmed.
+func WithMaxOutputChars(n int, locale string) Option {
+	return func(o *Options) {
+		o.MaxOutputChars = n
+		o.OutputCharsLocale = locale
+	}
+}
+
+// WithOutputCharsRetries regenerates responses exceeding MaxOutputChars up to retries times before trimming.
+func WithOutputCharsRetries(retries int) Option {
+	return func(o *Options) {
+		o.OutputCharsRetries = retries
+	}
+}
+
+// outputCharsPrompts are the length instructions by language.
+var outputCharsPrompts = map[string]string{
+	"en": "Keep the response within %d characters.",
+	"ja": "回答は%d文字以内に収めてください。",
+	"ko": "답변은 %d자 이내로 작성하세요.",
+	"zh": "回答请控制在%d个字符以内。",
+}
+
+// OutputCharsPrompt returns the instruction to respond within n characters in the locale,
+// or in English if the locale has no instruction.
+func OutputCharsPrompt(n int, locale string) string {
+	prompt, ok := outputCharsPrompts[baseLanguage(locale)]
+	if !ok {
+		prompt = outputCharsPrompts["en"]
+	}
+	return fmt.Sprintf(prompt, n)
+}
+
+// ApplyMaxOutputChars returns a copy of the request with the length instruction
+// appended to the system message, or a new system message if there is none.
+func (o *Options) ApplyMaxOutputChars(req *Request) *Request {
+	if o.MaxOutputChars <= 0 {
+		return req
+	}
+	return AppendSystemPrompt(req, OutputCharsPrompt(o.MaxOutputChars, o.OutputCharsLocale))
+}
+
+// CheckOutputChars returns *OutputCharsExceededError if the text response is longer than limit characters.
+// Structured outputs and tool calls are not checked.
+func CheckOutputChars(limit int, req *Request, resp *Response) error {
+	if limit <= 0 || req.EffectiveResponseType() != ResponseTypeText || len(resp.ToolCalls()) > 0 {
+		return nil
+	}
+	text := resp.Text()
+	if got := CountChars(text); got > limit {
+		return &OutputCharsExceededError{Max: limit, Got: got, Text: text, Response: resp}
+	}
+	return nil
+}
+
+// TrimResponseChars trims the text of AI messages to limit characters in total,
+// and records the number of trimmed characters in the metadata.
+// Structured outputs and tool calls are not trimmed.
+func TrimResponseChars(limit int, req *Request, resp *Response) {
+	if limit <= 0 || req.EffectiveResponseType() != ResponseTypeText || len(resp.ToolCalls()) > 0 {
+		return
+	}
+	remain, trimmed := limit, 0
+	for i, m := range resp.Messages {
+		if m.Role != MessageRoleAI {
+			continue
+		}
+		for j, part := range m.Content {
+			if part.Type != "text" {
+				continue
+			}
+			n := CountChars(part.Text)
+			if n <= remain {
+				remain -= n
+				continue
+			}
+			if trimmed == 0 {
+				resp.Messages[i].Content = append([]ContentPart(nil), m.Content...)
+			}
+			resp.Messages[i].Content[j].Text = TrimChars(part.Text, remain)
+			trimmed += n - remain
+			remain = 0
+		}
+	}
+	if trimmed == 0 {
+		return
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = Metadata{}
+	}
+	resp.Metadata[MetadataTrimmedChars] = strconv.Itoa(trimmed)
+}
+
+// CountChars returns the number of user-perceived characters of s.
+// Combining marks, variation selectors, emoji modifiers, zero width joiner sequences,
+// regional indicator pairs and Hangul jamo are counted as one character with the base,
+// so "が" written with a combining dakuten and "👨‍👩‍👧" are one character each.
+func CountChars(s string) int {
+	n := 0
+	for i := 0; i < len(s); n++ {
+		i += nextChar(s[i:])
+	}
+	return n
+}
+
+// TrimChars returns the first n characters of s counted by CountChars.
+func TrimChars(s string, n int) string {
+	i := 0
+	for ; i < len(s) && n > 0; n-- {
+		i += nextChar(s[i:])
+	}
+	return s[:i]
+}
+
+// nextChar returns the byte length of the first character of s.
+func nextChar(s string) int {
+	prev, i := utf8.DecodeRuneInString(s)
+	regional := isRegionalIndicator(prev)
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case prev == '\r':
+			if r != '\n' {
+				return i
+			}
+		case prev == '\n':
+			return i
+		case isExtend(r), isHangulJamo(prev, r):
+		case prev == zeroWidthJoiner:
+			// the emoji joined by the zero width joiner
+		case regional && isRegionalIndicator(r):
+			// a flag is a pair of regional indicators
+			regional = false
+		default:
+			return i
+		}
+		prev = r
+		i += size
+	}
+	return i
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// isExtend reports whether r extends the preceding character.
+func isExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		r >= 0xfe00 && r <= 0xfe0f || // variation selectors
+		r >= 0xe0100 && r <= 0xe01ef || // ideographic variation selectors
+		r >= 0x1f3fb && r <= 0x1f3ff || // emoji skin tone modifiers
+		r >= 0xe0020 && r <= 0xe007f || // emoji tags
+		r == 0xff9e || r == 0xff9f // halfwidth katakana voiced sound marks
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isHangulJamo reports whether the conjoining jamo r continues the syllable ending with prev.
+func isHangulJamo(prev, r rune) bool {
+	leading := prev >= 0x1100 && prev <= 0x115f
+	vowel := prev >= 0x1160 && prev <= 0x11a7
+	trailing := prev >= 0x11a8 && prev <= 0x11ff
+	syllable := prev >= 0xac00 && prev <= 0xd7a3
+	// LV syllables take a vowel or trailing jamo, LVT syllables only a trailing jamo.
+	lv := syllable && (prev-0xac00)%28 == 0
+	switch {
+	case r >= 0x1100 && r <= 0x115f:
+		return leading
+	case r >= 0x1160 && r <= 0x11a7:
+		return leading || vowel || lv
+	case r >= 0x11a8 && r <= 0x11ff:
+		return vowel || trailing || syllable
+	}
+	return false
+}
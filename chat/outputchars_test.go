@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCountChars(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"こんにちは", 5},
+		{"が", 1},
+		{"ｶﾞ", 1},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧", 1},
+		{"🇯🇵🇺🇸", 2},
+		{"❤️", 1},
+		{"葛\U000e0100", 1},
+		{"각", 1},
+		{"a\r\nb", 3},
+	}
+	for _, tt := range tests {
+		if got := CountChars(tt.text); got != tt.want {
+			t.Errorf("CountChars(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTrimChars(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"こんにちは", 3, "こんに"},
+		{"こんにちは", 10, "こんにちは"},
+		{"がき", 1, "が"},
+		{"👨‍👩‍👧ok", 1, "👨‍👩‍👧"},
+		{"🇯🇵🇺🇸", 1, "🇯🇵"},
+		{"abc", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := TrimChars(tt.text, tt.n); got != tt.want {
+			t.Errorf("TrimChars(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMaxOutputChars(t *testing.T) {
+	req := &Request{Messages: []Message{NewTextMessage(MessageRoleHuman, "Hello")}}
+	got := NewOptions(WithMaxOutputChars(140, "ja-JP")).ApplyMaxOutputChars(req)
+	if got.Messages[0].Role != MessageRoleSystem || got.Messages[0].ContentString() != "回答は140文字以内に収めてください。" {
+		t.Errorf("system message mismatch: %+v", got.Messages)
+	}
+	got = NewOptions(WithMaxOutputChars(280, "fr")).ApplyMaxOutputChars(req)
+	if got.Messages[0].ContentString() != "Keep the response within 280 characters." {
+		t.Errorf("fallback instruction mismatch: %q", got.Messages[0].ContentString())
+	}
+	if got := NewOptions().ApplyMaxOutputChars(req); got != req {
+		t.Error("request without limit should not be modified")
+	}
+}
+
+func TestTrimResponseChars(t *testing.T) {
+	req := &Request{}
+	resp := &Response{Messages: []Message{{Role: MessageRoleAI, Content: []ContentPart{
+		{Type: "text", Text: "今日は"},
+		{Type: "text", Text: "晴れです。"},
+	}}}}
+	original := resp.Messages[0].Content
+
+	var exceeded *OutputCharsExceededError
+	if !errors.As(CheckOutputChars(5, req, resp), &exceeded) || exceeded.Got != 8 {
+		t.Fatalf("exceeded error mismatch: %v", exceeded)
+	}
+	TrimResponseChars(5, req, resp)
+	if resp.Text() != "今日は晴れ" || resp.Metadata[MetadataTrimmedChars] != "3" {
+		t.Errorf("trimmed response mismatch: %q %v", resp.Text(), resp.Metadata)
+	}
+	if original[1].Text != "晴れです。" {
+		t.Error("original content modified")
+	}
+	if err := CheckOutputChars(5, req, resp); err != nil {
+		t.Error(err)
+	}
+
+	// structured outputs are not trimmed.
+	resp = &Response{Messages: []Message{NewTextMessage(MessageRoleAI, `{"answer":"long"}`)}}
+	TrimResponseChars(5, &Request{ResponseType: ResponseTypeJSON}, resp)
+	if resp.Text() != `{"answer":"long"}` {
+		t.Errorf("structured output trimmed: %q", resp.Text())
+	}
+}
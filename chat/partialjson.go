@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// PartialJSON parses a structured output stream as it arrives.
+// Value returns the fields parsed so far, eg. the first items of an array,
+// so the response can be rendered progressively.
+type PartialJSON struct {
+	text strings.Builder
+}
+
+// Write appends the streamed text chunk.
+func (p *PartialJSON) Write(chunk string) {
+	p.text.WriteString(chunk)
+}
+
+// Text returns the streamed text so far.
+func (p *PartialJSON) Text() string {
+	return p.text.String()
+}
+
+// Value returns the value parsed so far as decoded with json.Decoder.UseNumber.
+// Unterminated strings are included as is, while unterminated numbers and literals are omitted.
+// Text before the first '{' or '[', eg. a markdown code fence, is skipped.
+// done is true if the top-level value is complete.
+// An error is returned if the text is not a prefix of valid JSON.
+func (p *PartialJSON) Value() (v any, done bool, err error) {
+	return ParsePartialJSON(p.text.String())
+}
+
+// Decode decodes the value parsed so far into v, eg. a pointer to the response struct.
+func (p *PartialJSON) Decode(v any) error {
+	value, _, err := p.Value()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal partial value: %w", err)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// JSONStreamer returns a streamer calling fn with the partial value of the streamed structured output
+// each time the value changes. An error of fn or a JSON syntax error stops the stream.
+func JSONStreamer(fn func(value any) error) Streamer {
+	p := &PartialJSON{}
+	var last []byte
+	return func(resp *StreamResponse) error {
+		if resp.Type != "text" {
+			return nil
+		}
+		p.Write(resp.Content)
+		value, _, err := p.Value()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal partial value: %w", err)
+		}
+		if value == nil || bytes.Equal(data, last) {
+			return nil
+		}
+		last = data
+		return fn(value)
+	}
+}
+
+// WithStreamSchemaValidation validates the streamed structured output against ResponseSchema
+// as it arrives and aborts the stream with *SchemaMismatchError when the output can no longer match,
+// saving the output tokens of bad generations.
+func WithStreamSchemaValidation() Option {
+	return func(o *Options) {
+		o.StreamSchemaValidation = true
+	}
+}
+
+// SchemaStreamer returns a streamer validating the partial structured output against the schema
+// before passing the chunks to the next streamer. It returns *SchemaMismatchError with the streamed text
+// on invalid JSON or when the output diverges from the schema, which stops the stream.
+func SchemaStreamer(schema jsonschema.Schema, next Streamer) Streamer {
+	p := &PartialJSON{}
+	return func(resp *StreamResponse) error {
+		if resp.Type == "text" {
+			p.Write(resp.Content)
+			value, _, err := p.Value()
+			if err == nil && value != nil {
+				err = schema.ValidatePartial(value)
+			}
+			if err != nil {
+				return &SchemaMismatchError{Text: p.Text(), Err: err, Attempts: 1}
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		return next(resp)
+	}
+}
+
+// ParsePartialJSON parses the prefix of a JSON text. See PartialJSON.Value.
+func ParsePartialJSON(text string) (v any, done bool, err error) {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return nil, false, nil
+	}
+	p := &partialParser{s: text, i: start}
+	return p.value()
+}
+
+// partialParser parses a JSON prefix. A nil value with done false means the value is absent.
+type partialParser struct {
+	s string
+	i int
+}
+
+func (p *partialParser) eof() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *partialParser) skipSpace() {
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *partialParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid JSON at offset %d: %s", p.i, fmt.Sprintf(format, args...))
+}
+
+func (p *partialParser) value() (any, bool, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, false, nil
+	}
+	switch c := p.s[p.i]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		return p.string()
+	case c == 't':
+		return p.literal("true", true)
+	case c == 'f':
+		return p.literal("false", false)
+	case c == 'n':
+		return p.literal("null", nil)
+	case c == '-' || c >= '0' && c <= '9':
+		return p.number()
+	default:
+		return nil, false, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *partialParser) object() (any, bool, error) {
+	p.i++
+	m := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return m, false, nil
+		}
+		if p.s[p.i] == '}' && len(m) == 0 {
+			p.i++
+			return m, true, nil
+		}
+		if p.s[p.i] != '"' {
+			return nil, false, p.errorf("expected object key")
+		}
+		key, done, err := p.string()
+		if err != nil || !done {
+			return m, false, err
+		}
+		p.skipSpace()
+		if p.eof() {
+			return m, false, nil
+		}
+		if p.s[p.i] != ':' {
+			return nil, false, p.errorf("expected ':'")
+		}
+		p.i++
+		v, done, err := p.value()
+		if err != nil {
+			return nil, false, err
+		}
+		if done || v != nil {
+			m[key.(string)] = v
+		}
+		if !done {
+			return m, false, nil
+		}
+		p.skipSpace()
+		if p.eof() {
+			return m, false, nil
+		}
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case '}':
+			p.i++
+			return m, true, nil
+		default:
+			return nil, false, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (p *partialParser) array() (any, bool, error) {
+	p.i++
+	a := []any{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return a, false, nil
+		}
+		if p.s[p.i] == ']' && len(a) == 0 {
+			p.i++
+			return a, true, nil
+		}
+		v, done, err := p.value()
+		if err != nil {
+			return nil, false, err
+		}
+		if done || v != nil {
+			a = append(a, v)
+		}
+		if !done {
+			return a, false, nil
+		}
+		p.skipSpace()
+		if p.eof() {
+			return a, false, nil
+		}
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case ']':
+			p.i++
+			return a, true, nil
+		default:
+			return nil, false, p.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+func (p *partialParser) string() (any, bool, error) {
+	p.i++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.s[p.i]
+		switch {
+		case c == '"':
+			p.i++
+			return b.String(), true, nil
+		case c == '\\':
+			r, n, ok, err := p.escape()
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				return b.String(), false, nil
+			}
+			b.WriteRune(r)
+			p.i += n
+		case c < 0x20:
+			return nil, false, p.errorf("control character in string")
+		default:
+			r, n := utf8.DecodeRuneInString(p.s[p.i:])
+			if r == utf8.RuneError && !utf8.FullRuneInString(p.s[p.i:]) {
+				return b.String(), false, nil
+			}
+			b.WriteRune(r)
+			p.i += n
+		}
+	}
+	return b.String(), false, nil
+}
+
+// escape decodes the escape sequence at the position. ok is false if the sequence is incomplete.
+func (p *partialParser) escape() (r rune, n int, ok bool, err error) {
+	s := p.s[p.i:]
+	if len(s) < 2 {
+		return 0, 0, false, nil
+	}
+	switch s[1] {
+	case '"', '\\', '/':
+		return rune(s[1]), 2, true, nil
+	case 'b':
+		return '\b', 2, true, nil
+	case 'f':
+		return '\f', 2, true, nil
+	case 'n':
+		return '\n', 2, true, nil
+	case 'r':
+		return '\r', 2, true, nil
+	case 't':
+		return '\t', 2, true, nil
+	case 'u':
+		r, ok, err := p.hex(s[2:])
+		if err != nil || !ok {
+			return 0, 0, ok, err
+		}
+		if !utf16.IsSurrogate(r) {
+			return r, 6, true, nil
+		}
+		// surrogate pair, eg. \ud83d\ude00
+		rest := s[6:]
+		if len(rest) < 2 {
+			return 0, 0, false, nil
+		}
+		if rest[:2] != `\u` {
+			return utf8.RuneError, 6, true, nil
+		}
+		low, ok, err := p.hex(rest[2:])
+		if err != nil || !ok {
+			return 0, 0, ok, err
+		}
+		return utf16.DecodeRune(r, low), 12, true, nil
+	}
+	return 0, 0, false, p.errorf("invalid escape %q", s[:2])
+}
+
+func (p *partialParser) hex(s string) (rune, bool, error) {
+	if len(s) < 4 {
+		for _, c := range []byte(s) {
+			if !isHex(c) {
+				return 0, false, p.errorf("invalid unicode escape")
+			}
+		}
+		return 0, false, nil
+	}
+	v, err := strconv.ParseUint(s[:4], 16, 32)
+	if err != nil {
+		return 0, false, p.errorf("invalid unicode escape")
+	}
+	return rune(v), true, nil
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func (p *partialParser) literal(word string, v any) (any, bool, error) {
+	rest := p.s[p.i:]
+	if strings.HasPrefix(rest, word) {
+		p.i += len(word)
+		return v, true, nil
+	}
+	if strings.HasPrefix(word, rest) {
+		p.i = len(p.s)
+		return nil, false, nil
+	}
+	return nil, false, p.errorf("invalid literal")
+}
+
+func (p *partialParser) number() (any, bool, error) {
+	start := p.i
+	for !p.eof() && strings.IndexByte("+-0123456789.eE", p.s[p.i]) >= 0 {
+		p.i++
+	}
+	// the number may continue in the next chunk.
+	if p.eof() {
+		return nil, false, nil
+	}
+	n := json.Number(p.s[start:p.i])
+	if _, err := strconv.ParseFloat(string(n), 64); err != nil || !json.Valid([]byte(n)) {
+		return nil, false, p.errorf("invalid number %q", n)
+	}
+	return n, true, nil
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		done bool
+	}{
+		{"", "null", false},
+		{"```json\n{", "{}", false},
+		{`{"title": "Hel`, `{"title":"Hel"}`, false},
+		{`{"title": "a\u00`, `{"title":"a"}`, false},
+		{`{"title": "a😀", "n": 12`, `{"title":"a😀"}`, false},
+		{`{"items": [{"id": 1}, {"id": 2}, {"id"`, `{"items":[{"id":1},{"id":2},{}]}`, false},
+		{`{"ok": tr`, `{}`, false},
+		{`{"ok": true, "items": []}` + "\n```", `{"items":[],"ok":true}`, true},
+	}
+	for _, tt := range tests {
+		v, done, err := ParsePartialJSON(tt.text)
+		if err != nil {
+			t.Errorf("ParsePartialJSON(%q) error: %v", tt.text, err)
+			continue
+		}
+		got, _ := json.Marshal(v)
+		if string(got) != tt.want || done != tt.done {
+			t.Errorf("ParsePartialJSON(%q) = %s, %v, want %s, %v", tt.text, got, done, tt.want, tt.done)
+		}
+	}
+
+	for _, text := range []string{`{"a" 1`, `{"a": x`, `[1 2]`, `{"a": 1,}`} {
+		if _, _, err := ParsePartialJSON(text); err == nil {
+			t.Errorf("ParsePartialJSON(%q) should fail", text)
+		}
+	}
+}
+
+func TestJSONStreamer(t *testing.T) {
+	var values []string
+	streamer := JSONStreamer(func(value any) error {
+		data, _ := json.Marshal(value)
+		values = append(values, string(data))
+		return nil
+	})
+	for _, chunk := range []string{`{"tags": ["a"`, `, "b`, `"`, `]}`} {
+		if err := streamer(&StreamResponse{Type: "text", Content: chunk}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{`{"tags":["a"]}`, `{"tags":["a","b"]}`}
+	if diff := cmp.Diff(want, values); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+
+	p := &PartialJSON{}
+	p.Write(`{"tags": ["x", "y`)
+	var out struct {
+		Tags []string `json:"tags"`
+	}
+	if err := p.Decode(&out); err != nil || len(out.Tags) != 2 || out.Tags[1] != "y" {
+		t.Errorf("Decode() = %+v, %v", out, err)
+	}
+}
+
+func TestSchemaStreamer(t *testing.T) {
+	schema := jsonschema.MustParseJSONString(`{"type": "object", "properties": {"status": {"enum": ["ok", "error"]}}}`)
+	var out strings.Builder
+	streamer := SchemaStreamer(schema, StreamToWriter(&out))
+
+	if err := streamer(&StreamResponse{Type: "text", Content: `{"status": "o`}); err != nil {
+		t.Fatal(err)
+	}
+	err := streamer(&StreamResponse{Type: "text", Content: `ther"}`})
+	var mismatch *SchemaMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Text != `{"status": "other"}` {
+		t.Fatalf("expected SchemaMismatchError, got %v", err)
+	}
+	if out.String() != `{"status": "o` {
+		t.Errorf("diverging chunk should not be streamed: %s", out.String())
+	}
+}
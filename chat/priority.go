@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "context"
+
+// Priority is the scheduling priority of a request.
+type Priority string
+
+const (
+	// PriorityHigh is interactive traffic dispatched before queued lower priority requests.
+	PriorityHigh Priority = "high"
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = "normal"
+	// PriorityBatch is background traffic dispatched after the others,
+	// sent to the cheaper flex tier where available (OpenAI).
+	PriorityBatch Priority = "batch"
+)
+
+// WithPriority sets the priority of the request, honored by schedulers in the middlewares.
+func WithPriority(p Priority) Option {
+	return func(o *Options) {
+		o.Priority = p
+	}
+}
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a context with the request priority, eg. set by gengo.Generate for middlewares.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the request priority of the context, PriorityNormal if not set.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p != "" {
+		return p
+	}
+	return PriorityNormal
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"slices"
+	"time"
+)
+
+// ProviderProfile is the timeout and retry profile of a provider.
+type ProviderProfile struct {
+	// Timeout of a request without streaming. Negative disables the timeout.
+	Timeout time.Duration
+	// StreamTimeout of a streaming request. Negative disables the timeout.
+	StreamTimeout time.Duration
+	// BatchTimeout of a PriorityBatch request if longer than Timeout or StreamTimeout,
+	// eg. of the OpenAI flex service tier queuing requests for minutes.
+	BatchTimeout time.Duration
+	// MaxRetries of retryable errors. Negative disables retries.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for each retry.
+	RetryBackoff time.Duration
+	// ClassBackoff overrides RetryBackoff by retry class, eg. longer for rate limits.
+	ClassBackoff map[RetryClass]time.Duration
+	// Retryable reports whether the error is retryable, eg. rate limit or overloaded.
+	// Used if Classify is nil.
+	Retryable func(err error) bool
+	// Classify returns the retry class of the error.
+	Classify func(err error) RetryClass
+	// MaxResumes of a stream dropped by a retryable error after chunks have been streamed.
+	// The model is re-prompted with the streamed text to continue from where it stopped.
+	// Negative disables resuming.
+	MaxResumes int
+}
+
+// ResumePrompt asks the model to continue the streamed text of a dropped stream.
+const ResumePrompt = "Your previous response was cut off. Continue exactly from where it stopped, without repeating any of it."
+
+// ResumeRequest returns a copy of the request re-prompting the model to continue the streamed text.
+func ResumeRequest(req *Request, streamed string) *Request {
+	r := *req
+	r.Messages = append(slices.Clone(req.Messages),
+		NewTextMessage(MessageRoleAI, streamed),
+		NewTextMessage(MessageRoleHuman, ResumePrompt),
+	)
+	return &r
+}
+
+// PrependText prepends the text to the first AI text of the response, eg. the streamed text of a resumed stream.
+func PrependText(resp *Response, text string) {
+	for i, m := range resp.Messages {
+		if m.Role != MessageRoleAI {
+			continue
+		}
+		for j, part := range m.Content {
+			if part.Type == "text" {
+				content := slices.Clone(m.Content)
+				content[j].Text = text + part.Text
+				resp.Messages[i].Content = content
+				return
+			}
+		}
+	}
+	resp.Messages = append([]Message{NewTextMessage(MessageRoleAI, text)}, resp.Messages...)
+}
+
+// RetryClass is the class of a provider error deciding the retry and backoff.
+type RetryClass string
+
+const (
+	// RetryClassNone is not retryable, eg. invalid request.
+	RetryClassNone RetryClass = ""
+	// RetryClassTransient is a server error or timeout.
+	RetryClassTransient RetryClass = "transient"
+	// RetryClassRateLimit is a short-term rate limit, eg. requests per minute.
+	RetryClassRateLimit RetryClass = "rate_limit"
+	// RetryClassOverloaded is a provider capacity error, eg. Anthropic overloaded_error.
+	RetryClassOverloaded RetryClass = "overloaded"
+	// RetryClassQuota is an exhausted quota or billing limit, not recovering by retries.
+	RetryClassQuota RetryClass = "quota"
+)
+
+// Retryable reports whether the class is retried.
+func (c RetryClass) Retryable() bool {
+	switch c {
+	case RetryClassTransient, RetryClassRateLimit, RetryClassOverloaded:
+		return true
+	}
+	return false
+}
+
+// Class returns the retry class of the error with Classify, or Retryable as transient.
+func (p ProviderProfile) Class(err error) RetryClass {
+	if p.Classify != nil {
+		return p.Classify(err)
+	}
+	if p.Retryable != nil && p.Retryable(err) {
+		return RetryClassTransient
+	}
+	return RetryClassNone
+}
+
+// Backoff returns the wait before the retry of the class, doubled for each retry from 1.
+func (p ProviderProfile) Backoff(class RetryClass, retry int) time.Duration {
+	backoff := p.RetryBackoff
+	if b, ok := p.ClassBackoff[class]; ok {
+		backoff = b
+	}
+	return backoff << (retry - 1)
+}
+
+// WithProviderProfile overrides the default profile of the provider.
+// Zero fields keep the default values.
+func WithProviderProfile(provider string, profile ProviderProfile) Option {
+	return func(o *Options) {
+		if o.ProviderProfiles == nil {
+			o.ProviderProfiles = map[string]ProviderProfile{}
+		}
+		o.ProviderProfiles[provider] = profile
+	}
+}
+
+// ProviderProfile returns the default profile of the provider overridden by WithRetry and WithProviderProfile.
+func (o *Options) ProviderProfile(provider string, defaults ProviderProfile) ProviderProfile {
+	p := defaults
+	if o.Retry != nil {
+		if o.Retry.MaxAttempts != 0 {
+			p.MaxRetries = max(o.Retry.MaxAttempts-1, 0)
+		}
+		if o.Retry.Backoff != 0 {
+			p.RetryBackoff = o.Retry.Backoff
+			p.ClassBackoff = nil
+		}
+	}
+	override, ok := o.ProviderProfiles[provider]
+	if !ok {
+		return p
+	}
+	if override.Timeout != 0 {
+		p.Timeout = override.Timeout
+	}
+	if override.StreamTimeout != 0 {
+		p.StreamTimeout = override.StreamTimeout
+	}
+	if override.BatchTimeout != 0 {
+		p.BatchTimeout = override.BatchTimeout
+	}
+	if override.MaxRetries != 0 {
+		p.MaxRetries = max(override.MaxRetries, 0)
+	}
+	if override.RetryBackoff != 0 {
+		p.RetryBackoff = override.RetryBackoff
+	}
+	if override.ClassBackoff != nil {
+		p.ClassBackoff = override.ClassBackoff
+	}
+	if override.MaxResumes != 0 {
+		p.MaxResumes = max(override.MaxResumes, 0)
+	}
+	// Retryable alone replaces the default Classify.
+	if override.Retryable != nil || override.Classify != nil {
+		p.Retryable = override.Retryable
+		p.Classify = override.Classify
+	}
+	return p
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProviderProfile(t *testing.T) {
+	defaults := ProviderProfile{Timeout: time.Minute, StreamTimeout: 10 * time.Minute, MaxRetries: 2, RetryBackoff: time.Second}
+
+	o := NewOptions(WithProviderProfile("gemini", ProviderProfile{StreamTimeout: 30 * time.Minute, MaxRetries: -1}))
+	p := o.ProviderProfile("gemini", defaults)
+	if p.Timeout != time.Minute || p.StreamTimeout != 30*time.Minute || p.MaxRetries != 0 {
+		t.Errorf("profile mismatch: %+v", p)
+	}
+	if p := o.ProviderProfile("openai", defaults); p.MaxRetries != 2 || p.StreamTimeout != 10*time.Minute {
+		t.Error("profile of other provider should be the default")
+	}
+}
+
+func TestProviderProfileBackoff(t *testing.T) {
+	p := ProviderProfile{
+		RetryBackoff: time.Second,
+		ClassBackoff: map[RetryClass]time.Duration{RetryClassRateLimit: 10 * time.Second},
+		Retryable:    func(error) bool { return true },
+	}
+	if got := p.Backoff(RetryClassTransient, 3); got != 4*time.Second {
+		t.Errorf("transient backoff = %v", got)
+	}
+	if got := p.Backoff(RetryClassRateLimit, 2); got != 20*time.Second {
+		t.Errorf("rate limit backoff = %v", got)
+	}
+	if p.Class(nil) != RetryClassTransient || RetryClassQuota.Retryable() {
+		t.Error("class mismatch")
+	}
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Providers are the names of the supported providers.
+var Providers = []string{"anthropic", "gemini", "openai"}
+
+// WithProvider forces the provider regardless of the catalog, eg. for a model name served
+// by both a native provider and an OpenAI-compatible gateway with WithBaseURL.
+// Models not in the catalog are allowed with the provider, without costs and limits.
+func WithProvider(provider string) Option {
+	return func(o *Options) {
+		o.Provider = provider
+	}
+}
+
+// LookupModel returns the catalog model info with the provider of WithProvider.
+// With WithAzure, models not in the catalog are of the openai provider.
+func (o *Options) LookupModel(model string) (*ModelInfo, error) {
+	if o.Provider != "" && !slices.Contains(Providers, o.Provider) {
+		return nil, fmt.Errorf("provider not found: %s", o.Provider)
+	}
+	info := o.ModelCatalog.GetModel(model)
+	if o.Provider == "" {
+		if info == nil && o.Azure.Enabled() {
+			return &ModelInfo{Model: model, Provider: "openai"}, nil
+		}
+		if info == nil {
+			return nil, fmt.Errorf("model not found: %s", model)
+		}
+		return info, nil
+	}
+	if info == nil {
+		return &ModelInfo{Model: model, Provider: o.Provider}, nil
+	}
+	overridden := *info
+	overridden.Provider = o.Provider
+	return &overridden, nil
+}
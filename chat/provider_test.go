@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import "testing"
+
+func TestLookupModel(t *testing.T) {
+	info, err := NewOptions(WithProvider("openai")).LookupModel("claude-3-5-haiku-latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Provider != "openai" || info.MaxTokens == 0 {
+		t.Errorf("provider should be overridden with the catalog info: %+v", info)
+	}
+	if NewOptions().ModelCatalog.GetModel("claude-3-5-haiku-latest").Provider != "anthropic" {
+		t.Error("catalog should not be modified")
+	}
+
+	info, err = NewOptions(WithProvider("openai")).LookupModel("llama-3.3-70b")
+	if err != nil || info.Provider != "openai" {
+		t.Errorf("unknown model should be allowed with the provider: %+v %v", info, err)
+	}
+	if _, err := NewOptions().LookupModel("llama-3.3-70b"); err == nil {
+		t.Error("expected model not found error")
+	}
+	if _, err := NewOptions(WithProvider("unknown")).LookupModel("gpt-4o-mini"); err == nil {
+		t.Error("expected provider not found error")
+	}
+}
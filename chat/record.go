@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoRecording is returned in replay mode when no recording matches the request.
+var ErrNoRecording = errors.New("no recording for request")
+
+// Recording is a recorded provider API request and response.
+type Recording struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Body   string      `json:"body"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	// Response is the response body, eg. JSON or server-sent events.
+	Response string `json:"response"`
+}
+
+// WithRecording records provider API traffic to dir.
+func WithRecording(dir string) Option {
+	return func(o *Options) {
+		o.RecordDir = dir
+	}
+}
+
+// WithReplay replays provider API traffic recorded in dir instead of calling the provider.
+// Requests are matched by the fingerprint of method, URL and body.
+func WithReplay(dir string) Option {
+	return func(o *Options) {
+		o.ReplayDir = dir
+	}
+}
+
+// Fingerprint returns the fingerprint of the request used as the recording file name.
+func Fingerprint(method, rawURL string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + rawURL + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+type recordTransport struct {
+	base      http.RoundTripper
+	recordDir string
+	replayDir string
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// the "key" query parameter is an API key (Gemini), so it is not recorded.
+	u := *req.URL
+	q := u.Query()
+	q.Del("key")
+	u.RawQuery = q.Encode()
+	fingerprint := Fingerprint(req.Method, u.String(), body)
+
+	if t.replayDir != "" {
+		return t.replay(req, fingerprint)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Recording{
+		Method: req.Method,
+		URL:    u.String(),
+		Body:   string(body),
+		Status: resp.StatusCode,
+		Header: recordedHeader(resp.Header),
+	}
+	// the body is recorded while it is read, so streamed responses are not delayed.
+	resp.Body = &recordingBody{ReadCloser: resp.Body, dir: t.recordDir, fingerprint: fingerprint, rec: rec}
+	return resp, nil
+}
+
+// redactedHeaders are the response headers identifying the account or the request, which are not recorded.
+var redactedHeaders = []string{
+	"Set-Cookie", "Openai-Organization", "Openai-Project", "Anthropic-Organization-Id",
+	"Request-Id", "X-Request-Id", "Cf-Ray",
+}
+
+func recordedHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, k := range redactedHeaders {
+		header.Del(k)
+	}
+	return header
+}
+
+// recordingBody copies the response body while it is read, and writes the recording at the end of the body.
+// Bodies closed before the end are not recorded.
+type recordingBody struct {
+	io.ReadCloser
+	dir         string
+	fingerprint string
+	rec         *Recording
+	buf         bytes.Buffer
+	written     bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF && !b.written {
+		b.written = true
+		b.rec.Response = b.buf.String()
+		if werr := writeRecording(b.dir, b.fingerprint, b.rec); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (t *recordTransport) replay(req *http.Request, fingerprint string) (*http.Response, error) {
+	data, err := os.ReadFile(filepath.Join(t.replayDir, fingerprint+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s %s (%s)", ErrNoRecording, req.Method, req.URL.Path, fingerprint)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	rec := &Recording{}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, fmt.Errorf("decode recording: %w", err)
+	}
+	return &http.Response{
+		Status:        http.StatusText(rec.Status),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(rec.Response))),
+		ContentLength: int64(len(rec.Response)),
+		Request:       req,
+	}, nil
+}
+
+func writeRecording(dir, fingerprint string, rec *Recording) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create recording dir: %w", err)
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode recording: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, fingerprint+".json"), data, 0o644); err != nil {
+		return fmt.Errorf("write recording: %w", err)
+	}
+	return nil
+}
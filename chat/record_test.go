@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Openai-Organization", "org-secret")
+		io.WriteString(w, `{"text":"hello"}`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	post := func(o *Options, body string) (string, error) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+"/v1/chat?key=secret", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := o.HTTPClient("openai").Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	got, err := post(NewOptions(WithRecording(dir)), `{"prompt":"hi"}`)
+	if err != nil || got != `{"text":"hello"}` {
+		t.Fatalf("record: %q, %v", got, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 recording, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("recording should not contain the API key, cookies or organization: %s", data)
+	}
+
+	got, err = post(NewOptions(WithReplay(dir)), `{"prompt":"hi"}`)
+	if err != nil || got != `{"text":"hello"}` {
+		t.Fatalf("replay: %q, %v", got, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 server call, got %d", calls)
+	}
+
+	_, err = post(NewOptions(WithReplay(dir)), `{"prompt":"other"}`)
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording, got %v", err)
+	}
+}
+
+func TestRecordStream(t *testing.T) {
+	flushed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: one\n\n")
+		w.(http.Flusher).Flush()
+		<-flushed
+		io.WriteString(w, "data: two\n\n")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	req, _ := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL, strings.NewReader(`{}`))
+	resp, err := NewOptions(WithRecording(dir)).HTTPClient("openai").Do(req)
+	if err != nil {
+		close(flushed)
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf := make([]byte, 64)
+	n, err := resp.Body.Read(buf)
+	close(flushed)
+	if err != nil || string(buf[:n]) != "data: one\n\n" {
+		t.Fatalf("first event should be read before the response ends: %q, %v", buf[:n], err)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 recording, got %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if want := "data: one\n\n" + string(rest); rec.Response != want {
+		t.Errorf("recorded response mismatch: %q", rec.Response)
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetadataAttempts is the response metadata key of the number of provider calls, including retries and resumes.
+const MetadataAttempts = "attempts"
+
+// MaxRetryAfter is the longest Retry-After waited for. Errors with a longer Retry-After are not retried.
+const MaxRetryAfter = 5 * time.Minute
+
+// RetryPolicy is the retry policy of all providers set by WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts of a request including the first. 1 disables retries, 0 keeps the provider default.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for each retry. 0 keeps the provider default.
+	Backoff time.Duration
+}
+
+// WithRetry sets the retry of transient errors, eg. rate limits (429) and Anthropic overloaded errors,
+// for all providers. The Retry-After header of the provider response is honored over the backoff.
+// WithProviderProfile of the provider takes precedence.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *Options) {
+		o.Retry = &RetryPolicy{MaxAttempts: maxAttempts, Backoff: backoff}
+	}
+}
+
+// RetryAfter records the Retry-After of the failed provider responses of an attempt.
+// It is safe for concurrent use.
+type RetryAfter struct {
+	mu   sync.Mutex
+	wait time.Duration
+}
+
+// Wait returns the recorded Retry-After, 0 if none.
+func (r *RetryAfter) Wait() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wait
+}
+
+func (r *RetryAfter) record(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError {
+		return
+	}
+	if wait := ParseRetryAfter(resp.Header, time.Now()); wait > 0 {
+		r.mu.Lock()
+		r.wait = wait
+		r.mu.Unlock()
+	}
+}
+
+type retryAfterKey struct{}
+
+// ContextWithRetryAfter returns a context recording the Retry-After of the provider responses in r.
+// Recorded by the client of Options.HTTPClient.
+func ContextWithRetryAfter(ctx context.Context, r *RetryAfter) context.Context {
+	return context.WithValue(ctx, retryAfterKey{}, r)
+}
+
+// ParseRetryAfter returns the wait of the retry-after-ms (OpenAI, Anthropic) or Retry-After header
+// in seconds or HTTP date, 0 if absent or invalid.
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.ParseFloat(v, 64); err == nil {
+		return max(time.Duration(s*float64(time.Second)), 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// SetAttempts sets the number of provider calls in the response metadata.
+func SetAttempts(resp *Response, attempts int) {
+	if resp.Metadata == nil {
+		resp.Metadata = Metadata{}
+	}
+	resp.Metadata[MetadataAttempts] = strconv.Itoa(attempts)
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute},
+		{http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+		{http.Header{}, 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	defaults := ProviderProfile{
+		MaxRetries:   2,
+		RetryBackoff: time.Second,
+		ClassBackoff: map[RetryClass]time.Duration{RetryClassRateLimit: 5 * time.Second},
+	}
+	p := NewOptions(WithRetry(5, 100*time.Millisecond)).ProviderProfile("openai", defaults)
+	if p.MaxRetries != 4 || p.Backoff(RetryClassRateLimit, 2) != 200*time.Millisecond {
+		t.Errorf("profile mismatch: %+v", p)
+	}
+	p = NewOptions(WithRetry(1, 0)).ProviderProfile("openai", defaults)
+	if p.MaxRetries != 0 || p.Backoff(RetryClassRateLimit, 1) != 5*time.Second {
+		t.Errorf("profile mismatch: %+v", p)
+	}
+	o := NewOptions(WithRetry(5, 0), WithProviderProfile("openai", ProviderProfile{MaxRetries: -1}))
+	if p := o.ProviderProfile("openai", defaults); p.MaxRetries != 0 {
+		t.Errorf("provider profile should take precedence: %+v", p)
+	}
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ra := &RetryAfter{}
+	req, _ := http.NewRequestWithContext(ContextWithRetryAfter(context.Background(), ra), http.MethodGet, srv.URL, nil)
+	resp, err := NewOptions(WithRetry(3, time.Second)).HTTPClient("openai").Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if ra.Wait() != 7*time.Second {
+		t.Errorf("retry after = %v", ra.Wait())
+	}
+}
@@ -0,0 +1,318 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// RunState is the serializable state of an agent run.
+type RunState struct {
+	ID string `json:"id"`
+	// Request holds the conversation so far.
+	Request *Request `json:"request"`
+	// PendingToolCalls are tool calls from AI not yet executed.
+	PendingToolCalls []Message `json:"pending_tool_calls,omitempty"`
+	// Iteration is the number of Generate calls.
+	Iteration int `json:"iteration"`
+	// Usage is the budget spent so far.
+	Usage Usage `json:"usage"`
+	// Start is the index of the first message added by the run.
+	Start int  `json:"start"`
+	Done  bool `json:"done,omitempty"`
+}
+
+// RunStore saves and loads agent run states.
+type RunStore interface {
+	Save(ctx context.Context, state *RunState) error
+	Load(ctx context.Context, id string) (*RunState, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WithRunStore checkpoints the agent run state to the store with the run ID.
+func WithRunStore(store RunStore, runID string) Option {
+	return func(o *Options) {
+		o.RunStore = store
+		o.RunID = runID
+	}
+}
+
+// WithMaxIterations sets the maximum number of Generate calls in an agent run.
+func WithMaxIterations(n int) Option {
+	return func(o *Options) {
+		o.MaxIterations = n
+	}
+}
+
+// ToolLoopAction is the action for repeated identical tool calls in an agent run.
+type ToolLoopAction string
+
+const (
+	// ToolLoopCache returns the result of the previous identical call without executing the tool.
+	ToolLoopCache ToolLoopAction = "cache"
+	// ToolLoopNote returns a corrective note as the result without executing the tool.
+	ToolLoopNote ToolLoopAction = "note"
+)
+
+// ToolLoopNoteText is the corrective note returned for a looping tool call.
+const ToolLoopNoteText = "This tool was already called %d times with the same arguments. " +
+	"Do not repeat the call; use the previous results or try a different approach."
+
+// WithToolLoopDetection applies the action to a tool call with the same name and arguments
+// as threshold or more previous calls in the agent run.
+func WithToolLoopDetection(threshold int, action ToolLoopAction) Option {
+	return func(o *Options) {
+		o.ToolLoopThreshold = threshold
+		o.ToolLoopAction = action
+	}
+}
+
+// AskUserToolName is the name of the tool the model calls to ask the user a clarification question.
+const AskUserToolName = "ask_user"
+
+// Clarification is a question of the model to the user.
+type Clarification struct {
+	Question string `json:"question"`
+	// Options are the suggested answers, if any.
+	Options []string `json:"options,omitempty"`
+}
+
+// AskUserTool returns the tool to ask the user a clarification question.
+func AskUserTool() Tool {
+	return Tool{
+		Name: AskUserToolName,
+		Description: "Ask the user a clarification question when the request is ambiguous or information is missing. " +
+			"The run pauses until the user answers.",
+		InputSchema: jsonschema.MustParseJSONString(`{"type": "object", "properties": {
+			"question": {"type": "string", "description": "The question to the user."},
+			"options": {"type": "array", "items": {"type": "string"}, "description": "Suggested answers, if any."}
+		}, "required": ["question"]}`),
+	}
+}
+
+// WithClarification adds the ask_user tool to agent runs. When the model calls it,
+// the run pauses and returns the question, and continues after the answer is set with
+// RunState.AnswerClarification.
+func WithClarification() Option {
+	return func(o *Options) {
+		o.Clarification = true
+	}
+}
+
+// PendingClarification returns the pending ask_user call and its question, or nil if none.
+func (s *RunState) PendingClarification() (*ToolCall, *Clarification) {
+	for _, msg := range s.PendingToolCalls {
+		if msg.ToolCall == nil || msg.ToolCall.Name != AskUserToolName {
+			continue
+		}
+		c := &Clarification{}
+		if err := json.Unmarshal([]byte(msg.ToolCall.Arguments), c); err != nil {
+			c.Question = msg.ToolCall.Arguments
+		}
+		return msg.ToolCall, c
+	}
+	return nil, nil
+}
+
+// AnswerClarification answers the pending ask_user call with the answer of the user,
+// so the run can be continued.
+func (s *RunState) AnswerClarification(answer string) error {
+	call, _ := s.PendingClarification()
+	if call == nil {
+		return fmt.Errorf("no pending clarification")
+	}
+	result, err := json.Marshal(map[string]string{"answer": answer})
+	if err != nil {
+		return fmt.Errorf("marshal answer: %w", err)
+	}
+	s.Request.Messages = append(s.Request.Messages, NewToolResponseMessage(call.Name, call.ID, string(result)))
+	s.PendingToolCalls = slices.DeleteFunc(slices.Clone(s.PendingToolCalls), func(m Message) bool {
+		return m.ToolCall == call
+	})
+	return nil
+}
+
+// WithDeadlineMargin suspends the agent run before the next Generate or tool call
+// when the context deadline is within the margin, returning a resumable state
+// instead of a deadline exceeded error.
+func WithDeadlineMargin(margin time.Duration) Option {
+	return func(o *Options) {
+		o.DeadlineMargin = margin
+	}
+}
+
+// MemoryRunStore is an in-memory RunStore storing states as JSON.
+type MemoryRunStore struct {
+	// keys encrypt the states with Seal if set.
+	keys KeyProvider
+
+	mu     sync.Mutex
+	states map[string][]byte
+}
+
+// NewMemoryRunStore creates a new in-memory run store.
+func NewMemoryRunStore() *MemoryRunStore {
+	return &MemoryRunStore{states: map[string][]byte{}}
+}
+
+// NewEncryptedMemoryRunStore creates a new in-memory run store encrypting the states with Seal.
+// Use SealedRunStore to encrypt the states of a persistent store.
+func NewEncryptedMemoryRunStore(keys KeyProvider) *MemoryRunStore {
+	return &MemoryRunStore{keys: keys, states: map[string][]byte{}}
+}
+
+func (m *MemoryRunStore) Save(ctx context.Context, state *RunState) error {
+	var data []byte
+	var err error
+	if m.keys != nil {
+		data, err = Seal(ctx, m.keys, state, []byte(state.ID))
+	} else {
+		data, err = json.Marshal(state)
+	}
+	if err != nil {
+		return fmt.Errorf("marshal run state: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.ID] = data
+	return nil
+}
+
+func (m *MemoryRunStore) Load(ctx context.Context, id string) (*RunState, error) {
+	m.mu.Lock()
+	data, ok := m.states[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("run state not found: %s", id)
+	}
+	state := &RunState{}
+	var err error
+	if m.keys != nil {
+		err = Open(ctx, m.keys, data, state, []byte(id))
+	} else {
+		err = json.Unmarshal(data, state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal run state: %w", err)
+	}
+	return state, nil
+}
+
+func (m *MemoryRunStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, id)
+	return nil
+}
+
+// Rotate reseals the encrypted states with the current key, so the rotated out keys can be retired.
+// The lock is not held while calling the KeyProvider, and states saved meanwhile are kept.
+func (m *MemoryRunStore) Rotate(ctx context.Context) error {
+	if m.keys == nil {
+		return nil
+	}
+	m.mu.Lock()
+	states := maps.Clone(m.states)
+	m.mu.Unlock()
+	for id, data := range states {
+		resealed, err := Reseal(ctx, m.keys, data, []byte(id))
+		if err != nil {
+			return fmt.Errorf("reseal run state %s: %w", id, err)
+		}
+		m.mu.Lock()
+		if current, ok := m.states[id]; ok && bytes.Equal(current, data) {
+			m.states[id] = resealed
+		}
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// BlobStore stores the serialized run states of SealedRunStore by ID,
+// eg. in a database, object storage or Redis.
+type BlobStore interface {
+	Put(ctx context.Context, id string, data []byte) error
+	// Get returns an error if the ID is not found.
+	Get(ctx context.Context, id string) ([]byte, error)
+	Delete(ctx context.Context, id string) error
+	// List returns the stored IDs.
+	List(ctx context.Context) ([]string, error)
+	// CompareAndSwap replaces the data of the ID with data only if it is still old,
+	// and reports whether it was replaced, eg. with a conditional write or a transaction.
+	CompareAndSwap(ctx context.Context, id string, old, data []byte) (bool, error)
+}
+
+// SealedRunStore is a RunStore encrypting the states with Seal before storing them in a BlobStore,
+// so run states of any persistent backend are encrypted at rest.
+type SealedRunStore struct {
+	blobs BlobStore
+	keys  KeyProvider
+}
+
+// NewSealedRunStore creates a run store encrypting the states in the blob store with the keys.
+func NewSealedRunStore(blobs BlobStore, keys KeyProvider) *SealedRunStore {
+	return &SealedRunStore{blobs: blobs, keys: keys}
+}
+
+func (s *SealedRunStore) Save(ctx context.Context, state *RunState) error {
+	data, err := Seal(ctx, s.keys, state, []byte(state.ID))
+	if err != nil {
+		return fmt.Errorf("seal run state: %w", err)
+	}
+	if err := s.blobs.Put(ctx, state.ID, data); err != nil {
+		return fmt.Errorf("put run state: %w", err)
+	}
+	return nil
+}
+
+func (s *SealedRunStore) Load(ctx context.Context, id string) (*RunState, error) {
+	data, err := s.blobs.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get run state: %w", err)
+	}
+	state := &RunState{}
+	if err := Open(ctx, s.keys, data, state, []byte(id)); err != nil {
+		return nil, fmt.Errorf("open run state: %w", err)
+	}
+	return state, nil
+}
+
+func (s *SealedRunStore) Delete(ctx context.Context, id string) error {
+	return s.blobs.Delete(ctx, id)
+}
+
+// Rotate reseals the stored states with the current key, so the rotated out keys can be retired.
+// States are replaced with BlobStore.CompareAndSwap, so states saved or deleted meanwhile are kept.
+func (s *SealedRunStore) Rotate(ctx context.Context) error {
+	ids, err := s.blobs.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list run states: %w", err)
+	}
+	for _, id := range ids {
+		data, err := s.blobs.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get run state %s: %w", id, err)
+		}
+		resealed, err := Reseal(ctx, s.keys, data, []byte(id))
+		if err != nil {
+			return fmt.Errorf("reseal run state %s: %w", id, err)
+		}
+		if bytes.Equal(resealed, data) {
+			continue
+		}
+		if _, err := s.blobs.CompareAndSwap(ctx, id, data, resealed); err != nil {
+			return fmt.Errorf("swap run state %s: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaMismatch is matched by errors.Is for all SchemaMismatchError.
+var ErrSchemaMismatch = errors.New("response does not match schema")
+
+// SchemaMismatchError is returned when the response does not match ResponseSchema.
+// Text is the raw response text, so callers can still show a best-effort answer.
+type SchemaMismatchError struct {
+	Text string
+	// Err is the parse or validation error with details.
+	Err error
+	// Attempts is the number of generations including retries.
+	Attempts int
+	// Response is the last response.
+	Response *Response
+}
+
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("response does not match schema: %v", e.Err)
+}
+
+func (e *SchemaMismatchError) Unwrap() []error {
+	return []error{ErrSchemaMismatch, e.Err}
+}
+
+// WithSchemaValidation validates the response against ResponseSchema and regenerates with
+// the validation error up to retries times. If the response still does not match,
+// *SchemaMismatchError with the raw text is returned.
+func WithSchemaValidation(retries int) Option {
+	return func(o *Options) {
+		o.SchemaValidation = true
+		o.SchemaRetries = retries
+	}
+}
+
+// ValidateResponseSchema checks that the response text is valid JSON of the request ResponseSchema.
+func ValidateResponseSchema(req *Request, resp *Response) error {
+	if req.ResponseSchema == nil || len(resp.ToolCalls()) > 0 {
+		return nil
+	}
+	text := resp.Text()
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return &SchemaMismatchError{Text: text, Err: fmt.Errorf("invalid JSON: %w", err), Attempts: 1, Response: resp}
+	}
+	if err := req.ResponseSchema.Validate([]byte(text)); err != nil {
+		return &SchemaMismatchError{Text: text, Err: err, Attempts: 1, Response: resp}
+	}
+	return nil
+}
+
+// WithSchemaFieldOrder reorders the JSON keys of structured outputs by the ResponseSchema
+// property order, so the response text is stable across providers, eg. for golden tests.
+// See jsonschema.Schema.PropertyOrder for the order.
+func WithSchemaFieldOrder() Option {
+	return func(o *Options) {
+		o.SchemaFieldOrder = true
+	}
+}
+
+// OrderResponseFields replaces the response text with the compact JSON ordered by the request ResponseSchema.
+// The response is left as is if it is not valid JSON.
+func OrderResponseFields(req *Request, resp *Response) {
+	if req.ResponseSchema == nil || len(resp.ToolCalls()) > 0 {
+		return
+	}
+	ordered, err := req.ResponseSchema.Reorder([]byte(resp.Text()))
+	if err != nil {
+		return
+	}
+	msgs := []Message{}
+	replaced := false
+	for _, m := range resp.Messages {
+		if m.Role != MessageRoleAI {
+			msgs = append(msgs, m)
+			continue
+		}
+		if !replaced {
+			m.Content = []ContentPart{{Type: "text", Text: string(ordered)}}
+			msgs = append(msgs, m)
+			replaced = true
+		}
+	}
+	resp.Messages = msgs
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestValidateResponseSchema(t *testing.T) {
+	req := &Request{ResponseSchema: jsonschema.MustParseJSONString(`{"type": "object", "required": ["name"]}`)}
+
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{`{"name": "Gengo"}`, true},
+		{`{"title": "Gengo"}`, false},
+		{`Gengo`, false},
+	}
+	for _, tt := range tests {
+		err := ValidateResponseSchema(req, &Response{Messages: []Message{NewTextMessage(MessageRoleAI, tt.text)}})
+		if tt.ok != (err == nil) {
+			t.Errorf("ValidateResponseSchema(%s) = %v", tt.text, err)
+		}
+		var mismatch *SchemaMismatchError
+		if err != nil && (!errors.Is(err, ErrSchemaMismatch) || !errors.As(err, &mismatch) || mismatch.Text != tt.text) {
+			t.Errorf("expected SchemaMismatchError with text, got %v", err)
+		}
+	}
+
+	if err := ValidateResponseSchema(&Request{}, &Response{}); err != nil {
+		t.Errorf("no schema should be valid: %v", err)
+	}
+}
+
+func TestOrderResponseFields(t *testing.T) {
+	req := &Request{ResponseSchema: jsonschema.MustParseJSONString(
+		`{"type": "object", "required": ["name", "age"], "properties": {"name": {"type": "string"}, "age": {"type": "integer"}}}`)}
+	resp := &Response{Messages: []Message{
+		NewTextMessage(MessageRoleAI, `{"age": 3, `),
+		NewTextMessage(MessageRoleAI, `"name": "Gengo"}`),
+	}}
+
+	OrderResponseFields(req, resp)
+	if got := resp.Text(); got != `{"name":"Gengo","age":3}` || len(resp.Messages) != 1 {
+		t.Errorf("ordered response mismatch: %s (%d messages)", got, len(resp.Messages))
+	}
+
+	invalid := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, `{"age":`)}}
+	OrderResponseFields(req, invalid)
+	if invalid.Text() != `{"age":` {
+		t.Errorf("invalid JSON should be left as is: %s", invalid.Text())
+	}
+}
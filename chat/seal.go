@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrKeyNotFound is returned by KeyProvider for unknown key IDs.
+var ErrKeyNotFound = errors.New("encryption key not found")
+
+// KeyProvider provides the AES keys (16, 24 or 32 bytes) of Seal, eg. from a KMS.
+// Keep the rotated out keys available by ID until the data is resealed with Reseal.
+type KeyProvider interface {
+	// CurrentKey returns the ID and the key to encrypt with.
+	CurrentKey(ctx context.Context) (id string, key []byte, err error)
+	// Key returns the key of the ID to decrypt with.
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider of fixed keys by ID.
+type StaticKeys struct {
+	// Current is the ID of the key to encrypt with.
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k *StaticKeys) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := k.Key(ctx, k.Current)
+	return k.Current, key, err
+}
+
+func (k *StaticKeys) Key(ctx context.Context, id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return key, nil
+}
+
+// sealed is the serialized envelope of Seal. The key ID and the additional data of the caller are authenticated.
+type sealed struct {
+	KeyID      string `json:"kid"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal serializes v as JSON and encrypts it with AES-GCM with the current key,
+// eg. to store a Conversation or RunState at rest.
+// The additional data, eg. the record ID, is authenticated but not stored, and must be passed to Open,
+// so sealed data copied to another record fails to open.
+func Seal(ctx context.Context, keys KeyProvider, v any, aad []byte) ([]byte, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	id, key, err := keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("current key: %w", err)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+	return json.Marshal(sealed{
+		KeyID:      id,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData(id, aad)),
+	})
+}
+
+// Open decrypts the data of Seal with the key of its key ID and the additional data of Seal,
+// and unmarshals it into v.
+func Open(ctx context.Context, keys KeyProvider, data []byte, v any, aad []byte) error {
+	plaintext, _, err := open(ctx, keys, data, aad)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(plaintext, v); err != nil {
+		return fmt.Errorf("unmarshal: %w", err)
+	}
+	return nil
+}
+
+// Reseal re-encrypts the data of Seal with the current key, eg. after a key rotation.
+// Data already sealed with the current key is returned as is.
+func Reseal(ctx context.Context, keys KeyProvider, data []byte, aad []byte) ([]byte, error) {
+	plaintext, keyID, err := open(ctx, keys, data, aad)
+	if err != nil {
+		return nil, err
+	}
+	if id, _, err := keys.CurrentKey(ctx); err == nil && id == keyID {
+		return data, nil
+	}
+	return Seal(ctx, keys, json.RawMessage(plaintext), aad)
+}
+
+func open(ctx context.Context, keys KeyProvider, data []byte, aad []byte) ([]byte, string, error) {
+	var s sealed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, "", fmt.Errorf("unmarshal sealed data: %w", err)
+	}
+	key, err := keys.Key(ctx, s.KeyID)
+	if err != nil {
+		return nil, "", err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(s.Nonce) != aead.NonceSize() {
+		return nil, "", fmt.Errorf("invalid nonce size: %d", len(s.Nonce))
+	}
+	plaintext, err := aead.Open(nil, s.Nonce, s.Ciphertext, additionalData(s.KeyID, aad))
+	if err != nil {
+		return nil, "", fmt.Errorf("decrypt with key %s: %w", s.KeyID, err)
+	}
+	return plaintext, s.KeyID, nil
+}
+
+// additionalData returns the authenticated data of the key ID and the additional data of the caller.
+// Without additional data it is the key ID, as sealed before the additional data was added.
+func additionalData(keyID string, aad []byte) []byte {
+	if len(aad) == 0 {
+		return []byte(keyID)
+	}
+	return append(append([]byte(keyID), 0), aad...)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
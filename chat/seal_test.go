@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	ctx := context.Background()
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	conv := NewConversation(NewTextMessage(MessageRoleHuman, "my card is 4111 1111 1111 1111"))
+
+	data, err := Seal(ctx, keys, conv, []byte("conv-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("4111")) {
+		t.Fatalf("sealed data should not contain the plaintext: %s", data)
+	}
+	var got Conversation
+	if err := Open(ctx, keys, data, &got, []byte("conv-1")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Messages[0].ContentString() != conv.Messages[0].ContentString() {
+		t.Errorf("conversation mismatch: %+v", got)
+	}
+
+	// rotate the key; the old key is kept for decryption until resealed.
+	keys.Keys["k2"] = bytes.Repeat([]byte{2}, 32)
+	keys.Current = "k2"
+	if err := Open(ctx, keys, data, &got, []byte("conv-1")); err != nil {
+		t.Fatalf("data of the old key should be opened: %v", err)
+	}
+	resealed, err := Reseal(ctx, keys, data, []byte("conv-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(keys.Keys, "k1")
+	if err := Open(ctx, keys, resealed, &got, []byte("conv-1")); err != nil {
+		t.Fatalf("resealed data should be opened with the new key: %v", err)
+	}
+	if err := Open(ctx, keys, data, &got, []byte("conv-1")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected key not found: %v", err)
+	}
+
+	tampered := bytes.Replace(resealed, []byte(`"kid":"k2"`), []byte(`"kid":"k3"`), 1)
+	keys.Keys["k3"] = keys.Keys["k2"]
+	if err := Open(ctx, keys, tampered, &got, []byte("conv-1")); err == nil {
+		t.Error("tampered key ID should fail authentication")
+	}
+	if err := Open(ctx, keys, resealed, &got, []byte("conv-2")); err == nil {
+		t.Error("data of another record should fail authentication")
+	}
+}
+
+func TestEncryptedMemoryRunStore(t *testing.T) {
+	ctx := context.Background()
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)}}
+	store := NewEncryptedMemoryRunStore(keys)
+	state := &RunState{ID: "run", Request: &Request{Model: "m", Messages: []Message{NewTextMessage(MessageRoleHuman, "secret")}}}
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(store.states["run"], []byte("secret")) {
+		t.Error("state should be encrypted")
+	}
+
+	keys.Keys["k2"] = bytes.Repeat([]byte{2}, 16)
+	keys.Current = "k2"
+	if err := store.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delete(keys.Keys, "k1")
+	got, err := store.Load(ctx, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Request.Messages[0].ContentString() != "secret" {
+		t.Errorf("state mismatch: %+v", got.Request)
+	}
+}
+
+// dirBlobs is a BlobStore persisting the blobs as files of the directory.
+type dirBlobs string
+
+func (d dirBlobs) Put(_ context.Context, id string, data []byte) error {
+	return os.WriteFile(filepath.Join(string(d), id), data, 0o600)
+}
+
+func (d dirBlobs) Get(_ context.Context, id string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), id))
+}
+
+func (d dirBlobs) Delete(_ context.Context, id string) error {
+	return os.Remove(filepath.Join(string(d), id))
+}
+
+func (d dirBlobs) CompareAndSwap(ctx context.Context, id string, old, data []byte) (bool, error) {
+	current, err := d.Get(ctx, id)
+	if err != nil || !bytes.Equal(current, old) {
+		return false, err
+	}
+	return true, d.Put(ctx, id, data)
+}
+
+func (d dirBlobs) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.Name())
+	}
+	return ids, nil
+}
+
+func TestSealedRunStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	state := &RunState{ID: "run", Request: &Request{Model: "m", Messages: []Message{NewTextMessage(MessageRoleHuman, "secret")}}}
+	if err := NewSealedRunStore(dirBlobs(dir), keys).Save(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "run"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Error("persisted state should be encrypted")
+	}
+
+	// another store instance, eg. after a restart, rotates and loads the persisted state.
+	keys.Keys["k2"] = bytes.Repeat([]byte{2}, 32)
+	keys.Current = "k2"
+	store := NewSealedRunStore(dirBlobs(dir), keys)
+	if err := store.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delete(keys.Keys, "k1")
+	got, err := store.Load(ctx, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Request.Messages[0].ContentString() != "secret" {
+		t.Errorf("state mismatch: %+v", got.Request)
+	}
+	if err := store.Delete(ctx, "run"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx, "run"); err == nil {
+		t.Error("deleted state should not be loaded")
+	}
+}
+
+// savingBlobs is a BlobStore saving a state while the first state is read, as a run would during Rotate.
+type savingBlobs struct {
+	dirBlobs
+	save func()
+}
+
+func (b *savingBlobs) Get(ctx context.Context, id string) ([]byte, error) {
+	data, err := b.dirBlobs.Get(ctx, id)
+	if b.save != nil {
+		b.save()
+		b.save = nil
+	}
+	return data, err
+}
+
+func TestSealedRunStoreRotateConcurrentSave(t *testing.T) {
+	ctx := context.Background()
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	blobs := &savingBlobs{dirBlobs: dirBlobs(t.TempDir())}
+	store := NewSealedRunStore(blobs, keys)
+	state := &RunState{ID: "run", Iteration: 1}
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+
+	keys.Keys["k2"] = bytes.Repeat([]byte{2}, 32)
+	keys.Current = "k2"
+	blobs.save = func() {
+		if err := store.Save(ctx, &RunState{ID: "run", Iteration: 2}); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := store.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(ctx, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Iteration != 2 {
+		t.Errorf("state saved during the rotation should be kept: %+v", got)
+	}
+
+	// sealed states are bound to their IDs.
+	data, _ := blobs.dirBlobs.Get(ctx, "run")
+	if err := blobs.Put(ctx, "other", data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx, "other"); err == nil {
+		t.Error("state copied to another ID should not be opened")
+	}
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// SecretsProvider gets the API key of a provider, eg. from Vault or KMS.
+// It is consulted per request, so rotated keys are used without restarts.
+type SecretsProvider interface {
+	GetKey(ctx context.Context, provider string) (string, error)
+}
+
+// SecretsProviderFunc is a function SecretsProvider.
+type SecretsProviderFunc func(ctx context.Context, provider string) (string, error)
+
+func (f SecretsProviderFunc) GetKey(ctx context.Context, provider string) (string, error) {
+	return f(ctx, provider)
+}
+
+// ProviderKeyEnv is the environment variables of the provider API keys, in lookup order.
+var ProviderKeyEnv = map[string][]string{
+	"openai":    {"OPENAI_API_KEY"},
+	"anthropic": {"ANTHROPIC_API_KEY"},
+	"gemini":    {"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+	"azure":     {"AZURE_OPENAI_API_KEY"},
+}
+
+// EnvSecrets gets the API keys from the ProviderKeyEnv environment variables. It is the default.
+type EnvSecrets struct{}
+
+func (EnvSecrets) GetKey(_ context.Context, provider string) (string, error) {
+	for _, env := range ProviderKeyEnv[provider] {
+		if key := os.Getenv(env); key != "" {
+			return key, nil
+		}
+	}
+	return "", nil
+}
+
+// WithSecretsProvider sets the provider of the API keys instead of the environment variables.
+func WithSecretsProvider(p SecretsProvider) Option {
+	return func(o *Options) {
+		o.Secrets = p
+	}
+}
+
+// ResolveAPIKey returns the API key of the provider: WithAPIKey, WithSecretsProvider
+// or the environment variables in this order.
+func (o *Options) ResolveAPIKey(ctx context.Context, provider string) (string, error) {
+	if o.APIKey != "" {
+		return o.APIKey, nil
+	}
+	secrets := o.Secrets
+	if secrets == nil {
+		secrets = EnvSecrets{}
+	}
+	key, err := secrets.GetKey(ctx, provider)
+	if err != nil {
+		return "", fmt.Errorf("get %s API key: %w", provider, err)
+	}
+	return key, nil
+}
+
+// CachedSecrets caches the keys of a SecretsProvider for the TTL,
+// so a remote secret store is not called on every request.
+type CachedSecrets struct {
+	provider SecretsProvider
+	ttl      time.Duration
+	clock    func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]cachedKey
+}
+
+type cachedKey struct {
+	key     string
+	expires time.Time
+}
+
+// NewCachedSecrets creates a CachedSecrets.
+func NewCachedSecrets(provider SecretsProvider, ttl time.Duration) *CachedSecrets {
+	return &CachedSecrets{provider: provider, ttl: ttl, clock: time.Now, keys: map[string]cachedKey{}}
+}
+
+func (s *CachedSecrets) GetKey(ctx context.Context, provider string) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.keys[provider]
+	s.mu.Unlock()
+	if ok && s.clock().Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := s.provider.GetKey(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.keys[provider] = cachedKey{key: key, expires: s.clock().Add(s.ttl)}
+	s.mu.Unlock()
+	return key, nil
+}
+
+// Invalidate removes the cached key of the provider, eg. after an authentication error.
+func (s *CachedSecrets) Invalidate(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, provider)
+}
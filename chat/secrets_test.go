@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveAPIKey(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "env-key")
+
+	key, err := NewOptions().ResolveAPIKey(ctx, "gemini")
+	if err != nil || key != "env-key" {
+		t.Errorf("env key = %q, %v", key, err)
+	}
+
+	secrets := SecretsProviderFunc(func(_ context.Context, provider string) (string, error) {
+		if provider == "openai" {
+			return "", errors.New("vault sealed")
+		}
+		return "vault-" + provider, nil
+	})
+	key, err = NewOptions(WithSecretsProvider(secrets)).ResolveAPIKey(ctx, "gemini")
+	if err != nil || key != "vault-gemini" {
+		t.Errorf("secrets key = %q, %v", key, err)
+	}
+	if _, err := NewOptions(WithSecretsProvider(secrets)).ResolveAPIKey(ctx, "openai"); err == nil {
+		t.Error("expected secrets provider error")
+	}
+
+	key, err = NewOptions(WithSecretsProvider(secrets), WithAPIKey("explicit")).ResolveAPIKey(ctx, "gemini")
+	if err != nil || key != "explicit" {
+		t.Errorf("explicit key = %q, %v", key, err)
+	}
+}
+
+func TestCachedSecrets(t *testing.T) {
+	calls := 0
+	secrets := NewCachedSecrets(SecretsProviderFunc(func(_ context.Context, provider string) (string, error) {
+		calls++
+		return "key", nil
+	}), time.Minute)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	secrets.clock = func() time.Time { return now }
+
+	ctx := context.Background()
+	for range 3 {
+		if _, err := secrets.GetKey(ctx, "openai"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("key should be cached, calls = %d", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	_, _ = secrets.GetKey(ctx, "openai")
+	secrets.Invalidate("openai")
+	_, _ = secrets.GetKey(ctx, "openai")
+	if calls != 3 {
+		t.Errorf("key should be refreshed after expiry and invalidation, calls = %d", calls)
+	}
+}
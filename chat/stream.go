@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"io"
+)
+
+// StreamToWriter returns a streamer writing text chunks to the writer.
+func StreamToWriter(w io.Writer) Streamer {
+	return func(resp *StreamResponse) error {
+		if resp.Type != "text" {
+			return nil
+		}
+		_, err := io.WriteString(w, resp.Content)
+		return err
+	}
+}
+
+// MultiStreamer returns a streamer calling all streamers in order.
+// It stops at the first error.
+func MultiStreamer(streamers ...Streamer) Streamer {
+	return func(resp *StreamResponse) error {
+		for _, s := range streamers {
+			if s == nil {
+				continue
+			}
+			if err := s(resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMultiStreamer(t *testing.T) {
+	out := &bytes.Buffer{}
+	acc := &strings.Builder{}
+	chunks := 0
+	streamer := MultiStreamer(
+		StreamToWriter(out),
+		StreamToWriter(acc),
+		func(resp *StreamResponse) error {
+			chunks++
+			return nil
+		},
+	)
+
+	for _, c := range []StreamResponse{{Type: "text", Content: "Hello, "}, {Type: "thinking", Content: "hmm"}, {Type: "text", Content: "world"}} {
+		if err := streamer(&c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if out.String() != "Hello, world" || acc.String() != "Hello, world" {
+		t.Errorf("content mismatch: %q %q", out.String(), acc.String())
+	}
+	if chunks != 3 {
+		t.Errorf("chunks mismatch: %d", chunks)
+	}
+
+	errStop := errors.New("stop")
+	called := false
+	streamer = MultiStreamer(
+		func(resp *StreamResponse) error { return errStop },
+		func(resp *StreamResponse) error { called = true; return nil },
+	)
+	if err := streamer(&StreamResponse{Type: "text"}); !errors.Is(err, errStop) {
+		t.Fatalf("expected error, got %v", err)
+	}
+	if called {
+		t.Error("streamer after error should not be called")
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"slices"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the LatencyHistogram buckets.
+// Latencies above the last bound are counted in the last bucket of Buckets.
+var LatencyBuckets = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// StallThreshold is the inter-chunk latency counted as a stall, eg. provider throttling.
+var StallThreshold = 2 * time.Second
+
+// Timing is the timing of a generation.
+type Timing struct {
+	// Duration from the request to the response, including retries.
+	Duration time.Duration `json:"duration"`
+	// FirstChunk is the time to the first stream chunk. Zero without streaming.
+	FirstChunk time.Duration `json:"first_chunk,omitempty"`
+	// Chunks is the number of stream chunks.
+	Chunks int `json:"chunks,omitempty"`
+	// ChunkLatency summarizes the latencies between stream chunks. Nil with less than 2 chunks.
+	ChunkLatency *LatencyHistogram `json:"chunk_latency,omitempty"`
+}
+
+// LatencyHistogram summarizes latencies, eg. between stream chunks.
+type LatencyHistogram struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+	// Buckets counts the latencies up to the LatencyBuckets bounds, with an overflow bucket last.
+	Buckets []int `json:"buckets"`
+	// Stalls is the number of latencies of StallThreshold or longer.
+	Stalls int `json:"stalls,omitempty"`
+}
+
+// NewTiming returns the timing of a generation from start to end with the times of the stream chunks.
+func NewTiming(start, end time.Time, chunks []time.Time) *Timing {
+	t := &Timing{Duration: end.Sub(start), Chunks: len(chunks)}
+	if len(chunks) == 0 {
+		return t
+	}
+	t.FirstChunk = chunks[0].Sub(start)
+	latencies := make([]time.Duration, 0, len(chunks)-1)
+	for i := 1; i < len(chunks); i++ {
+		latencies = append(latencies, chunks[i].Sub(chunks[i-1]))
+	}
+	t.ChunkLatency = NewLatencyHistogram(latencies)
+	return t
+}
+
+// NewLatencyHistogram summarizes the latencies, or returns nil if empty.
+func NewLatencyHistogram(latencies []time.Duration) *LatencyHistogram {
+	if len(latencies) == 0 {
+		return nil
+	}
+	h := &LatencyHistogram{
+		Count:   len(latencies),
+		Min:     slices.Min(latencies),
+		Max:     slices.Max(latencies),
+		P50:     percentile(latencies, 0.5),
+		P90:     percentile(latencies, 0.9),
+		P99:     percentile(latencies, 0.99),
+		Buckets: make([]int, len(LatencyBuckets)+1),
+	}
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+		i, _ := slices.BinarySearch(LatencyBuckets, l)
+		h.Buckets[i]++
+		if l >= StallThreshold {
+			h.Stalls++
+		}
+	}
+	h.Mean = total / time.Duration(len(latencies))
+	return h
+}
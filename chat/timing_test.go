@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewTiming(t *testing.T) {
+	start := time.Unix(0, 0)
+	offsets := []time.Duration{800, 820, 840, 860, 3500, 3520} // ms
+	chunks := make([]time.Time, len(offsets))
+	for i, o := range offsets {
+		chunks[i] = start.Add(o * time.Millisecond)
+	}
+
+	timing := NewTiming(start, start.Add(4*time.Second), chunks)
+	if timing.Duration != 4*time.Second || timing.FirstChunk != 800*time.Millisecond || timing.Chunks != 6 {
+		t.Errorf("timing = %+v", timing)
+	}
+	h := timing.ChunkLatency
+	if h.Count != 5 || h.Min != 20*time.Millisecond || h.Max != 2640*time.Millisecond || h.P50 != 20*time.Millisecond {
+		t.Errorf("histogram = %+v", h)
+	}
+	if h.Mean != 544*time.Millisecond || h.Stalls != 1 {
+		t.Errorf("mean = %v, stalls = %d", h.Mean, h.Stalls)
+	}
+	if h.Buckets[1] != 4 || h.Buckets[7] != 1 || len(h.Buckets) != len(LatencyBuckets)+1 {
+		t.Errorf("buckets = %v", h.Buckets)
+	}
+}
+
+func TestNewTimingWithoutChunks(t *testing.T) {
+	start := time.Unix(0, 0)
+	timing := NewTiming(start, start.Add(time.Second), nil)
+	if timing.Duration != time.Second || timing.FirstChunk != 0 || timing.ChunkLatency != nil {
+		t.Errorf("timing = %+v", timing)
+	}
+	if NewLatencyHistogram(nil) != nil {
+		t.Error("histogram of no latencies")
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"math"
+
+	"github.com/jumonmd/gengo/tokenizer"
+)
+
+const (
+	// messageOverheadTokens is the approximate tokens of role and separators per message.
+	messageOverheadTokens = 4
+	// imageTokens is the approximate tokens of an image or file part.
+	imageTokens = 1000
+	// DefaultMaxTokensMargin is the margin of the token estimation error in auto MaxTokens.
+	DefaultMaxTokensMargin = 256
+)
+
+// EstimateTokens estimates the prompt tokens of the request with tokenizer.Heuristic.
+// It is an approximation for budgeting, not an exact provider tokenizer count.
+func EstimateTokens(req *Request) int {
+	return CountTokens(req, tokenizer.Heuristic)
+}
+
+// CountTokens counts the prompt tokens of the request with the tokenizer.
+// Message overheads and non-text parts are approximated.
+func CountTokens(req *Request, t tokenizer.Tokenizer) int {
+	tokens := 0
+	for _, msg := range req.Messages {
+		tokens += messageOverheadTokens
+		for _, part := range msg.Content {
+			if part.Type == "text" {
+				tokens += t.Count(part.Text)
+				continue
+			}
+			tokens += imageTokens
+		}
+		if msg.ToolCall != nil {
+			tokens += t.Count(msg.ToolCall.Name + msg.ToolCall.Arguments)
+		}
+		if msg.ToolResponse != nil {
+			tokens += t.Count(msg.ToolResponse.Name + msg.ToolResponse.Result)
+		}
+	}
+	for _, tool := range req.Tools {
+		tokens += t.Count(tool.Name + tool.Description + string(tool.InputSchema.JSON()))
+	}
+	if req.ResponseSchema != nil {
+		tokens += t.Count(string(req.ResponseSchema.JSON()))
+	}
+	return tokens
+}
+
+// DefaultTokenCorrections are approximate multipliers of the estimate per provider tokenizer.
+// Calibrate a model with WithTokenCorrection by comparing estimates with Usage.InputTokens.
+var DefaultTokenCorrections = map[string]float64{
+	"openai":    1.0,
+	"anthropic": 1.2,
+	"gemini":    0.9,
+}
+
+// estimateTextTokens estimates the tokens of the text with tokenizer.Heuristic.
+func estimateTextTokens(s string) int {
+	return tokenizer.Heuristic.Count(s)
+}
+
+// WithTokenCorrection sets the multiplier of the token estimate of the model,
+// eg. the ratio of Usage.InputTokens to the estimate observed for the model.
+func WithTokenCorrection(model string, factor float64) Option {
+	return func(o *Options) {
+		if o.TokenCorrections == nil {
+			o.TokenCorrections = map[string]float64{}
+		}
+		o.TokenCorrections[model] = factor
+	}
+}
+
+// EstimateModelTokens counts the prompt tokens of the request with the tokenizer registered for the model,
+// or estimates them corrected for the model tokenizer.
+func (o *Options) EstimateModelTokens(req *Request, info *ModelInfo) int {
+	if t, ok := tokenizer.Lookup(req.Model); ok {
+		return CountTokens(req, t)
+	}
+	if t, ok := tokenizer.Lookup(info.Model); ok {
+		return CountTokens(req, t)
+	}
+	factor, ok := o.TokenCorrections[req.Model]
+	if !ok {
+		factor, ok = o.TokenCorrections[info.Model]
+	}
+	if !ok {
+		factor, ok = DefaultTokenCorrections[info.Provider]
+	}
+	if !ok {
+		factor = 1
+	}
+	return int(math.Ceil(float64(EstimateTokens(req)) * factor))
+}
+
+// WithAutoMaxTokens sets MaxTokens of requests without MaxTokens to
+// min(MaxOutputTokens, MaxInputTokens - prompt tokens - margin).
+// margin 0 means DefaultMaxTokensMargin.
+func WithAutoMaxTokens(margin int) Option {
+	return func(o *Options) {
+		o.AutoMaxTokens = true
+		o.AutoMaxTokensMargin = margin
+	}
+}
+
+// MaxTokens returns the max output tokens computed from the remaining context of the model.
+// Returns an error if the prompt leaves no room for output.
+func (o *Options) MaxTokens(req *Request, info *ModelInfo) (int32, error) {
+	margin := o.AutoMaxTokensMargin
+	if margin == 0 {
+		margin = DefaultMaxTokensMargin
+	}
+	maxOutput := info.MaxOutputTokens
+	if maxOutput == 0 {
+		maxOutput = info.MaxTokens
+	}
+	if info.MaxInputTokens == 0 {
+		return int32(maxOutput), nil
+	}
+
+	prompt := o.EstimateModelTokens(req, info)
+	remaining := info.MaxInputTokens - prompt - margin
+	if remaining <= 0 {
+		return 0, &ContextTooLargeError{
+			Model:        req.Model,
+			Needed:       prompt + margin,
+			PromptTokens: prompt,
+			MaxTokens:    margin,
+			Limit:        info.MaxInputTokens,
+		}
+	}
+	if maxOutput > 0 && maxOutput < remaining {
+		return int32(maxOutput), nil
+	}
+	return int32(remaining), nil
+}
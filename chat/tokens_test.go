@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/tokenizer"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	req := &Request{
+		Messages: []Message{
+			NewTextMessage(MessageRoleHuman, strings.Repeat("a", 400)),
+			NewToolCallMessage("search", "call_1", `{"q":"go"}`),
+		},
+	}
+	// 2 messages overhead + 100 text tokens + 4 tool call tokens.
+	if got := EstimateTokens(req); got != 2*messageOverheadTokens+100+4 {
+		t.Errorf("tokens mismatch: %d", got)
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	info := &ModelInfo{MaxInputTokens: 10000, MaxOutputTokens: 4096}
+	o := NewOptions(WithAutoMaxTokens(100))
+
+	short := &Request{Messages: []Message{NewTextMessage(MessageRoleHuman, "hi")}}
+	if got, err := o.MaxTokens(short, info); err != nil || got != 4096 {
+		t.Errorf("short prompt: %d, %v", got, err)
+	}
+
+	long := &Request{Messages: []Message{NewTextMessage(MessageRoleHuman, strings.Repeat("a", 32000))}}
+	if got, err := o.MaxTokens(long, info); err != nil || got != 10000-8000-messageOverheadTokens-100 {
+		t.Errorf("long prompt: %d, %v", got, err)
+	}
+
+	tooLong := &Request{Messages: []Message{NewTextMessage(MessageRoleHuman, strings.Repeat("a", 40000))}}
+	if _, err := o.MaxTokens(tooLong, info); err == nil {
+		t.Error("expected error for prompt exceeding context")
+	}
+}
+
+func TestEstimateTextTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello world!", 3},
+		{"こんにちは世界", 7},
+		{"東京は晴れ, sunny", 5 + 2},
+		{"Привет", 3},
+		{"café", 1},
+	}
+	for _, tt := range tests {
+		if got := estimateTextTokens(tt.text); got != tt.want {
+			t.Errorf("estimateTextTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateModelTokens(t *testing.T) {
+	req := &Request{Model: "claude-3-5-haiku-latest", Messages: []Message{NewTextMessage(MessageRoleHuman, strings.Repeat("a", 384))}}
+	info := &ModelInfo{Model: "claude-3-5-haiku-latest", Provider: "anthropic"}
+
+	if got := NewOptions().EstimateModelTokens(req, info); got != 120 {
+		t.Errorf("provider default correction: %d", got)
+	}
+	if got := NewOptions(WithTokenCorrection("claude-3-5-haiku-latest", 1.5)).EstimateModelTokens(req, info); got != 150 {
+		t.Errorf("model correction: %d", got)
+	}
+	if got := NewOptions().EstimateModelTokens(req, &ModelInfo{}); got != 100 {
+		t.Errorf("no correction: %d", got)
+	}
+
+	tokenizer.Register("claude-3-5", tokenizer.Func(func(s string) int { return len(s) }))
+	t.Cleanup(func() { tokenizer.Unregister("claude-3-5") })
+	if got := NewOptions().EstimateModelTokens(req, info); got != 388 {
+		t.Errorf("registered tokenizer should be used without correction: %d", got)
+	}
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// WithToolResultLimit limits the size of tool results in bytes in agent runs.
+// Larger results are truncated by TruncateToolResult.
+func WithToolResultLimit(limit int) Option {
+	return func(o *Options) {
+		o.ToolResultLimit = limit
+	}
+}
+
+// WithToolResultLimits sets the tool result size limits by tool name, overriding WithToolResultLimit.
+func WithToolResultLimits(limits map[string]int) Option {
+	return func(o *Options) {
+		o.ToolResultLimits = limits
+	}
+}
+
+// ToolResultLimitFor returns the tool result size limit of the tool. 0 means no limit.
+func (o *Options) ToolResultLimitFor(name string) int {
+	if limit, ok := o.ToolResultLimits[name]; ok {
+		return limit
+	}
+	return o.ToolResultLimit
+}
+
+// TruncateToolResult truncates the result to at most limit bytes with a truncation indicator for the model.
+// JSON results are pruned to valid JSON by shortening arrays and strings,
+// other results keep the head and the tail. The result is returned as is if limit is 0 or not exceeded.
+func TruncateToolResult(result string, limit int) string {
+	if limit <= 0 || len(result) <= limit {
+		return result
+	}
+	if pruned, ok := pruneJSON(result, limit); ok {
+		return pruned
+	}
+	return truncateHeadTail(result, limit)
+}
+
+const truncatedMarker = "\n... [truncated %d bytes] ...\n"
+
+func truncateHeadTail(s string, limit int) string {
+	// the marker of len(s) is at least as long as the final marker.
+	keep := limit - len(fmt.Sprintf(truncatedMarker, len(s)))
+	if keep <= 0 {
+		return cutRunes(s, limit)
+	}
+	head := cutRunes(s, keep*2/3)
+	tail := cutRunesTail(s, keep-len(head))
+	return head + fmt.Sprintf(truncatedMarker, len(s)-len(head)-len(tail)) + tail
+}
+
+// cutRunes returns the prefix of s at most n bytes without splitting a rune.
+func cutRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// cutRunesTail returns the suffix of s at most n bytes without splitting a rune.
+func cutRunesTail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+// pruneJSON halves the maximum array and string lengths until the JSON fits the limit.
+func pruneJSON(s string, limit int) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	maxItems, maxChars := jsonMaxLengths(v)
+	for maxItems > 1 || maxChars > 16 {
+		maxItems = max(maxItems/2, 1)
+		maxChars = max(maxChars/2, 16)
+		data, err := json.Marshal(pruneValue(v, maxItems, maxChars))
+		if err != nil {
+			return "", false
+		}
+		if len(data) <= limit {
+			return string(data), true
+		}
+	}
+	return "", false
+}
+
+func jsonMaxLengths(v any) (items, chars int) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			i, c := jsonMaxLengths(child)
+			items, chars = max(items, i), max(chars, c)
+		}
+	case []any:
+		items = len(v)
+		for _, child := range v {
+			i, c := jsonMaxLengths(child)
+			items, chars = max(items, i), max(chars, c)
+		}
+	case string:
+		chars = utf8.RuneCountInString(v)
+	}
+	return items, chars
+}
+
+func pruneValue(v any, maxItems, maxChars int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		pruned := make(map[string]any, len(v))
+		for k, child := range v {
+			pruned[k] = pruneValue(child, maxItems, maxChars)
+		}
+		return pruned
+	case []any:
+		n := min(len(v), maxItems)
+		pruned := make([]any, 0, n+1)
+		for _, child := range v[:n] {
+			pruned = append(pruned, pruneValue(child, maxItems, maxChars))
+		}
+		if len(v) > n {
+			pruned = append(pruned, fmt.Sprintf("... [%d more items truncated]", len(v)-n))
+		}
+		return pruned
+	case string:
+		runes := []rune(v)
+		if len(runes) <= maxChars {
+			return v
+		}
+		return string(runes[:maxChars]) + fmt.Sprintf("... [%d chars truncated]", len(runes)-maxChars)
+	}
+	return v
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTruncateToolResult(t *testing.T) {
+	if got := TruncateToolResult("short", 100); got != "short" {
+		t.Errorf("short result should not be truncated: %s", got)
+	}
+
+	text := strings.Repeat("あ", 200) + "END"
+	got := TruncateToolResult(text, 100)
+	if len(got) > 100 || !strings.Contains(got, "[truncated") || !strings.HasSuffix(got, "END") {
+		t.Errorf("head and tail truncation mismatch: %d bytes %q", len(got), got)
+	}
+
+	items := []map[string]string{}
+	for range 50 {
+		items = append(items, map[string]string{"body": strings.Repeat("x", 100)})
+	}
+	data, _ := json.Marshal(map[string]any{"total": 50, "items": items})
+	got = TruncateToolResult(string(data), 500)
+	var v struct {
+		Total int   `json:"total"`
+		Items []any `json:"items"`
+	}
+	if len(got) > 500 || json.Unmarshal([]byte(got), &v) != nil || v.Total != 50 {
+		t.Fatalf("JSON pruning mismatch: %d bytes %s", len(got), got)
+	}
+	if !strings.Contains(got, "more items truncated") {
+		t.Errorf("missing truncation indicator: %s", got)
+	}
+}
+
+func TestToolResultLimitFor(t *testing.T) {
+	o := NewOptions(WithToolResultLimit(1000), WithToolResultLimits(map[string]int{"search": 5000}))
+	if o.ToolResultLimitFor("search") != 5000 || o.ToolResultLimitFor("other") != 1000 {
+		t.Errorf("limits mismatch: %d, %d", o.ToolResultLimitFor("search"), o.ToolResultLimitFor("other"))
+	}
+}
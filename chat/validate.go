@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultMaxPartBytes is the default maximum decoded size of a content part.
+	DefaultMaxPartBytes = 20 << 20
+	// DefaultMaxRequestBytes is the default maximum total content size of a request.
+	DefaultMaxRequestBytes = 50 << 20
+)
+
+// PayloadTooLargeError is returned when a content part or the request exceeds the size limit.
+// Message and Part are -1 for the total request size.
+type PayloadTooLargeError struct {
+	Message int
+	Part    int
+	Size    int
+	Limit   int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	if e.Message < 0 {
+		return fmt.Sprintf("request payload too large: %d bytes exceeds limit %d", e.Size, e.Limit)
+	}
+	return fmt.Sprintf("content part too large: messages[%d].content[%d] %d bytes exceeds limit %d",
+		e.Message, e.Part, e.Size, e.Limit)
+}
+
+// UnsupportedContentError is returned when a content part MIME type is not allowed for the model.
+type UnsupportedContentError struct {
+	Model    string
+	MIMEType string
+}
+
+func (e *UnsupportedContentError) Error() string {
+	return fmt.Sprintf("content type %s is not supported by model %s", e.MIMEType, e.Model)
+}
+
+// WithMaxPartBytes sets the maximum decoded size of a content part.
+func WithMaxPartBytes(n int) Option {
+	return func(o *Options) {
+		o.MaxPartBytes = n
+	}
+}
+
+// WithMaxRequestBytes sets the maximum total content size of a request.
+func WithMaxRequestBytes(n int) Option {
+	return func(o *Options) {
+		o.MaxRequestBytes = n
+	}
+}
+
+// WithAllowedMIMETypes sets the allowed MIME types of data URL parts, eg. "image/*", "application/pdf".
+// By default, the allowed types are derived from the model capabilities.
+func WithAllowedMIMETypes(types ...string) Option {
+	return func(o *Options) {
+		o.AllowedMIMETypes = types
+	}
+}
+
+// ValidateRequest validates content sizes and MIME types before dispatch.
+func (o *Options) ValidateRequest(req *Request, info *ModelInfo) error {
+	maxPart := o.MaxPartBytes
+	if maxPart == 0 {
+		maxPart = DefaultMaxPartBytes
+	}
+	maxRequest := o.MaxRequestBytes
+	if maxRequest == 0 {
+		maxRequest = DefaultMaxRequestBytes
+	}
+	allowed := o.AllowedMIMETypes
+	if allowed == nil {
+		allowed = capabilityMIMETypes(info)
+	}
+
+	total := 0
+	for i, msg := range req.Messages {
+		for j, part := range msg.Content {
+			size := len(part.Text)
+			if part.Type == "file" && part.DataURL == "" && part.URL != "" {
+				if mimeType := part.FileURLMIMEType(); !matchMIMEType(allowed, mimeType) {
+					return &UnsupportedContentError{Model: info.Model, MIMEType: mimeType}
+				}
+			}
+			if part.DataURL != "" {
+				mimeType, data, err := SplitDataURL(part.DataURL)
+				if err != nil {
+					return fmt.Errorf("messages[%d].content[%d]: %w", i, j, err)
+				}
+				if !matchMIMEType(allowed, mimeType) {
+					return &UnsupportedContentError{Model: info.Model, MIMEType: mimeType}
+				}
+				size = len(data) / 4 * 3
+			}
+			if size > maxPart {
+				return &PayloadTooLargeError{Message: i, Part: j, Size: size, Limit: maxPart}
+			}
+			total += size
+		}
+	}
+	if total > maxRequest {
+		return &PayloadTooLargeError{Message: -1, Part: -1, Size: total, Limit: maxRequest}
+	}
+	return nil
+}
+
+func capabilityMIMETypes(info *ModelInfo) []string {
+	types := []string{"text/*"}
+	if info.SupportsVision {
+		types = append(types, "image/*")
+	}
+	if info.SupportsPDFInput {
+		types = append(types, "application/pdf")
+	}
+	return types
+}
+
+// FileURLMIMEType returns the MIME type of the file part hosted remotely, PDF unless specified.
+func (p *ContentPart) FileURLMIMEType() string {
+	if p.MIMEType != "" {
+		return p.MIMEType
+	}
+	return "application/pdf"
+}
+
+func matchMIMEType(patterns []string, mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
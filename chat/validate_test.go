@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRequest(t *testing.T) {
+	vision := &ModelInfo{Model: "vision-model", SupportsVision: true}
+	textOnly := &ModelInfo{Model: "text-model"}
+	pdf := &ModelInfo{Model: "pdf-model", SupportsPDFInput: true}
+
+	image := func(size int) Message {
+		return Message{Role: MessageRoleHuman, Content: []ContentPart{{
+			Type:    "image",
+			DataURL: EncodeDataURL("image/png", make([]byte, size)),
+		}}}
+	}
+	file := Message{Role: MessageRoleHuman, Content: []ContentPart{{
+		Type:    "file",
+		DataURL: EncodeDataURL("application/pdf", []byte("%PDF-1.4")),
+	}}}
+	fileURL := Message{Role: MessageRoleHuman, Content: []ContentPart{NewURLPart("file", "https://example.com/report.pdf")}}
+
+	tests := []struct {
+		name    string
+		info    *ModelInfo
+		opts    []Option
+		msgs    []Message
+		wantErr any
+	}{
+		{"text", textOnly, nil, []Message{NewTextMessage(MessageRoleHuman, "hello")}, nil},
+		{"image", vision, nil, []Message{image(1024)}, nil},
+		{"image not supported", textOnly, nil, []Message{image(1024)}, &UnsupportedContentError{}},
+		{"pdf", pdf, nil, []Message{file, fileURL}, nil},
+		{"pdf not supported", vision, nil, []Message{file}, &UnsupportedContentError{}},
+		{"pdf url not supported", vision, nil, []Message{fileURL}, &UnsupportedContentError{}},
+		{"allowed mime type", textOnly, []Option{WithAllowedMIMETypes("image/*")}, []Message{image(1024)}, nil},
+		{"part too large", vision, []Option{WithMaxPartBytes(1000)}, []Message{image(1024)}, &PayloadTooLargeError{}},
+		{"request too large", vision, []Option{WithMaxRequestBytes(2000)}, []Message{image(1024), image(1024)}, &PayloadTooLargeError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewOptions(tt.opts...).ValidateRequest(&Request{Messages: tt.msgs}, tt.info)
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			case *UnsupportedContentError:
+				if !errors.As(err, &want) {
+					t.Fatalf("expected UnsupportedContentError, got %v", err)
+				}
+			case *PayloadTooLargeError:
+				if !errors.As(err, &want) {
+					t.Fatalf("expected PayloadTooLargeError, got %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// ResponseVariant is one of the schemas the model may answer with,
+// eg. "answer" or "clarification_request".
+type ResponseVariant struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Schema      jsonschema.Schema `json:"schema"`
+}
+
+// ValidateResponseVariants checks that the variants have unique names and object schemas.
+func ValidateResponseVariants(variants []ResponseVariant) error {
+	if len(variants) == 0 {
+		return fmt.Errorf("no response variants")
+	}
+	names := []string{}
+	for _, v := range variants {
+		if v.Name == "" {
+			return fmt.Errorf("response variant name is empty")
+		}
+		if slices.Contains(names, v.Name) {
+			return fmt.Errorf("duplicate response variant: %s", v.Name)
+		}
+		names = append(names, v.Name)
+		if v.Schema["type"] != "object" {
+			return fmt.Errorf("response variant %s: schema type must be object", v.Name)
+		}
+	}
+	return nil
+}
+
+// VariantSchema returns the schema of an object with the chosen variant name and the value.
+// The value schemas are combined with anyOf, the form of oneOf accepted by OpenAI and Gemini.
+func VariantSchema(variants []ResponseVariant) jsonschema.Schema {
+	names := make([]any, len(variants))
+	values := make([]any, len(variants))
+	for i, v := range variants {
+		names[i] = v.Name
+		values[i] = map[string]any(v.Schema)
+	}
+	return jsonschema.Schema{
+		"type": "object",
+		"properties": map[string]any{
+			"variant": map[string]any{"type": "string", "enum": names},
+			"value":   map[string]any{"anyOf": values},
+		},
+		"required":             []any{"variant", "value"},
+		"additionalProperties": false,
+	}
+}
+
+// VariantTools returns the variants as tools, for models without native structured output.
+func VariantTools(variants []ResponseVariant) []Tool {
+	tools := make([]Tool, len(variants))
+	for i, v := range variants {
+		tools[i] = Tool{Name: v.Name, Description: v.Description, InputSchema: v.Schema}
+	}
+	return tools
+}
+
+// ResolveResponseVariant sets the chosen variant of the response and replaces the response with
+// the JSON text of the value. The response is either the JSON of VariantSchema or a tool call of VariantTools.
+// Responses calling other tools, eg. of the request, are left untouched for the caller to execute.
+// *SchemaMismatchError is returned if the variant is unknown or the value does not match its schema.
+func ResolveResponseVariant(variants []ResponseVariant, resp *Response) error {
+	var name, value string
+	if calls := resp.ToolCalls(); len(calls) > 0 {
+		i := slices.IndexFunc(calls, func(m Message) bool {
+			return slices.ContainsFunc(variants, func(v ResponseVariant) bool { return v.Name == m.ToolCall.Name })
+		})
+		if i < 0 {
+			return nil
+		}
+		name, value = calls[i].ToolCall.Name, calls[i].ToolCall.Arguments
+	} else {
+		var chosen struct {
+			Variant string          `json:"variant"`
+			Value   json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(resp.Text()), &chosen); err != nil {
+			return &SchemaMismatchError{Text: resp.Text(), Err: fmt.Errorf("invalid JSON: %w", err), Attempts: 1, Response: resp}
+		}
+		name, value = chosen.Variant, string(chosen.Value)
+	}
+
+	i := slices.IndexFunc(variants, func(v ResponseVariant) bool { return v.Name == name })
+	if i < 0 {
+		return &SchemaMismatchError{Text: value, Err: fmt.Errorf("unknown response variant: %q", name), Attempts: 1, Response: resp}
+	}
+	if err := variants[i].Schema.Validate([]byte(value)); err != nil {
+		return &SchemaMismatchError{Text: value, Err: fmt.Errorf("variant %s: %w", name, err), Attempts: 1, Response: resp}
+	}
+
+	msgs := []Message{}
+	for _, m := range resp.Messages {
+		if m.Role != MessageRoleAI {
+			msgs = append(msgs, m)
+		}
+	}
+	resp.Messages = append(msgs, NewTextMessage(MessageRoleAI, value))
+	resp.Variant = name
+	if resp.FinishReason == FinishReasonToolUse {
+		resp.FinishReason = FinishReasonStop
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestResolveResponseVariant(t *testing.T) {
+	variants := []ResponseVariant{{Name: "answer", Schema: jsonschema.MustParseJSONString(
+		`{"type": "object", "properties": {"text": {"type": "string"}}, "required": ["text"]}`)}}
+	if !VariantSchema(variants).IsValid() {
+		t.Fatal("variant schema should be valid")
+	}
+
+	tests := []struct {
+		text string
+		ok   bool
+	}{
+		{`{"variant": "answer", "value": {"text": "hi"}}`, true},
+		{`{"variant": "question", "value": {"text": "hi"}}`, false},
+		{`{"variant": "answer", "value": {"question": "hi"}}`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		resp := &Response{Messages: []Message{NewTextMessage(MessageRoleAI, tt.text)}}
+		err := ResolveResponseVariant(variants, resp)
+		if tt.ok != (err == nil) || !tt.ok && !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("%s: unexpected error: %v", tt.text, err)
+		}
+	}
+
+	// tool calls of other tools are left for the caller, and a variant call is chosen among them.
+	resp := &Response{FinishReason: FinishReasonToolUse, Messages: []Message{NewToolCallMessage("search", "1", `{"q": "go"}`)}}
+	if err := ResolveResponseVariant(variants, resp); err != nil || resp.Variant != "" || len(resp.ToolCalls()) != 1 {
+		t.Errorf("response calling other tools should be untouched: %v %+v", err, resp)
+	}
+	resp.Messages = append(resp.Messages, NewToolCallMessage("answer", "2", `{"text": "hi"}`))
+	if err := ResolveResponseVariant(variants, resp); err != nil || resp.Variant != "answer" || resp.Text() != `{"text": "hi"}` {
+		t.Errorf("variant call should be chosen: %v %+v", err, resp)
+	}
+
+	if err := ValidateResponseVariants(append(variants, variants[0])); err == nil {
+		t.Error("expected duplicate variant error")
+	}
+}
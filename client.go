@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Client carries default request values and options applied to every request.
+// Request values win over the defaults.
+type Client struct {
+	// Defaults are merged into every request.
+	Defaults chat.Request
+	// SystemPrompt is prepended when the request has no system message.
+	SystemPrompt string
+	// Options are applied before the per-call options.
+	Options []chat.Option
+	// Policy is enforced on every request, after the per-call options.
+	Policy *Policy
+}
+
+// ClientOption configures a Client.
+type ClientOption func(c *Client)
+
+// NewClient creates a client with defaults.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithDefaultModel sets the model used when the request has no model.
+func WithDefaultModel(model string) ClientOption {
+	return func(c *Client) {
+		c.Defaults.Model = model
+	}
+}
+
+// WithDefaultConfig sets the default model config. Non-zero request config fields win.
+func WithDefaultConfig(config chat.ModelConfig) ClientOption {
+	return func(c *Client) {
+		c.Defaults.Config = config
+	}
+}
+
+// WithSystemPrompt sets the system prompt prepended when the request has no system message.
+func WithSystemPrompt(prompt string) ClientOption {
+	return func(c *Client) {
+		c.SystemPrompt = prompt
+	}
+}
+
+// WithDefaultMetadata sets the default metadata. Request metadata keys win.
+func WithDefaultMetadata(metadata chat.Metadata) ClientOption {
+	return func(c *Client) {
+		c.Defaults.Metadata = metadata
+	}
+}
+
+// WithDefaultTools adds tools to every request. Request tools with the same name win.
+func WithDefaultTools(tools ...chat.Tool) ClientOption {
+	return func(c *Client) {
+		c.Defaults.Tools = append(c.Defaults.Tools, tools...)
+	}
+}
+
+// WithOptions sets options applied to every request.
+func WithOptions(opts ...chat.Option) ClientOption {
+	return func(c *Client) {
+		c.Options = append(c.Options, opts...)
+	}
+}
+
+// Generate generates a response for the request merged with the client defaults.
+func (c *Client) Generate(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	return generate(ctx, c.Request(req), c.options(opts)...)
+}
+
+// Run runs the agent loop for the request merged with the client defaults.
+func (c *Client) Run(ctx context.Context, req *chat.Request, tools map[string]ToolHandler, opts ...chat.Option) (*chat.Response, error) {
+	return Run(ctx, c.Request(req), tools, c.options(opts)...)
+}
+
+func (c *Client) options(opts []chat.Option) []chat.Option {
+	merged := slices.Concat(c.Options, opts)
+	if c.Policy != nil {
+		merged = append(merged, c.Policy.Options()...)
+	}
+	return merged
+}
+
+// Request returns a copy of the request merged with the client defaults.
+func (c *Client) Request(req *chat.Request) *chat.Request {
+	r := *req
+	d := &c.Defaults
+
+	if r.Model == "" {
+		r.Model = d.Model
+	}
+	r.Config = mergeConfig(d.Config, r.Config)
+
+	if len(d.Metadata) > 0 {
+		md := maps.Clone(d.Metadata)
+		maps.Copy(md, r.Metadata)
+		r.Metadata = md
+	}
+
+	if len(d.Tools) > 0 {
+		tools := slices.Clone(r.Tools)
+		for _, t := range d.Tools {
+			if !slices.ContainsFunc(r.Tools, func(rt chat.Tool) bool { return rt.Name == t.Name }) {
+				tools = append(tools, t)
+			}
+		}
+		r.Tools = tools
+	}
+
+	if r.ResponseSchema == nil {
+		r.ResponseSchema = d.ResponseSchema
+	}
+
+	if c.SystemPrompt != "" && !slices.ContainsFunc(r.Messages, func(m chat.Message) bool { return m.Role == chat.MessageRoleSystem }) {
+		r.Messages = append([]chat.Message{chat.NewTextMessage(chat.MessageRoleSystem, c.SystemPrompt)}, r.Messages...)
+	}
+
+	return &r
+}
+
+func mergeConfig(d, r chat.ModelConfig) chat.ModelConfig {
+	if r.MaxTokens == 0 {
+		r.MaxTokens = d.MaxTokens
+	}
+	if r.Temperature == 0 {
+		r.Temperature = d.Temperature
+	}
+	if r.TopP == 0 {
+		r.TopP = d.TopP
+	}
+	if r.PresencePenalty == 0 {
+		r.PresencePenalty = d.PresencePenalty
+	}
+	if r.FrequencyPenalty == 0 {
+		r.FrequencyPenalty = d.FrequencyPenalty
+	}
+	if len(r.StopWords) == 0 {
+		r.StopWords = d.StopWords
+	}
+	return r
+}
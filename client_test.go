@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestClientRequest(t *testing.T) {
+	c := NewClient(
+		WithDefaultModel("default-model"),
+		WithDefaultConfig(chat.ModelConfig{MaxTokens: 100, Temperature: 0.5}),
+		WithSystemPrompt("You are a helpful assistant."),
+		WithDefaultMetadata(chat.Metadata{"team": "search", "env": "prod"}),
+		WithDefaultTools(chat.Tool{Name: "search", Description: "default"}, chat.Tool{Name: "calc"}),
+	)
+
+	req := &chat.Request{
+		Config:   chat.ModelConfig{Temperature: 0.9},
+		Metadata: chat.Metadata{"env": "dev"},
+		Tools:    []chat.Tool{{Name: "search", Description: "request"}},
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hello")},
+	}
+
+	r := c.Request(req)
+
+	if r.Model != "default-model" {
+		t.Errorf("model mismatch: %s", r.Model)
+	}
+	if r.Config.MaxTokens != 100 || r.Config.Temperature != 0.9 {
+		t.Errorf("config mismatch: %+v", r.Config)
+	}
+	if r.Metadata["team"] != "search" || r.Metadata["env"] != "dev" {
+		t.Errorf("metadata mismatch: %v", r.Metadata)
+	}
+	if len(r.Tools) != 2 || r.Tools[0].Description != "request" || r.Tools[1].Name != "calc" {
+		t.Errorf("tools mismatch: %+v", r.Tools)
+	}
+	if len(r.Messages) != 2 || r.Messages[0].Role != chat.MessageRoleSystem {
+		t.Errorf("system prompt mismatch: %+v", r.Messages)
+	}
+
+	if len(req.Messages) != 1 || len(req.Tools) != 1 || len(req.Metadata) != 1 {
+		t.Error("original request should not be modified")
+	}
+
+	req.Messages = append([]chat.Message{chat.NewTextMessage(chat.MessageRoleSystem, "custom")}, req.Messages...)
+	if r := c.Request(req); len(r.Messages) != 2 || r.Messages[0].ContentString() != "custom" {
+		t.Errorf("request system message should win: %+v", r.Messages)
+	}
+}
@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+const (
+	modelCostURL       = "https://raw.githubusercontent.com/BerriAI/litellm/main/model_prices_and_context_window.json"
+	modelCostCopyright = "Data from [BerriAI/litellm](https://github.com/BerriAI/litellm/blob/main/model_prices_and_context_window.json) Copyright Berri AI, MIT License."
+)
+
+var (
+	defaultProviders = []string{"openai", "anthropic", "gemini"}
+	defaultExcludes  = []string{
+		"ft:",
+		"-audio-",
+		"-realtime-",
+		"-search-",
+		"chatgpt-",
+	}
+)
+
+type catalogConfig struct {
+	Providers    []string
+	Excludes     []string
+	JSONFile     string
+	MarkdownFile string
+	Enrich       bool
+	DryRun       bool
+}
+
+type LiteLLMModelInfo struct {
+	Mode                   string  `json:"mode"`
+	Model                  string  `json:"model"`
+	Provider               string  `json:"litellm_provider"`
+	MaxTokens              int     `json:"max_tokens"`
+	MaxInputTokens         int     `json:"max_input_tokens"`
+	MaxOutputTokens        int     `json:"max_output_tokens"`
+	InputTokenCost         float64 `json:"input_cost_per_token"`
+	OutputTokenCost        float64 `json:"output_cost_per_token"`
+	CacheCreationTokenCost float64 `json:"cache_creation_input_token_cost"`
+	CacheReadTokenCost     float64 `json:"cache_read_input_token_cost"`
+	// CacheCreation1hTokenCost is the cache write cost with 1 hour TTL (Anthropic).
+	CacheCreation1hTokenCost float64 `json:"cache_creation_input_token_cost_above_1hr"`
+	SupportsWebSearch        bool    `json:"supports_web_search"`
+	SupportsVision           bool    `json:"supports_vision"`
+	SupportsPDFInput         bool    `json:"supports_pdf_input"`
+	// SupportsFunctionCalling and the following are capabilities used by gengo.Capabilities.
+	SupportsFunctionCalling         bool `json:"supports_function_calling"`
+	SupportsParallelFunctionCalling bool `json:"supports_parallel_function_calling"`
+	SupportsAudioInput              bool `json:"supports_audio_input"`
+	SupportsResponseSchema          bool `json:"supports_response_schema"`
+	SupportsPromptCaching           bool `json:"supports_prompt_caching"`
+	// DeprecationDate is the shutdown date of the model in YYYY-MM-DD format.
+	DeprecationDate string `json:"deprecation_date"`
+}
+type ModelCatalog map[string]LiteLLMModelInfo
+
+func runCatalogUpdate(args []string) error {
+	cfg, err := parseCatalogFlags(args)
+	if err != nil {
+		return err
+	}
+
+	modelData, err := fetchModelData()
+	if err != nil {
+		return fmt.Errorf("fetch model data: %w", err)
+	}
+
+	catalog, err := filterModels(modelData, cfg)
+	if err != nil {
+		return fmt.Errorf("filter models: %w", err)
+	}
+
+	if cfg.Enrich {
+		enrichCatalog(context.Background(), catalog, cfg)
+	}
+
+	previous, err := loadPreviousCatalog(cfg.JSONFile)
+	if err != nil {
+		return fmt.Errorf("load previous catalog: %w", err)
+	}
+	writeDiffReport(os.Stdout, previous, catalog)
+
+	if cfg.DryRun {
+		return nil
+	}
+
+	if err := writeJSON(catalog, cfg.JSONFile); err != nil {
+		return fmt.Errorf("write JSON output: %w", err)
+	}
+
+	if err := writeMarkdown(catalog, cfg); err != nil {
+		return fmt.Errorf("write Markdown output: %w", err)
+	}
+	return nil
+}
+
+func parseCatalogFlags(args []string) (*catalogConfig, error) {
+	fs := flag.NewFlagSet("catalog update", flag.ContinueOnError)
+	providers := fs.String("providers", strings.Join(defaultProviders, ","), "comma separated LiteLLM provider names")
+	excludes := fs.String("excludes", strings.Join(defaultExcludes, ","), "comma separated model name patterns to exclude")
+	jsonFile := fs.String("json", "./chat/modelcatalog.json", "output catalog JSON file")
+	markdownFile := fs.String("markdown", "./MODELS.md", "output Markdown file")
+	enrich := fs.Bool("enrich", false, "add models from provider model-list APIs (requires API keys)")
+	dryRun := fs.Bool("dry-run", false, "print the diff report without writing files")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return &catalogConfig{
+		Providers:    splitList(*providers),
+		Excludes:     splitList(*excludes),
+		JSONFile:     *jsonFile,
+		MarkdownFile: *markdownFile,
+		Enrich:       *enrich,
+		DryRun:       *dryRun,
+	}, nil
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func fetchModelData() ([]byte, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, modelCostURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	return body, nil
+}
+
+func filterModels(rawdata []byte, cfg *catalogConfig) (ModelCatalog, error) {
+	var rawModels map[string]map[string]any
+	if err := json.Unmarshal(rawdata, &rawModels); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+	filteredRawModels := make(map[string]map[string]any)
+	for key, model := range rawModels {
+		if model["mode"] == "chat" {
+			filteredRawModels[key] = model
+		}
+	}
+
+	data, err := json.Marshal(filteredRawModels)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	var allModels ModelCatalog
+	if err := json.Unmarshal(data, &allModels); err != nil {
+		return nil, fmt.Errorf("error decoding JSON: %w", err)
+	}
+
+	filteredModels := make(ModelCatalog)
+	for _, provider := range cfg.Providers {
+		for modelName, modelInfo := range allModels {
+			if !isModelEligible(modelName, modelInfo, provider, cfg.Excludes) {
+				continue
+			}
+			filteredModels[modelName] = modelInfo
+		}
+	}
+	return filteredModels, nil
+}
+
+func isModelEligible(modelName string, info LiteLLMModelInfo, provider string, excludes []string) bool {
+	if info.Mode != "chat" {
+		return false
+	}
+
+	for _, excludePattern := range excludes {
+		if strings.Contains(modelName, excludePattern) {
+			return false
+		}
+	}
+
+	return info.Provider == provider
+}
+
+func loadPreviousCatalog(path string) (chat.ModelCatalog, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return chat.ModelCatalog{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return chat.NewModelCatalog(f)
+}
+
+func writeJSON(catalog ModelCatalog, path string) error {
+	models := []*chat.ModelInfo{}
+	for key, model := range catalog {
+		models = append(models, &chat.ModelInfo{
+			Model:                           key,
+			Provider:                        model.Provider,
+			MaxTokens:                       model.MaxTokens,
+			MaxInputTokens:                  model.MaxInputTokens,
+			MaxOutputTokens:                 model.MaxOutputTokens,
+			InputTokenCost:                  model.InputTokenCost,
+			OutputTokenCost:                 model.OutputTokenCost,
+			CacheCreationTokenCost:          model.CacheCreationTokenCost,
+			CacheReadTokenCost:              model.CacheReadTokenCost,
+			CacheCreation1hTokenCost:        model.CacheCreation1hTokenCost,
+			SupportsWebSearch:               model.SupportsWebSearch,
+			SupportsVision:                  model.SupportsVision,
+			SupportsPDFInput:                model.SupportsPDFInput,
+			SupportsFunctionCalling:         model.SupportsFunctionCalling,
+			SupportsParallelFunctionCalling: model.SupportsParallelFunctionCalling,
+			SupportsAudioInput:              model.SupportsAudioInput,
+			SupportsResponseSchema:          model.SupportsResponseSchema,
+			SupportsPromptCaching:           model.SupportsPromptCaching,
+			Deprecated:                      model.DeprecationDate != "",
+			ShutdownDate:                    model.DeprecationDate,
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Model < models[j].Model })
+
+	jsonData, err := json.Marshal(models)
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating JSON file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(jsonData); err != nil {
+		return fmt.Errorf("error writing to JSON file: %w", err)
+	}
+
+	return nil
+}
+
+func writeMarkdown(catalog ModelCatalog, cfg *catalogConfig) error {
+	file, err := os.Create(cfg.MarkdownFile)
+	if err != nil {
+		return fmt.Errorf("error creating markdown file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "# Model Catalog\n\n"); err != nil {
+		return fmt.Errorf("error writing header: %w", err)
+	}
+
+	for _, provider := range cfg.Providers {
+		if err := writeProviderSection(catalog, provider, file); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(file, "%s\n", modelCostCopyright); err != nil {
+		return fmt.Errorf("error writing copyright: %w", err)
+	}
+
+	return nil
+}
+
+func writeProviderSection(catalog ModelCatalog, provider string, w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "## %s\n\n", provider); err != nil {
+		return fmt.Errorf("error writing provider header: %w", err)
+	}
+
+	providerModels := []string{}
+	for modelName, modelInfo := range catalog {
+		if modelInfo.Provider == provider {
+			providerModels = append(providerModels, modelName)
+		}
+	}
+
+	sort.Strings(providerModels)
+
+	for _, modelName := range providerModels {
+		cleanModelName := strings.ReplaceAll(modelName, "gemini/", "")
+		line := fmt.Sprintf("- `%s`", cleanModelName)
+		if date := catalog[modelName].DeprecationDate; date != "" {
+			line += fmt.Sprintf(" (deprecation: %s)", date)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("error writing model entry: %w", err)
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "\n"); err != nil {
+		return fmt.Errorf("error writing section footer: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+type catalogDiff struct {
+	Added      []string
+	Removed    []string
+	Deprecated []string
+	// Unpriced models have no token prices, eg. of the enrichment, so their costs are reported as 0.
+	Unpriced []string
+}
+
+func diffCatalog(previous chat.ModelCatalog, next ModelCatalog) catalogDiff {
+	diff := catalogDiff{}
+
+	prev := map[string]bool{}
+	for _, m := range previous {
+		prev[m.Model] = true
+	}
+
+	for name, info := range next {
+		if !prev[name] {
+			diff.Added = append(diff.Added, name)
+		}
+		if info.DeprecationDate != "" {
+			diff.Deprecated = append(diff.Deprecated, name+" ("+info.DeprecationDate+")")
+		}
+		if info.InputTokenCost == 0 && info.OutputTokenCost == 0 {
+			diff.Unpriced = append(diff.Unpriced, name)
+		}
+	}
+
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Deprecated)
+	sort.Strings(diff.Unpriced)
+	return diff
+}
+
+func writeDiffReport(w io.Writer, previous chat.ModelCatalog, next ModelCatalog) {
+	diff := diffCatalog(previous, next)
+
+	fmt.Fprintf(w, "models: %d -> %d\n", len(previous), len(next))
+	writeDiffSection(w, "added", diff.Added)
+	writeDiffSection(w, "removed", diff.Removed)
+	writeDiffSection(w, "deprecated", diff.Deprecated)
+	writeDiffSection(w, "unpriced", diff.Unpriced)
+}
+
+func writeDiffSection(w io.Writer, title string, models []string) {
+	if len(models) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s (%d):\n", title, len(models))
+	for _, m := range models {
+		fmt.Fprintf(w, "  %s\n", m)
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestDiffCatalog(t *testing.T) {
+	previous := chat.ModelCatalog{
+		{Model: "gpt-4o"},
+		{Model: "gpt-3.5-turbo"},
+	}
+	next := ModelCatalog{
+		"gpt-4o":  {Provider: "openai", DeprecationDate: "2026-01-01", InputTokenCost: 0.0000025},
+		"gpt-4.1": {Provider: "openai"},
+	}
+
+	diff := diffCatalog(previous, next)
+
+	if !reflect.DeepEqual(diff.Added, []string{"gpt-4.1"}) {
+		t.Errorf("added mismatch: %v", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"gpt-3.5-turbo"}) {
+		t.Errorf("removed mismatch: %v", diff.Removed)
+	}
+	if !reflect.DeepEqual(diff.Deprecated, []string{"gpt-4o (2026-01-01)"}) {
+		t.Errorf("deprecated mismatch: %v", diff.Deprecated)
+	}
+	if !reflect.DeepEqual(diff.Unpriced, []string{"gpt-4.1"}) {
+		t.Errorf("unpriced mismatch: %v", diff.Unpriced)
+	}
+}
+
+func TestIsModelEligible(t *testing.T) {
+	info := LiteLLMModelInfo{Mode: "chat", Provider: "openai"}
+	if !isModelEligible("gpt-4o", info, "openai", defaultExcludes) {
+		t.Error("gpt-4o should be eligible")
+	}
+	if isModelEligible("gpt-4o-realtime-preview", info, "openai", defaultExcludes) {
+		t.Error("realtime model should be excluded")
+	}
+	if isModelEligible("gpt-4o", info, "anthropic", defaultExcludes) {
+		t.Error("provider mismatch should not be eligible")
+	}
+}
+
+func TestReasoningModel(t *testing.T) {
+	for id, want := range map[string]bool{"o1": true, "o3-mini": true, "o4-mini": true, "omni-moderation-latest": false, "gpt-4o": false} {
+		if got := reasoningModel.MatchString(id); got != want {
+			t.Errorf("reasoningModel(%s) = %v", id, got)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// providerModel is a model listed by a provider model-list API.
+type providerModel struct {
+	ID              string
+	MaxInputTokens  int
+	MaxOutputTokens int
+}
+
+type modelLister func(ctx context.Context, client *http.Client) ([]providerModel, error)
+
+// modelListers are the provider model-list APIs keyed by LiteLLM provider name.
+var modelListers = map[string]modelLister{
+	"openai":    listOpenAIModels,
+	"anthropic": listAnthropicModels,
+	"gemini":    listGeminiModels,
+}
+
+// enrichCatalog adds models missing from LiteLLM using provider model-list APIs.
+// Providers without API keys are skipped. The added models have no prices,
+// which the diff report lists as unpriced.
+func enrichCatalog(ctx context.Context, catalog ModelCatalog, cfg *catalogConfig) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for _, provider := range cfg.Providers {
+		lister, ok := modelListers[provider]
+		if !ok {
+			continue
+		}
+		models, err := lister(ctx, client)
+		if err != nil {
+			log.Printf("skip %s enrichment: %v", provider, err)
+			continue
+		}
+		for _, m := range models {
+			name := m.ID
+			if provider == "gemini" {
+				name = "gemini/" + m.ID
+			}
+			if _, ok := catalog[name]; ok {
+				continue
+			}
+			if !isModelEligible(name, LiteLLMModelInfo{Mode: "chat", Provider: provider}, provider, cfg.Excludes) {
+				continue
+			}
+			catalog[name] = LiteLLMModelInfo{
+				Mode:            "chat",
+				Provider:        provider,
+				MaxTokens:       m.MaxOutputTokens,
+				MaxInputTokens:  m.MaxInputTokens,
+				MaxOutputTokens: m.MaxOutputTokens,
+			}
+		}
+	}
+}
+
+// reasoningModel matches the OpenAI o-series model IDs.
+var reasoningModel = regexp.MustCompile(`^o\d`)
+
+func listOpenAIModels(ctx context.Context, client *http.Client) ([]providerModel, error) {
+	key := os.Getenv("OPENAI_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY is not set")
+	}
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + key}}
+	if err := getJSON(ctx, client, "https://api.openai.com/v1/models", header, &resp); err != nil {
+		return nil, err
+	}
+
+	models := []providerModel{}
+	for _, m := range resp.Data {
+		// only chat models, eg. gpt-4o and o3, not omni-moderation.
+		if strings.HasPrefix(m.ID, "gpt-") || reasoningModel.MatchString(m.ID) {
+			models = append(models, providerModel{ID: m.ID})
+		}
+	}
+	return models, nil
+}
+
+func listAnthropicModels(ctx context.Context, client *http.Client) ([]providerModel, error) {
+	key := os.Getenv("ANTHROPIC_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY is not set")
+	}
+	var resp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	header := http.Header{
+		"X-Api-Key":         {key},
+		"Anthropic-Version": {"2023-06-01"},
+	}
+	if err := getJSON(ctx, client, "https://api.anthropic.com/v1/models?limit=1000", header, &resp); err != nil {
+		return nil, err
+	}
+
+	models := []providerModel{}
+	for _, m := range resp.Data {
+		models = append(models, providerModel{ID: m.ID})
+	}
+	return models, nil
+}
+
+func listGeminiModels(ctx context.Context, client *http.Client) ([]providerModel, error) {
+	key := os.Getenv("GOOGLE_API_KEY")
+	if key == "" {
+		return nil, fmt.Errorf("GOOGLE_API_KEY is not set")
+	}
+	var resp struct {
+		Models []struct {
+			Name                       string   `json:"name"`
+			InputTokenLimit            int      `json:"inputTokenLimit"`
+			OutputTokenLimit           int      `json:"outputTokenLimit"`
+			SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+		} `json:"models"`
+	}
+	url := "https://generativelanguage.googleapis.com/v1beta/models?pageSize=1000&key=" + key
+	if err := getJSON(ctx, client, url, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	models := []providerModel{}
+	for _, m := range resp.Models {
+		if !slices.Contains(m.SupportedGenerationMethods, "generateContent") {
+			continue
+		}
+		models = append(models, providerModel{
+			ID:              strings.TrimPrefix(m.Name, "models/"),
+			MaxInputTokens:  m.InputTokenLimit,
+			MaxOutputTokens: m.OutputTokenLimit,
+		})
+	}
+	return models, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Command gengo is a maintenance tool for gengo.
+//
+// Usage:
+//
+//	gengo catalog update [flags]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+const usage = `Usage:
+  gengo catalog update [flags]  update the model catalog from LiteLLM and provider APIs
+`
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] + " " + os.Args[2] {
+	case "catalog update":
+		if err := runCatalogUpdate(os.Args[3:]); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+	"github.com/jumonmd/gengo/prompt"
+)
+
+// DefaultConsistencyTemperature is the sampling temperature of GenerateConsistent
+// for requests without a temperature, so the samples can differ.
+const DefaultConsistencyTemperature = 0.7
+
+// Consensus is the sample chosen by an Aggregator.
+type Consensus struct {
+	// Index of the consensus sample.
+	Index int
+	// Agreement is the fraction of the samples agreeing with the consensus, from 0 to 1.
+	Agreement float64
+	// Usage of the aggregation, eg. a judge model call.
+	Usage *chat.Usage
+}
+
+// Aggregator chooses the consensus of the sampled responses of the request.
+type Aggregator func(ctx context.Context, req *chat.Request, samples []*chat.Response, opts ...chat.Option) (*Consensus, error)
+
+// ConsistentResponse is the consensus of the sampled responses.
+type ConsistentResponse struct {
+	// Response is the consensus sample.
+	Response  *chat.Response   `json:"response"`
+	Agreement float64          `json:"agreement"`
+	Samples   []*chat.Response `json:"samples"`
+	// Usage is the combined usage of the samples and the aggregation.
+	Usage *chat.Usage `json:"usage"`
+}
+
+// GenerateConsistent samples k responses of the request in parallel and returns the consensus
+// chosen by the aggregator, eg. MajorityVote for enum or structured outputs and JudgeVote for free text.
+// Requests without a temperature are sampled with DefaultConsistencyTemperature if the model supports it.
+// Failed samples are dropped, and an error is returned only if all samples fail.
+func GenerateConsistent(ctx context.Context, req *chat.Request, k int, aggregate Aggregator, opts ...chat.Option,
+) (*ConsistentResponse, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid number of samples: %d", k)
+	}
+	r := samplingRequest(req)
+	samples, usage, err := sample(ctx, &r, k, opts...)
+	if err != nil {
+		return nil, err
+	}
+	result := &ConsistentResponse{Samples: samples, Usage: usage}
+
+	consensus, err := aggregate(ctx, &r, result.Samples, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("aggregate samples: %w", err)
+	}
+	if consensus.Index < 0 || consensus.Index >= len(result.Samples) {
+		return nil, fmt.Errorf("aggregate samples: consensus index out of range: %d", consensus.Index)
+	}
+	result.Response = result.Samples[consensus.Index]
+	result.Agreement = consensus.Agreement
+	result.Usage.Add(consensus.Usage)
+	return result, nil
+}
+
+// samplingRequest returns a copy of the request with DefaultConsistencyTemperature if the temperature is not set,
+// except for models rejecting the temperature, eg. OpenAI reasoning models.
+func samplingRequest(req *chat.Request) chat.Request {
+	r := *req
+	if r.Config.Temperature == 0 && chat.SupportsTemperature(r.Model) {
+		r.Config.Temperature = DefaultConsistencyTemperature
+	}
+	return r
+}
+
+// sample generates k responses of the request in parallel and returns the successful ones
+// with their combined usage. An error is returned only if all samples fail.
+func sample(ctx context.Context, req *chat.Request, k int, opts ...chat.Option) ([]*chat.Response, *chat.Usage, error) {
+	responses := make([]*chat.Response, k)
+	errs := make([]error, k)
+	var wg sync.WaitGroup
+	for i := range k {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = generate(ctx, req, opts...)
+		}()
+	}
+	wg.Wait()
+
+	var samples []*chat.Response
+	usage := &chat.Usage{}
+	for i, resp := range responses {
+		if errs[i] != nil {
+			continue
+		}
+		samples = append(samples, resp)
+		usage.Add(resp.Usage)
+	}
+	if len(samples) == 0 {
+		return nil, nil, fmt.Errorf("all samples failed: %w", errors.Join(errs...))
+	}
+	return samples, usage, nil
+}
+
+// MajorityVote chooses the most common answer of the samples. JSON answers are compared by value,
+// and text answers case-insensitively with whitespace collapsed. Ties go to the earlier sample.
+func MajorityVote(_ context.Context, _ *chat.Request, samples []*chat.Response, _ ...chat.Option) (*Consensus, error) {
+	counts := map[string]int{}
+	first := map[string]int{}
+	best := ""
+	for i, resp := range samples {
+		key := normalizeAnswer(resp.Text())
+		if _, ok := first[key]; !ok {
+			first[key] = i
+		}
+		counts[key]++
+		if counts[key] > counts[best] || counts[key] == counts[best] && first[key] < first[best] {
+			best = key
+		}
+	}
+	return &Consensus{Index: first[best], Agreement: float64(counts[best]) / float64(len(samples))}, nil
+}
+
+// normalizeAnswer returns the comparison key of the answer.
+func normalizeAnswer(text string) string {
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// lastHumanText returns the text of the last human message of the request, eg. the question to judge.
+func lastHumanText(req *chat.Request) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == chat.MessageRoleHuman {
+			return req.Messages[i].ContentString()
+		}
+	}
+	return ""
+}
+
+const judgeVotePrompt = `Several answers were sampled for the same question. Choose the answer that the others agree with most,
+and estimate the fraction of the answers agreeing with it in substance, from 0 to 1.
+
+%s
+%s`
+
+var judgeVoteSchema = jsonschema.MustParseJSONString(`{"type": "object", "properties": {
+	"index": {"type": "integer", "minimum": 0},
+	"agreement": {"type": "number", "minimum": 0, "maximum": 1}
+}, "required": ["index", "agreement"]}`)
+
+// JudgeVote returns an aggregator asking the model to choose the consensus of free text samples,
+// which cannot be compared exactly. The judge call is not streamed to the streamer of the options.
+func JudgeVote(model string) Aggregator {
+	return func(ctx context.Context, req *chat.Request, samples []*chat.Response, opts ...chat.Option) (*Consensus, error) {
+		question := lastHumanText(req)
+		var answers strings.Builder
+		for i, resp := range samples {
+			fmt.Fprintf(&answers, "<answer index=\"%d\">\n%s\n</answer>\n", i, prompt.XML("text", resp.Text()))
+		}
+		var out struct {
+			Index     int     `json:"index"`
+			Agreement float64 `json:"agreement"`
+		}
+		text := fmt.Sprintf(judgeVotePrompt, prompt.XML("question", question), strings.TrimSpace(answers.String()))
+		usage, err := judge(ctx, model, text, judgeVoteSchema, &out, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Consensus{Index: out.Index, Agreement: out.Agreement, Usage: usage}, nil
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerateConsistentMajorityVote(t *testing.T) {
+	answers := []string{`{"label": "spam", "score": 1}`, `{"score": 1, "label": "spam"}`, `{"label": "ham", "score": 1}`, "error"}
+	var mu sync.Mutex
+	calls := 0
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if req.Config.Temperature != DefaultConsistencyTemperature {
+			t.Errorf("temperature = %v", req.Config.Temperature)
+		}
+		answer := answers[calls]
+		calls++
+		if answer == "error" {
+			return nil, errors.New("overloaded")
+		}
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, answer)},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	})
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "classify")}}
+	result, err := GenerateConsistent(context.Background(), req, 4, MajorityVote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Samples) != 3 || result.Usage.TotalTokens != 30 {
+		t.Errorf("samples = %d, usage = %+v", len(result.Samples), result.Usage)
+	}
+	if !strings.Contains(result.Response.Text(), `"spam"`) || result.Agreement != 2.0/3 {
+		t.Errorf("consensus = %s, agreement = %v", result.Response.Text(), result.Agreement)
+	}
+}
+
+func TestGenerateConsistentJudgeVote(t *testing.T) {
+	var judged *chat.Request
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		text := `Tokyo is the "capital".`
+		if req.Model == "judge" {
+			judged = req
+			text = `{"index": 0, "agreement": 1}`
+			if chat.NewOptions(opts...).Streamer != nil {
+				t.Error("judge should not be streamed")
+			}
+		}
+		return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)}, Usage: &chat.Usage{TotalTokens: 5}}, nil
+	})
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "capital of Japan?")}}
+	stream := chat.WithStream(func(*chat.StreamResponse) error { return nil })
+	result, err := GenerateConsistent(context.Background(), req, 2, JudgeVote("judge"), stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt := judged.Messages[0].ContentString(); !strings.Contains(prompt, "<question>capital of Japan?</question>") ||
+		!strings.Contains(prompt, `<answer index="1">`) || !strings.Contains(prompt, `<text>Tokyo is the "capital".</text>`) {
+		t.Errorf("judge prompt mismatch: %s", prompt)
+	}
+	if result.Agreement != 1 || result.Usage.TotalTokens != 15 {
+		t.Errorf("result mismatch: %+v", result)
+	}
+}
+
+func TestGenerateConsistentAllFailed(t *testing.T) {
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		return nil, errors.New("overloaded")
+	})
+	req := &chat.Request{Model: "gpt-4o-mini"}
+	if _, err := GenerateConsistent(context.Background(), req, 2, MajorityVote); err == nil {
+		t.Error("expected error when all samples fail")
+	}
+}
+
+func TestSamplingRequest(t *testing.T) {
+	if r := samplingRequest(&chat.Request{Model: "gpt-4o-mini"}); r.Config.Temperature != DefaultConsistencyTemperature {
+		t.Errorf("temperature = %v", r.Config.Temperature)
+	}
+	if r := samplingRequest(&chat.Request{Model: "o3-mini"}); r.Config.Temperature != 0 {
+		t.Errorf("reasoning models should be sampled without temperature: %v", r.Config.Temperature)
+	}
+}
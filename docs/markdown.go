@@ -0,0 +1,383 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package docs converts documents to compact text for LLM consumption.
+//
+//	md, err := docs.HTMLToMarkdown(resp.Body, docs.WithBaseURL(resp.Request.URL))
+package docs
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Option configures the conversion.
+type Option func(c *converter)
+
+// WithBaseURL resolves relative links and image sources against the base URL.
+func WithBaseURL(base *url.URL) Option {
+	return func(c *converter) {
+		c.base = base
+	}
+}
+
+// WithBoilerplate keeps navigation, headers, footers and other page chrome.
+func WithBoilerplate() Option {
+	return func(c *converter) {
+		c.boilerplate = true
+	}
+}
+
+type converter struct {
+	base        *url.URL
+	boilerplate bool
+	// root is the converted element. Headers and footers within it are a part of the content.
+	root *html.Node
+}
+
+// HTMLToMarkdown converts the HTML to markdown, keeping headings, links, lists, tables and code.
+// Scripts, styles and hidden elements are removed, and page chrome such as navigation and footers
+// unless WithBoilerplate. The main or article element is converted if present.
+func HTMLToMarkdown(r io.Reader, opts ...Option) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+	c := &converter{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.root = doc
+	if !c.boilerplate {
+		if main := findElement(doc, atom.Main); main != nil {
+			c.root = main
+		} else if article := findElement(doc, atom.Article); article != nil {
+			c.root = article
+		}
+	}
+	return cleanMarkdown(c.children(c.root)), nil
+}
+
+var skipElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Iframe: true, atom.Svg: true, atom.Canvas: true, atom.Form: true, atom.Button: true,
+	atom.Select: true, atom.Input: true, atom.Textarea: true,
+}
+
+var boilerplateElements = map[atom.Atom]bool{
+	atom.Nav: true, atom.Aside: true,
+}
+
+// boilerplateNames are the class names and IDs of page chrome, matched exactly,
+// so wrappers such as "has-sidebar" or "main-nav-offset" are kept.
+var boilerplateNames = map[string]bool{
+	"nav": true, "navbar": true, "navigation": true, "menu": true, "sidebar": true, "footer": true,
+	"site-header": true, "site-footer": true, "site-nav": true, "breadcrumb": true, "breadcrumbs": true,
+	"cookie-banner": true, "cookie-consent": true, "ad": true, "ads": true, "advert": true,
+	"advertisement": true, "share": true, "social": true, "share-buttons": true, "social-links": true,
+}
+
+func (c *converter) skip(n *html.Node) bool {
+	if skipElements[n.DataAtom] || attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden") {
+		return true
+	}
+	if c.boilerplate {
+		return false
+	}
+	if boilerplateElements[n.DataAtom] {
+		return true
+	}
+	if (n.DataAtom == atom.Header || n.DataAtom == atom.Footer) && !c.withinContent(n) {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary":
+		return true
+	}
+	for _, name := range strings.Fields(attr(n, "class") + " " + attr(n, "id")) {
+		if boilerplateNames[strings.ToLower(name)] {
+			// layout wrappers named like chrome are kept if they contain the content.
+			return findElement(n, atom.Main) == nil && findElement(n, atom.Article) == nil
+		}
+	}
+	return false
+}
+
+// withinContent reports whether the node is within the main or article element converted.
+func (c *converter) withinContent(n *html.Node) bool {
+	if c.root.Type == html.DocumentNode {
+		return false
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == c.root {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *converter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		s := c.node(child)
+		if strings.HasPrefix(s, "\n") {
+			trimmed := strings.TrimRight(b.String(), " ")
+			b.Reset()
+			b.WriteString(trimmed)
+		}
+		if child.Type == html.TextNode && (b.Len() == 0 || strings.HasSuffix(b.String(), "\n")) {
+			s = strings.TrimLeft(s, " ")
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+func (c *converter) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return spaceRe.ReplaceAllString(n.Data, " ")
+	case html.DocumentNode:
+		return c.children(n)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if c.skip(n) {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := inline(c.children(n))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+		atom.Nav, atom.Aside, atom.Figure, atom.Figcaption, atom.Dl, atom.Dt, atom.Dd, atom.Body:
+		return "\n\n" + strings.TrimSpace(c.children(n)) + "\n\n"
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.A:
+		text := inline(c.children(n))
+		href := c.resolve(attr(n, "href"))
+		if text == "" || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case atom.Img:
+		alt := inline(attr(n, "alt"))
+		if alt == "" {
+			return ""
+		}
+		return "![" + alt + "](" + c.resolve(attr(n, "src")) + ")"
+	case atom.Strong, atom.B:
+		return wrap(c.children(n), "**")
+	case atom.Em, atom.I:
+		return wrap(c.children(n), "*")
+	case atom.Code:
+		text := strings.TrimSpace(textContent(n))
+		if text == "" {
+			return ""
+		}
+		fence := backticks(text, 1)
+		if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+			text = " " + text + " "
+		}
+		return fence + text + fence
+	case atom.Pre:
+		lang := ""
+		if code := findElement(n, atom.Code); code != nil {
+			lang = strings.TrimPrefix(attr(code, "class"), "language-")
+			if strings.ContainsAny(lang, " ") {
+				lang = ""
+			}
+		}
+		code := strings.Trim(textContent(n), "\n")
+		fence := backticks(code, 3)
+		return "\n\n" + fence + lang + "\n" + code + "\n" + fence + "\n\n"
+	case atom.Ul, atom.Ol:
+		return c.list(n)
+	case atom.Blockquote:
+		text := strings.TrimSpace(cleanMarkdown(c.children(n)))
+		if text == "" {
+			return ""
+		}
+		return "\n\n> " + strings.ReplaceAll(text, "\n", "\n> ") + "\n\n"
+	case atom.Table:
+		return c.table(n)
+	}
+	return c.children(n)
+}
+
+func (c *converter) list(n *html.Node) string {
+	start := 1
+	if s, err := strconv.Atoi(attr(n, "start")); err == nil {
+		start = s
+	}
+	items := []string{}
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li || c.skip(li) {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(start+len(items)) + ". "
+		}
+		text := strings.TrimSpace(cleanMarkdown(c.children(li)))
+		text = strings.ReplaceAll(text, "\n\n", "\n")
+		text = strings.ReplaceAll(text, "\n", "\n"+strings.Repeat(" ", len(marker)))
+		items = append(items, marker+text)
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(items, "\n") + "\n\n"
+}
+
+func (c *converter) table(n *html.Node) string {
+	rows := [][]string{}
+	columns := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			switch child.DataAtom {
+			case atom.Tr:
+				row := []string{}
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						text := inline(cleanMarkdown(c.children(cell)))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				columns = max(columns, len(row))
+				rows = append(rows, row)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 || columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n")
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (c *converter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if c.base == nil || ref == "" || strings.HasPrefix(ref, "#") {
+		return ref
+	}
+	u, err := c.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+var blankLinesRe = regexp.MustCompile(`\n{3,}`)
+
+// cleanMarkdown removes trailing spaces and collapses blank lines.
+func cleanMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(s, "\n\n"))
+}
+
+// inline joins the text into a single line.
+func inline(s string) string {
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+func wrap(s, marker string) string {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return s
+	}
+	return marker + text + marker
+}
+
+// backticks returns a code fence of at least n backticks, longer than the backtick runs in the code.
+func backticks(code string, n int) string {
+	run := 0
+	for _, r := range code {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		n = max(n, run+1)
+	}
+	return strings.Repeat("`", n)
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package docs
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const page = `<html><head><title>T</title><script>var x = 1;</script></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<div class="cookie-banner">We use cookies</div>
+<main>
+  <h1>Release   notes</h1>
+  <p>Gengo is <strong>fast</strong>. See the <a href="/docs">docs</a>
+  and <a href="#top">top</a>.</p>
+  <ul>
+    <li>One</li>
+    <li>Two
+      <ol><li>Nested</li></ol>
+    </li>
+  </ul>
+  <table>
+    <thead><tr><th>Model</th><th>Price</th></tr></thead>
+    <tbody><tr><td>a|b</td><td>1</td></tr></tbody>
+  </table>
+  <pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+  <p hidden>secret</p>
+</main>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestHTMLToMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/")
+	got, err := HTMLToMarkdown(strings.NewReader(page), WithBaseURL(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Release notes\n\n" +
+		"Gengo is **fast**. See the [docs](https://example.com/docs) and top.\n\n" +
+		"- One\n- Two\n  1. Nested\n\n" +
+		"| Model | Price |\n| --- | --- |\n| a\\|b | 1 |\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("markdown mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHTMLToMarkdownBoilerplate(t *testing.T) {
+	got, err := HTMLToMarkdown(strings.NewReader(page), WithBoilerplate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"[Home](/)", "We use cookies", "Copyright"} {
+		if !strings.Contains(got, s) {
+			t.Errorf("boilerplate %q should be kept: %s", s, got)
+		}
+	}
+	if strings.Contains(got, "var x") || strings.Contains(got, "secret") {
+		t.Errorf("scripts and hidden elements should be removed: %s", got)
+	}
+}
+
+func TestHTMLToMarkdownLayout(t *testing.T) {
+	page := `<body><div class="layout has-sidebar">
+<div class="sidebar">Links</div>
+<div id="main-content"><p>Body</p></div>
+</div></body>`
+	got, err := HTMLToMarkdown(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Body" {
+		t.Errorf("layout wrappers should be kept and the sidebar removed: %q", got)
+	}
+}
+
+func TestHTMLToMarkdownArticleHeader(t *testing.T) {
+	page := `<body><header>Site</header>
+<article><header><h1>Title</h1></header><p>Body</p><footer>By Jane</footer></article>
+<footer>Copyright</footer></body>`
+	got, err := HTMLToMarkdown(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Title\n\nBody\n\nBy Jane"; got != want {
+		t.Errorf("headers of the article should be kept: %q", got)
+	}
+}
+
+func TestHTMLToMarkdownCodeFence(t *testing.T) {
+	page := "<main><p>Use <code>a`b</code> or <code>`x`</code>.</p><pre><code>```go\nx := 1\n```</code></pre></main>"
+	got, err := HTMLToMarkdown(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Use ``a`b`` or `` `x` ``.\n\n" + "````\n```go\nx := 1\n```\n````"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("markdown mismatch (-want +got):\n%s", diff)
+	}
+}
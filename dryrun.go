@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jumonmd/gengo/anthropic"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/google"
+	"github.com/jumonmd/gengo/openai"
+)
+
+// Payload is the provider-specific request that would be sent.
+type Payload struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Body is the serialized provider request.
+	Body json.RawMessage `json:"body"`
+}
+
+// DryRun performs the conversion and validation of Generate and returns the provider payload
+// without sending it, eg. to inspect conversion issues. Middlewares are not called.
+func DryRun(ctx context.Context, req *chat.Request, opts ...chat.Option) (*Payload, error) {
+	opts = chat.MergeContextOptions(ctx, opts)
+	o := chat.NewOptions(opts...)
+	model, req, err := prepareRequest(o, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var body json.RawMessage
+	switch model.Provider {
+	case "anthropic":
+		body, err = anthropic.Payload(req, opts...)
+	case "gemini":
+		body, err = google.Payload(req, opts...)
+	case "openai":
+		body, err = openai.Payload(req, opts...)
+	default:
+		return nil, fmt.Errorf("provider not found: %s", model.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{Provider: model.Provider, Model: req.Model, Body: body}, nil
+}
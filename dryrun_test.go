@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestDryRun(t *testing.T) {
+	req := &chat.Request{Messages: []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleSystem, "Be brief."),
+		chat.NewTextMessage(chat.MessageRoleHuman, "Hello"),
+	}}
+	tests := []struct {
+		model    string
+		provider string
+		want     []string
+	}{
+		{"gpt-4o-mini", "openai", []string{`"model":"gpt-4o-mini"`, `"role":"system"`, `"text":"Hello"`}},
+		{"claude-3-5-haiku-latest", "anthropic", []string{`"model":"claude-3-5-haiku-latest"`, `"max_tokens":2048`, `"text":"Hello"`}},
+		{"gemini-2.0-flash", "gemini", []string{`"model":"gemini-2.0-flash"`, `"text":"Hello"`}},
+	}
+	for _, tt := range tests {
+		r := *req
+		r.Model = tt.model
+		payload, err := DryRun(context.Background(), &r, chat.WithPriority(chat.PriorityBatch))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.model, err)
+		}
+		if payload.Provider != tt.provider {
+			t.Errorf("%s: provider mismatch: %s", tt.model, payload.Provider)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(payload.Body), want) {
+				t.Errorf("%s: payload should contain %s: %s", tt.model, want, payload.Body)
+			}
+		}
+	}
+
+	if _, err := DryRun(context.Background(), &chat.Request{Model: "unknown-model"}); err == nil {
+		t.Error("expected model not found error")
+	}
+}
+
+func TestDryRunWithProvider(t *testing.T) {
+	req := &chat.Request{Model: "llama-3.3-70b", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Hello")}}
+	payload, err := DryRun(context.Background(), req, chat.WithProvider("openai"), chat.WithBaseURL("http://localhost:8000/v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Provider != "openai" || !strings.Contains(string(payload.Body), `"model":"llama-3.3-70b"`) {
+		t.Errorf("payload mismatch: %+v", payload)
+	}
+}
+
+func TestDryRunWithMaxCost(t *testing.T) {
+	req := &chat.Request{Model: "claude-3-5-haiku-latest", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Hello")}}
+	price := chat.ModelPrice{InputTokenCost: 0.000001, OutputTokenCost: 0.00001}
+	payload, err := DryRun(context.Background(), req, chat.WithMaxCost(0.001), chat.WithPriceOverride(req.Model, price))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload.Body), `"max_tokens":99,`) {
+		t.Errorf("max_tokens not capped: %s", payload.Body)
+	}
+}
+
+func TestDryRunWithContextOptions(t *testing.T) {
+	// o3 supports the flex service tier of batch priority requests.
+	req := &chat.Request{Model: "o3", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Hello")}}
+	ctx := chat.ContextWithOptions(context.Background(), chat.WithPriority(chat.PriorityBatch), chat.WithProvider("openai"))
+	payload, err := DryRun(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(payload.Body), `"service_tier":"flex"`) {
+		t.Errorf("context options should be applied: %s", payload.Body)
+	}
+
+	payload, err = DryRun(ctx, req, chat.WithPriority(chat.PriorityNormal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(payload.Body), `"service_tier"`) {
+		t.Errorf("explicit options should override the context options: %s", payload.Body)
+	}
+}
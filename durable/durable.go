@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package durable provides replay-safe building blocks to run gengo calls
+// in durable execution engines like Temporal or Restate.
+//
+// Side effects (Generate and tool execution) are methods of Activities,
+// which can be registered as activities. The agent loop in RunAgent is deterministic:
+// it has no hidden time, randomness or I/O and calls side effects only through the Executor.
+//
+// Temporal example:
+//
+//	// worker
+//	w.RegisterActivity(&durable.Activities{Tools: tools})
+//
+//	// workflow
+//	var a *durable.Activities
+//	exec := durable.ExecutorFuncs{
+//		GenerateFunc: func(req *chat.Request) (*chat.Response, error) {
+//			var resp chat.Response
+//			err := workflow.ExecuteActivity(ctx, a.Generate, req).Get(ctx, &resp)
+//			return &resp, err
+//		},
+//		ExecuteToolFunc: func(call chat.ToolCall) (chat.Message, error) {
+//			var msg chat.Message
+//			err := workflow.ExecuteActivity(ctx, a.ExecuteTool, call).Get(ctx, &msg)
+//			return msg, err
+//		},
+//	}
+//	resp, err := durable.RunAgent(exec, req, 10)
+package durable
+
+import (
+	"context"
+	"slices"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Activities are the side effects of gengo as activity methods.
+// Inputs and outputs are JSON serializable.
+type Activities struct {
+	Tools map[string]gengo.ToolHandler
+	// Options are passed to gengo.Generate.
+	Options []chat.Option
+}
+
+// Generate calls gengo.Generate.
+func (a *Activities) Generate(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+	return gengo.Generate(ctx, req, a.Options...)
+}
+
+// ExecuteTool executes the tool call and returns the tool response message.
+func (a *Activities) ExecuteTool(ctx context.Context, call chat.ToolCall) (chat.Message, error) {
+	return gengo.ExecuteToolCall(ctx, &call, a.Tools), nil
+}
+
+// Executor executes side effects on behalf of the deterministic agent loop.
+type Executor interface {
+	Generate(req *chat.Request) (*chat.Response, error)
+	ExecuteTool(call chat.ToolCall) (chat.Message, error)
+}
+
+// ExecutorFuncs is a function adapter for Executor.
+type ExecutorFuncs struct {
+	GenerateFunc    func(req *chat.Request) (*chat.Response, error)
+	ExecuteToolFunc func(call chat.ToolCall) (chat.Message, error)
+}
+
+func (e ExecutorFuncs) Generate(req *chat.Request) (*chat.Response, error) {
+	return e.GenerateFunc(req)
+}
+
+func (e ExecutorFuncs) ExecuteTool(call chat.ToolCall) (chat.Message, error) {
+	return e.ExecuteToolFunc(call)
+}
+
+// LocalExecutor executes activities directly without a durable execution engine.
+func LocalExecutor(ctx context.Context, a *Activities) Executor {
+	return ExecutorFuncs{
+		GenerateFunc: func(req *chat.Request) (*chat.Response, error) {
+			return a.Generate(ctx, req)
+		},
+		ExecuteToolFunc: func(call chat.ToolCall) (chat.Message, error) {
+			return a.ExecuteTool(ctx, call)
+		},
+	}
+}
+
+// RunAgent runs the agent loop deterministically with the executor.
+// It returns all messages added during the run with the total usage,
+// and gengo.ErrMaxIterations when the model keeps calling tools.
+func RunAgent(exec Executor, req *chat.Request, maxIterations int) (*chat.Response, error) {
+	if maxIterations == 0 {
+		maxIterations = gengo.DefaultMaxIterations
+	}
+
+	r := *req
+	r.Messages = slices.Clone(req.Messages)
+	start := len(r.Messages)
+	usage := &chat.Usage{}
+
+	for i := 0; i < maxIterations; i++ {
+		resp, err := exec.Generate(&r)
+		if err != nil {
+			return nil, err
+		}
+		usage.Add(resp.Usage)
+		r.Messages = append(r.Messages, resp.Messages...)
+
+		calls := resp.ToolCalls()
+		if len(calls) == 0 {
+			return &chat.Response{
+				Model:        resp.Model,
+				FinishReason: resp.FinishReason,
+				Messages:     r.Messages[start:],
+				Metadata:     resp.Metadata,
+				Usage:        usage,
+			}, nil
+		}
+
+		for _, call := range calls {
+			msg, err := exec.ExecuteTool(*call.ToolCall)
+			if err != nil {
+				return nil, err
+			}
+			r.Messages = append(r.Messages, msg)
+		}
+	}
+
+	return &chat.Response{
+		Model:        r.Model,
+		FinishReason: chat.FinishReasonToolUse,
+		Messages:     r.Messages[start:],
+		Usage:        usage,
+	}, gengo.ErrMaxIterations
+}
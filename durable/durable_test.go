@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package durable
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// replayExecutor records activity results as a durable engine would, and replays them.
+type replayExecutor struct {
+	history []json.RawMessage
+	replay  bool
+	pos     int
+	calls   int
+}
+
+func (e *replayExecutor) record(v any, fn func() (any, error)) error {
+	if e.replay {
+		err := json.Unmarshal(e.history[e.pos], v)
+		e.pos++
+		return err
+	}
+	e.calls++
+	out, err := fn()
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(out)
+	e.history = append(e.history, data)
+	return json.Unmarshal(data, v)
+}
+
+func (e *replayExecutor) Generate(req *chat.Request) (*chat.Response, error) {
+	resp := &chat.Response{}
+	err := e.record(resp, func() (any, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.IsToolResponse() {
+			return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "done: "+last.ToolResponse.Result)}}, nil
+		}
+		return &chat.Response{Messages: []chat.Message{chat.NewToolCallMessage("lookup", "call-1", `{}`)}}, nil
+	})
+	return resp, err
+}
+
+func (e *replayExecutor) ExecuteTool(call chat.ToolCall) (chat.Message, error) {
+	msg := chat.Message{}
+	err := e.record(&msg, func() (any, error) {
+		return chat.NewToolResponseMessage(call.Name, call.ID, "42"), nil
+	})
+	return msg, err
+}
+
+func TestRunAgentReplay(t *testing.T) {
+	req := &chat.Request{
+		Model:    "test-model",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "lookup")},
+	}
+
+	exec := &replayExecutor{}
+	first, err := RunAgent(exec, req, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exec.calls != 3 {
+		t.Fatalf("activity calls mismatch: %d", exec.calls)
+	}
+
+	exec.replay = true
+	replayed, err := RunAgent(exec, req, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exec.calls != 3 {
+		t.Errorf("replay should not execute activities: %d", exec.calls)
+	}
+	if !reflect.DeepEqual(first.Messages, replayed.Messages) {
+		t.Errorf("replay is not deterministic:\n%v\n%v", first.Messages, replayed.Messages)
+	}
+	if got := replayed.Messages[len(replayed.Messages)-1].ContentString(); got != "done: 42" {
+		t.Errorf("content mismatch: %s", got)
+	}
+}
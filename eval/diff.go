@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package eval compares and evaluates model responses.
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Op is a diff operation.
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// TokenDiff is a run of tokens with the same operation. Insert is in B, delete is in A.
+type TokenDiff struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// FieldDiff is a difference of a JSON leaf field, eg. path "items[0].name".
+type FieldDiff struct {
+	Path string `json:"path"`
+	// A and B are the JSON values. Empty if the field is missing.
+	A string `json:"a,omitempty"`
+	B string `json:"b,omitempty"`
+}
+
+// DiffResult is the difference between two responses.
+type DiffResult struct {
+	Tokens []TokenDiff `json:"tokens"`
+	// TextSimilarity is 2*common tokens/(tokens of A + tokens of B), 1 for identical text.
+	TextSimilarity float64 `json:"text_similarity"`
+	// JSON is true if both responses are JSON. Fields and FieldSimilarity are set only if JSON.
+	JSON   bool        `json:"json"`
+	Fields []FieldDiff `json:"fields,omitempty"`
+	// FieldSimilarity is equal leaf fields/all leaf fields of A and B.
+	FieldSimilarity float64 `json:"field_similarity,omitempty"`
+}
+
+// Diff compares the text of two responses at token level, and at JSON field level if both are JSON.
+// Tool calls are compared as "name(arguments)" text.
+func Diff(a, b *chat.Response) *DiffResult {
+	textA, textB := responseText(a), responseText(b)
+	result := &DiffResult{}
+	result.Tokens, result.TextSimilarity = diffTokens(tokenize(textA), tokenize(textB))
+
+	var va, vb any
+	if json.Unmarshal([]byte(textA), &va) == nil && json.Unmarshal([]byte(textB), &vb) == nil {
+		result.JSON = true
+		result.Fields, result.FieldSimilarity = diffFields(va, vb)
+	}
+	return result
+}
+
+func responseText(resp *chat.Response) string {
+	if resp == nil {
+		return ""
+	}
+	text := resp.Text()
+	for _, msg := range resp.ToolCalls() {
+		text += fmt.Sprintf("%s(%s)", msg.ToolCall.Name, msg.ToolCall.Arguments)
+	}
+	return strings.TrimSpace(text)
+}
+
+// tokenize splits text into words, punctuation and whitespace runs.
+func tokenize(text string) []string {
+	tokens := []string{}
+	current := []rune{}
+	kind := 0
+	flush := func() {
+		if len(current) > 0 {
+			tokens = append(tokens, string(current))
+			current = current[:0]
+		}
+	}
+	for _, r := range text {
+		k := 1
+		switch {
+		case unicode.IsSpace(r):
+			k = 2
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			// punctuation is a token by itself.
+			flush()
+			tokens = append(tokens, string(r))
+			kind = 0
+			continue
+		}
+		if k != kind {
+			flush()
+		}
+		kind = k
+		current = append(current, r)
+	}
+	flush()
+	return tokens
+}
+
+// diffTokens computes the longest common subsequence diff of the tokens.
+func diffTokens(a, b []string) ([]TokenDiff, float64) {
+	if len(a)+len(b) == 0 {
+		return []TokenDiff{}, 1
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diffs := []TokenDiff{}
+	add := func(op Op, text string) {
+		if n := len(diffs); n > 0 && diffs[n-1].Op == op {
+			diffs[n-1].Text += text
+			return
+		}
+		diffs = append(diffs, TokenDiff{Op: op, Text: text})
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			add(OpEqual, a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			add(OpInsert, b[j])
+			j++
+		default:
+			add(OpDelete, a[i])
+			i++
+		}
+	}
+	return diffs, 2 * float64(lcs[0][0]) / float64(len(a)+len(b))
+}
+
+// diffFields compares the leaf fields of two JSON values.
+func diffFields(a, b any) ([]FieldDiff, float64) {
+	leavesA, leavesB := map[string]any{}, map[string]any{}
+	flatten("", a, leavesA)
+	flatten("", b, leavesB)
+
+	paths := map[string]bool{}
+	for p := range leavesA {
+		paths[p] = true
+	}
+	for p := range leavesB {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	diffs := []FieldDiff{}
+	for _, p := range sorted {
+		va, okA := leavesA[p]
+		vb, okB := leavesB[p]
+		if okA && okB && reflect.DeepEqual(va, vb) {
+			continue
+		}
+		d := FieldDiff{Path: p}
+		if okA {
+			d.A = jsonString(va)
+		}
+		if okB {
+			d.B = jsonString(vb)
+		}
+		diffs = append(diffs, d)
+	}
+	if len(sorted) == 0 {
+		return diffs, 1
+	}
+	return diffs, float64(len(sorted)-len(diffs)) / float64(len(sorted))
+}
+
+func flatten(path string, v any, leaves map[string]any) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 {
+			leaves[path] = val
+		}
+		for k, child := range val {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flatten(p, child, leaves)
+		}
+	case []any:
+		if len(val) == 0 {
+			leaves[path] = val
+		}
+		for i, child := range val {
+			flatten(fmt.Sprintf("%s[%d]", path, i), child, leaves)
+		}
+	default:
+		leaves[path] = val
+	}
+}
+
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package eval
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func textResponse(text string) *chat.Response {
+	return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)}}
+}
+
+func TestDiffText(t *testing.T) {
+	result := Diff(textResponse("The capital of Japan is Tokyo."), textResponse("The capital of Japan is Kyoto."))
+
+	want := []TokenDiff{
+		{Op: OpEqual, Text: "The capital of Japan is "},
+		{Op: OpInsert, Text: "Kyoto"},
+		{Op: OpDelete, Text: "Tokyo"},
+		{Op: OpEqual, Text: "."},
+	}
+	if !reflect.DeepEqual(result.Tokens, want) {
+		t.Errorf("tokens mismatch: %+v", result.Tokens)
+	}
+	// 11 of 12 tokens are common.
+	if math.Abs(result.TextSimilarity-11.0/12.0) > 1e-9 {
+		t.Errorf("similarity mismatch: %f", result.TextSimilarity)
+	}
+	if result.JSON {
+		t.Error("text responses should not be JSON")
+	}
+
+	if same := Diff(textResponse("same"), textResponse("same")); same.TextSimilarity != 1 {
+		t.Errorf("identical similarity: %f", same.TextSimilarity)
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	a := textResponse(`{"name": "Tokyo", "country": "Japan", "tags": ["capital", "city"]}`)
+	b := textResponse(`{"name": "Tokyo", "country": "JP", "tags": ["capital"], "population": 14000000}`)
+
+	result := Diff(a, b)
+	if !result.JSON {
+		t.Fatal("expected JSON diff")
+	}
+	want := []FieldDiff{
+		{Path: "country", A: `"Japan"`, B: `"JP"`},
+		{Path: "population", B: "14000000"},
+		{Path: "tags[1]", A: `"city"`},
+	}
+	if !reflect.DeepEqual(result.Fields, want) {
+		t.Errorf("fields mismatch: %+v", result.Fields)
+	}
+	// name and tags[0] are equal of 5 fields.
+	if math.Abs(result.FieldSimilarity-0.4) > 1e-9 {
+		t.Errorf("field similarity mismatch: %f", result.FieldSimilarity)
+	}
+}
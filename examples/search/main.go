@@ -26,7 +26,5 @@ func main() {
 	}
 
 	// Print the response
-	for _, msg := range resp.Messages {
-		fmt.Printf("%+v\n", msg.ContentString())
-	}
+	fmt.Println(resp.Text())
 }
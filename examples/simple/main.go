@@ -24,7 +24,5 @@ func main() {
 	}
 
 	// Print the response
-	for _, msg := range resp.Messages {
-		fmt.Println(msg.String())
-	}
+	fmt.Println(resp.Text())
 }
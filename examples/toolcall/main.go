@@ -13,14 +13,11 @@ import (
 func main() {
 	ctx := context.Background()
 
-	msgs := []chat.Message{
-		chat.NewTextMessage(chat.MessageRoleHuman, "What is the weather in Tokyo?"),
-	}
-
-	// Simple text generation
-	resp, err := gengo.Generate(ctx, &chat.Request{
-		Model:    "gpt-4o-mini", // or gemini-2.0-flash, claude-3-5-haiku-latest
-		Messages: msgs,
+	req := &chat.Request{
+		Model: "gpt-4o-mini", // or gemini-2.0-flash, claude-3-5-haiku-latest
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleHuman, "What is the weather in Tokyo?"),
+		},
 		Tools: []chat.Tool{
 			{
 				Name:        "get_current_weather",
@@ -28,28 +25,18 @@ func main() {
 				InputSchema: jsonschema.MustParseJSONString(`{"type": "object", "properties": {"location": {"type": "string"}}}`),
 			},
 		},
-		MustCallTool: true,
-	})
-	if err != nil {
-		panic(err)
 	}
 
-	msgs = append(msgs, resp.Messages...)
-
-	for _, msg := range resp.ToolCalls() {
-		msgs = append(msgs, chat.NewToolResponseMessage("get_current_weather", msg.ToolCall.ID, "Rainy"))
-	}
-
-	resp, err = gengo.Generate(ctx, &chat.Request{
-		Model:    "gpt-4o-mini", // or gemini-2.0-flash, claude-3-5-haiku-latest
-		Messages: msgs,
+	// Run executes the tool calls and generates again until the model stops calling tools.
+	resp, err := gengo.Run(ctx, req, map[string]gengo.ToolHandler{
+		"get_current_weather": func(ctx context.Context, call *chat.ToolCall) (string, error) {
+			return `{"weather": "rainy", "temperature_celsius": 18}`, nil
+		},
 	})
 	if err != nil {
 		panic(err)
 	}
 
 	// Print the response
-	for _, msg := range resp.Messages {
-		fmt.Printf("%+v\n", msg.ContentString())
-	}
+	fmt.Println(resp.Text())
 }
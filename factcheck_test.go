@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerateFactChecked(t *testing.T) {
+	sources := []Source{{ID: "policy", Title: "Refunds", Text: "Refunds are issued\nwithin 14 days of purchase."}}
+	var requests []*chat.Request
+	var streamed []bool
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		requests = append(requests, req)
+		streamed = append(streamed, chat.NewOptions(opts...).Streamer != nil)
+		text := "Refunds are issued within 14 days, by bank transfer."
+		if len(requests) == 2 {
+			text = `{"claims": [
+				{"claim": "Refunds are issued within 14 days.", "verdict": "supported", "source_id": "policy",
+					"quote": "Refunds are issued within 14 days", "explanation": ""},
+				{"claim": "Refunds are paid by bank transfer.", "verdict": "supported", "source_id": "policy",
+					"quote": "paid by bank transfer", "explanation": ""}
+			]}`
+		}
+		return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)}}, nil
+	})
+
+	req := &chat.Request{Model: "gpt-4o", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "How are refunds issued?")}}
+	stream := chat.WithStream(func(*chat.StreamResponse) error { return nil })
+	result, err := GenerateFactChecked(context.Background(), req, "gpt-4o-mini", sources, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !streamed[0] || streamed[1] {
+		t.Errorf("only the answer should be streamed: %v", streamed)
+	}
+	verify := requests[1]
+	if verify.Model != "gpt-4o-mini" || verify.ResponseSchema == nil {
+		t.Errorf("verify request mismatch: %+v", verify)
+	}
+	if prompt := verify.Messages[0].ContentString(); !strings.Contains(prompt, `<source id="policy">`) ||
+		!strings.Contains(prompt, "<answer>Refunds are issued within 14 days, by bank transfer.</answer>") {
+		t.Errorf("verify prompt mismatch: %s", prompt)
+	}
+	claims := result.Report.Claims
+	if len(claims) != 2 || !claims[0].QuoteFound || claims[1].QuoteFound || result.Report.Verified() {
+		t.Errorf("report mismatch: %+v", result.Report)
+	}
+	if result.Response.Text() != "Refunds are issued within 14 days, by bank transfer." {
+		t.Errorf("answer mismatch: %s", result.Response.Text())
+	}
+}
+
+func TestFactCheckWithoutSources(t *testing.T) {
+	if _, err := FactCheck(context.Background(), "gpt-4o-mini", "answer", nil); err == nil {
+		t.Error("expected error without sources")
+	}
+}
+
+func TestQuoteFound(t *testing.T) {
+	sources := []Source{{ID: "spec", Text: "Use a < b && \"c\"\nfor comparison."}}
+	if !quoteFound(sources, "spec", `a &lt; b &amp;&amp; "c" for`) {
+		t.Error("escaped quote should be found")
+	}
+	if quoteFound(sources, "other", "a < b") {
+		t.Error("quote of unknown source should not be found")
+	}
+}
@@ -5,7 +5,11 @@ package gengo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jumonmd/gengo/anthropic"
 	"github.com/jumonmd/gengo/chat"
@@ -13,18 +17,303 @@ import (
 	"github.com/jumonmd/gengo/openai"
 )
 
+// GenerateFunc generates a response for the request. Generate by default where it is configurable.
+type GenerateFunc func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error)
+
 // Generate fetches responses from various AI models.
 // Routes requests to the appropriate provider (OpenAI, Gemini, or Anthropic)
 // based on the requested model name.
+// Options attached by chat.ContextWithOptions are applied before opts.
 func Generate(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	opts = chat.MergeContextOptions(ctx, opts)
 	o := chat.NewOptions(opts...)
 
-	model := o.ModelCatalog.GetModel(req.Model)
-	if model == nil {
-		return nil, fmt.Errorf("model not found: %s", req.Model)
+	original := req
+	model, req, err := prepareRequest(o, req)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Emit(&chat.Event{Type: chat.EventRequest, Model: req.Model, Request: req})
+
+	if o.Priority != "" {
+		ctx = chat.ContextWithPriority(ctx, o.Priority)
+	}
+
+	start := o.Now()
+	tracker := &streamTracker{}
+	if o.Streamer != nil {
+		streamer := tracker.streamer(o, req.Model)
+		if o.StreamSchemaValidation && req.ResponseSchema != nil {
+			schema, next := req.ResponseSchema, streamer
+			streamer = tracker.perAttempt(func() chat.Streamer { return chat.SchemaStreamer(schema, next) })
+		}
+		opts = append(opts, chat.WithStream(streamer))
+	}
+
+	call := providerCall(o, model.Provider, opts, tracker)
+	resp, err := call(ctx, req)
+	// providers validating structured outputs return the mismatch, regenerated by validateSchema.
+	var mismatch *chat.SchemaMismatchError
+	if errors.As(err, &mismatch) && o.SchemaValidation && mismatch.Response != nil {
+		resp, err = mismatch.Response, nil
+		if resp.Usage != nil {
+			resp.Usage.Requests = 1
+		}
+	}
+	if err == nil && o.FinishPolicy != nil {
+		var final generation
+		final, resp, err = applyFinishPolicy(ctx, o, original, generation{model: model, req: req, call: call}, resp, opts, tracker)
+		model, req, call = final.model, final.req, final.call
+	}
+	if err == nil {
+		err = chat.ValidateResponseRegex(req, resp)
+	}
+	if err == nil && o.ResponseLanguage != "" {
+		resp, err = checkLanguage(ctx, o, req, resp, regenerate(o, call))
+	}
+	if err == nil && o.MaxOutputChars > 0 {
+		resp, err = limitOutputChars(ctx, o, req, resp, regenerate(o, call))
+	}
+	if err == nil && o.SchemaValidation {
+		resp, err = validateSchema(ctx, o, req, resp, regenerate(o, call))
+	}
+	if err == nil && o.SchemaFieldOrder {
+		chat.OrderResponseFields(req, resp)
+	}
+	if err != nil {
+		o.Emit(&chat.Event{Type: chat.EventError, Model: req.Model, Provider: model.Provider, Error: err.Error()})
+		return nil, err
+	}
+
+	resp.Timing = chat.NewTiming(start, o.Now(), tracker.times)
+	o.Emit(&chat.Event{Type: chat.EventFinal, Model: req.Model, Provider: model.Provider, Request: req, Response: resp})
+	return resp, nil
+}
+
+// prepareRequest looks up the model, and normalizes and validates the request for the model.
+func prepareRequest(o *chat.Options, req *chat.Request) (*chat.ModelInfo, *chat.Request, error) {
+	model, err := o.LookupModel(req.Model)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	switch model.Provider {
+	if err := o.CheckDeprecation(model, o.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	req = o.NormalizeRequest(req)
+	req = o.ApplyResponseLanguage(req)
+	req = o.ApplyMaxOutputChars(req)
+
+	if err := o.ValidateRequest(req, model); err != nil {
+		return nil, nil, err
+	}
+
+	if err := chat.ValidateResponseFormat(model.Provider, req); err != nil {
+		return nil, nil, err
+	}
+
+	if o.AutoMaxTokens && req.Config.MaxTokens == 0 {
+		maxTokens, err := o.MaxTokens(req, model)
+		if err != nil {
+			return nil, nil, err
+		}
+		r := *req
+		r.Config.MaxTokens = maxTokens
+		req = &r
+	}
+
+	if o.MaxCost > 0 {
+		maxTokens, err := o.MaxCostTokens(req, model)
+		if err != nil {
+			return nil, nil, err
+		}
+		if req.Config.MaxTokens == 0 || maxTokens < req.Config.MaxTokens {
+			r := *req
+			r.Config.MaxTokens = maxTokens
+			req = &r
+		}
+	}
+
+	if err := o.CheckContextWindow(req, model); err != nil {
+		return nil, nil, err
+	}
+	return model, req, nil
+}
+
+const schemaRetryPrompt = `The response does not match the JSON schema: %v
+Respond again with only JSON matching the schema.`
+
+// validateSchema validates the response against the request schema and regenerates
+// with the validation error up to the schema retries. Usage is summed over the attempts.
+func validateSchema(ctx context.Context, o *chat.Options, req *chat.Request, resp *chat.Response, generate chat.GenerateFunc,
+) (*chat.Response, error) {
+	usage := resp.Usage
+	err := chat.ValidateResponseSchema(req, resp)
+	attempts := 1
+	var mismatch *chat.SchemaMismatchError
+	for ; errors.As(err, &mismatch) && attempts <= o.SchemaRetries; attempts++ {
+		retry := *req
+		retry.Messages = append(slices.Clone(req.Messages),
+			chat.NewTextMessage(chat.MessageRoleAI, mismatch.Text),
+			chat.NewTextMessage(chat.MessageRoleHuman, fmt.Sprintf(schemaRetryPrompt, mismatch.Err)))
+		resp, err = generate(ctx, &retry)
+		if err != nil {
+			return nil, err
+		}
+		usage = chat.SumUsage(usage, resp.Usage)
+		err = chat.ValidateResponseSchema(req, resp)
+	}
+	resp.Usage = usage
+	if errors.As(err, &mismatch) {
+		mismatch.Attempts = attempts
+	}
+	return resp, err
+}
+
+const languageRetryPrompt = "Respond again in %s only."
+
+// checkLanguage regenerates once if the response is not in the response language.
+// A mismatch of the retry is an error only with StrictResponseLanguage. Usage is summed over the attempts.
+func checkLanguage(ctx context.Context, o *chat.Options, req *chat.Request, resp *chat.Response, generate chat.GenerateFunc,
+) (*chat.Response, error) {
+	var mismatch *chat.LanguageMismatchError
+	if !errors.As(chat.CheckResponseLanguage(o.ResponseLanguage, req, resp), &mismatch) {
+		return resp, nil
+	}
+	retry := *req
+	retry.Messages = append(slices.Clone(req.Messages),
+		chat.NewTextMessage(chat.MessageRoleAI, mismatch.Text),
+		chat.NewTextMessage(chat.MessageRoleHuman, fmt.Sprintf(languageRetryPrompt, chat.LanguageName(o.ResponseLanguage))))
+	usage := resp.Usage
+	resp, err := generate(ctx, &retry)
+	if err != nil {
+		return nil, err
+	}
+	resp.Usage = chat.SumUsage(usage, resp.Usage)
+	err = chat.CheckResponseLanguage(o.ResponseLanguage, req, resp)
+	if errors.As(err, &mismatch) && !o.StrictResponseLanguage {
+		if resp.Metadata == nil {
+			resp.Metadata = chat.Metadata{}
+		}
+		resp.Metadata[chat.MetadataLanguageMismatch] = mismatch.Got
+		return resp, nil
+	}
+	return resp, err
+}
+
+const outputCharsRetryPrompt = "The response has %d characters. Respond again within %d characters."
+
+// limitOutputChars regenerates the response exceeding MaxOutputChars up to the retries,
+// and trims the last response to MaxOutputChars. Usage is summed over the attempts.
+func limitOutputChars(ctx context.Context, o *chat.Options, req *chat.Request, resp *chat.Response, generate chat.GenerateFunc,
+) (*chat.Response, error) {
+	usage := resp.Usage
+	var exceeded *chat.OutputCharsExceededError
+	for i := 0; i < o.OutputCharsRetries && errors.As(chat.CheckOutputChars(o.MaxOutputChars, req, resp), &exceeded); i++ {
+		retry := *req
+		retry.Messages = append(slices.Clone(req.Messages),
+			chat.NewTextMessage(chat.MessageRoleAI, exceeded.Text),
+			chat.NewTextMessage(chat.MessageRoleHuman, fmt.Sprintf(outputCharsRetryPrompt, exceeded.Got, o.MaxOutputChars)))
+		var err error
+		resp, err = generate(ctx, &retry)
+		if err != nil {
+			return nil, err
+		}
+		usage = chat.SumUsage(usage, resp.Usage)
+	}
+	resp.Usage = usage
+	chat.TrimResponseChars(o.MaxOutputChars, req, resp)
+	return resp, nil
+}
+
+// regenerate returns the call streaming a reset before the regenerated response,
+// so streamers can discard the output of the replaced response.
+func regenerate(o *chat.Options, call chat.GenerateFunc) chat.GenerateFunc {
+	if o.Streamer == nil {
+		return call
+	}
+	return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		if err := o.Streamer(&chat.StreamResponse{Type: chat.StreamTypeReset}); err != nil {
+			return nil, err
+		}
+		return call(ctx, req)
+	}
+}
+
+// providerCall returns the call of the provider with the middlewares, timeout and retries of the options.
+func providerCall(o *chat.Options, provider string, opts []chat.Option, tracker *streamTracker) chat.GenerateFunc {
+	generate := o.Wrap(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		resp, err := generateProvider(ctx, provider, req, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Usage != nil {
+			resp.Usage.Requests = 1
+		}
+		return resp, nil
+	})
+	profile := o.ProviderProfile(provider, DefaultProviderProfiles[provider])
+	return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		return generateWithProfile(ctx, o, provider, profile, req,
+			func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+				tracker.reset()
+				resp, err := generate(ctx, req)
+				return resp, tracker.streamed(), err
+			})
+	}
+}
+
+// generation is the model, the prepared request and the call generating the response.
+type generation struct {
+	model *chat.ModelInfo
+	req   *chat.Request
+	call  chat.GenerateFunc
+}
+
+// applyFinishPolicy retries the response finished by safety or error with the request of the finish policy,
+// and returns the generation of the final response for the checks after the policy.
+// Requests of another model are prepared from the original request of the caller with the model,
+// so the instructions of the options are not added twice, and are called with the provider of the model.
+func applyFinishPolicy(ctx context.Context, o *chat.Options, original *chat.Request, gen generation, resp *chat.Response,
+	opts []chat.Option, tracker *streamTracker,
+) (generation, *chat.Response, error) {
+	for retries := 0; retries < o.FinishPolicyRetries && chat.PolicyFinishReason(resp.FinishReason); retries++ {
+		retry, err := o.FinishPolicy(ctx, gen.req, resp)
+		if err != nil {
+			return gen, nil, fmt.Errorf("finish policy: %w", err)
+		}
+		if retry == nil {
+			return gen, resp, nil
+		}
+		o.Emit(&chat.Event{Type: chat.EventRetry, Model: retry.Model, Attempt: retries + 1,
+			Error: "finish reason: " + string(resp.FinishReason)})
+
+		next := gen
+		next.req = retry
+		if retry.Model != gen.req.Model {
+			r := *original
+			r.Model = retry.Model
+			model, prepared, err := prepareRequest(o, &r)
+			if err != nil {
+				return gen, nil, err
+			}
+			next = generation{model: model, req: prepared, call: providerCall(o, model.Provider, opts, tracker)}
+		}
+		usage := resp.Usage
+		resp, err = regenerate(o, next.call)(ctx, next.req)
+		if err != nil {
+			return gen, nil, err
+		}
+		resp.Usage = chat.SumUsage(usage, resp.Usage)
+		gen = next
+	}
+	return gen, resp, nil
+}
+
+func generateProvider(ctx context.Context, provider string, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	switch provider {
 	case "anthropic":
 		return anthropic.Generate(ctx, req, opts...)
 	case "gemini":
@@ -33,5 +322,60 @@ func Generate(ctx context.Context, req *chat.Request, opts ...chat.Option) (*cha
 		return openai.Generate(ctx, req, opts...)
 	}
 
-	return nil, fmt.Errorf("provider not found: %s", model.Provider)
+	return nil, fmt.Errorf("provider not found: %s", provider)
+}
+
+// streamTracker records the output streamed by the current attempt and the chunk times of all attempts.
+type streamTracker struct {
+	text      strings.Builder
+	chunks    int
+	toolCalls bool
+	times     []time.Time
+
+	// attempt is the streamer of the current attempt created by newAttempt, eg. a stateful validator.
+	attempt    chat.Streamer
+	newAttempt func() chat.Streamer
+}
+
+// streamer returns the streamer of the options emitting chunk events and recording the chunks.
+func (t *streamTracker) streamer(o *chat.Options, model string) chat.Streamer {
+	streamer := o.Streamer
+	return func(resp *chat.StreamResponse) error {
+		t.chunks++
+		t.times = append(t.times, o.Now())
+		switch resp.Type {
+		case "text":
+			t.text.WriteString(resp.Content)
+		case chat.StreamTypeToolCallDelta:
+			t.toolCalls = true
+		}
+		if len(o.EventHandlers) > 0 {
+			chunk := *resp
+			o.Emit(&chat.Event{Type: chat.EventChunk, Model: model, Chunk: &chunk})
+		}
+		return streamer(resp)
+	}
+}
+
+// perAttempt returns a streamer calling the streamer created by newAttempt, recreated on each attempt.
+func (t *streamTracker) perAttempt(newAttempt func() chat.Streamer) chat.Streamer {
+	t.newAttempt = newAttempt
+	t.attempt = newAttempt()
+	return func(resp *chat.StreamResponse) error {
+		return t.attempt(resp)
+	}
+}
+
+// reset clears the output of the previous attempt.
+func (t *streamTracker) reset() {
+	t.text.Reset()
+	t.chunks = 0
+	t.toolCalls = false
+	if t.newAttempt != nil {
+		t.attempt = t.newAttempt()
+	}
+}
+
+func (t *streamTracker) streamed() streamed {
+	return streamed{chunks: t.chunks, text: t.text.String(), toolCalls: t.toolCalls}
 }
@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestValidateSchema(t *testing.T) {
+	req := &chat.Request{
+		Messages:       []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "name?")},
+		ResponseSchema: jsonschema.MustParseJSONString(`{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}`),
+	}
+	textResponse := func(text string) *chat.Response {
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)},
+			Usage:    &chat.Usage{TotalTokens: 10, Requests: 1},
+		}
+	}
+
+	t.Run("retry succeeds", func(t *testing.T) {
+		var got *chat.Request
+		generate := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			got = req
+			return textResponse(`{"name": "Gengo"}`), nil
+		}
+		o := chat.NewOptions(chat.WithSchemaValidation(2))
+		resp, err := validateSchema(context.Background(), o, req, textResponse(`Gengo`), generate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != `{"name": "Gengo"}` || resp.Usage.TotalTokens != 20 || resp.Usage.Requests != 2 {
+			t.Errorf("response mismatch: %s %+v", resp.Text(), resp.Usage)
+		}
+		if len(got.Messages) != 3 {
+			t.Errorf("retry should include the validation error: %+v", got.Messages)
+		}
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		generate := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			return textResponse(`{"title": "Gengo"}`), nil
+		}
+		o := chat.NewOptions(chat.WithSchemaValidation(1))
+		_, err := validateSchema(context.Background(), o, req, textResponse(`Gengo`), generate)
+		var mismatch *chat.SchemaMismatchError
+		if !errors.Is(err, chat.ErrSchemaMismatch) || !errors.As(err, &mismatch) {
+			t.Fatalf("expected SchemaMismatchError, got %v", err)
+		}
+		if mismatch.Text != `{"title": "Gengo"}` || mismatch.Attempts != 2 || mismatch.Response.Usage.TotalTokens != 20 {
+			t.Errorf("mismatch error: %+v", mismatch)
+		}
+	})
+}
+
+func TestApplyFinishPolicy(t *testing.T) {
+	req := &chat.Request{Model: "m", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "tell me a story")}}
+	blocked := &chat.Response{FinishReason: chat.FinishReasonSafety, Usage: &chat.Usage{TotalTokens: 5, Requests: 1}}
+
+	var got []*chat.Request
+	generate := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = append(got, req)
+		return &chat.Response{
+			FinishReason: chat.FinishReasonStop,
+			Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Once upon a time")},
+			Usage:        &chat.Usage{TotalTokens: 10, Requests: 1},
+		}, nil
+	}
+	o := chat.NewOptions(chat.WithFinishPolicy(chat.SoftenPolicy, 3))
+	_, resp, err := applyFinishPolicy(context.Background(), o, req, generation{req: req, call: generate}, blocked, nil, &streamTracker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != chat.FinishReasonStop || resp.Usage.TotalTokens != 15 || resp.Usage.Requests != 2 {
+		t.Errorf("response mismatch: %s %+v", resp.FinishReason, resp.Usage)
+	}
+	if len(got) != 1 || got[0].Messages[0].Role != chat.MessageRoleSystem {
+		t.Errorf("retry should add the soften prompt: %+v", got)
+	}
+
+	// the policy returns no request when the soften prompt is already added.
+	got = nil
+	generate = func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = append(got, req)
+		return blocked, nil
+	}
+	_, resp, err = applyFinishPolicy(context.Background(), o, req, generation{req: req, call: generate}, blocked, nil, &streamTracker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != chat.FinishReasonSafety || len(got) != 1 {
+		t.Errorf("retries = %d, finish reason = %s", len(got), resp.FinishReason)
+	}
+}
+
+func TestLimitOutputChars(t *testing.T) {
+	req := &chat.Request{Model: "m", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "自己紹介して")}}
+	long := &chat.Response{
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "こんにちは、私はアシスタントです。")},
+		Usage:    &chat.Usage{TotalTokens: 5, Requests: 1},
+	}
+
+	var got []*chat.Request
+	generate := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = append(got, req)
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "こんにちは、アシスタントです。")},
+			Usage:    &chat.Usage{TotalTokens: 10, Requests: 1},
+		}, nil
+	}
+	o := chat.NewOptions(chat.WithMaxOutputChars(10, "ja"), chat.WithOutputCharsRetries(1))
+	resp, err := limitOutputChars(context.Background(), o, req, long, generate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Messages[2].ContentString() != "The response has 17 characters. Respond again within 10 characters." {
+		t.Errorf("retry mismatch: %+v", got)
+	}
+	if resp.Text() != "こんにちは、アシスタ" || resp.Metadata[chat.MetadataTrimmedChars] != "5" {
+		t.Errorf("trimmed response mismatch: %q %v", resp.Text(), resp.Metadata)
+	}
+	if resp.Usage.TotalTokens != 15 || resp.Usage.Requests != 2 {
+		t.Errorf("usage mismatch: %+v", resp.Usage)
+	}
+}
+
+func TestStreamTrackerPerAttempt(t *testing.T) {
+	schema := jsonschema.MustParseJSONString(`{"type": "object", "properties": {"a": {"type": "integer"}}}`)
+	o := chat.NewOptions(chat.WithStream(func(*chat.StreamResponse) error { return nil }))
+	tracker := &streamTracker{}
+	next := tracker.streamer(o, "m")
+	streamer := tracker.perAttempt(func() chat.Streamer { return chat.SchemaStreamer(schema, next) })
+
+	tracker.reset()
+	if err := streamer(&chat.StreamResponse{Type: "text", Content: `{"a": `}); err != nil {
+		t.Fatal(err)
+	}
+	// the retry starts a new output, not a continuation of the aborted one.
+	tracker.reset()
+	if err := streamer(&chat.StreamResponse{Type: "text", Content: `{"a": 1}`}); err != nil {
+		t.Errorf("validator should be reset per attempt: %v", err)
+	}
+	if got := tracker.streamed().text; got != `{"a": 1}` {
+		t.Errorf("streamed text mismatch: %q", got)
+	}
+}
+
+func TestApplyFinishPolicyFallbackModel(t *testing.T) {
+	original := &chat.Request{Model: "gpt-4o", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "tell me a story")}}
+	var got []*chat.Request
+	fallback := func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			got = append(got, req)
+			return &chat.Response{FinishReason: chat.FinishReasonStop,
+				Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Once upon a time")}}, nil
+		}
+	}
+	o := chat.NewOptions(chat.WithResponseLanguage("en"), chat.WithMiddleware(fallback),
+		chat.WithFinishPolicy(chat.FallbackModelPolicy("claude-3-5-haiku-latest"), 1))
+	model, req, err := prepareRequest(o, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocked := &chat.Response{FinishReason: chat.FinishReasonSafety}
+	gen, resp, err := applyFinishPolicy(context.Background(), o, original, generation{model: model, req: req}, blocked, nil, &streamTracker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != chat.FinishReasonStop || gen.model.Provider != "anthropic" || gen.req != got[0] {
+		t.Errorf("final generation mismatch: %+v %+v", gen.model, gen.req)
+	}
+	system := got[0].Messages[0].ContentString()
+	if prompt := chat.ResponseLanguagePrompt("en"); strings.Count(system, prompt) != 1 {
+		t.Errorf("language instruction should be added once: %q", system)
+	}
+}
+
+func TestCheckLanguage(t *testing.T) {
+	req := &chat.Request{Model: "m", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "自己紹介して")}}
+	english := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "I am an assistant and this is the answer.")},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+	resp, _ := english(context.Background(), req)
+
+	o := chat.NewOptions(chat.WithResponseLanguage("ja"))
+	got, err := checkLanguage(context.Background(), o, req, resp, english)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Metadata[chat.MetadataLanguageMismatch] != "en" || got.Usage.TotalTokens != 20 {
+		t.Errorf("mismatch should be returned in the metadata: %v %+v", got.Metadata, got.Usage)
+	}
+
+	o = chat.NewOptions(chat.WithResponseLanguage("ja"), chat.WithStrictResponseLanguage())
+	var mismatch *chat.LanguageMismatchError
+	if _, err := checkLanguage(context.Background(), o, req, resp, english); !errors.As(err, &mismatch) {
+		t.Errorf("expected LanguageMismatchError, got %v", err)
+	}
+}
+
+func TestGenerateStreamReset(t *testing.T) {
+	texts := []string{"I am an assistant and this is the answer.", "私はアシスタントです。"}
+	calls := 0
+	mock := func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			text := texts[min(calls, len(texts)-1)]
+			calls++
+			return &chat.Response{Model: req.Model, Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)}}, nil
+		}
+	}
+	var types []string
+	streamer := func(resp *chat.StreamResponse) error {
+		types = append(types, resp.Type)
+		return nil
+	}
+	req := &chat.Request{Model: "gpt-4o", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "自己紹介して")}}
+	resp, err := Generate(t.Context(), req, chat.WithMiddleware(mock), chat.WithStream(streamer), chat.WithResponseLanguage("ja"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != texts[1] || calls != 2 {
+		t.Fatalf("response mismatch: %q, calls = %d", resp.Text(), calls)
+	}
+	if len(types) != 1 || types[0] != chat.StreamTypeReset {
+		t.Errorf("a reset should be streamed before the regeneration: %v", types)
+	}
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package gengotest provides a mock provider and assertions for tests of LLM outputs,
+// eg. CI tests for prompt regressions.
+//
+//	mock := gengotest.NewMock(`{"city": "Tokyo"}`)
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(mock.Middleware()))
+//	gengotest.AssertJSONSchema(t, schema, resp.Text())
+//	gengotest.AssertJudgeScore(t, "Answers with the capital of Japan.", resp.Text(), 0.8)
+package gengotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// ErrNoMockResponse is returned by the mock without responses.
+var ErrNoMockResponse = errors.New("no mock response")
+
+// Mock is a provider returning canned responses in order. The last response is repeated.
+// It records the requests for assertions.
+type Mock struct {
+	mu        sync.Mutex
+	responses []*chat.Response
+	requests  []*chat.Request
+}
+
+// NewMock creates a mock responding with the texts.
+func NewMock(texts ...string) *Mock {
+	m := &Mock{}
+	for _, text := range texts {
+		m.Add(&chat.Response{
+			FinishReason: chat.FinishReasonStop,
+			Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)},
+			Usage:        &chat.Usage{},
+		})
+	}
+	return m
+}
+
+// Add appends the response, eg. with tool calls.
+func (m *Mock) Add(resp *chat.Response) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responses = append(m.responses, resp)
+}
+
+// Requests returns the received requests.
+func (m *Mock) Requests() []*chat.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*chat.Request{}, m.requests...)
+}
+
+// Middleware returns the canned responses instead of calling the provider.
+func (m *Mock) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.requests = append(m.requests, req)
+			if len(m.responses) == 0 {
+				return nil, ErrNoMockResponse
+			}
+			resp := m.responses[0]
+			if len(m.responses) > 1 {
+				m.responses = m.responses[1:]
+			}
+			r := *resp
+			r.Model = req.Model
+			r.Messages = append([]chat.Message{}, resp.Messages...)
+			return &r, nil
+		}
+	}
+}
+
+// AssertContains checks that the output contains all the substrings.
+func AssertContains(t testing.TB, output string, substrs ...string) bool {
+	t.Helper()
+	ok := true
+	for _, s := range substrs {
+		if !strings.Contains(output, s) {
+			t.Errorf("output does not contain %q:\n%s", s, output)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertJSONSchema checks that the output is JSON valid against the schema.
+func AssertJSONSchema(t testing.TB, schema jsonschema.Schema, output string) bool {
+	t.Helper()
+	if err := schema.Validate([]byte(output)); err != nil {
+		t.Errorf("output does not match schema: %v\n%s", err, output)
+		return false
+	}
+	return true
+}
+
+// JudgeModel is the model scoring outputs in AssertJudgeScore.
+var JudgeModel = "gpt-4o-mini"
+
+// Judgement is the score of an output by the judge model.
+type Judgement = gengo.Judgement
+
+// Judge scores the output by the rubric with JudgeModel.
+func Judge(ctx context.Context, rubric, output string, opts ...chat.Option) (*Judgement, error) {
+	return gengo.Judge(ctx, JudgeModel, rubric, "", output, opts...)
+}
+
+// AssertJudgeScore checks that the judge model scores the output by the rubric at least minScore, from 0 to 1.
+// The options are passed to the judge generation, eg. a mock middleware.
+func AssertJudgeScore(t testing.TB, rubric, output string, minScore float64, opts ...chat.Option) bool {
+	t.Helper()
+	j, err := Judge(t.Context(), rubric, output, opts...)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	if j.Score < minScore {
+		t.Errorf("judge score %.2f < %.2f: %s\n%s", j.Score, minScore, j.Reason, output)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// recorder records the failures of assertions.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	mock := NewMock("first", "second")
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+	for _, want := range []string{"first", "second", "second"} {
+		resp, err := gengo.Generate(context.Background(), req, chat.WithMiddleware(mock.Middleware()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text() != want || resp.Model != "gpt-4o-mini" {
+			t.Errorf("response mismatch: %+v", resp)
+		}
+	}
+	if len(mock.Requests()) != 3 {
+		t.Errorf("requests should be recorded: %d", len(mock.Requests()))
+	}
+	if _, err := NewMock().Middleware()(nil)(context.Background(), req); !errors.Is(err, ErrNoMockResponse) {
+		t.Errorf("expected no mock response error: %v", err)
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	r := &recorder{TB: t}
+	if !AssertContains(r, "It is sunny in Tokyo.", "sunny", "Tokyo") || AssertContains(r, "It is sunny.", "rain") {
+		t.Error("contains assertion mismatch")
+	}
+	schema := jsonschema.MustParseJSONString(`{"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}`)
+	if !AssertJSONSchema(r, schema, `{"city": "Tokyo"}`) || AssertJSONSchema(r, schema, `{"town": "Tokyo"}`) {
+		t.Error("schema assertion mismatch")
+	}
+	if len(r.errors) != 2 {
+		t.Errorf("expected two failures: %v", r.errors)
+	}
+}
+
+func TestAssertJudgeScore(t *testing.T) {
+	mock := NewMock(`{"score": 0.6, "reason": "Mentions Tokyo but not the weather."}`)
+	r := &recorder{TB: t}
+	if !AssertJudgeScore(r, "Answers the weather in Tokyo.", "Tokyo.", 0.5, chat.WithMiddleware(mock.Middleware())) {
+		t.Errorf("score should pass: %v", r.errors)
+	}
+	if AssertJudgeScore(r, "Answers the weather in Tokyo.", "Tokyo.", 0.8, chat.WithMiddleware(mock.Middleware())) {
+		t.Error("score should fail")
+	}
+	if req := mock.Requests()[0]; req.Model != JudgeModel || req.ResponseSchema == nil {
+		t.Errorf("judge request mismatch: %+v", req)
+	}
+}
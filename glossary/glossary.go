@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package glossary constrains the terminology of translations and generations.
+// Terms relevant to the request are injected into the system prompt,
+// and the response is checked for the required and forbidden terms.
+//
+//	g := glossary.New([]glossary.Term{
+//		{Source: "契約者", Target: "policyholder", Variants: []string{"contractor"}},
+//	}, glossary.WithAutoCorrect())
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(g.Middleware()))
+package glossary
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Term is a glossary entry.
+type Term struct {
+	// Source is the term in the input, eg. the source language term of a translation.
+	// An empty Source applies the term to all requests.
+	Source string `json:"source,omitempty"`
+	// Target is the term to use in the output.
+	Target string `json:"target"`
+	// Variants are forbidden alternatives of Target, eg. synonyms or wrong translations.
+	Variants []string `json:"variants,omitempty"`
+}
+
+// Violation is a term not followed in the output.
+type Violation struct {
+	Term Term
+	// Variant is the forbidden variant found in the output. Empty if Target is missing.
+	Variant string
+}
+
+func (v Violation) String() string {
+	if v.Variant != "" {
+		return fmt.Sprintf("%q used instead of %q", v.Variant, v.Target())
+	}
+	return fmt.Sprintf("%q missing for %q", v.Target(), v.Term.Source)
+}
+
+// Target returns the required term.
+func (v Violation) Target() string {
+	return v.Term.Target
+}
+
+// ViolationError is returned when the response does not follow the glossary.
+type ViolationError struct {
+	Violations []Violation
+	// Response is the response, corrected if auto-correction is enabled.
+	Response *chat.Response
+}
+
+func (e *ViolationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "glossary violations: " + strings.Join(msgs, ", ")
+}
+
+// Option configures the glossary.
+type Option func(g *Glossary)
+
+// WithAutoCorrect replaces forbidden variants in the response with the target terms.
+func WithAutoCorrect() Option {
+	return func(g *Glossary) {
+		g.autoCorrect = true
+	}
+}
+
+// Glossary is a set of terms.
+type Glossary struct {
+	terms       []Term
+	autoCorrect bool
+}
+
+// New creates a glossary of the terms.
+func New(terms []Term, opts ...Option) *Glossary {
+	g := &Glossary{terms: terms}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+const termsPrompt = `Use the following terminology exactly:
+%s`
+
+// Prompt returns the terminology instruction of the terms relevant to the input, or "" if none.
+func (g *Glossary) Prompt(input string) string {
+	lines := []string{}
+	for _, t := range g.Relevant(input) {
+		line := "- " + t.Target
+		if t.Source != "" {
+			line = fmt.Sprintf("- %q: translate as %q", t.Source, t.Target)
+		}
+		if len(t.Variants) > 0 {
+			line += fmt.Sprintf(" (do not use %s)", quoteJoin(t.Variants))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(termsPrompt, strings.Join(lines, "\n"))
+}
+
+// Relevant returns the terms without Source or with Source in the input.
+func (g *Glossary) Relevant(input string) []Term {
+	terms := []Term{}
+	for _, t := range g.terms {
+		if t.Source == "" || containsTerm(input, t.Source) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// Check returns the violations of the output for the input:
+// forbidden variants in the output, and targets missing for sources in the input.
+func (g *Glossary) Check(input, output string) []Violation {
+	violations := []Violation{}
+	for _, t := range g.Relevant(input) {
+		for _, v := range t.Variants {
+			if containsTerm(output, v) {
+				violations = append(violations, Violation{Term: t, Variant: v})
+			}
+		}
+		if t.Source != "" && !containsTerm(output, t.Target) {
+			violations = append(violations, Violation{Term: t})
+		}
+	}
+	return violations
+}
+
+// Correct replaces the forbidden variants of the relevant terms in the output with the targets.
+func (g *Glossary) Correct(input, output string) string {
+	for _, t := range g.Relevant(input) {
+		for _, v := range t.Variants {
+			if v == "" {
+				continue
+			}
+			output = termPattern(v).ReplaceAllLiteralString(output, t.Target)
+		}
+	}
+	return output
+}
+
+// Middleware injects the terminology into the system prompt and checks the text response.
+// It returns *ViolationError if the response does not follow the glossary after auto-correction.
+func (g *Glossary) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			input := requestText(req)
+			if prompt := g.Prompt(input); prompt != "" {
+				req = chat.AppendSystemPrompt(req, prompt)
+			}
+			resp, err := next(ctx, req)
+			if err != nil || len(resp.ToolCalls()) > 0 {
+				return resp, err
+			}
+			if g.autoCorrect {
+				correctResponse(resp, func(s string) string { return g.Correct(input, s) })
+			}
+			if violations := g.Check(input, resp.Text()); len(violations) > 0 {
+				return nil, &ViolationError{Violations: violations, Response: resp}
+			}
+			return resp, nil
+		}
+	}
+}
+
+// requestText returns the text of the last human message, the input of the response.
+// Terms of earlier turns are not applied, as the response may not be about them.
+func requestText(req *chat.Request) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if msg := req.Messages[i]; msg.Role == chat.MessageRoleHuman && !msg.IsToolResponse() {
+			return msg.ContentString()
+		}
+	}
+	return ""
+}
+
+func correctResponse(resp *chat.Response, correct func(string) string) {
+	for i, msg := range resp.Messages {
+		if msg.Role != chat.MessageRoleAI {
+			continue
+		}
+		for j, part := range msg.Content {
+			if part.Type == "text" {
+				resp.Messages[i].Content[j].Text = correct(part.Text)
+			}
+		}
+	}
+}
+
+// termPattern matches the term case-insensitively, at word boundaries for terms starting
+// and ending with ASCII word characters.
+func termPattern(term string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if wordChar.MatchString(term[:1]) {
+		pattern = `\b` + pattern
+	}
+	if wordChar.MatchString(term[len(term)-1:]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+var wordChar = regexp.MustCompile(`\w`)
+
+func containsTerm(text, term string) bool {
+	return term != "" && termPattern(term).MatchString(text)
+}
+
+func quoteJoin(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = fmt.Sprintf("%q", t)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package glossary
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+var terms = []Term{
+	{Source: "契約者", Target: "policyholder", Variants: []string{"contractor", "subscriber"}},
+	{Source: "保険料", Target: "premium"},
+	{Target: "Acme Insurance", Variants: []string{"Acme Corp"}},
+}
+
+func TestPrompt(t *testing.T) {
+	g := New(terms)
+	prompt := g.Prompt("契約者の住所")
+	want := "Use the following terminology exactly:\n" +
+		`- "契約者": translate as "policyholder" (do not use "contractor", "subscriber")` + "\n" +
+		`- Acme Insurance (do not use "Acme Corp")`
+	if prompt != want {
+		t.Errorf("prompt mismatch:\n%s", prompt)
+	}
+	if New(terms[:2]).Prompt("hello") != "" {
+		t.Error("prompt should be empty without relevant terms")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	g := New(terms)
+	violations := g.Check("契約者の保険料", "The Contractor pays the premium to Acme Corp.")
+	got := []string{}
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	want := []string{
+		`"contractor" used instead of "policyholder"`,
+		`"policyholder" missing for "契約者"`,
+		`"Acme Corp" used instead of "Acme Insurance"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("violations mismatch: %v", got)
+	}
+	if v := g.Check("契約者", "The policyholder of subscribers-only plans."); len(v) != 0 {
+		t.Errorf("variants should match at word boundaries: %v", v)
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	g := New(terms)
+	got := g.Correct("契約者", "The contractor and the Subscriber of Acme Corp.")
+	if got != "The policyholder and the policyholder of Acme Insurance." {
+		t.Errorf("correct mismatch: %s", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got *chat.Request
+	answer := "The contractor pays the premium."
+	next := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, answer)}}, nil
+	}
+	req := &chat.Request{Messages: []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleSystem, "Translate into English."),
+		chat.NewTextMessage(chat.MessageRoleHuman, "契約者が保険料を支払う。"),
+	}}
+
+	resp, err := New(terms, WithAutoCorrect()).Middleware()(next)(t.Context(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "The policyholder pays the premium." {
+		t.Errorf("response should be corrected: %s", resp.Text())
+	}
+	if !strings.Contains(got.Messages[0].ContentString(), `"契約者": translate as "policyholder"`) {
+		t.Errorf("system prompt mismatch: %s", got.Messages[0].ContentString())
+	}
+	if req.Messages[0].ContentString() != "Translate into English." {
+		t.Errorf("request should not be modified: %s", req.Messages[0].ContentString())
+	}
+
+	_, err = New(terms).Middleware()(next)(t.Context(), req)
+	var verr *ViolationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 || verr.Response == nil {
+		t.Errorf("expected violation error: %v", err)
+	}
+}
+
+func TestRequestText(t *testing.T) {
+	req := &chat.Request{Messages: []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleHuman, "契約者とは？"),
+		chat.NewTextMessage(chat.MessageRoleAI, "policyholder"),
+		chat.NewTextMessage(chat.MessageRoleHuman, "保険料を翻訳して"),
+	}}
+	if got := requestText(req); got != "保険料を翻訳して" {
+		t.Errorf("only the last human message should be the input: %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+// CreateCache creates a Gemini cached content from the messages and returns the cache name.
+// System messages are cached as the system instruction.
+// Use the name with chat.WithCachedContent in subsequent requests.
+func CreateCache(ctx context.Context, model string, messages []chat.Message, ttl time.Duration, opts ...chat.Option) (string, error) {
+	client, err := newClient(ctx, chat.NewOptions(opts...))
+	if err != nil {
+		return "", err
+	}
+
+	config, err := convertCacheConfig(messages, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	cache, err := client.Caches.Create(ctx, model, config)
+	if err != nil {
+		return "", fmt.Errorf("create cache: %w", err)
+	}
+	return cache.Name, nil
+}
+
+// DeleteCache deletes the Gemini cached content.
+func DeleteCache(ctx context.Context, name string, opts ...chat.Option) error {
+	client, err := newClient(ctx, chat.NewOptions(opts...))
+	if err != nil {
+		return err
+	}
+	if _, err := client.Caches.Delete(ctx, name, nil); err != nil {
+		return fmt.Errorf("delete cache: %w", err)
+	}
+	return nil
+}
+
+func convertCacheConfig(messages []chat.Message, ttl time.Duration) (*genai.CreateCachedContentConfig, error) {
+	system := []*genai.Part{}
+	rest := []chat.Message{}
+	for _, msg := range messages {
+		if msg.Role == chat.MessageRoleSystem {
+			system = append(system, genai.NewPartFromText(msg.ContentString()))
+			continue
+		}
+		rest = append(rest, msg)
+	}
+
+	contents, err := convertChatMessages(rest)
+	if err != nil {
+		return nil, fmt.Errorf("convert chat messages: %w", err)
+	}
+
+	config := &genai.CreateCachedContentConfig{
+		TTL:      ttl,
+		Contents: contents,
+	}
+	if len(system) > 0 {
+		config.SystemInstruction = &genai.Content{Parts: system}
+	}
+	return config, nil
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+func TestRegisterPartConverter(t *testing.T) {
+	RegisterPartConverter("video", func(part *chat.ContentPart) (*genai.Part, error) {
+		return genai.NewPartFromURI(part.URL, "video/mp4"), nil
+	})
+	defer delete(partConverters, "video")
+
+	contents, err := convertChatMessages([]chat.Message{{
+		Role:    chat.MessageRoleHuman,
+		Content: []chat.ContentPart{{Type: "video", URL: "gs://bucket/video.mp4"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	part := contents[0].Parts[0]
+	if part.FileData == nil || part.FileData.FileURI != "gs://bucket/video.mp4" {
+		t.Errorf("converted part mismatch: %+v", part)
+	}
+}
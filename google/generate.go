@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/jumonmd/gengo/chat"
 	"github.com/jumonmd/gengo/jsonschema"
@@ -18,38 +19,76 @@ import (
 func Generate(ctx context.Context, r *chat.Request, opts ...chat.Option) (*chat.Response, error) {
 	opt := chat.NewOptions(opts...)
 
-	client, err := genai.NewClient(ctx, nil)
+	client, err := newClient(ctx, opt)
 	if err != nil {
 		return nil, err
 	}
 
-	// tool call will not use stream for simplicity
-	if opt.Streamer != nil && len(r.Tools) == 0 {
-		resp, err := generateContentStream(ctx, client, r, opt.Streamer)
+	req, err := buildRequest(r, opt, client.ClientConfig().Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if opt.Streamer != nil {
+		resp, err := generateContentStream(ctx, client, r.Model, req, opt.Streamer)
 		if err != nil {
 			return nil, fmt.Errorf("generate content stream: %w", err)
 		}
-		opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
+		unquoteRegexResponse(r, resp)
+		opt.CalculateCost(r.Model, resp.Usage)
 		return resp, nil
 	}
 
-	req, err := convertChatRequest(r, convertChatConfig(r))
+	resp, err := generateContent(ctx, client, r.Model, req)
 	if err != nil {
-		return nil, fmt.Errorf("convert chat request: %w", err)
+		return nil, fmt.Errorf("generate content: %w", err)
 	}
+	unquoteRegexResponse(r, resp)
+	opt.CalculateCost(r.Model, resp.Usage)
+	return resp, nil
+}
 
-	if opt.UseSearch {
-		req.Config.Tools = append(req.Config.Tools, &genai.Tool{
-			GoogleSearch: &genai.GoogleSearch{},
-		})
+// userLabels returns the user ID label. Labels are supported by Vertex AI only.
+func userLabels(backend genai.Backend, userID string) map[string]string {
+	if userID == "" || backend != genai.BackendVertexAI {
+		return nil
 	}
+	return map[string]string{"user_id": labelValue(userID)}
+}
 
-	resp, err := generateContent(ctx, client, r.Model, req)
-	if err != nil {
-		return nil, fmt.Errorf("generate content: %w", err)
+// labelValue converts the value to a label value: lowercase letters, digits, - and _, up to 63 characters.
+func labelValue(v string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(v) {
+		if b.Len() >= 63 {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
 	}
-	opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
-	return resp, nil
+	return b.String()
+}
+
+// newClient creates a client. The API key is set only if WithAPIKey or WithSecretsProvider is given,
+// otherwise genai resolves the environment, so Vertex AI and application default credentials keep working.
+func newClient(ctx context.Context, opt *chat.Options) (*genai.Client, error) {
+	config := &genai.ClientConfig{
+		HTTPClient: opt.HTTPClient("gemini"),
+	}
+	if opt.APIKey != "" || opt.Secrets != nil {
+		apiKey, err := opt.ResolveAPIKey(ctx, "gemini")
+		if err != nil {
+			return nil, err
+		}
+		config.APIKey = apiKey
+	}
+	if opt.BaseURL != "" {
+		config.HTTPOptions.BaseURL = opt.BaseURL
+	}
+	return genai.NewClient(ctx, config)
 }
 
 func generateContent(ctx context.Context, client *genai.Client, model string, req *generateContentRequest) (*chat.Response, error) {
@@ -62,17 +101,12 @@ func generateContent(ctx context.Context, client *genai.Client, model string, re
 	return response, nil
 }
 
-func generateContentStream(ctx context.Context, client *genai.Client, r *chat.Request, streamer chat.Streamer) (*chat.Response, error) {
-	config := convertChatConfig(r)
-	req, err := convertChatRequest(r, config)
-	if err != nil {
-		return nil, fmt.Errorf("convert chat request: %w", err)
-	}
-
+func generateContentStream(ctx context.Context, client *genai.Client, model string, req *generateContentRequest, streamer chat.Streamer) (*chat.Response, error) {
 	usage := chat.Usage{}
 	content := ""
+	toolCalls := []chat.Message{}
 	finishReason := genai.FinishReasonUnspecified
-	for resp, err := range client.Models.GenerateContentStream(ctx, r.Model, req.Contents, req.Config) {
+	for resp, err := range client.Models.GenerateContentStream(ctx, model, req.Contents, req.Config) {
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
@@ -97,15 +131,36 @@ func generateContentStream(ctx context.Context, client *genai.Client, r *chat.Re
 					return nil, fmt.Errorf("stream: %w", err)
 				}
 			}
+			// Gemini streams each function call whole, in a single delta.
+			if call := part.FunctionCall; call != nil {
+				args, err := json.Marshal(call.Args)
+				if err != nil {
+					return nil, fmt.Errorf("marshal function call args: %w", err)
+				}
+				err = streamer(chat.NewToolCallDelta(len(toolCalls), call.ID, call.Name, string(args)))
+				if err != nil {
+					return nil, fmt.Errorf("stream: %w", err)
+				}
+				toolCalls = append(toolCalls, chat.NewToolCallMessage(call.Name, call.ID, string(args)))
+			}
 		}
 
 		finishReason = resp.Candidates[0].FinishReason
 	}
 
+	msgs := []chat.Message{}
+	if content != "" || len(toolCalls) == 0 {
+		msgs = append(msgs, chat.NewTextMessage(chat.MessageRoleAI, content))
+	}
+	msgs = append(msgs, toolCalls...)
+	finish := convertFinishReason(finishReason)
+	if len(toolCalls) > 0 {
+		finish = chat.FinishReasonToolUse
+	}
 	return &chat.Response{
-		Model:        r.Model,
-		Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, content)},
-		FinishReason: convertFinishReason(finishReason),
+		Model:        model,
+		Messages:     msgs,
+		FinishReason: finish,
 		Usage:        &usage,
 	}, nil
 }
@@ -167,11 +222,32 @@ func convertChatRequest(r *chat.Request, config *genai.GenerateContentConfig) (*
 		}
 		config.ResponseMIMEType = "application/json"
 		config.ResponseSchema = schema
+	} else if r.ResponseType == chat.ResponseTypeJSON {
+		config.ResponseMIMEType = "application/json"
+	} else if r.Config.ResponseRegex != "" {
+		// constrained as a JSON string with the pattern, unquoted by unquoteRegexResponse.
+		config.ResponseMIMEType = "application/json"
+		config.ResponseSchema = &genai.Schema{Type: genai.TypeString, Pattern: r.Config.ResponseRegex}
 	}
 
 	return req, nil
 }
 
+// unquoteRegexResponse unquotes the JSON string response of ResponseRegex.
+func unquoteRegexResponse(r *chat.Request, resp *chat.Response) {
+	if r.Config.ResponseRegex == "" || r.ResponseSchema != nil {
+		return
+	}
+	for i, msg := range resp.Messages {
+		for j, part := range msg.Content {
+			var text string
+			if part.Type == "text" && json.Unmarshal([]byte(part.Text), &text) == nil {
+				resp.Messages[i].Content[j].Text = text
+			}
+		}
+	}
+}
+
 func convertChatMessages(messages []chat.Message) ([]*genai.Content, error) {
 	contents := []*genai.Content{}
 
@@ -191,18 +267,13 @@ func convertChatMessages(messages []chat.Message) ([]*genai.Content, error) {
 			}
 			parts = append(parts, genai.NewPartFromFunctionCall(msg.ToolCall.Name, args))
 		default:
-			for _, part := range msg.Content {
-				switch part.Type {
-				case "text":
-					parts = append(parts, genai.NewPartFromText(part.Text))
-				case "image":
-					if !chat.IsDataURL(part.DataURL) {
-						return nil, fmt.Errorf("invalid data URL: %s", part.DataURL)
-					}
-					data, mimeType, err := chat.DecodeDataURL(part.DataURL)
-					if err == nil {
-						parts = append(parts, genai.NewPartFromBytes(data, mimeType))
-					}
+			for _, part := range msg.NamedContent() {
+				p, err := convertContentPart(&part)
+				if err != nil {
+					return nil, err
+				}
+				if p != nil {
+					parts = append(parts, p)
 				}
 			}
 		}
@@ -219,6 +290,67 @@ func convertChatMessages(messages []chat.Message) ([]*genai.Content, error) {
 	return contents, nil
 }
 
+// convertContentPart converts a content part. Returns nil for unsupported parts.
+func convertContentPart(part *chat.ContentPart) (*genai.Part, error) {
+	if fn, ok := lookupPartConverter(part.Type); ok {
+		p, err := fn(part)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s part: %w", part.Type, err)
+		}
+		return p, nil
+	}
+
+	switch part.Type {
+	case "text":
+		return genai.NewPartFromText(part.Text), nil
+	case "image":
+		if !chat.IsDataURL(part.DataURL) {
+			return nil, fmt.Errorf("invalid data URL: %s", part.DataURL)
+		}
+		data, mimeType, err := chat.DecodeDataURL(part.DataURL)
+		if err != nil {
+			return nil, fmt.Errorf("decode data URL: %w", err)
+		}
+		return genai.NewPartFromBytes(data, mimeType), nil
+	case "file":
+		return convertFilePart(part)
+	case "uri":
+		return convertURIPart(part), nil
+	}
+	return nil, nil
+}
+
+// convertFilePart converts a file part, a data URL or a remote file, eg. a PDF, to an inline or file data part.
+func convertFilePart(part *chat.ContentPart) (*genai.Part, error) {
+	if part.DataURL == "" && part.URL != "" {
+		return genai.NewPartFromURI(part.URL, part.FileURLMIMEType()), nil
+	}
+	data, mimeType, err := chat.DecodeDataURL(part.DataURL)
+	if err != nil {
+		return nil, fmt.Errorf("decode file data URL: %w", err)
+	}
+	return genai.NewPartFromBytes(data, mimeType), nil
+}
+
+// convertURIPart converts a uri part to a file data part.
+// YouTube URLs are passed as video without MIME type.
+func convertURIPart(part *chat.ContentPart) *genai.Part {
+	mimeType := part.MIMEType
+	if mimeType == "" && isYouTubeURL(part.URL) {
+		mimeType = "video/*"
+	}
+	return genai.NewPartFromURI(part.URL, mimeType)
+}
+
+func isYouTubeURL(u string) bool {
+	for _, prefix := range []string{"https://www.youtube.com/", "https://youtube.com/", "https://youtu.be/", "https://m.youtube.com/"} {
+		if strings.HasPrefix(u, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func convertChatRole(role chat.MessageRole) string {
 	switch role {
 	case chat.MessageRoleSystem:
@@ -337,5 +469,6 @@ func updateUsage(usage *chat.Usage, metadata *genai.GenerateContentResponseUsage
 		usage.InputTokens = int(metadata.PromptTokenCount)
 		usage.OutputTokens = int(metadata.CandidatesTokenCount)
 		usage.TotalTokens = int(metadata.TotalTokenCount)
+		usage.CachedTokens = int(metadata.CachedContentTokenCount)
 	}
 }
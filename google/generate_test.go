@@ -6,6 +6,7 @@ package google
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jumonmd/gengo/chat"
 	"github.com/jumonmd/gengo/jsonschema"
@@ -72,3 +73,111 @@ func TestConvertChatTools(t *testing.T) {
 		t.Errorf("toolConfig mismatch: expected %v, got %v", genai.FunctionCallingConfigModeAny, toolConfig.FunctionCallingConfig.Mode)
 	}
 }
+
+func TestConvertCacheConfig(t *testing.T) {
+	msgs := []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleSystem, "You are a helpful assistant."),
+		chat.NewTextMessage(chat.MessageRoleHuman, "long document"),
+	}
+
+	config, err := convertCacheConfig(msgs, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.SystemInstruction == nil || config.SystemInstruction.Parts[0].Text != "You are a helpful assistant." {
+		t.Errorf("system instruction mismatch: %+v", config.SystemInstruction)
+	}
+	if len(config.Contents) != 1 {
+		t.Errorf("contents mismatch: %d", len(config.Contents))
+	}
+	if config.TTL != time.Hour {
+		t.Errorf("TTL mismatch: %v", config.TTL)
+	}
+}
+
+func TestConvertURIPart(t *testing.T) {
+	contents, err := convertChatMessages([]chat.Message{{
+		Role: chat.MessageRoleHuman,
+		Content: []chat.ContentPart{
+			chat.NewURIPart("gs://bucket/report.pdf", "application/pdf"),
+			chat.NewURIPart("https://www.youtube.com/watch?v=9hE5-98ZeCg", ""),
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := contents[0].Parts
+	if parts[0].FileData.FileURI != "gs://bucket/report.pdf" || parts[0].FileData.MIMEType != "application/pdf" {
+		t.Errorf("gcs part mismatch: %+v", parts[0].FileData)
+	}
+	if parts[1].FileData.MIMEType != "video/*" {
+		t.Errorf("youtube part mismatch: %+v", parts[1].FileData)
+	}
+}
+
+func TestConvertFilePart(t *testing.T) {
+	contents, err := convertChatMessages([]chat.Message{{
+		Role: chat.MessageRoleHuman,
+		Content: []chat.ContentPart{
+			{Type: "file", DataURL: chat.EncodeDataURL("application/pdf", []byte("%PDF-1.4")), Title: "report.pdf"},
+			chat.NewURLPart("file", "https://example.com/report.pdf"),
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := contents[0].Parts
+	if len(parts) != 2 || parts[0].InlineData == nil || string(parts[0].InlineData.Data) != "%PDF-1.4" || parts[0].InlineData.MIMEType != "application/pdf" {
+		t.Fatalf("inline file part mismatch: %+v", parts)
+	}
+	if parts[1].FileData.FileURI != "https://example.com/report.pdf" || parts[1].FileData.MIMEType != "application/pdf" {
+		t.Errorf("remote file part mismatch: %+v", parts[1].FileData)
+	}
+}
+
+func TestConvertNamedMessage(t *testing.T) {
+	contents, err := convertChatMessages([]chat.Message{
+		chat.NewNamedTextMessage(chat.MessageRoleHuman, "Alice", "hello"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents[0].Parts[0].Text; got != "Alice: hello" {
+		t.Errorf("text mismatch: %q", got)
+	}
+}
+
+func TestResponseRegex(t *testing.T) {
+	r := &chat.Request{
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "zip code of Tokyo Station")},
+		Config:   chat.ModelConfig{ResponseRegex: `\d{3}-\d{4}`},
+	}
+	req, err := convertChatRequest(r, convertChatConfig(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Config.ResponseSchema.Pattern != `\d{3}-\d{4}` || req.Config.ResponseMIMEType != "application/json" {
+		t.Errorf("schema mismatch: %+v", req.Config.ResponseSchema)
+	}
+
+	resp := &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, `"100-0005"`)}}
+	unquoteRegexResponse(r, resp)
+	if resp.Text() != "100-0005" {
+		t.Errorf("unquote mismatch: %s", resp.Text())
+	}
+}
+
+func TestUserLabels(t *testing.T) {
+	if labels := userLabels(genai.BackendGeminiAPI, "user-1"); labels != nil {
+		t.Errorf("Gemini API does not support labels: %v", labels)
+	}
+	labels := userLabels(genai.BackendVertexAI, "User@Example")
+	if labels["user_id"] != "user_example" {
+		t.Errorf("label mismatch: %v", labels)
+	}
+	if labels := userLabels(genai.BackendVertexAI, ""); labels != nil {
+		t.Errorf("empty user ID should have no labels: %v", labels)
+	}
+}
@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// ListModels returns the model names available to the API key, without the "models/" prefix.
+func ListModels(ctx context.Context, opts ...chat.Option) ([]string, error) {
+	opt := chat.NewOptions(opts...)
+
+	client, err := newClient(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	models := []string{}
+	for m, err := range client.Models.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("list models: %w", err)
+		}
+		models = append(models, strings.TrimPrefix(m.Name, "models/"))
+	}
+	return models, nil
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+// nativeConversation is the conversation of a generateContent request body.
+type nativeConversation struct {
+	SystemInstruction *genai.Content   `json:"systemInstruction,omitempty"`
+	Contents          []*genai.Content `json:"contents"`
+}
+
+// ImportMessages converts Gemini contents JSON into messages, eg. logged conversations.
+// The data is a request body with systemInstruction and contents, or a contents array,
+// in the camelCase JSON of the API and the SDKs. Function calls and responses are
+// tool call and tool response messages. Thought parts are skipped.
+func ImportMessages(data []byte) ([]chat.Message, error) {
+	var conv nativeConversation
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &conv.Contents); err != nil {
+			return nil, fmt.Errorf("unmarshal contents: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &conv); err != nil {
+		return nil, fmt.Errorf("unmarshal conversation: %w", err)
+	}
+
+	messages := []chat.Message{}
+	if conv.SystemInstruction != nil {
+		texts := []string{}
+		for _, p := range conv.SystemInstruction.Parts {
+			if p != nil && p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+		if len(texts) > 0 {
+			messages = append(messages, chat.NewTextMessage(chat.MessageRoleSystem, strings.Join(texts, "\n")))
+		}
+	}
+	for i, c := range conv.Contents {
+		if c == nil {
+			continue
+		}
+		msgs, err := importContent(c)
+		if err != nil {
+			return nil, fmt.Errorf("content %d: %w", i, err)
+		}
+		messages = append(messages, msgs...)
+	}
+	return messages, nil
+}
+
+func importContent(c *genai.Content) ([]chat.Message, error) {
+	role := chat.MessageRoleHuman
+	if c.Role == genai.RoleModel {
+		role = chat.MessageRoleAI
+	}
+
+	messages := []chat.Message{}
+	var content []chat.ContentPart
+	flush := func() {
+		if len(content) > 0 {
+			messages = append(messages, chat.Message{Role: role, Content: content})
+			content = nil
+		}
+	}
+	for _, p := range c.Parts {
+		switch {
+		case p == nil || p.Thought:
+		case p.FunctionCall != nil:
+			flush()
+			args, err := json.Marshal(p.FunctionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("marshal function call args: %w", err)
+			}
+			if p.FunctionCall.Args == nil {
+				args = []byte("{}")
+			}
+			messages = append(messages, chat.NewToolCallMessage(p.FunctionCall.Name, p.FunctionCall.ID, string(args)))
+		case p.FunctionResponse != nil:
+			flush()
+			result, err := functionResult(p.FunctionResponse.Response)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, chat.NewToolResponseMessage(p.FunctionResponse.Name, p.FunctionResponse.ID, result))
+		case p.Text != "":
+			content = append(content, chat.ContentPart{Type: "text", Text: p.Text})
+		case p.InlineData != nil:
+			partType := "file"
+			if strings.HasPrefix(p.InlineData.MIMEType, "image/") {
+				partType = "image"
+			}
+			content = append(content, chat.ContentPart{Type: partType, DataURL: chat.EncodeDataURL(p.InlineData.MIMEType, p.InlineData.Data)})
+		case p.FileData != nil:
+			content = append(content, chat.NewURIPart(p.FileData.FileURI, p.FileData.MIMEType))
+		default:
+			return nil, fmt.Errorf("part is not supported: %+v", p)
+		}
+	}
+	flush()
+	return messages, nil
+}
+
+// functionResult returns the result of a function response, the string output or content
+// of the response if any, or the response as JSON.
+func functionResult(response map[string]any) (string, error) {
+	if len(response) == 1 || len(response) == 2 && response["name"] != nil {
+		for _, key := range []string{"output", "content"} {
+			if s, ok := response[key].(string); ok {
+				return s, nil
+			}
+		}
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("marshal function response: %w", err)
+	}
+	return string(data), nil
+}
+
+// ExportMessages converts messages into Gemini JSON with systemInstruction and contents,
+// eg. to replay a conversation with the Gemini SDK. System messages are joined into systemInstruction,
+// tool responses are user contents with the result as output, and consecutive function calls and
+// responses are grouped into a content.
+func ExportMessages(messages []chat.Message) ([]byte, error) {
+	conv := nativeConversation{Contents: []*genai.Content{}}
+	var system []string
+	var prev *chat.Message
+	for i := range messages {
+		msg := &messages[i]
+		var part *genai.Part
+		var parts []*genai.Part
+		role := genai.RoleUser
+		switch {
+		case msg.Role == chat.MessageRoleSystem:
+			system = append(system, msg.ContentString())
+			continue
+		case msg.IsToolCall():
+			args := map[string]any{}
+			if err := json.Unmarshal([]byte(msg.ToolCall.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("message %d: unmarshal tool call arguments: %w", i, err)
+			}
+			part = &genai.Part{FunctionCall: &genai.FunctionCall{ID: msg.ToolCall.ID, Name: msg.ToolCall.Name, Args: args}}
+			role = genai.RoleModel
+		case msg.IsToolResponse():
+			part = &genai.Part{FunctionResponse: &genai.FunctionResponse{
+				ID:       msg.ToolResponse.ID,
+				Name:     msg.ToolResponse.Name,
+				Response: map[string]any{"output": msg.ToolResponse.Result},
+			}}
+		default:
+			for _, cp := range msg.NamedContent() {
+				p, err := convertContentPart(&cp)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: %w", i, err)
+				}
+				if p != nil {
+					parts = append(parts, p)
+				}
+			}
+			if msg.Role == chat.MessageRoleAI {
+				role = genai.RoleModel
+			}
+		}
+		if part != nil {
+			if prev != nil && (prev.IsToolCall() && msg.IsToolCall() || prev.IsToolResponse() && msg.IsToolResponse()) {
+				last := conv.Contents[len(conv.Contents)-1]
+				last.Parts = append(last.Parts, part)
+				prev = msg
+				continue
+			}
+			parts = []*genai.Part{part}
+		}
+		conv.Contents = append(conv.Contents, &genai.Content{Role: string(role), Parts: parts})
+		prev = msg
+	}
+	if len(system) > 0 {
+		conv.SystemInstruction = &genai.Content{Parts: []*genai.Part{genai.NewPartFromText(strings.Join(system, "\n\n"))}}
+	}
+
+	data, err := json.Marshal(conv)
+	if err != nil {
+		return nil, fmt.Errorf("marshal contents: %w", err)
+	}
+	return data, nil
+}
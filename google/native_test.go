@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestImportMessages(t *testing.T) {
+	data := `{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "Describe the video and the weather."},
+				{"fileData": {"mimeType": "video/mp4", "fileUri": "gs://bucket/video.mp4"}}
+			]},
+			{"role": "model", "parts": [
+				{"text": "thinking...", "thought": true},
+				{"functionCall": {"name": "weather", "args": {"city": "Tokyo"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "weather", "response": {"output": "Rainy"}}}
+			]},
+			{"role": "model", "parts": [{"text": "Rainy."}]}
+		]
+	}`
+	messages, err := ImportMessages([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("messages mismatch: %+v", messages)
+	}
+	if messages[0].Role != chat.MessageRoleSystem || messages[0].ContentString() != "Be brief." {
+		t.Errorf("system mismatch: %+v", messages[0])
+	}
+	if len(messages[1].Content) != 2 || messages[1].Content[1].URL != "gs://bucket/video.mp4" {
+		t.Errorf("user content mismatch: %+v", messages[1])
+	}
+	if call := messages[2].ToolCall; call == nil || call.Name != "weather" || call.Arguments != `{"city":"Tokyo"}` {
+		t.Errorf("tool call mismatch: %+v", messages[2])
+	}
+	if resp := messages[3].ToolResponse; resp == nil || resp.Result != "Rainy" {
+		t.Errorf("tool response mismatch: %+v", messages[3])
+	}
+	if messages[4].Role != chat.MessageRoleAI || messages[4].ContentString() != "Rainy." {
+		t.Errorf("model message mismatch: %+v", messages[4])
+	}
+
+	exported, err := ExportMessages(messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"systemInstruction":{"parts":[{"text":"Be brief."}]}`, `"role":"model"`, `"response":{"output":"Rainy"}`} {
+		if !strings.Contains(string(exported), want) {
+			t.Errorf("exported should contain %s: %s", want, exported)
+		}
+	}
+	roundtrip, err := ImportMessages(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roundtrip) != len(messages) || roundtrip[3].ToolResponse.Result != "Rainy" {
+		t.Errorf("roundtrip mismatch: %+v", roundtrip)
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+// Payload returns the JSON of the generate content request without sending it.
+// Vertex AI labels are not included, as the backend is known by the client only.
+func Payload(r *chat.Request, opts ...chat.Option) (json.RawMessage, error) {
+	req, err := buildRequest(r, chat.NewOptions(opts...), genai.BackendUnspecified)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(map[string]any{
+		"model":    r.Model,
+		"contents": req.Contents,
+		"config":   req.Config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return data, nil
+}
+
+// buildRequest converts the request with the options for the backend.
+func buildRequest(r *chat.Request, opt *chat.Options, backend genai.Backend) (*generateContentRequest, error) {
+	req, err := convertChatRequest(r, convertChatConfig(r))
+	if err != nil {
+		return nil, fmt.Errorf("convert chat request: %w", err)
+	}
+	if opt.CachedContent != "" {
+		req.Config.CachedContent = opt.CachedContent
+	}
+	req.Config.Labels = userLabels(backend, opt.UserID)
+	// search is not used with streaming, like tool calls.
+	if opt.UseSearch && (opt.Streamer == nil || len(r.Tools) > 0) {
+		req.Config.Tools = append(req.Config.Tools, &genai.Tool{
+			GoogleSearch: &genai.GoogleSearch{},
+		})
+	}
+	return req, nil
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+// IsRetryable reports whether the error is a rate limit or server error.
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// Classify returns the retry class of the error.
+// RESOURCE_EXHAUSTED is a rate limit, or a quota if a daily quota is exceeded.
+// UNAVAILABLE (503) is overloaded.
+func Classify(err error) chat.RetryClass {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return chat.RetryClassNone
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests || apiErr.Status == "RESOURCE_EXHAUSTED":
+		if dailyQuota(apiErr.Details) {
+			return chat.RetryClassQuota
+		}
+		return chat.RetryClassRateLimit
+	case apiErr.Code == http.StatusServiceUnavailable:
+		return chat.RetryClassOverloaded
+	case apiErr.Code == http.StatusRequestTimeout || apiErr.Code >= http.StatusInternalServerError:
+		return chat.RetryClassTransient
+	}
+	return chat.RetryClassNone
+}
+
+// dailyQuota reports whether the QuotaFailure details include a per-day quota,
+// eg. "GenerateRequestsPerDayPerProjectPerModel-FreeTier".
+func dailyQuota(details []map[string]any) bool {
+	for _, d := range details {
+		if t, _ := d["@type"].(string); !strings.HasSuffix(t, "QuotaFailure") {
+			continue
+		}
+		if strings.Contains(fmt.Sprint(d["violations"]), "PerDay") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package google
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"google.golang.org/genai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("generate: %w", genai.APIError{Code: 429}), true},
+		{genai.APIError{Code: 503}, true},
+		{genai.APIError{Code: 400}, false},
+		{errors.New("invalid request"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	daily := genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED", Details: []map[string]any{{
+		"@type":      "type.googleapis.com/google.rpc.QuotaFailure",
+		"violations": []any{map[string]any{"quotaId": "GenerateRequestsPerDayPerProjectPerModel-FreeTier"}},
+	}}}
+	tests := []struct {
+		err  error
+		want chat.RetryClass
+	}{
+		{genai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, chat.RetryClassRateLimit},
+		{daily, chat.RetryClassQuota},
+		{genai.APIError{Code: 503, Status: "UNAVAILABLE"}, chat.RetryClassOverloaded},
+		{genai.APIError{Code: 500}, chat.RetryClassTransient},
+		{genai.APIError{Code: 400}, chat.RetryClassNone},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package graph executes multi-step workflows as a graph of nodes.
+// The state is checkpointed before each node and at the end so that a run can be resumed.
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jumonmd/gengo"
+)
+
+// End is the terminal node name.
+const End = "__end__"
+
+// DefaultMaxSteps is the default maximum number of node executions in a run.
+const DefaultMaxSteps = 100
+
+// ErrMaxSteps is returned when the run exceeds the maximum steps.
+var ErrMaxSteps = errors.New("max steps exceeded")
+
+// NodeFunc is a node of the graph that updates the state.
+type NodeFunc func(ctx context.Context, s *State) error
+
+// Condition decides whether an edge is taken.
+type Condition func(s *State) bool
+
+type edge struct {
+	to   string
+	cond Condition
+}
+
+// Graph is a workflow graph.
+type Graph struct {
+	nodes map[string]NodeFunc
+	edges map[string][]edge
+	entry string
+}
+
+// New creates an empty graph.
+func New() *Graph {
+	return &Graph{
+		nodes: map[string]NodeFunc{},
+		edges: map[string][]edge{},
+	}
+}
+
+// AddNode adds a node. The first added node is the entry node.
+func (g *Graph) AddNode(name string, fn NodeFunc) *Graph {
+	if g.entry == "" {
+		g.entry = name
+	}
+	g.nodes[name] = fn
+	return g
+}
+
+// SetEntry sets the entry node.
+func (g *Graph) SetEntry(name string) *Graph {
+	g.entry = name
+	return g
+}
+
+// AddEdge adds an unconditional edge.
+func (g *Graph) AddEdge(from, to string) *Graph {
+	return g.AddConditionalEdge(from, to, nil)
+}
+
+// AddConditionalEdge adds an edge taken when the condition is true.
+// Edges are evaluated in the order they are added. If no edge is taken, the run ends.
+func (g *Graph) AddConditionalEdge(from, to string, cond Condition) *Graph {
+	g.edges[from] = append(g.edges[from], edge{to: to, cond: cond})
+	return g
+}
+
+// Validate checks that all edges point to existing nodes.
+func (g *Graph) Validate() error {
+	if _, ok := g.nodes[g.entry]; !ok {
+		return fmt.Errorf("entry node not found: %s", g.entry)
+	}
+	for from, edges := range g.edges {
+		if _, ok := g.nodes[from]; !ok {
+			return fmt.Errorf("edge from unknown node: %s", from)
+		}
+		for _, e := range edges {
+			if _, ok := g.nodes[e.to]; !ok && e.to != End {
+				return fmt.Errorf("edge to unknown node: %s -> %s", from, e.to)
+			}
+		}
+	}
+	return nil
+}
+
+type runConfig struct {
+	checkpointer Checkpointer
+	generate     gengo.GenerateFunc
+	maxSteps     int
+}
+
+// RunOption configures a graph run.
+type RunOption func(c *runConfig)
+
+// WithCheckpointer saves the state before each node and at the end of the run.
+func WithCheckpointer(cp Checkpointer) RunOption {
+	return func(c *runConfig) {
+		c.checkpointer = cp
+	}
+}
+
+// WithGenerator replaces the generate function used by generate nodes.
+func WithGenerator(fn gengo.GenerateFunc) RunOption {
+	return func(c *runConfig) {
+		c.generate = fn
+	}
+}
+
+// WithMaxSteps sets the maximum number of node executions.
+func WithMaxSteps(n int) RunOption {
+	return func(c *runConfig) {
+		c.maxSteps = n
+	}
+}
+
+func newRunConfig(opts []RunOption) *runConfig {
+	c := &runConfig{
+		generate: gengo.Generate,
+		maxSteps: DefaultMaxSteps,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run runs the graph from the entry node with the initial state.
+func (g *Graph) Run(ctx context.Context, runID string, state *State, opts ...RunOption) (*State, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = NewState()
+	}
+	return g.run(ctx, &Checkpoint{RunID: runID, Node: g.entry, State: state}, newRunConfig(opts))
+}
+
+// Resume resumes the run from the last checkpoint.
+func (g *Graph) Resume(ctx context.Context, runID string, opts ...RunOption) (*State, error) {
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	c := newRunConfig(opts)
+	if c.checkpointer == nil {
+		return nil, fmt.Errorf("checkpointer is required to resume")
+	}
+	cp, err := c.checkpointer.Load(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+	if cp.State == nil {
+		cp.State = NewState()
+	}
+	return g.run(ctx, cp, c)
+}
+
+func (g *Graph) run(ctx context.Context, cp *Checkpoint, c *runConfig) (*State, error) {
+	cp.State.generate = c.generate
+
+	for cp.Node != End {
+		if cp.Steps >= c.maxSteps {
+			return cp.State, ErrMaxSteps
+		}
+		if err := ctx.Err(); err != nil {
+			return cp.State, err
+		}
+
+		fn, ok := g.nodes[cp.Node]
+		if !ok {
+			return cp.State, fmt.Errorf("node not found: %s", cp.Node)
+		}
+		// the checkpoint is saved before the node, so a run failing in any node, even the entry, can be resumed.
+		if err := c.save(ctx, cp); err != nil {
+			return cp.State, err
+		}
+		if err := fn(ctx, cp.State); err != nil {
+			return cp.State, fmt.Errorf("node %s: %w", cp.Node, err)
+		}
+
+		cp.Steps++
+		cp.Node = g.next(cp.Node, cp.State)
+	}
+	return cp.State, c.save(ctx, cp)
+}
+
+func (c *runConfig) save(ctx context.Context, cp *Checkpoint) error {
+	if c.checkpointer == nil {
+		return nil
+	}
+	if err := c.checkpointer.Save(ctx, cp); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (g *Graph) next(from string, s *State) string {
+	for _, e := range g.edges[from] {
+		if e.cond == nil || e.cond(s) {
+			return e.to
+		}
+	}
+	return End
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestRunConditionalEdges(t *testing.T) {
+	g := New().
+		AddNode("classify", func(ctx context.Context, s *State) error {
+			s.Values["class"] = map[string]any{"kind": s.Values["input"]}
+			return nil
+		}).
+		AddNode("question", func(ctx context.Context, s *State) error {
+			s.Values["route"] = "question"
+			return nil
+		}).
+		AddNode("other", func(ctx context.Context, s *State) error {
+			s.Values["route"] = "other"
+			return nil
+		}).
+		AddConditionalEdge("classify", "question", FieldEquals("class", "kind", "question")).
+		AddEdge("classify", "other")
+
+	for input, want := range map[string]string{"question": "question", "chat": "other"} {
+		state := NewState()
+		state.Values["input"] = input
+		out, err := g.Run(t.Context(), "run", state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Values["route"] != want {
+			t.Errorf("route mismatch: %v, want %s", out.Values["route"], want)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	errTransient := errors.New("transient")
+	fail := true
+	calls := map[string]int{}
+
+	g := New().
+		AddNode("first", func(ctx context.Context, s *State) error {
+			calls["first"]++
+			s.Values["first"] = true
+			return nil
+		}).
+		AddNode("second", func(ctx context.Context, s *State) error {
+			calls["second"]++
+			if fail {
+				return errTransient
+			}
+			return nil
+		}).
+		AddEdge("first", "second")
+
+	cp := NewMemoryCheckpointer()
+	if _, err := g.Run(t.Context(), "run-1", nil, WithCheckpointer(cp)); !errors.Is(err, errTransient) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+
+	fail = false
+	state, err := g.Resume(t.Context(), "run-1", WithCheckpointer(cp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls["first"] != 1 || calls["second"] != 2 {
+		t.Errorf("calls mismatch: %v", calls)
+	}
+	if state.Values["first"] != true {
+		t.Errorf("state is not restored: %v", state.Values)
+	}
+}
+
+func TestResumeEntry(t *testing.T) {
+	fail := true
+	g := New().AddNode("first", func(ctx context.Context, s *State) error {
+		if fail {
+			return errors.New("transient")
+		}
+		s.Values["done"] = true
+		return nil
+	})
+
+	cp := NewMemoryCheckpointer()
+	state := NewState()
+	state.Values["input"] = "x"
+	if _, err := g.Run(t.Context(), "run-1", state, WithCheckpointer(cp)); err == nil {
+		t.Fatal("expected error")
+	}
+
+	fail = false
+	state, err := g.Resume(t.Context(), "run-1", WithCheckpointer(cp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Values["input"] != "x" || state.Values["done"] != true {
+		t.Errorf("state mismatch: %v", state.Values)
+	}
+	last, err := cp.Load(t.Context(), "run-1")
+	if err != nil || last.Node != End {
+		t.Errorf("finished run should be checkpointed at the end: %+v, %v", last, err)
+	}
+}
+
+func TestGenerateAndToolNodes(t *testing.T) {
+	generate := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.IsToolResponse() {
+			return &chat.Response{
+				Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "It is "+last.ToolResponse.Result)},
+				Usage:    &chat.Usage{TotalTokens: 10},
+			}, nil
+		}
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewToolCallMessage("weather", "call-1", `{"location": "Tokyo"}`)},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+
+	g := New().
+		AddNode("agent", GenerateNode("test-model", "answer", nil)).
+		AddNode("tools", ToolNode(map[string]ToolFunc{
+			"weather": func(ctx context.Context, arguments string) (string, error) { return "Rainy", nil },
+		})).
+		AddConditionalEdge("agent", "tools", HasToolCalls).
+		AddEdge("tools", "agent")
+
+	state := NewState()
+	state.Messages = []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")}
+	out, err := g.Run(t.Context(), "run", state, WithGenerator(generate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Values["answer"] != "It is Rainy" {
+		t.Errorf("answer mismatch: %v", out.Values["answer"])
+	}
+	if out.Usage.TotalTokens != 20 {
+		t.Errorf("usage mismatch: %+v", out.Usage)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	g := New().
+		AddNode("loop", func(ctx context.Context, s *State) error { return nil }).
+		AddEdge("loop", "loop")
+	if _, err := g.Run(t.Context(), "run", nil, WithMaxSteps(3)); !errors.Is(err, ErrMaxSteps) {
+		t.Fatalf("expected ErrMaxSteps, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// ToolFunc executes a tool with stringified json arguments and returns the result.
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
+// GenerateNode creates a node that generates a response for state messages with the model.
+// The response messages are appended to the state messages and the text is stored in Values[outputKey].
+func GenerateNode(model string, outputKey string, build func(s *State) (*chat.Request, error), opts ...chat.Option) NodeFunc {
+	return func(ctx context.Context, s *State) error {
+		req := &chat.Request{Model: model, Messages: s.Messages}
+		if build != nil {
+			r, err := build(s)
+			if err != nil {
+				return fmt.Errorf("build request: %w", err)
+			}
+			req = r
+			if req.Model == "" {
+				req.Model = model
+			}
+		}
+
+		resp, err := s.Generate(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+
+		s.Messages = append(s.Messages, resp.Messages...)
+		if outputKey != "" {
+			text := resp.Text()
+			s.Values[outputKey] = text
+			if req.ResponseSchema != nil {
+				var v any
+				if err := json.Unmarshal([]byte(text), &v); err == nil {
+					s.Values[outputKey] = v
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// ToolNode creates a node that executes tool calls in the last AI messages and appends the tool responses.
+func ToolNode(tools map[string]ToolFunc) NodeFunc {
+	return func(ctx context.Context, s *State) error {
+		calls := []chat.Message{}
+		for i := len(s.Messages) - 1; i >= 0 && s.Messages[i].Role == chat.MessageRoleAI; i-- {
+			if s.Messages[i].IsToolCall() {
+				calls = append([]chat.Message{s.Messages[i]}, calls...)
+			}
+		}
+
+		for _, call := range calls {
+			tool, ok := tools[call.ToolCall.Name]
+			if !ok {
+				return fmt.Errorf("tool not found: %s", call.ToolCall.Name)
+			}
+			result, err := tool(ctx, call.ToolCall.Arguments)
+			if err != nil {
+				result = chat.ToolErrorResult(err.Error())
+			}
+			s.Messages = append(s.Messages, chat.NewToolResponseMessage(call.ToolCall.Name, call.ToolCall.ID, result))
+		}
+		return nil
+	}
+}
+
+// HasToolCalls is a condition true when the last AI messages contain tool calls.
+func HasToolCalls(s *State) bool {
+	for i := len(s.Messages) - 1; i >= 0 && s.Messages[i].Role == chat.MessageRoleAI; i-- {
+		if s.Messages[i].IsToolCall() {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldEquals is a condition true when the structured output in Values[key] has the field with the value.
+func FieldEquals(key, field string, value any) Condition {
+	return func(s *State) bool {
+		obj, ok := s.Values[key].(map[string]any)
+		if !ok {
+			return false
+		}
+		v, ok := obj[field]
+		if !ok {
+			return false
+		}
+		// compare through JSON to ignore numeric type differences.
+		a, _ := json.Marshal(v)
+		b, _ := json.Marshal(value)
+		return bytes.Equal(a, b)
+	}
+}
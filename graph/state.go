@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// State is the workflow state passed between nodes.
+// Values must be JSON serializable to be checkpointed.
+type State struct {
+	Values   map[string]any `json:"values"`
+	Messages []chat.Message `json:"messages,omitempty"`
+	Usage    chat.Usage     `json:"usage"`
+
+	generate gengo.GenerateFunc
+}
+
+// NewState creates an empty state.
+func NewState() *State {
+	return &State{Values: map[string]any{}}
+}
+
+// Generate generates a response with the run generator and adds the usage to the state.
+func (s *State) Generate(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	resp, err := s.generate(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.Usage.Add(resp.Usage)
+	return resp, nil
+}
+
+// Checkpoint is a saved run state. Node is the next node to run.
+type Checkpoint struct {
+	RunID string `json:"run_id"`
+	Node  string `json:"node"`
+	Steps int    `json:"steps"`
+	State *State `json:"state"`
+}
+
+// Checkpointer saves and loads checkpoints.
+type Checkpointer interface {
+	Save(ctx context.Context, cp *Checkpoint) error
+	Load(ctx context.Context, runID string) (*Checkpoint, error)
+}
+
+// MemoryCheckpointer is an in-memory checkpointer storing checkpoints as JSON.
+type MemoryCheckpointer struct {
+	mu          sync.Mutex
+	checkpoints map[string][]byte
+}
+
+// NewMemoryCheckpointer creates a new in-memory checkpointer.
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{checkpoints: map[string][]byte{}}
+}
+
+func (m *MemoryCheckpointer) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[cp.RunID] = data
+	return nil
+}
+
+func (m *MemoryCheckpointer) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	m.mu.Lock()
+	data, ok := m.checkpoints[runID]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("checkpoint not found: %s", runID)
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	}
+	return cp, nil
+}
@@ -8,7 +8,6 @@
 package gengo_test
 
 import (
-	"encoding/json"
 	"strings"
 	"testing"
 
@@ -132,9 +131,9 @@ func runImageInput(t *testing.T, req *chat.Request) {
 		t.Fatalf("expected finish reason `stop`, got %s", resp.FinishReason)
 	}
 
-	t.Logf("Content: %s", resp.Messages[0].ContentString())
-	if !strings.Contains(resp.Messages[0].ContentString(), "GENGO") {
-		t.Fatalf("expected content to contain `GENGO`, got %s", resp.Messages[0].ContentString())
+	t.Logf("Content: %s", resp.Text())
+	if !strings.Contains(resp.Text(), "GENGO") {
+		t.Fatalf("expected content to contain `GENGO`, got %s", resp.Text())
 	}
 }
 
@@ -154,14 +153,14 @@ func runResponseSchema(t *testing.T, req *chat.Request) {
 		t.Fatalf("expected finish reason `stop`, got %s", resp.FinishReason)
 	}
 
-	t.Logf("Content: %s", resp.Messages[0].ContentString())
+	t.Logf("Content: %s", resp.Text())
 
 	type name struct {
 		Name string `json:"name"`
 	}
 
 	var result name
-	err = json.Unmarshal([]byte(resp.Messages[0].ContentString()), &result)
+	err = resp.JSON(&result)
 	if err != nil {
 		t.Fatalf("Error unmarshalling response: %v", err)
 	}
@@ -187,7 +186,7 @@ func runGenerate(t *testing.T, req *chat.Request) {
 		t.Fatalf("expected finish reason `stop`, got %s", resp.FinishReason)
 	}
 
-	t.Logf("Content: %s", resp.Messages[0].ContentString())
+	t.Logf("Content: %s", resp.Text())
 }
 
 func runGenerateStream(t *testing.T, req *chat.Request) {
@@ -225,7 +224,7 @@ func runGenerateStream(t *testing.T, req *chat.Request) {
 	}
 	t.Logf("Content: %s, Streams: %d", content, len(responses))
 
-	if content != resp.Messages[0].ContentString() {
+	if content != resp.Text() {
 		t.Fatalf("expected stream content and response content to be the same, got %s", content)
 	}
 }
@@ -249,7 +248,7 @@ func checkResponse(t *testing.T, resp *chat.Response) {
 		t.Fatalf("expected cost, got 0")
 	}
 
-	if resp.Messages[0].ToolCall == nil && resp.Messages[0].ContentString() == "" {
+	if resp.Messages[0].ToolCall == nil && resp.Text() == "" {
 		t.Fatalf("expected content, got empty string")
 	}
 
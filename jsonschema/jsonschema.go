@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
 )
@@ -53,6 +54,34 @@ func (s Schema) PropertiesJSON() json.RawMessage {
 	return js
 }
 
+// Clone returns a deep copy of the schema.
+func (s Schema) Clone() Schema {
+	var clone Schema
+	if err := json.Unmarshal(s.JSON(), &clone); err != nil {
+		return nil
+	}
+	return clone
+}
+
+// WithEnum returns a copy of the schema with the enum values of the property.
+// The property is a dot separated path of nested object properties, eg. "filter.project_id".
+func (s Schema) WithEnum(property string, values ...any) (Schema, error) {
+	clone := s.Clone()
+	node := map[string]any(clone)
+	for _, name := range strings.Split(property, ".") {
+		props, ok := node["properties"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("property not found: %s", property)
+		}
+		node, ok = props[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("property not found: %s", property)
+		}
+	}
+	node["enum"] = values
+	return clone, nil
+}
+
 // IsValid checks if the schema is valid.
 func (s Schema) IsValid() bool {
 	js := s.JSON()
@@ -156,3 +156,22 @@ func TestSchemaValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestWithEnum(t *testing.T) {
+	s := MustParseJSONString(`{"type": "object", "properties": {"filter": {"type": "object", "properties": {"project_id": {"type": "string"}}}}}`)
+
+	got, err := s.WithEnum("filter.project_id", "p1", "p2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Validate([]byte(`{"filter": {"project_id": "p3"}}`)); err == nil {
+		t.Error("expected validation error for value not in enum")
+	}
+	if err := s.Validate([]byte(`{"filter": {"project_id": "p3"}}`)); err != nil {
+		t.Errorf("original schema should not be modified: %v", err)
+	}
+
+	if _, err := s.WithEnum("filter.unknown", "p1"); err == nil {
+		t.Error("expected error for unknown property")
+	}
+}
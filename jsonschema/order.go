@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// PropertyOrder returns the property names of the object schema in order:
+// "propertyOrdering" (as Gemini), then "required", then the remaining properties sorted by name.
+func (s Schema) PropertyOrder() []string {
+	return propertyOrder(s)
+}
+
+func propertyOrder(node map[string]any) []string {
+	props, _ := node["properties"].(map[string]any)
+	order := []string{}
+	add := func(names any) {
+		list, _ := names.([]any)
+		for _, name := range list {
+			if s, ok := name.(string); ok && !slices.Contains(order, s) {
+				order = append(order, s)
+			}
+		}
+	}
+	add(node["propertyOrdering"])
+	add(node["required"])
+	rest := []string{}
+	for name := range props {
+		if !slices.Contains(order, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// Reorder returns the compact JSON data with the object keys following PropertyOrder
+// of the schema recursively. Keys not in the schema follow sorted by name.
+func (s Schema) Reorder(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("unmarshal data: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := encodeOrdered(&buf, v, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeOrdered(buf *bytes.Buffer, v any, schema map[string]any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		keys := []string{}
+		for _, name := range propertyOrder(schema) {
+			if _, ok := v[name]; ok {
+				keys = append(keys, name)
+			}
+		}
+		rest := []string{}
+		for name := range v {
+			if !slices.Contains(keys, name) {
+				rest = append(rest, name)
+			}
+		}
+		sort.Strings(rest)
+		buf.WriteByte('{')
+		for i, name := range append(keys, rest...) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, name); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			child, _ := props[name].(map[string]any)
+			if err := encodeOrdered(buf, v[name], child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		items, _ := schema["items"].(map[string]any)
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeOrdered(buf, item, items); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return encodeValue(buf, v)
+	}
+	return nil
+}
+
+// encodeValue encodes a scalar value without HTML escaping.
+func encodeValue(buf *bytes.Buffer, v any) error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+	return nil
+}
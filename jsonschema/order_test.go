@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReorder(t *testing.T) {
+	schema := MustParseJSONString(`{
+		"type": "object",
+		"propertyOrdering": ["title"],
+		"required": ["score", "title"],
+		"properties": {
+			"title": {"type": "string"},
+			"score": {"type": "number"},
+			"author": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "id": {"type": "integer"}}}}
+		}
+	}`)
+
+	if diff := cmp.Diff([]string{"title", "score", "author", "tags"}, schema.PropertyOrder()); diff != "" {
+		t.Errorf("PropertyOrder() mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := schema.Reorder([]byte(`{"tags": [{"id": 1, "name": "<a>"}], "extra": 1.50, "author": "x", "score": 0.9, "title": "t"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"title":"t","score":0.9,"author":"x","tags":[{"name":"<a>","id":1}],"extra":1.50}`
+	if string(got) != want {
+		t.Errorf("Reorder() = %s, want %s", got, want)
+	}
+
+	if _, err := schema.Reorder([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
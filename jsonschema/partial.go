@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidatePartial checks that the partially streamed value can still become valid,
+// eg. a value of chat.PartialJSON. Strings may be incomplete, so enum strings are matched by prefix.
+// It checks type, enum, const, maxLength, maxItems, additionalProperties and nested schemas,
+// but not required properties or minimums, which may be satisfied later. $ref is not followed.
+func (s Schema) ValidatePartial(v any) error {
+	return validatePartial(s, v, "$")
+}
+
+func validatePartial(schema map[string]any, v any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if err := checkPartialType(schema["type"], v, path); err != nil {
+		return err
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return partialEqual(e, v) }) {
+		return fmt.Errorf("%s: value is not one of the enum values", path)
+	}
+	if c, ok := schema["const"]; ok && !partialEqual(c, v) {
+		return fmt.Errorf("%s: value does not match const", path)
+	}
+	if branches, ok := schema["allOf"].([]any); ok {
+		for _, b := range branches {
+			branch, _ := b.(map[string]any)
+			if err := validatePartial(branch, v, path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		branches, ok := schema[keyword].([]any)
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(branches, func(b any) bool {
+			branch, _ := b.(map[string]any)
+			return validatePartial(branch, v, path) == nil
+		}) {
+			return fmt.Errorf("%s: value does not match %s", path, keyword)
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		if limit, ok := number(schema["maxLength"]); ok && float64(utf8.RuneCountInString(v)) > limit {
+			return fmt.Errorf("%s: string longer than maxLength %v", path, limit)
+		}
+	case []any:
+		if limit, ok := number(schema["maxItems"]); ok && float64(len(v)) > limit {
+			return fmt.Errorf("%s: array longer than maxItems %v", path, limit)
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range v {
+			if err := validatePartial(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		for key, value := range v {
+			prop, ok := props[key].(map[string]any)
+			if !ok {
+				if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+					return fmt.Errorf("%s: additional property %q", path, key)
+				}
+				prop, _ = schema["additionalProperties"].(map[string]any)
+			}
+			if err := validatePartial(prop, value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkPartialType(t any, v any, path string) error {
+	types := []string{}
+	switch t := t.(type) {
+	case string:
+		types = append(types, t)
+	case []any:
+		for _, s := range t {
+			if s, ok := s.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	actual := valueType(v)
+	if slices.Contains(types, actual) || actual == "integer" && slices.Contains(types, "number") {
+		return nil
+	}
+	return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+}
+
+func valueType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if f, ok := number(v); ok {
+		if f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// partialEqual reports whether the value equals the expected value,
+// or is a prefix of the expected string.
+func partialEqual(expected, v any) bool {
+	if s, ok := v.(string); ok {
+		e, ok := expected.(string)
+		return ok && strings.HasPrefix(e, s)
+	}
+	ef, eok := number(expected)
+	vf, vok := number(v)
+	if eok || vok {
+		return eok && vok && ef == vf
+	}
+	e, err1 := json.Marshal(expected)
+	a, err2 := json.Marshal(v)
+	return err1 == nil && err2 == nil && string(e) == string(a)
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidatePartial(t *testing.T) {
+	schema := MustParseJSONString(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["status", "items"],
+		"properties": {
+			"status": {"type": "string", "enum": ["approved", "rejected"]},
+			"count": {"type": "integer"},
+			"items": {"type": "array", "maxItems": 2, "items": {"type": "string", "maxLength": 5}}
+		}
+	}`)
+
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{`{}`, true},
+		{`{"status": "appr"}`, true},
+		{`{"status": "maybe"}`, false},
+		{`{"count": 3, "items": ["a", "b"]}`, true},
+		{`{"count": 1.5}`, false},
+		{`{"items": ["a", "b", "c"]}`, false},
+		{`{"items": ["toolong"]}`, false},
+		{`{"items": "a"}`, false},
+		{`{"extra": 1}`, false},
+		{`[]`, false},
+	}
+	for _, tt := range tests {
+		var v any
+		if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		err := schema.ValidatePartial(v)
+		if tt.ok != (err == nil) {
+			t.Errorf("ValidatePartial(%s) = %v", tt.value, err)
+		}
+	}
+}
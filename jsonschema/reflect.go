@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// For returns the schema of the JSON encoding of T, eg. for ResponseSchema. See Reflect.
+func For[T any]() (Schema, error) {
+	return Reflect(reflect.TypeFor[T]())
+}
+
+// Reflect returns the schema of the JSON encoding of the type.
+// Struct fields are properties named by the json tag, required unless omitempty,
+// and described by the description tag, eg. `json:"name" description:"full name"`.
+// Objects do not allow additional properties. Recursive types are not supported.
+func Reflect(t reflect.Type) (Schema, error) {
+	node, err := reflectType(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	s := Schema(node)
+	if !s.IsValid() {
+		return nil, fmt.Errorf("invalid schema of %s", t)
+	}
+	return s, nil
+}
+
+func reflectType(t reflect.Type, seen map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string.
+			return map[string]any{"type": "string"}, nil
+		}
+		items, err := reflectType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map key must be string: %s", t)
+		}
+		values, err := reflectType(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if seen[t] {
+			return nil, fmt.Errorf("recursive type is not supported: %s", t)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		node := map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{},
+			"required":             []any{},
+			"additionalProperties": false,
+		}
+		if err := reflectFields(t, node, seen); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("type is not supported: %s", t)
+}
+
+// reflectFields adds the fields of the struct to the object node. Embedded structs without
+// a json name are flattened as encoding/json does.
+func reflectFields(t reflect.Type, node map[string]any, seen map[reflect.Type]bool) error {
+	props := node["properties"].(map[string]any)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if err := reflectFields(ft, node, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop, err := reflectType(f.Type, seen)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		if strings.Contains(","+opts+",", ",string,") {
+			prop = map[string]any{"type": "string"}
+		}
+		if desc := f.Tag.Get("description"); desc != "" {
+			prop["description"] = desc
+		}
+		props[name] = prop
+		if !strings.Contains(","+opts+",", ",omitempty,") && !strings.Contains(","+opts+",", ",omitzero,") {
+			node["required"] = append(node["required"].([]any), name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type reflectBase struct {
+	ID string `json:"id"`
+}
+
+type reflectItem struct {
+	reflectBase
+	Name     string            `json:"name" description:"display name"`
+	Count    int               `json:"count,omitempty"`
+	Price    float64           `json:"price,string"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Created  time.Time         `json:"created"`
+	Parent   *reflectBase      `json:"parent,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestFor(t *testing.T) {
+	s, err := For[reflectItem]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"name": {"type": "string", "description": "display name"},
+			"count": {"type": "integer"},
+			"price": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"created": {"type": "string", "format": "date-time"},
+			"parent": {"type": "object", "properties": {"id": {"type": "string"}}, "required": ["id"], "additionalProperties": false}
+		},
+		"required": ["id", "name", "price", "tags", "created"],
+		"additionalProperties": false
+	}`
+	var got, expected any
+	_ = json.Unmarshal(s.JSON(), &got)
+	_ = json.Unmarshal([]byte(want), &expected)
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	type node struct {
+		Children []node `json:"children"`
+	}
+	if _, err := For[node](); err == nil {
+		t.Error("expected recursive type error")
+	}
+	if _, err := For[map[int]string](); err == nil {
+		t.Error("expected map key error")
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package localize localizes system prompts to the locale of the end user,
+// selecting provided translations or translating with a model and caching the result.
+//
+//	l := localize.New("gemini-2.0-flash", localize.WithTranslation("ja", prompt, jaPrompt))
+//	req.Metadata = chat.Metadata{"locale": "ja-JP"}
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(l.Middleware()))
+package localize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultLocaleKey is the metadata key of the requested locale, eg. "ja-JP".
+const DefaultLocaleKey = "locale"
+
+const translatePrompt = `Translate the following system prompt into the language of the locale %q.
+Keep placeholders, code, URLs and formatting unchanged.
+Respond with the translated prompt only.`
+
+// Localizer localizes prompts to locales.
+type Localizer struct {
+	model        string
+	localeKey    string
+	sourceLocale string
+	generate     gengo.GenerateFunc
+	opts         []chat.Option
+	providedOnly bool
+
+	mu    sync.Mutex
+	cache map[cacheKey]string
+}
+
+type cacheKey struct {
+	locale string
+	text   string
+}
+
+// Option configures Localizer.
+type Option func(l *Localizer)
+
+// WithTranslation provides the translation of the prompt to the locale, used instead of the model.
+func WithTranslation(locale, text, translated string) Option {
+	return func(l *Localizer) {
+		l.cache[cacheKey{locale: NormalizeLocale(locale), text: text}] = translated
+	}
+}
+
+// WithSourceLocale sets the locale of the prompts. "en" by default.
+// Prompts are not translated for the source language.
+func WithSourceLocale(locale string) Option {
+	return func(l *Localizer) {
+		l.sourceLocale = NormalizeLocale(locale)
+	}
+}
+
+// WithLocaleKey sets the metadata key of the locale. DefaultLocaleKey by default.
+func WithLocaleKey(key string) Option {
+	return func(l *Localizer) {
+		l.localeKey = key
+	}
+}
+
+// WithProvidedOnly disables model translation; prompts without a provided translation are kept as is.
+func WithProvidedOnly() Option {
+	return func(l *Localizer) {
+		l.providedOnly = true
+	}
+}
+
+// WithGenerator sets the generate function used for translation.
+func WithGenerator(generate gengo.GenerateFunc) Option {
+	return func(l *Localizer) {
+		l.generate = generate
+	}
+}
+
+// WithOptions sets the options of the translation requests.
+func WithOptions(opts ...chat.Option) Option {
+	return func(l *Localizer) {
+		l.opts = opts
+	}
+}
+
+// New creates a Localizer translating with the model.
+func New(model string, opts ...Option) *Localizer {
+	l := &Localizer{
+		model:        model,
+		localeKey:    DefaultLocaleKey,
+		sourceLocale: "en",
+		generate:     gengo.Generate,
+		cache:        map[cacheKey]string{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NormalizeLocale normalizes the locale, eg. "ja_JP" to "ja-jp".
+func NormalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func baseLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
+
+// Localize returns the text localized to the locale.
+// A translation for the locale, eg. "ja-jp", is preferred over the language, eg. "ja".
+func (l *Localizer) Localize(ctx context.Context, text, locale string) (string, error) {
+	locale = NormalizeLocale(locale)
+	if locale == "" || baseLanguage(locale) == baseLanguage(l.sourceLocale) || strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+
+	l.mu.Lock()
+	for _, loc := range []string{locale, baseLanguage(locale)} {
+		if translated, ok := l.cache[cacheKey{locale: loc, text: text}]; ok {
+			l.mu.Unlock()
+			return translated, nil
+		}
+	}
+	l.mu.Unlock()
+	if l.providedOnly {
+		return text, nil
+	}
+
+	translated, err := l.translate(ctx, text, locale)
+	if err != nil {
+		return "", err
+	}
+	l.mu.Lock()
+	l.cache[cacheKey{locale: locale, text: text}] = translated
+	l.mu.Unlock()
+	return translated, nil
+}
+
+func (l *Localizer) translate(ctx context.Context, text, locale string) (string, error) {
+	resp, err := l.generate(ctx, &chat.Request{
+		Model: l.model,
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleSystem, fmt.Sprintf(translatePrompt, locale)),
+			chat.NewTextMessage(chat.MessageRoleHuman, text),
+		},
+	}, l.opts...)
+	if err != nil {
+		return "", fmt.Errorf("translate prompt to %s: %w", locale, err)
+	}
+	translated := strings.TrimSpace(resp.Text())
+	if translated == "" {
+		return "", fmt.Errorf("translate prompt to %s: empty response", locale)
+	}
+	return translated, nil
+}
+
+// LocalizeRequest returns a copy of the request with the system messages localized to the locale.
+func (l *Localizer) LocalizeRequest(ctx context.Context, req *chat.Request, locale string) (*chat.Request, error) {
+	r := *req
+	r.Messages = make([]chat.Message, len(req.Messages))
+	copy(r.Messages, req.Messages)
+	for i, msg := range r.Messages {
+		if msg.Role != chat.MessageRoleSystem {
+			continue
+		}
+		content := make([]chat.ContentPart, len(msg.Content))
+		copy(content, msg.Content)
+		for j, part := range content {
+			if part.Type != "text" {
+				continue
+			}
+			text, err := l.Localize(ctx, part.Text, locale)
+			if err != nil {
+				return nil, err
+			}
+			content[j].Text = text
+		}
+		r.Messages[i].Content = content
+	}
+	return &r, nil
+}
+
+// Middleware localizes the system messages to the locale in the request metadata.
+// Requests without the locale metadata are not changed.
+func (l *Localizer) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			locale := req.Metadata[l.localeKey]
+			if locale == "" {
+				return next(ctx, req)
+			}
+			localized, err := l.LocalizeRequest(ctx, req, locale)
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, localized)
+		}
+	}
+}
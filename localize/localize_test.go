@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package localize
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestLocalize(t *testing.T) {
+	calls := 0
+	fake := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		calls++
+		return &chat.Response{Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleAI, "[fr] "+req.Messages[1].Content[0].Text),
+		}}, nil
+	}
+	l := New("model", WithGenerator(fake), WithTranslation("ja", "Be concise.", "簡潔に答えてください。"))
+	ctx := context.Background()
+
+	tests := []struct {
+		text, locale, want string
+	}{
+		{"Be concise.", "ja_JP", "簡潔に答えてください。"},
+		{"Be concise.", "en-US", "Be concise."},
+		{"Be concise.", "", "Be concise."},
+		{"Be concise.", "fr-FR", "[fr] Be concise."},
+		{"Be concise.", "fr-FR", "[fr] Be concise."},
+	}
+	for _, tt := range tests {
+		got, err := l.Localize(ctx, tt.text, tt.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Localize(%q, %q) = %q, want %q", tt.text, tt.locale, got, tt.want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("translation should be cached, calls = %d", calls)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	l := New("model", WithProvidedOnly(), WithTranslation("ja", "Be concise.", "簡潔に答えてください。"))
+
+	var got *chat.Request
+	generate := l.Middleware()(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{}, nil
+	})
+	req := &chat.Request{
+		Metadata: chat.Metadata{DefaultLocaleKey: "ja-JP"},
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleSystem, "Be concise."),
+			chat.NewTextMessage(chat.MessageRoleHuman, "Be concise."),
+		},
+	}
+	if _, err := generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got.Messages[0].Content[0].Text != "簡潔に答えてください。" || got.Messages[1].Content[0].Text != "Be concise." {
+		t.Errorf("only system messages should be localized: %+v", got.Messages)
+	}
+	if req.Messages[0].Content[0].Text != "Be concise." {
+		t.Error("original request should not be modified")
+	}
+}
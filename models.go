@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo/anthropic"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/google"
+	"github.com/jumonmd/gengo/openai"
+)
+
+// ModelListTTL is the cache duration of the provider model lists.
+const ModelListTTL = time.Hour
+
+type modelList struct {
+	models  []string
+	fetched time.Time
+}
+
+var (
+	modelListsMu sync.Mutex
+	modelLists   = map[string]modelList{}
+)
+
+// listProviderModels is replaced in tests.
+var listProviderModels = func(ctx context.Context, provider string, opts ...chat.Option) ([]string, error) {
+	switch provider {
+	case "anthropic":
+		return anthropic.ListModels(ctx, opts...)
+	case "gemini":
+		return google.ListModels(ctx, opts...)
+	case "openai":
+		return openai.ListModels(ctx, opts...)
+	}
+	return nil, fmt.Errorf("provider not found: %s", provider)
+}
+
+// ListModels returns the models available from the provider model-list endpoint.
+// The list is cached for ModelListTTL by provider, base URL, Azure endpoint and API key,
+// as the models available may differ by account.
+func ListModels(ctx context.Context, provider string, opts ...chat.Option) ([]string, error) {
+	o := chat.NewOptions(opts...)
+	key := modelListKey(ctx, provider, o)
+
+	modelListsMu.Lock()
+	cached, ok := modelLists[key]
+	modelListsMu.Unlock()
+	if ok && o.Now().Sub(cached.fetched) < ModelListTTL {
+		return slices.Clone(cached.models), nil
+	}
+
+	models, err := listProviderModels(ctx, provider, opts...)
+	if err != nil {
+		return nil, err
+	}
+	modelListsMu.Lock()
+	modelLists[key] = modelList{models: models, fetched: o.Now()}
+	modelListsMu.Unlock()
+	return slices.Clone(models), nil
+}
+
+// modelListKey returns the cache key of the model list. The API key is hashed, so it is not kept in memory.
+func modelListKey(ctx context.Context, provider string, o *chat.Options) string {
+	secret, endpoint := provider, ""
+	if provider == "openai" && o.Azure.Enabled() {
+		secret, endpoint = "azure", o.Azure.Endpoint
+	}
+	// a missing key fails the listing, so it is not cached.
+	apiKey, _ := o.ResolveAPIKey(ctx, secret)
+	sum := sha256.Sum256([]byte(apiKey))
+	return strings.Join([]string{provider, o.BaseURL, endpoint, hex.EncodeToString(sum[:8])}, " ")
+}
+
+// ModelReport is the reconciliation of the provider model list against the model catalog.
+type ModelReport struct {
+	Provider string `json:"provider"`
+	// Unknown models are listed by the provider but not in the catalog, so costs and limits are unknown.
+	Unknown []string `json:"unknown,omitempty"`
+	// Unlisted models are in the catalog but not listed by the provider, eg. retired models or aliases.
+	Unlisted []string `json:"unlisted,omitempty"`
+	// Deprecated models are listed by the provider and deprecated in the catalog.
+	Deprecated []string `json:"deprecated,omitempty"`
+}
+
+// ReconcileModels compares the provider model list with the catalog models of the provider,
+// eg. as a preflight check at startup.
+func ReconcileModels(ctx context.Context, provider string, opts ...chat.Option) (*ModelReport, error) {
+	listed, err := ListModels(ctx, provider, opts...)
+	if err != nil {
+		return nil, err
+	}
+	catalog := chat.NewOptions(opts...).ModelCatalog
+
+	report := &ModelReport{Provider: provider}
+	for _, model := range listed {
+		info := catalog.GetModel(model)
+		switch {
+		case info == nil:
+			report.Unknown = append(report.Unknown, model)
+		case info.Deprecated:
+			report.Deprecated = append(report.Deprecated, model)
+		}
+	}
+	for _, info := range catalog {
+		if info.Provider != provider {
+			continue
+		}
+		// eg. "gemini/gemini-2.0-flash" -> "gemini-2.0-flash"
+		name := info.Model[strings.LastIndex(info.Model, "/")+1:]
+		if !slices.Contains(listed, name) {
+			report.Unlisted = append(report.Unlisted, info.Model)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestReconcileModels(t *testing.T) {
+	calls := 0
+	orig := listProviderModels
+	listProviderModels = func(ctx context.Context, provider string, opts ...chat.Option) ([]string, error) {
+		calls++
+		return []string{"model-a", "model-b", "model-new"}, nil
+	}
+	t.Cleanup(func() { listProviderModels = orig })
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	catalog := chat.ModelCatalog{
+		{Model: "test/model-a", Provider: "test"},
+		{Model: "model-b", Provider: "test", Deprecated: true},
+		{Model: "model-old", Provider: "test"},
+		{Model: "other", Provider: "other"},
+	}
+	opts := []chat.Option{chat.WithModelCatalog(catalog), chat.WithClock(func() time.Time { return now })}
+
+	report, err := ReconcileModels(t.Context(), "test", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ModelReport{Provider: "test", Unknown: []string{"model-new"}, Unlisted: []string{"model-old"}, Deprecated: []string{"model-b"}}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ListModels(t.Context(), "test", opts...); err != nil || calls != 1 {
+		t.Errorf("model list should be cached: %d calls, %v", calls, err)
+	}
+	now = now.Add(ModelListTTL)
+	if _, err := ListModels(t.Context(), "test", opts...); err != nil || calls != 2 {
+		t.Errorf("model list should be refreshed: %d calls, %v", calls, err)
+	}
+	if _, err := ListModels(t.Context(), "test", append(opts, chat.WithAPIKey("other-account"))...); err != nil || calls != 3 {
+		t.Errorf("model list should be cached by API key: %d calls, %v", calls, err)
+	}
+}
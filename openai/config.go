@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// clientConfig returns the client config of the OpenAI API, or of the Azure OpenAI resource with chat.WithAzure.
+func clientConfig(ctx context.Context, opt *chat.Options) (openai.ClientConfig, error) {
+	if opt.Azure.Enabled() {
+		return azureConfig(ctx, opt)
+	}
+	apiKey, err := opt.ResolveAPIKey(ctx, "openai")
+	if err != nil {
+		return openai.ClientConfig{}, err
+	}
+	cfg := openai.DefaultConfig(apiKey)
+	if opt.BaseURL != "" {
+		cfg.BaseURL = opt.BaseURL
+	}
+	return cfg, nil
+}
+
+func azureConfig(ctx context.Context, opt *chat.Options) (openai.ClientConfig, error) {
+	apiKey, err := opt.ResolveAPIKey(ctx, "azure")
+	if err != nil {
+		return openai.ClientConfig{}, err
+	}
+	endpoint := opt.Azure.Endpoint
+	if endpoint == "" {
+		endpoint = opt.BaseURL
+	}
+	if endpoint == "" {
+		return openai.ClientConfig{}, errors.New("azure openai endpoint is not set")
+	}
+	cfg := openai.DefaultAzureConfig(apiKey, endpoint)
+	cfg.APIVersion = opt.Azure.APIVersion
+	mapModel := cfg.AzureModelMapperFunc
+	cfg.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := opt.Azure.Deployment(model); ok {
+			return deployment
+		}
+		return mapModel(model)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerateAzure(t *testing.T) {
+	var path, apiVersion, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiVersion, apiKey = r.URL.Path, r.URL.Query().Get("api-version"), r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("AZURE_OPENAI_API_KEY", "azure-key")
+	t.Setenv("AZURE_OPENAI_API_VERSION", "")
+
+	tests := []struct {
+		model string
+		opts  []chat.Option
+		path  string
+	}{
+		{"gpt-4o-mini", []chat.Option{chat.WithAzure(server.URL, "")}, "/openai/deployments/gpt-4o-mini/chat/completions"},
+		{"gpt-3.5-turbo", []chat.Option{chat.WithAzure(server.URL, "")}, "/openai/deployments/gpt-35-turbo/chat/completions"},
+		{"gpt-4o", []chat.Option{chat.WithAzure(server.URL, ""), chat.WithAzureDeployment("gpt-4o", "prod-4o")}, "/openai/deployments/prod-4o/chat/completions"},
+	}
+	for _, tt := range tests {
+		r := &chat.Request{Model: tt.model, Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+		if _, err := Generate(t.Context(), r, tt.opts...); err != nil {
+			t.Fatalf("%s: %v", tt.model, err)
+		}
+		if path != tt.path || apiVersion != chat.DefaultAzureAPIVersion || apiKey != "azure-key" {
+			t.Errorf("%s: path = %s, api-version = %s, api-key = %s", tt.model, path, apiVersion, apiKey)
+		}
+	}
+}
+
+func TestGenerateAzureWithoutEndpoint(t *testing.T) {
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	r := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+	if _, err := Generate(t.Context(), r, chat.WithAzure("", "")); err == nil {
+		t.Error("expected error without endpoint")
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// constraintFields returns the request body fields of constrained decoding
+// for OpenAI-compatible servers (vLLM guided decoding and llama.cpp grammar).
+func constraintFields(config *chat.ModelConfig) map[string]any {
+	fields := map[string]any{}
+	if config.ResponseRegex != "" {
+		fields["guided_regex"] = config.ResponseRegex
+	}
+	if config.Grammar != "" {
+		fields["guided_grammar"] = config.Grammar
+		fields["grammar"] = config.Grammar
+	}
+	return fields
+}
+
+// flexServiceTier is the OpenAI service tier of batch priority requests.
+const flexServiceTier = "flex"
+
+// flexModel reports whether the model supports the flex service tier: o3, o4-mini and the gpt-5 family.
+func flexModel(model string) bool {
+	if strings.HasPrefix(model, "gpt-5") {
+		return true
+	}
+	for _, m := range []string{"o3", "o4-mini"} {
+		if model == m || strings.HasPrefix(model, m+"-20") {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyFields returns the request body fields not supported by the SDK.
+// The constrained decoding fields are not supported by the official OpenAI API,
+// so BaseURL of a compatible server is required for Grammar.
+// ResponseRegex is validated after generation without a compatible server.
+// Batch priority requests of the models supporting it are sent to the official API with the flex service tier.
+// Messages with file parts are replaced as the SDK has no file parts.
+func bodyFields(r *chat.Request, opt *chat.Options) (map[string]any, error) {
+	fields := map[string]any{}
+	switch {
+	case opt.BaseURL != "" && !opt.Azure.Enabled():
+		fields = constraintFields(&r.Config)
+	case r.Config.Grammar != "":
+		return nil, errors.New("grammar requires an OpenAI-compatible server with BaseURL")
+	case opt.Priority == chat.PriorityBatch && !opt.Azure.Enabled() && flexModel(r.Model):
+		fields["service_tier"] = flexServiceTier
+	}
+	messages, err := fileMessages(r)
+	if err != nil {
+		return nil, err
+	}
+	if messages != nil {
+		fields["messages"] = messages
+	}
+	return fields, nil
+}
+
+// constraintClient returns an HTTP client adding the bodyFields to the request body.
+func constraintClient(r *chat.Request, opt *chat.Options, client *http.Client) (*http.Client, error) {
+	fields, err := bodyFields(r, opt)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return client, nil
+	}
+
+	// the client is copied to keep its timeout, cookie jar and redirect policy.
+	c := &http.Client{}
+	if client != nil {
+		*c = *client
+	}
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.Transport = &bodyFieldsTransport{base: base, fields: fields}
+	return c, nil
+}
+
+type bodyFieldsTransport struct {
+	base   http.RoundTripper
+	fields map[string]any
+}
+
+func (t *bodyFieldsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Method != http.MethodPost {
+		return t.base.RoundTrip(req)
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	data, err = addBodyFields(data, t.fields)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	return t.base.RoundTrip(req)
+}
+
+// addBodyFields returns the JSON request body with the fields.
+func addBodyFields(data []byte, fields map[string]any) ([]byte, error) {
+	body := map[string]any{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("decode request body: %w", err)
+	}
+	for k, v := range fields {
+		body[k] = v
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("encode request body: %w", err)
+	}
+	return data, nil
+}
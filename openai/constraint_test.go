@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestConstraintClient(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	r := &chat.Request{Config: chat.ModelConfig{ResponseRegex: `\d+`}}
+	client, err := constraintClient(r, chat.NewOptions(chat.WithBaseURL(server.URL)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"model":"local"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if body["model"] != "local" || body["guided_regex"] != `\d+` {
+		t.Errorf("body mismatch: %v", body)
+	}
+
+	caller := &http.Client{Timeout: time.Minute}
+	client, err = constraintClient(&chat.Request{Model: "o3"}, chat.NewOptions(chat.WithPriority(chat.PriorityBatch)), caller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client == caller || client.Timeout != time.Minute || caller.Transport != nil {
+		t.Errorf("caller client should be copied: %+v", client)
+	}
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader(`{"model":"o3"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if body["service_tier"] != "flex" {
+		t.Errorf("batch priority should use the flex tier: %v", body)
+	}
+
+	for model, want := range map[string]bool{"o3": true, "o3-2025-04-16": true, "o4-mini": true, "gpt-5-mini": true,
+		"o3-mini": false, "gpt-4o-mini": false} {
+		if got := flexModel(model); got != want {
+			t.Errorf("flexModel(%s) = %v", model, got)
+		}
+	}
+
+	grammar := &chat.Request{Config: chat.ModelConfig{Grammar: `root ::= "yes" | "no"`}}
+	if _, err := constraintClient(grammar, chat.NewOptions(), nil); err == nil {
+		t.Error("grammar without BaseURL should be an error")
+	}
+}
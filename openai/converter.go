@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"sync"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// PartConverter converts a content part to an OpenAI message part.
+type PartConverter func(part *chat.ContentPart) openai.ChatMessagePart
+
+var (
+	partConvertersMu sync.RWMutex
+	partConverters   = map[string]PartConverter{}
+)
+
+// RegisterPartConverter registers a converter for the content part type, eg. "video".
+// It overrides the built-in conversion of the type.
+func RegisterPartConverter(partType string, fn PartConverter) {
+	partConvertersMu.Lock()
+	defer partConvertersMu.Unlock()
+	partConverters[partType] = fn
+}
+
+func lookupPartConverter(partType string) (PartConverter, bool) {
+	partConvertersMu.RLock()
+	defer partConvertersMu.RUnlock()
+	fn, ok := partConverters[partType]
+	return fn, ok
+}
@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRegisterPartConverter(t *testing.T) {
+	RegisterPartConverter("latex", func(part *chat.ContentPart) openai.ChatMessagePart {
+		return openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: "$" + part.Text + "$"}
+	})
+	defer delete(partConverters, "latex")
+
+	msg := convertChatMessage(&chat.Message{
+		Role:    chat.MessageRoleHuman,
+		Content: []chat.ContentPart{{Type: "latex", Text: "x^2"}},
+	})
+	if msg.MultiContent[0].Text != "$x^2$" {
+		t.Errorf("converted part mismatch: %+v", msg.MultiContent[0])
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"slices"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// fileMessages returns the messages of the request body with the file parts, which the SDK does not support,
+// or nil if there is no file part or a converter of the file type is registered.
+func fileMessages(r *chat.Request) ([]map[string]any, error) {
+	if _, ok := lookupPartConverter("file"); ok || !hasFileParts(r) {
+		return nil, nil
+	}
+	data, err := json.Marshal(convertChatRequest(r).Messages)
+	if err != nil {
+		return nil, fmt.Errorf("marshal messages: %w", err)
+	}
+	var messages []map[string]any
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("unmarshal messages: %w", err)
+	}
+	for i, msg := range r.Messages {
+		content, ok := messages[i]["content"].([]any)
+		if !ok {
+			continue
+		}
+		parts, _ := messageContent(&msg)
+		for j, part := range parts {
+			if part.Type != "file" {
+				continue
+			}
+			file, err := convertFilePart(&part)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d].content[%d]: %w", i, j, err)
+			}
+			content[j] = file
+		}
+	}
+	return messages, nil
+}
+
+func hasFileParts(r *chat.Request) bool {
+	return slices.ContainsFunc(r.Messages, func(msg chat.Message) bool {
+		return !msg.IsToolResponse() && slices.ContainsFunc(msg.Content, func(part chat.ContentPart) bool {
+			return part.Type == "file"
+		})
+	})
+}
+
+// convertFilePart converts a file part to a file input. Plain text files are inlined as text parts.
+// Only data URLs are supported as the Chat Completions API does not fetch remote files.
+func convertFilePart(part *chat.ContentPart) (map[string]any, error) {
+	if part.DataURL == "" {
+		return nil, fmt.Errorf("file URL is not supported, use a data URL: %s", part.URL)
+	}
+	data, mimeType, err := chat.DecodeDataURL(part.DataURL)
+	if err != nil {
+		return nil, fmt.Errorf("decode file data URL: %w", err)
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		return map[string]any{"type": "text", "text": string(data)}, nil
+	}
+	return map[string]any{
+		"type": "file",
+		"file": map[string]any{
+			"filename":  fileName(part.Title, mimeType),
+			"file_data": part.DataURL,
+		},
+	}, nil
+}
+
+// fileName returns the title, or a file name with the extension of the MIME type as the API requires one.
+func fileName(title, mimeType string) string {
+	if title != "" {
+		return title
+	}
+	name := "file"
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		name += exts[0]
+	}
+	return name
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestPayloadFileParts(t *testing.T) {
+	pdf := chat.EncodeDataURL("application/pdf", []byte("%PDF-1.4"))
+	r := &chat.Request{
+		Model: "gpt-4o",
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleSystem, "Be brief."),
+			{Role: chat.MessageRoleHuman, Content: []chat.ContentPart{
+				{Type: "text", Text: "Compare these."},
+				{Type: "file", DataURL: pdf, Title: "report.pdf"},
+				{Type: "file", DataURL: pdf},
+				chat.NewTextDocumentPart("notes", "plain notes"),
+			}},
+		},
+	}
+	body, err := Payload(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+				File struct {
+					Filename string `json:"filename"`
+					FileData string `json:"file_data"`
+				} `json:"file"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content[0].Text != "Be brief." {
+		t.Fatalf("messages mismatch: %s", body)
+	}
+	parts := got.Messages[1].Content
+	if len(parts) != 4 || parts[0].Text != "Compare these." {
+		t.Fatalf("content mismatch: %s", body)
+	}
+	if parts[1].Type != "file" || parts[1].File.Filename != "report.pdf" || parts[1].File.FileData != pdf {
+		t.Errorf("file part mismatch: %+v", parts[1])
+	}
+	if parts[2].File.Filename != "file.pdf" {
+		t.Errorf("default file name mismatch: %q", parts[2].File.Filename)
+	}
+	if parts[3].Type != "text" || parts[3].Text != "plain notes" {
+		t.Errorf("text file should be inlined: %+v", parts[3])
+	}
+
+	r.Messages[1].Content = []chat.ContentPart{chat.NewURLPart("file", "https://example.com/report.pdf")}
+	if _, err := Payload(r); err == nil {
+		t.Error("expected error for file URL")
+	}
+}
+
+func TestPayloadFilePartsInvalidName(t *testing.T) {
+	pdf := chat.EncodeDataURL("application/pdf", []byte("%PDF-1.4"))
+	r := &chat.Request{
+		Model: "gpt-4o",
+		Messages: []chat.Message{
+			{Role: chat.MessageRoleHuman, Name: "Jane Doe", Content: []chat.ContentPart{
+				{Type: "file", DataURL: pdf, Title: "report.pdf"},
+			}},
+		},
+	}
+	body, err := Payload(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Messages []struct {
+			Name    string `json:"name"`
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+				File struct {
+					Filename string `json:"filename"`
+				} `json:"file"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Name != "" {
+		t.Fatalf("messages mismatch: %s", body)
+	}
+	parts := got.Messages[0].Content
+	if len(parts) != 2 || parts[0].Type != "text" {
+		t.Fatalf("content mismatch: %s", body)
+	}
+	if parts[1].Type != "file" || parts[1].File.Filename != "report.pdf" {
+		t.Errorf("file part mismatch: %s", body)
+	}
+}
@@ -8,7 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"regexp"
 
 	"github.com/jumonmd/gengo/chat"
 	"github.com/jumonmd/gengo/jsonschema"
@@ -18,21 +18,27 @@ import (
 func Generate(ctx context.Context, r *chat.Request, opts ...chat.Option) (*chat.Response, error) {
 	opt := chat.NewOptions(opts...)
 
-	cfg := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	if opt.BaseURL != "" {
-		cfg.BaseURL = opt.BaseURL
+	cfg, err := clientConfig(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := constraintClient(r, opt, opt.HTTPClient("openai"))
+	if err != nil {
+		return nil, err
+	}
+	if httpClient != nil {
+		cfg.HTTPClient = httpClient
 	}
 	client := openai.NewClientWithConfig(cfg)
 
-	req := convertChatRequest(r)
+	req := buildRequest(r, opt)
 
-	// tool call will not use stream for simplicity
-	if opt.Streamer != nil && len(req.Tools) == 0 {
+	if opt.Streamer != nil {
 		resp, err := chatCompletionStream(ctx, client, req, opt.Streamer)
 		if err != nil {
 			return nil, fmt.Errorf("chat completion stream: %w", err)
 		}
-		opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
+		opt.CalculateCost(r.Model, resp.Usage)
 		return resp, nil
 	}
 
@@ -41,7 +47,7 @@ func Generate(ctx context.Context, r *chat.Request, opts ...chat.Option) (*chat.
 		return nil, fmt.Errorf("chat completion: %w", err)
 	}
 
-	opt.ModelCatalog.CalculateCost(r.Model, resp.Usage)
+	opt.CalculateCost(r.Model, resp.Usage)
 	return resp, nil
 }
 
@@ -89,6 +95,8 @@ func chatCompletionStream(ctx context.Context, client *openai.Client, r openai.C
 
 	usage := &chat.Usage{}
 	content := ""
+	var toolCalls []*chat.ToolCall
+	finishReason := chat.FinishReasonStop
 	for {
 		select {
 		case <-ctx.Done():
@@ -99,8 +107,8 @@ func chatCompletionStream(ctx context.Context, client *openai.Client, r openai.C
 				// chat completion stream is done
 				return &chat.Response{
 					Model:        r.Model,
-					Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, content)},
-					FinishReason: "stop",
+					Messages:     streamMessages(content, toolCalls),
+					FinishReason: finishReason,
 					Usage:        usage,
 				}, nil
 			} else if err != nil {
@@ -114,6 +122,9 @@ func chatCompletionStream(ctx context.Context, client *openai.Client, r openai.C
 			if len(response.Choices) == 0 {
 				continue
 			}
+			if reason := response.Choices[0].FinishReason; reason != "" {
+				finishReason = convertFinishReason(reason)
+			}
 
 			// stream chunk content
 			if c := response.Choices[0].Delta.Content; c != "" {
@@ -126,10 +137,50 @@ func chatCompletionStream(ctx context.Context, client *openai.Client, r openai.C
 					return nil, fmt.Errorf("stream: %w", err)
 				}
 			}
+
+			// stream tool call deltas, assembled by index
+			for _, delta := range response.Choices[0].Delta.ToolCalls {
+				index := toolCallIndex(delta, len(toolCalls))
+				for index >= len(toolCalls) {
+					toolCalls = append(toolCalls, &chat.ToolCall{})
+				}
+				call := toolCalls[index]
+				call.ID += delta.ID
+				call.Name += delta.Function.Name
+				call.Arguments += delta.Function.Arguments
+				err := streamer(chat.NewToolCallDelta(index, delta.ID, delta.Function.Name, delta.Function.Arguments))
+				if err != nil {
+					return nil, fmt.Errorf("stream: %w", err)
+				}
+			}
 		}
 	}
 }
 
+// toolCallIndex returns the index of the tool call of the delta. Compatible servers omitting the index
+// start a new call with each ID, and continue the last call otherwise.
+func toolCallIndex(delta openai.ToolCall, calls int) int {
+	switch {
+	case delta.Index != nil:
+		return *delta.Index
+	case delta.ID != "" || calls == 0:
+		return calls
+	}
+	return calls - 1
+}
+
+// streamMessages returns the messages of the streamed text and tool calls.
+func streamMessages(content string, toolCalls []*chat.ToolCall) []chat.Message {
+	msgs := []chat.Message{}
+	if content != "" || len(toolCalls) == 0 {
+		msgs = append(msgs, chat.NewTextMessage(chat.MessageRoleAI, content))
+	}
+	for _, call := range toolCalls {
+		msgs = append(msgs, chat.NewToolCallMessage(call.Name, call.ID, call.Arguments))
+	}
+	return msgs
+}
+
 func chatUsage(usage *openai.Usage) *chat.Usage {
 	return &chat.Usage{
 		InputTokens:  usage.PromptTokens,
@@ -162,8 +213,11 @@ func convertChatRequest(r *chat.Request) openai.ChatCompletionRequest {
 	req.PresencePenalty = r.Config.PresencePenalty
 	req.Stop = r.Config.StopWords
 
-	if r.ResponseSchema != nil {
+	switch r.EffectiveResponseType() {
+	case chat.ResponseTypeJSONSchema:
 		req.ResponseFormat = convertChatSchema(r.ResponseSchema)
+	case chat.ResponseTypeJSON:
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
 	}
 
 	if r.MustCallTool {
@@ -173,6 +227,18 @@ func convertChatRequest(r *chat.Request) openai.ChatCompletionRequest {
 	return req
 }
 
+// validName is the pattern of the message names accepted by the API.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// messageContent returns the content parts and the name sent for the message.
+// Names rejected by the API, eg. with spaces, are prefixed to the content instead.
+func messageContent(msg *chat.Message) ([]chat.ContentPart, string) {
+	if msg.Name != "" && !validName.MatchString(msg.Name) {
+		return msg.NamedContent(), ""
+	}
+	return msg.Content, msg.Name
+}
+
 func convertChatMessage(msg *chat.Message) openai.ChatCompletionMessage {
 	parts := []openai.ChatMessagePart{}
 
@@ -184,7 +250,8 @@ func convertChatMessage(msg *chat.Message) openai.ChatCompletionMessage {
 			ToolCallID: msg.ToolResponse.ID,
 		}
 	}
-	for _, part := range msg.Content {
+	content, name := messageContent(msg)
+	for _, part := range content {
 		parts = append(parts, convertContentPart(&part))
 	}
 
@@ -201,6 +268,7 @@ func convertChatMessage(msg *chat.Message) openai.ChatCompletionMessage {
 	}
 	return openai.ChatCompletionMessage{
 		Role:         convertChatRole(msg.Role),
+		Name:         name,
 		MultiContent: parts,
 		ToolCalls:    toolcalls,
 	}
@@ -222,11 +290,18 @@ func convertChatRole(role chat.MessageRole) string {
 }
 
 func convertContentPart(part *chat.ContentPart) openai.ChatMessagePart {
+	if fn, ok := lookupPartConverter(part.Type); ok {
+		return fn(part)
+	}
 	if part.Type == "image" {
+		url := part.DataURL
+		if url == "" {
+			url = part.URL
+		}
 		return openai.ChatMessagePart{
 			Type: openai.ChatMessagePartTypeImageURL,
 			ImageURL: &openai.ChatMessageImageURL{
-				URL:    part.DataURL,
+				URL:    url,
 				Detail: openai.ImageURLDetailAuto,
 			},
 		}
@@ -4,10 +4,16 @@
 package openai
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+	"github.com/sashabaranov/go-openai"
 )
 
 func TestConvertChatRequest(t *testing.T) {
@@ -55,3 +61,134 @@ func TestConvertChatRequest(t *testing.T) {
 		t.Errorf("ToolChoice mismatch: expected %s, got %s", "required", req.ToolChoice)
 	}
 }
+
+func TestConvertChatRequestResponseType(t *testing.T) {
+	req := convertChatRequest(&chat.Request{ResponseType: chat.ResponseTypeJSON})
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeJSONObject {
+		t.Errorf("ResponseFormat mismatch: %+v", req.ResponseFormat)
+	}
+	req = convertChatRequest(&chat.Request{ResponseType: chat.ResponseTypeText})
+	if req.ResponseFormat != nil {
+		t.Errorf("ResponseFormat should be nil for text: %+v", req.ResponseFormat)
+	}
+}
+
+func TestConvertChatMessageName(t *testing.T) {
+	msg := chat.NewNamedTextMessage(chat.MessageRoleHuman, "alice", "hello")
+	got := convertChatMessage(&msg)
+	if got.Name != "alice" || got.MultiContent[0].Text != "hello" {
+		t.Errorf("message mismatch: %+v", got)
+	}
+
+	msg = chat.NewNamedTextMessage(chat.MessageRoleHuman, "Alice Smith", "hello")
+	got = convertChatMessage(&msg)
+	if got.Name != "" || got.MultiContent[0].Text != "Alice Smith: hello" {
+		t.Errorf("invalid name should be prefixed to the content: %+v", got)
+	}
+}
+
+func TestGenerateUserID(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	r := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hi")}}
+	_, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"), chat.WithUserID("user-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["user"] != "user-1" {
+		t.Errorf("user mismatch: %v", body["user"])
+	}
+}
+
+func TestGenerateStreamToolCall(t *testing.T) {
+	chunks := []string{
+		`{"choices": [{"index": 0, "delta": {"role": "assistant", "tool_calls": [{"index": 0, "id": "call_1", "type": "function", "function": {"name": "weather", "arguments": ""}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "{\"city\":"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "\"Tokyo\"}"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 1, "id": "call_2", "type": "function", "function": {"name": "time", "arguments": "{}"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {}, "finish_reason": "tool_calls"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", c)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var deltas []chat.ToolCallDelta
+	streamer := func(resp *chat.StreamResponse) error {
+		if resp.Type == chat.StreamTypeToolCallDelta {
+			deltas = append(deltas, *resp.ToolCall)
+		}
+		return nil
+	}
+	r := &chat.Request{
+		Model:    "gpt-4o-mini",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+		Tools:    []chat.Tool{{Name: "weather", InputSchema: jsonschema.MustParseJSONString(`{"type": "object"}`)}},
+	}
+	resp, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"), chat.WithStream(streamer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 4 || deltas[0].Name != "weather" || deltas[3].Index != 1 || deltas[1].Arguments != `{"city":` {
+		t.Errorf("deltas mismatch: %+v", deltas)
+	}
+	calls := resp.ToolCalls()
+	if len(calls) != 2 || calls[0].ToolCall.Arguments != `{"city":"Tokyo"}` || calls[1].ToolCall.ID != "call_2" {
+		t.Errorf("tool calls mismatch: %+v", calls)
+	}
+	if len(resp.Messages) != 2 || resp.FinishReason != chat.FinishReasonToolUse {
+		t.Errorf("response mismatch: %+v", resp)
+	}
+}
+
+func TestGenerateStreamToolCallWithoutIndex(t *testing.T) {
+	chunks := []string{
+		`{"choices": [{"index": 0, "delta": {"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"function": {"arguments": "\"Tokyo\"}"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "time", "arguments": "{}"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {}, "finish_reason": "tool_calls"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, c := range chunks {
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", c)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var indexes []int
+	streamer := func(resp *chat.StreamResponse) error {
+		if resp.Type == chat.StreamTypeToolCallDelta {
+			indexes = append(indexes, resp.ToolCall.Index)
+		}
+		return nil
+	}
+	r := &chat.Request{
+		Model:    "local-model",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+	}
+	resp, err := Generate(t.Context(), r, chat.WithBaseURL(server.URL), chat.WithAPIKey("test"), chat.WithStream(streamer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 0, 1}) {
+		t.Errorf("delta indexes mismatch: %v", indexes)
+	}
+	calls := resp.ToolCalls()
+	if len(calls) != 2 || calls[0].ToolCall.Arguments != `{"city":"Tokyo"}` || calls[1].ToolCall.Name != "time" {
+		t.Errorf("tool calls mismatch: %+v", calls)
+	}
+}
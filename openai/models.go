@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// ListModels returns the model IDs available to the API key.
+func ListModels(ctx context.Context, opts ...chat.Option) ([]string, error) {
+	opt := chat.NewOptions(opts...)
+
+	cfg, err := clientConfig(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient := opt.HTTPClient("openai"); httpClient != nil {
+		cfg.HTTPClient = httpClient
+	}
+
+	list, err := openai.NewClientWithConfig(cfg).ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	models := make([]string, 0, len(list.Models))
+	for _, m := range list.Models {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// buildRequest converts the request with the options.
+func buildRequest(r *chat.Request, opt *chat.Options) openai.ChatCompletionRequest {
+	req := convertChatRequest(r)
+	req.User = opt.UserID
+	return req
+}
+
+// Payload returns the JSON body of the chat completion request without sending it.
+func Payload(r *chat.Request, opts ...chat.Option) (json.RawMessage, error) {
+	opt := chat.NewOptions(opts...)
+	data, err := json.Marshal(buildRequest(r, opt))
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	fields, err := bodyFields(r, opt)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return data, nil
+	}
+	return addBodyFields(data, fields)
+}
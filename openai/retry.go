@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+// IsRetryable reports whether the error is a rate limit or server error.
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// Classify returns the retry class of the error.
+// 429 with insufficient_quota is a quota, not a rate limit.
+func Classify(err error) chat.RetryClass {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code == "insufficient_quota" || apiErr.Type == "insufficient_quota" {
+			return chat.RetryClassQuota
+		}
+		return statusClass(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return statusClass(reqErr.HTTPStatusCode)
+	}
+	return chat.RetryClassNone
+}
+
+func statusClass(code int) chat.RetryClass {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return chat.RetryClassRateLimit
+	case code == http.StatusRequestTimeout, code == http.StatusConflict, code >= http.StatusInternalServerError:
+		return chat.RetryClassTransient
+	}
+	return chat.RetryClassNone
+}
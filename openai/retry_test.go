@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("generate: %w", &openai.APIError{HTTPStatusCode: 429}), true},
+		{&openai.APIError{HTTPStatusCode: 503}, true},
+		{&openai.APIError{HTTPStatusCode: 400}, false},
+		{&openai.RequestError{HTTPStatusCode: 502}, true},
+		{errors.New("invalid request"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want chat.RetryClass
+	}{
+		{&openai.APIError{HTTPStatusCode: 429}, chat.RetryClassRateLimit},
+		{&openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota"}, chat.RetryClassQuota},
+		{&openai.APIError{HTTPStatusCode: 500}, chat.RetryClassTransient},
+		{&openai.APIError{HTTPStatusCode: 400}, chat.RetryClassNone},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
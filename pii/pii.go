@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package pii keeps personally identifiable information within the boundary.
+// Detected PII is replaced with reversible tokens, eg. "[EMAIL_1]", before requests are sent to providers,
+// and the originals are restored in responses. Tokens are kept per conversation,
+// so the same value gets the same token across turns.
+//
+//	vaults := pii.NewVaults()
+//	req.Metadata = chat.Metadata{"conversation_id": id}
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(vaults.Middleware()))
+//	defer vaults.Delete(id)
+package pii
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultConversationKey is the metadata key of the conversation ID.
+const DefaultConversationKey = "conversation_id"
+
+// Pattern detects a kind of PII.
+type Pattern struct {
+	// Kind is the token prefix, eg. "EMAIL".
+	Kind   string
+	Regexp *regexp.Regexp
+}
+
+// DefaultPatterns detect email addresses, credit card numbers, IPv4 addresses and phone numbers.
+// IP addresses are detected before phone numbers, which may also be separated by dots.
+var DefaultPatterns = []Pattern{
+	{Kind: "EMAIL", Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	{Kind: "CARD", Regexp: regexp.MustCompile(`\b\d{4}[ \-]?\d{4}[ \-]?\d{4}[ \-]?\d{1,7}\b`)},
+	{Kind: "IP", Regexp: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`)},
+	{Kind: "PHONE", Regexp: regexp.MustCompile(`(?:\+\d{1,4}[ \-.]?|\b)\(?\d{2,4}\)?[ \-.]\d{3,4}[ \-.]\d{3,4}\b`)},
+}
+
+var tokenPattern = regexp.MustCompile(`\[[A-Z]+_\d+\]`)
+
+// Vault replaces PII with tokens and restores them. It is safe for concurrent use.
+type Vault struct {
+	patterns []Pattern
+
+	mu        sync.Mutex
+	tokens    map[string]string // original -> token
+	originals map[string]string // token -> original
+	counts    map[string]int
+}
+
+// NewVault creates a vault with the patterns, or DefaultPatterns if none.
+func NewVault(patterns ...Pattern) *Vault {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns
+	}
+	return &Vault{
+		patterns:  patterns,
+		tokens:    map[string]string{},
+		originals: map[string]string{},
+		counts:    map[string]int{},
+	}
+}
+
+// Tokenize replaces the detected PII in the text with the tokens.
+// Patterns are applied in order, so earlier patterns take precedence.
+// Matches within a longer run of digits and dots, eg. a part of a version number, are kept as is.
+func (v *Vault) Tokenize(text string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, p := range v.patterns {
+		var b strings.Builder
+		last := 0
+		for _, m := range p.Regexp.FindAllStringIndex(text, -1) {
+			if withinNumber(text, m[0], m[1]) {
+				continue
+			}
+			b.WriteString(text[last:m[0]])
+			b.WriteString(v.token(p.Kind, text[m[0]:m[1]]))
+			last = m[1]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+// withinNumber reports whether text[start:end] is adjacent to a digit, or a dot next to a digit.
+func withinNumber(text string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+	if isDigit(start-1) || (start > 0 && text[start-1] == '.' && isDigit(start-2)) {
+		return true
+	}
+	return isDigit(end) || (end < len(text) && text[end] == '.' && isDigit(end+1))
+}
+
+func (v *Vault) token(kind, original string) string {
+	if token, ok := v.tokens[original]; ok {
+		return token
+	}
+	v.counts[kind]++
+	token := fmt.Sprintf("[%s_%d]", kind, v.counts[kind])
+	v.tokens[original] = token
+	v.originals[token] = original
+	return token
+}
+
+// Restore replaces the tokens in the text with the originals. Unknown tokens are kept as is.
+func (v *Vault) Restore(text string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return tokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		if original, ok := v.originals[token]; ok {
+			return original
+		}
+		return token
+	})
+}
+
+// Len returns the number of the tokenized values.
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.tokens)
+}
+
+// TokenizeRequest returns a copy of the request with the PII of the messages tokenized.
+func (v *Vault) TokenizeRequest(req *chat.Request) *chat.Request {
+	r := *req
+	r.Messages = make([]chat.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		r.Messages[i] = mapMessage(msg, v.Tokenize)
+	}
+	return &r
+}
+
+// RestoreResponse restores the tokens in the response messages.
+func (v *Vault) RestoreResponse(resp *chat.Response) {
+	for i, msg := range resp.Messages {
+		resp.Messages[i] = mapMessage(msg, v.Restore)
+	}
+}
+
+// Middleware tokenizes the requests and restores the responses with the vault.
+// Streamed chunks are not restored.
+func (v *Vault) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			resp, err := next(ctx, v.TokenizeRequest(req))
+			if resp != nil {
+				v.RestoreResponse(resp)
+			}
+			return resp, err
+		}
+	}
+}
+
+// mapMessage returns a copy of the message with fn applied to the texts, tool call arguments and tool results.
+func mapMessage(msg chat.Message, fn func(string) string) chat.Message {
+	content := make([]chat.ContentPart, len(msg.Content))
+	for i, part := range msg.Content {
+		if part.Type == "text" {
+			part.Text = fn(part.Text)
+		}
+		content[i] = part
+	}
+	msg.Content = content
+	if msg.ToolCall != nil {
+		call := *msg.ToolCall
+		call.Arguments = fn(call.Arguments)
+		msg.ToolCall = &call
+	}
+	if msg.ToolResponse != nil {
+		result := *msg.ToolResponse
+		result.Result = fn(result.Result)
+		msg.ToolResponse = &result
+	}
+	return msg
+}
+
+// Option configures Vaults.
+type Option func(v *Vaults)
+
+// WithPatterns sets the patterns of the vaults. DefaultPatterns by default.
+func WithPatterns(patterns ...Pattern) Option {
+	return func(v *Vaults) {
+		v.patterns = patterns
+	}
+}
+
+// WithConversationKey sets the metadata key of the conversation ID. DefaultConversationKey by default.
+func WithConversationKey(key string) Option {
+	return func(v *Vaults) {
+		v.conversationKey = key
+	}
+}
+
+// Vaults keeps a vault per conversation.
+type Vaults struct {
+	patterns        []Pattern
+	conversationKey string
+
+	mu     sync.Mutex
+	vaults map[string]*Vault
+}
+
+// NewVaults creates Vaults.
+func NewVaults(opts ...Option) *Vaults {
+	v := &Vaults{conversationKey: DefaultConversationKey, vaults: map[string]*Vault{}}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Vault returns the vault of the conversation, creating it if absent.
+func (v *Vaults) Vault(conversationID string) *Vault {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	vault, ok := v.vaults[conversationID]
+	if !ok {
+		vault = NewVault(v.patterns...)
+		v.vaults[conversationID] = vault
+	}
+	return vault
+}
+
+// Delete removes the vault of the conversation, eg. when the conversation ends.
+func (v *Vaults) Delete(conversationID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.vaults, conversationID)
+}
+
+// Middleware tokenizes the requests and restores the responses with the vault of the conversation
+// in the request metadata. Requests without a conversation ID use a vault of the request only.
+func (v *Vaults) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			vault := NewVault(v.patterns...)
+			if id := strings.TrimSpace(req.Metadata[v.conversationKey]); id != "" {
+				vault = v.Vault(id)
+			}
+			return vault.Middleware()(next)(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package pii
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestVault(t *testing.T) {
+	v := NewVault()
+	text := "Mail alice@example.com or call +1 415-555-0132. Card 4111 1111 1111 1111 from 192.168.0.1."
+	got := v.Tokenize(text)
+	want := "Mail [EMAIL_1] or call [PHONE_1]. Card [CARD_1] from [IP_1]."
+	if got != want {
+		t.Errorf("tokenize mismatch:\n got: %s\nwant: %s", got, want)
+	}
+	if got := v.Tokenize("bob@example.com and alice@example.com"); got != "[EMAIL_2] and [EMAIL_1]" {
+		t.Errorf("tokens should be stable: %s", got)
+	}
+	if got := v.Restore("Sent to [EMAIL_1], not [EMAIL_9]."); got != "Sent to alice@example.com, not [EMAIL_9]." {
+		t.Errorf("restore mismatch: %s", got)
+	}
+	if v.Len() != 5 {
+		t.Errorf("len mismatch: %d", v.Len())
+	}
+}
+
+func TestVaultIPAddresses(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"server 192.168.100.200", "server [IP_1]"},
+		{"from 10.100.200.250 to 172.16.254.1.", "from [IP_1] to [IP_2]."},
+		{"call 555.123.4567", "call [PHONE_1]"},
+		{"version 1.2.100.200.300", "version 1.2.100.200.300"},
+	}
+	for _, tt := range tests {
+		if got := NewVault().Tokenize(tt.text); got != tt.want {
+			t.Errorf("tokenize %q: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestVaultsMiddleware(t *testing.T) {
+	var got *chat.Request
+	next := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleAI, "I will email [EMAIL_1]."),
+			chat.NewToolCallMessage("send_mail", "call_1", `{"to": "[EMAIL_1]"}`),
+		}}, nil
+	}
+	vaults := NewVaults()
+	generate := vaults.Middleware()(next)
+
+	req := &chat.Request{
+		Metadata: chat.Metadata{DefaultConversationKey: "c1"},
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Email alice@example.com")},
+	}
+	resp, err := generate(t.Context(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Messages[0].ContentString() != "Email [EMAIL_1]" {
+		t.Errorf("request should be tokenized: %s", got.Messages[0].ContentString())
+	}
+	if req.Messages[0].ContentString() != "Email alice@example.com" {
+		t.Errorf("request should not be modified: %s", req.Messages[0].ContentString())
+	}
+	if resp.Text() != "I will email alice@example.com." || !strings.Contains(resp.FirstToolCall().Arguments, "alice@example.com") {
+		t.Errorf("response should be restored: %+v", resp)
+	}
+
+	// the next turn of the conversation keeps the token.
+	req.Messages = append(req.Messages, chat.NewTextMessage(chat.MessageRoleHuman, "cc bob@example.com and alice@example.com"))
+	if _, err := generate(t.Context(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got.Messages[1].ContentString() != "cc [EMAIL_2] and [EMAIL_1]" {
+		t.Errorf("tokens should be kept across turns: %s", got.Messages[1].ContentString())
+	}
+
+	vaults.Delete("c1")
+	if vaults.Vault("c1").Len() != 0 {
+		t.Error("vault should be deleted")
+	}
+}
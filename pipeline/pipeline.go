@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package pipeline composes multi-step prompt chains with typed inputs and outputs.
+//
+//	step := pipeline.Then(
+//		pipeline.Generate("gpt-4o-mini", summarize),
+//		pipeline.Then(pipeline.Text(), pipeline.Generate("claude-3-5-haiku-latest", translate)),
+//	)
+//	out, usage, err := pipeline.Run(ctx, step, document)
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Step is a pipeline step transforming In to Out.
+type Step[In, Out any] func(ctx context.Context, s *State, in In) (Out, error)
+
+// State is shared across the steps of a run.
+type State struct {
+	mu       sync.Mutex
+	usage    chat.Usage
+	values   map[string]any
+	generate gengo.GenerateFunc
+}
+
+// RunOption configures a pipeline run.
+type RunOption func(s *State)
+
+// WithGenerator replaces the generate function used by Generate steps.
+func WithGenerator(fn gengo.GenerateFunc) RunOption {
+	return func(s *State) {
+		s.generate = fn
+	}
+}
+
+// WithValue sets a shared value before the run.
+func WithValue(key string, value any) RunOption {
+	return func(s *State) {
+		s.values[key] = value
+	}
+}
+
+// Run runs the step with the input and returns the output and combined usage of all steps.
+func Run[In, Out any](ctx context.Context, step Step[In, Out], in In, opts ...RunOption) (Out, chat.Usage, error) {
+	s := &State{
+		values:   map[string]any{},
+		generate: gengo.Generate,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	out, err := step(ctx, s, in)
+	return out, s.Usage(), err
+}
+
+// Usage returns the combined usage so far.
+func (s *State) Usage() chat.Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// AddUsage adds usage to the combined usage.
+func (s *State) AddUsage(usage *chat.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.Add(usage)
+}
+
+// Set sets a shared value.
+func (s *State) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Get returns a shared value.
+func (s *State) Get(key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Then composes two steps.
+func Then[A, B, C any](first Step[A, B], next Step[B, C]) Step[A, C] {
+	return func(ctx context.Context, s *State, in A) (C, error) {
+		mid, err := first(ctx, s, in)
+		if err != nil {
+			var zero C
+			return zero, err
+		}
+		return next(ctx, s, mid)
+	}
+}
+
+// Named wraps step errors with the step name.
+func Named[In, Out any](name string, step Step[In, Out]) Step[In, Out] {
+	return func(ctx context.Context, s *State, in In) (Out, error) {
+		out, err := step(ctx, s, in)
+		if err != nil {
+			return out, fmt.Errorf("step %s: %w", name, err)
+		}
+		return out, nil
+	}
+}
+
+// Transform creates a step from a plain function.
+func Transform[In, Out any](fn func(in In) (Out, error)) Step[In, Out] {
+	return func(ctx context.Context, s *State, in In) (Out, error) {
+		return fn(in)
+	}
+}
+
+// Generate creates a step that generates a response with the model.
+// build converts the input to request messages.
+func Generate[In any](model string, build func(in In) ([]chat.Message, error), opts ...chat.Option) Step[In, *chat.Response] {
+	return GenerateRequest(func(in In) (*chat.Request, error) {
+		msgs, err := build(in)
+		if err != nil {
+			return nil, err
+		}
+		return &chat.Request{Model: model, Messages: msgs}, nil
+	}, opts...)
+}
+
+// GenerateRequest creates a step that generates a response for the request built from the input.
+func GenerateRequest[In any](build func(in In) (*chat.Request, error), opts ...chat.Option) Step[In, *chat.Response] {
+	return func(ctx context.Context, s *State, in In) (*chat.Response, error) {
+		req, err := build(in)
+		if err != nil {
+			return nil, fmt.Errorf("build request: %w", err)
+		}
+		resp, err := s.generate(ctx, req, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("generate: %w", err)
+		}
+		s.AddUsage(resp.Usage)
+		return resp, nil
+	}
+}
+
+// Text creates a step that extracts the text content of the response.
+func Text() Step[*chat.Response, string] {
+	return func(ctx context.Context, s *State, resp *chat.Response) (string, error) {
+		return resp.Text(), nil
+	}
+}
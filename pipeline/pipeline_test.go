@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func echoGenerator(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	text := req.Model + ":" + req.Messages[len(req.Messages)-1].ContentString()
+	return &chat.Response{
+		Model:    req.Model,
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)},
+		Usage:    &chat.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, Cost: 0.1},
+	}, nil
+}
+
+func prompt(in string) ([]chat.Message, error) {
+	return []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, in)}, nil
+}
+
+func TestRun(t *testing.T) {
+	step := Then(
+		Then(Generate("model-a", prompt), Text()),
+		Then(
+			Transform(func(in string) (string, error) { return strings.ToUpper(in), nil }),
+			Then(Generate("model-b", prompt), Text()),
+		),
+	)
+
+	out, usage, err := Run(t.Context(), step, "hello", WithGenerator(echoGenerator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "model-b:MODEL-A:HELLO" {
+		t.Errorf("output mismatch: %s", out)
+	}
+	if usage.TotalTokens != 30 || usage.InputTokens != 20 {
+		t.Errorf("usage mismatch: %+v", usage)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	errFailed := errors.New("failed")
+	step := Then(
+		Named("fail", Transform(func(in string) (string, error) { return "", errFailed })),
+		Generate("model-a", prompt),
+	)
+
+	_, _, err := Run(t.Context(), step, "hello", WithGenerator(echoGenerator))
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("expected error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "step fail") {
+		t.Errorf("error should contain step name: %v", err)
+	}
+}
+
+func TestState(t *testing.T) {
+	step := func(ctx context.Context, s *State, in string) (string, error) {
+		v, _ := s.Get("prefix")
+		s.Set("seen", in)
+		return v.(string) + in, nil
+	}
+	out, _, err := Run(t.Context(), step, "world", WithValue("prefix", "hello "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "hello world" {
+		t.Errorf("output mismatch: %s", out)
+	}
+}
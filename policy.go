@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Policy constrains the requests of a Client, eg. set by a platform team for the client handed to an app team.
+// Empty fields are not enforced.
+type Policy struct {
+	// AllowedModels are the allowed model names or path.Match patterns, eg. "gpt-4o*".
+	AllowedModels []string
+	// AllowedTools are the names of the tools requests may declare.
+	AllowedTools []string
+	// MaxBudget is the max cost of a request in USD, enforced with chat.WithMaxCost.
+	// A lower max cost of the call options is kept.
+	MaxBudget float64
+	// AllowedContentTypes are the allowed message content part types, eg. "text" and "image".
+	AllowedContentTypes []string
+}
+
+// Policy rules of PolicyViolationError.
+const (
+	PolicyRuleModel       = "model"
+	PolicyRuleTool        = "tool"
+	PolicyRuleContentType = "content_type"
+)
+
+// ErrPolicyViolation is matched by errors.Is for all PolicyViolationError.
+var ErrPolicyViolation = errors.New("policy violation")
+
+// PolicyViolationError is returned when a request violates the Policy of the Client.
+// Requests exceeding MaxBudget return *chat.MaxCostError instead.
+type PolicyViolationError struct {
+	// Rule is the violated rule, eg. PolicyRuleModel.
+	Rule string
+	// Value is the value not allowed, eg. the model name.
+	Value string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return fmt.Sprintf("policy violation: %s %q is not allowed", e.Rule, e.Value)
+}
+
+func (e *PolicyViolationError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// WithPolicy sets the policy enforced on every request of the client.
+func WithPolicy(policy Policy) ClientOption {
+	return func(c *Client) {
+		c.Policy = &policy
+	}
+}
+
+type builtinToolsKey struct{}
+
+// contextWithBuiltinTools returns a context with the names of the tools added by gengo, eg. the ask_user tool
+// of Run and the tools of GenerateVariant, which the AllowedTools of the policy do not apply to.
+func contextWithBuiltinTools(ctx context.Context, names ...string) context.Context {
+	names = append(slices.Clone(builtinTools(ctx)), names...)
+	return context.WithValue(ctx, builtinToolsKey{}, names)
+}
+
+func builtinTools(ctx context.Context) []string {
+	names, _ := ctx.Value(builtinToolsKey{}).([]string)
+	return names
+}
+
+// Check returns *PolicyViolationError if the request violates the policy.
+func (p *Policy) Check(req *chat.Request) error {
+	return p.check(req, nil)
+}
+
+// check checks the request, except the tools of the builtin names.
+func (p *Policy) check(req *chat.Request, builtin []string) error {
+	if len(p.AllowedModels) > 0 && !slices.ContainsFunc(p.AllowedModels, func(pattern string) bool {
+		ok, _ := path.Match(pattern, req.Model)
+		return ok
+	}) {
+		return &PolicyViolationError{Rule: PolicyRuleModel, Value: req.Model}
+	}
+	if len(p.AllowedTools) > 0 {
+		for _, tool := range req.Tools {
+			if !slices.Contains(p.AllowedTools, tool.Name) && !slices.Contains(builtin, tool.Name) {
+				return &PolicyViolationError{Rule: PolicyRuleTool, Value: tool.Name}
+			}
+		}
+	}
+	if len(p.AllowedContentTypes) > 0 {
+		for _, msg := range req.Messages {
+			for _, part := range msg.Content {
+				if !slices.Contains(p.AllowedContentTypes, part.Type) {
+					return &PolicyViolationError{Rule: PolicyRuleContentType, Value: part.Type}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Options returns the options enforcing the policy, applied after the call options.
+// Requests are checked by a middleware at each provider call, so agent runs and
+// middlewares rewriting the request are checked too. The tools added by gengo,
+// the ask_user tool of chat.WithClarification and the tools of GenerateVariant, are not checked.
+func (p *Policy) Options() []chat.Option {
+	opts := []chat.Option{chat.WithMiddleware(func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if err := p.check(req, builtinTools(ctx)); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	})}
+	if p.MaxBudget > 0 {
+		opts = append(opts, func(o *chat.Options) {
+			if o.MaxCost <= 0 || o.MaxCost > p.MaxBudget {
+				o.MaxCost = p.MaxBudget
+			}
+		})
+	}
+	return opts
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{
+		AllowedModels:       []string{"gpt-4o*", "claude-3-5-haiku-latest"},
+		AllowedTools:        []string{"search"},
+		AllowedContentTypes: []string{"text"},
+	}
+	hello := []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hello")}
+	tests := []struct {
+		req  *chat.Request
+		rule string
+	}{
+		{&chat.Request{Model: "gpt-4o-mini", Messages: hello, Tools: []chat.Tool{{Name: "search"}}}, ""},
+		{&chat.Request{Model: "claude-3-5-haiku-latest", Messages: hello}, ""},
+		{&chat.Request{Model: "gpt-4.1", Messages: hello}, PolicyRuleModel},
+		{&chat.Request{Model: "gpt-4o", Messages: hello, Tools: []chat.Tool{{Name: "shell"}}}, PolicyRuleTool},
+		{&chat.Request{Model: "gpt-4o", Messages: []chat.Message{{Role: chat.MessageRoleHuman, Content: []chat.ContentPart{{Type: "image", URL: "data:image/png;base64,AA=="}}}}}, PolicyRuleContentType},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.req)
+		var violation *PolicyViolationError
+		if tt.rule == "" && err != nil || tt.rule != "" && (!errors.As(err, &violation) || violation.Rule != tt.rule) {
+			t.Errorf("%s: err = %v, want rule %q", tt.req.Model, err, tt.rule)
+		}
+	}
+	if err := (&Policy{}).Check(&chat.Request{Model: "any"}); err != nil {
+		t.Errorf("empty policy should allow all: %v", err)
+	}
+}
+
+func TestClientPolicy(t *testing.T) {
+	var maxCost float64
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		o := chat.NewOptions(opts...)
+		maxCost = o.MaxCost
+		return o.Wrap(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			return &chat.Response{Model: req.Model}, nil
+		})(ctx, req)
+	})
+	c := NewClient(WithDefaultModel("gpt-4o-mini"), WithPolicy(Policy{AllowedModels: []string{"gpt-4o-mini"}, MaxBudget: 0.1}))
+
+	if _, err := c.Generate(context.Background(), &chat.Request{}, chat.WithMaxCost(1)); err != nil {
+		t.Fatal(err)
+	}
+	if maxCost != 0.1 {
+		t.Errorf("max cost should be capped by the budget: %g", maxCost)
+	}
+	if _, err := c.Generate(context.Background(), &chat.Request{}, chat.WithMaxCost(0.01)); err != nil || maxCost != 0.01 {
+		t.Errorf("lower max cost should be kept: %g, %v", maxCost, err)
+	}
+	_, err := c.Generate(context.Background(), &chat.Request{Model: "gpt-4.1"})
+	if !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("expected policy violation: %v", err)
+	}
+}
+
+func TestPolicyBuiltinTools(t *testing.T) {
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		return chat.NewOptions(opts...).Wrap(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "done")}}, nil
+		})(ctx, req)
+	})
+	c := NewClient(WithDefaultModel("gpt-4o-mini"), WithPolicy(Policy{AllowedTools: []string{"search"}}))
+	req := &chat.Request{Tools: []chat.Tool{{Name: "search"}}}
+
+	// the ask_user tool added by the run is not a tool of the request.
+	if _, err := c.Run(context.Background(), req, nil, chat.WithClarification()); err != nil {
+		t.Errorf("ask_user should be exempted: %v", err)
+	}
+	req.Tools = append(req.Tools, chat.Tool{Name: chat.AskUserToolName})
+	if _, err := c.Generate(context.Background(), req); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("declared tools should be checked: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jumonmd/gengo/anthropic"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/google"
+	"github.com/jumonmd/gengo/openai"
+)
+
+// DefaultProviderProfiles are the timeout and retry profiles tuned per provider.
+// Override them with chat.WithProviderProfile, or set nil at startup for no timeouts and retries.
+var DefaultProviderProfiles = map[string]chat.ProviderProfile{
+	// Flex service tier requests of batch priority wait in the queue longer.
+	"openai": {
+		Timeout:       2 * time.Minute,
+		StreamTimeout: 10 * time.Minute,
+		BatchTimeout:  15 * time.Minute,
+		MaxRetries:    2,
+		RetryBackoff:  time.Second,
+		ClassBackoff:  map[chat.RetryClass]time.Duration{chat.RetryClassRateLimit: 5 * time.Second},
+		Classify:      openai.Classify,
+		MaxResumes:    2,
+	},
+	// Anthropic returns overloaded_error (529) under load, which recovers after a longer backoff.
+	"anthropic": {
+		Timeout:       5 * time.Minute,
+		StreamTimeout: 10 * time.Minute,
+		MaxRetries:    3,
+		RetryBackoff:  2 * time.Second,
+		ClassBackoff: map[chat.RetryClass]time.Duration{
+			chat.RetryClassRateLimit:  5 * time.Second,
+			chat.RetryClassOverloaded: 5 * time.Second,
+		},
+		Classify:   anthropic.Classify,
+		MaxResumes: 2,
+	},
+	// Gemini thinking models can stream for a long time before the first chunk.
+	// RESOURCE_EXHAUSTED of per-minute limits recovers in the next minute window.
+	"gemini": {
+		Timeout:       5 * time.Minute,
+		StreamTimeout: 20 * time.Minute,
+		MaxRetries:    2,
+		RetryBackoff:  time.Second,
+		ClassBackoff: map[chat.RetryClass]time.Duration{
+			chat.RetryClassRateLimit:  10 * time.Second,
+			chat.RetryClassOverloaded: 5 * time.Second,
+		},
+		Classify:   google.Classify,
+		MaxResumes: 2,
+	},
+}
+
+// ClassifyError returns the retry class of an error of any provider.
+// Timeouts of the attempts, eg. of the provider profile timeouts, are transient.
+func ClassifyError(err error) chat.RetryClass {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return chat.RetryClassTransient
+	}
+	for _, classify := range []func(err error) chat.RetryClass{openai.Classify, anthropic.Classify, google.Classify} {
+		if class := classify(err); class != chat.RetryClassNone {
+			return class
+		}
+	}
+	return chat.RetryClassNone
+}
+
+// streamed is the output streamed by an attempt.
+type streamed struct {
+	// chunks is the number of the streamed chunks, and text is the text of the text chunks.
+	chunks int
+	text   string
+	// toolCalls is true if tool call deltas have been streamed.
+	toolCalls bool
+}
+
+// generateWithProfile calls generate with the timeout and retries of the profile.
+// generate returns the output streamed before the error. Streaming requests are not retried
+// once a chunk has been streamed, but resumed up to MaxResumes by re-prompting the model
+// to continue the streamed text. Requests with ResponseSchema or streamed tool calls are not resumed.
+// The Retry-After of the provider response is waited for instead of the backoff if recorded.
+// The number of attempts is set in the response metadata.
+func generateWithProfile(ctx context.Context, o *chat.Options, provider string, profile chat.ProviderProfile,
+	req *chat.Request, generate func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error),
+) (*chat.Response, error) {
+	timeout := profile.Timeout
+	if o.Streamer != nil {
+		timeout = profile.StreamTimeout
+	}
+	if o.Priority == chat.PriorityBatch && timeout > 0 {
+		timeout = max(timeout, profile.BatchTimeout)
+	}
+	var resumed string // text streamed by the dropped attempts
+	retries, resumes := 0, 0
+	for attempt := 1; ; attempt++ {
+		o.Emit(&chat.Event{Type: chat.EventAttempt, Model: req.Model, Provider: provider, Attempt: attempt})
+		r := req
+		if resumed != "" {
+			r = chat.ResumeRequest(req, resumed)
+		}
+		retryAfter := &chat.RetryAfter{}
+		resp, out, err := generateTimeout(chat.ContextWithRetryAfter(ctx, retryAfter), timeout, r, generate)
+		if err == nil {
+			if resumed != "" {
+				chat.PrependText(resp, resumed)
+			}
+			chat.SetAttempts(resp, attempt)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+		class := profile.Class(err)
+		if !class.Retryable() || retryAfter.Wait() > chat.MaxRetryAfter {
+			return resp, err
+		}
+
+		var wait time.Duration
+		if out.chunks > 0 {
+			if resumes >= profile.MaxResumes || req.ResponseSchema != nil || out.toolCalls || out.text == "" {
+				return resp, err
+			}
+			resumes++
+			resumed += out.text
+			o.Emit(&chat.Event{Type: chat.EventResume, Model: req.Model, Provider: provider, Attempt: attempt, Error: err.Error()})
+			wait = profile.Backoff(class, resumes)
+		} else {
+			if retries >= profile.MaxRetries {
+				return resp, err
+			}
+			retries++
+			o.Emit(&chat.Event{Type: chat.EventRetry, Model: req.Model, Provider: provider, Attempt: attempt, Error: err.Error()})
+			wait = profile.Backoff(class, retries)
+		}
+		if after := retryAfter.Wait(); after > 0 {
+			wait = after
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func generateTimeout(ctx context.Context, timeout time.Duration, req *chat.Request,
+	generate func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error),
+) (*chat.Response, streamed, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return generate(ctx, req)
+}
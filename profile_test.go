@@ -0,0 +1,196 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+var errOverloaded = errors.New("overloaded")
+
+func TestGenerateWithProfile(t *testing.T) {
+	var retries int
+	o := chat.NewOptions(chat.WithEventHandler(func(e *chat.Event) {
+		if e.Type == chat.EventRetry {
+			retries++
+		}
+	}))
+	profile := chat.ProviderProfile{
+		MaxRetries:   3,
+		RetryBackoff: time.Millisecond,
+		Retryable:    func(err error) bool { return errors.Is(err, errOverloaded) },
+	}
+
+	calls := 0
+	resp, err := generateWithProfile(context.Background(), o, "anthropic", profile, &chat.Request{Model: "m"},
+		func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+			calls++
+			if calls < 3 {
+				return nil, streamed{}, errOverloaded
+			}
+			return &chat.Response{Model: req.Model}, streamed{}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "m" || calls != 3 || retries != 2 {
+		t.Errorf("calls = %d, retries = %d", calls, retries)
+	}
+}
+
+func TestGenerateWithProfileNoRetry(t *testing.T) {
+	o := chat.NewOptions()
+	profile := chat.ProviderProfile{MaxRetries: 3, Retryable: func(error) bool { return true }}
+
+	tests := []struct {
+		name     string
+		streamed streamed
+		err      error
+		profile  chat.ProviderProfile
+	}{
+		{name: "streamed", streamed: streamed{chunks: 1, text: "partial"}, err: errOverloaded, profile: profile},
+		{name: "tool calls", streamed: streamed{chunks: 1, toolCalls: true}, err: errOverloaded,
+			profile: chat.ProviderProfile{MaxRetries: 3, MaxResumes: 3, Retryable: profile.Retryable}},
+		{name: "not retryable", err: errOverloaded, profile: chat.ProviderProfile{MaxRetries: 3}},
+		{name: "no retries", err: errOverloaded, profile: chat.ProviderProfile{Retryable: profile.Retryable}},
+	}
+	for _, tt := range tests {
+		calls := 0
+		_, err := generateWithProfile(context.Background(), o, "openai", tt.profile, &chat.Request{},
+			func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+				calls++
+				return nil, tt.streamed, tt.err
+			})
+		if !errors.Is(err, errOverloaded) || calls != 1 {
+			t.Errorf("%s: calls = %d, err = %v", tt.name, calls, err)
+		}
+	}
+}
+
+func TestGenerateWithProfileTimeout(t *testing.T) {
+	profile := chat.ProviderProfile{Timeout: 10 * time.Millisecond}
+	_, err := generateWithProfile(context.Background(), chat.NewOptions(), "gemini", profile, &chat.Request{},
+		func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+			<-ctx.Done()
+			return nil, streamed{}, ctx.Err()
+		})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v", err)
+	}
+
+	// batch priority requests wait for the longer batch timeout.
+	profile.BatchTimeout = time.Hour
+	_, err = generateWithProfile(context.Background(), chat.NewOptions(chat.WithPriority(chat.PriorityBatch)), "openai", profile, &chat.Request{},
+		func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+			deadline, _ := ctx.Deadline()
+			if time.Until(deadline) < time.Minute {
+				t.Errorf("batch timeout should be applied: %v", time.Until(deadline))
+			}
+			return &chat.Response{}, streamed{}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateWithProfileResume(t *testing.T) {
+	var resumes int
+	o := chat.NewOptions(chat.WithEventHandler(func(e *chat.Event) {
+		if e.Type == chat.EventResume {
+			resumes++
+		}
+	}))
+	profile := chat.ProviderProfile{
+		MaxResumes:   2,
+		RetryBackoff: time.Millisecond,
+		Retryable:    func(err error) bool { return errors.Is(err, errOverloaded) },
+	}
+
+	chunks := []string{"Once upon ", "a time", " there was"}
+	var prompts []string
+	resp, err := generateWithProfile(context.Background(), o, "anthropic", profile,
+		&chat.Request{Model: "m", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Tell a story")}},
+		func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+			prompts = append(prompts, req.Messages[len(req.Messages)-1].ContentString())
+			if len(prompts) < len(chunks) {
+				return nil, streamed{chunks: 1, text: chunks[len(prompts)-1]}, errOverloaded
+			}
+			if got := req.Messages[len(req.Messages)-2].ContentString(); got != "Once upon a time" {
+				t.Errorf("streamed = %q", got)
+			}
+			return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, chunks[2])}}, streamed{chunks: 1, text: chunks[2]}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Text(); got != "Once upon a time there was" {
+		t.Errorf("text = %q", got)
+	}
+	if resumes != 2 || len(prompts) != 3 || prompts[0] != "Tell a story" || prompts[1] != chat.ResumePrompt {
+		t.Errorf("resumes = %d, prompts = %q", resumes, prompts)
+	}
+}
+
+func TestGenerateWithProfileRetryAfter(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("retry-after-ms", "20")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	// the backoff of an hour is replaced by the Retry-After of 20ms.
+	o := chat.NewOptions(chat.WithRetry(3, time.Hour))
+	profile := o.ProviderProfile("openai", chat.ProviderProfile{Retryable: func(err error) bool { return errors.Is(err, errOverloaded) }})
+	client := o.HTTPClient("openai")
+	resp, err := generateWithProfile(context.Background(), o, "openai", profile, &chat.Request{Model: "m"},
+		func(ctx context.Context, req *chat.Request) (*chat.Response, streamed, error) {
+			r, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			res, err := client.Do(r)
+			if err != nil {
+				return nil, streamed{}, err
+			}
+			res.Body.Close()
+			if res.StatusCode == http.StatusTooManyRequests {
+				return nil, streamed{}, errOverloaded
+			}
+			return &chat.Response{Model: req.Model}, streamed{}, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || resp.Metadata[chat.MetadataAttempts] != "2" {
+		t.Errorf("calls = %d, metadata = %v", calls, resp.Metadata)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want chat.RetryClass
+	}{
+		{fmt.Errorf("generate: %w", context.DeadlineExceeded), chat.RetryClassTransient},
+		{&openai.APIError{HTTPStatusCode: 429}, chat.RetryClassRateLimit},
+		{&openai.APIError{HTTPStatusCode: 400}, chat.RetryClassNone},
+		{errors.New("anthropic: overloaded_error"), chat.RetryClassOverloaded},
+		{errors.New("invalid request"), chat.RetryClassNone},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package prompt renders prompt templates with {{name}} variables.
+// Variables are escaped and delimited by their fence, so untrusted values such as user input
+// cannot close the delimiters and inject instructions. Only variables declared as FenceRaw are inserted as is.
+// Variables declared as FenceImage or FenceFile are rendered into image or file content parts.
+//
+//	t, err := prompt.New("Summarize the document.\n{{document}}\nCompare with {{chart}}",
+//		prompt.WithVar("document", prompt.FenceXML), prompt.WithVar("chart", prompt.FenceImage), prompt.WithStrict(),
+//		prompt.WithFS(os.DirFS("assets")))
+//	msg, err := t.Message(chat.MessageRoleHuman, map[string]any{"document": untrusted, "chart": "chart.png"})
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+var (
+	// ErrUndeclaredVariable is returned in strict mode for variables not declared by WithVar.
+	ErrUndeclaredVariable = errors.New("undeclared variable")
+	// ErrMissingVariable is returned when a template variable has no value.
+	ErrMissingVariable = errors.New("missing variable")
+	// ErrNoFS is returned for image and file paths without WithFS.
+	ErrNoFS = errors.New("no file system for paths")
+)
+
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Fence is how a variable value is escaped and delimited.
+type Fence string
+
+const (
+	// FenceXML wraps the value in a tag of the variable name with XML escaping, eg. <document>a &lt; b</document>.
+	FenceXML Fence = "xml"
+	// FenceQuote quotes the value as a JSON string.
+	FenceQuote Fence = "quote"
+	// FenceJSON encodes the value as JSON in a ```json code block.
+	FenceJSON Fence = "json"
+	// FenceRaw inserts the value as is. Use only for trusted values.
+	FenceRaw Fence = "raw"
+	// FenceImage renders the value into an image content part.
+	// Values are a path in the file system of WithFS, an http(s) or data URL, []byte or chat.ContentPart.
+	FenceImage Fence = "image"
+	// FenceFile renders the value into a file content part, eg. a PDF. Values are the same as FenceImage.
+	FenceFile Fence = "file"
+)
+
+// Template is a parsed prompt template.
+type Template struct {
+	text   string
+	names  []string
+	vars   map[string]Fence
+	fence  Fence
+	strict bool
+	fsys   fs.FS
+}
+
+// Option configures Template.
+type Option func(t *Template)
+
+// WithVar declares the variable with the fence.
+func WithVar(name string, fence Fence) Option {
+	return func(t *Template) {
+		t.vars[name] = fence
+	}
+}
+
+// WithDefaultFence sets the fence of undeclared variables. FenceXML by default.
+func WithDefaultFence(fence Fence) Option {
+	return func(t *Template) {
+		t.fence = fence
+	}
+}
+
+// WithStrict returns ErrUndeclaredVariable for template variables and values not declared by WithVar.
+func WithStrict() Option {
+	return func(t *Template) {
+		t.strict = true
+	}
+}
+
+// WithFS sets the file system of the image and file paths, eg. os.DirFS("assets").
+// Paths are rejected without it, so values from users cannot read local files.
+// Absolute paths and paths with ".." are rejected by the file system.
+func WithFS(fsys fs.FS) Option {
+	return func(t *Template) {
+		t.fsys = fsys
+	}
+}
+
+// New parses the template text.
+func New(text string, opts ...Option) (*Template, error) {
+	t := &Template{text: text, vars: map[string]Fence{}, fence: FenceXML}
+	for _, opt := range opts {
+		opt(t)
+	}
+	for _, fence := range append(slices.Collect(maps.Values(t.vars)), t.fence) {
+		if !validFence(fence) {
+			return nil, fmt.Errorf("invalid fence: %q", fence)
+		}
+	}
+	for _, m := range varPattern.FindAllStringSubmatch(text, -1) {
+		name := m[1]
+		if _, ok := t.vars[name]; !ok && t.strict {
+			return nil, fmt.Errorf("%w: %s", ErrUndeclaredVariable, name)
+		}
+		if !slices.Contains(t.names, name) {
+			t.names = append(t.names, name)
+		}
+	}
+	return t, nil
+}
+
+// Must is a helper that wraps a call to New and panics if the error is non-nil.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Variables returns the variable names in the template in order of appearance.
+func (t *Template) Variables() []string {
+	return slices.Clone(t.names)
+}
+
+// Render returns the text with the variables replaced by the fenced values.
+// Values other than strings are formatted with fmt.Sprint, or encoded as JSON by FenceJSON.
+// Templates with image or file variables are rendered by Parts.
+func (t *Template) Render(values map[string]any) (string, error) {
+	for _, name := range t.names {
+		if isMedia(t.fenceOf(name)) {
+			return "", fmt.Errorf("variable %s is %s, render with Parts", name, t.fenceOf(name))
+		}
+	}
+	parts, err := t.Parts(values)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	// without image and file variables, the text is a single part.
+	return parts[0].Text, nil
+}
+
+// Parts renders the template into content parts. The text around image and file variables
+// is split into text parts.
+func (t *Template) Parts(values map[string]any) ([]chat.ContentPart, error) {
+	if t.strict {
+		for name := range values {
+			if _, ok := t.vars[name]; !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUndeclaredVariable, name)
+			}
+		}
+	}
+	var parts []chat.ContentPart
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, chat.ContentPart{Type: "text", Text: text.String()})
+			text.Reset()
+		}
+	}
+	last := 0
+	for _, m := range varPattern.FindAllStringSubmatchIndex(t.text, -1) {
+		text.WriteString(t.text[last:m[0]])
+		last = m[1]
+		name := t.text[m[2]:m[3]]
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrMissingVariable, name)
+		}
+		if fence := t.fenceOf(name); isMedia(fence) {
+			part, err := t.mediaPart(name, fence, value)
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			parts = append(parts, part)
+			continue
+		}
+		fenced, err := t.fenceValue(name, value)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(fenced)
+	}
+	text.WriteString(t.text[last:])
+	flush()
+	return parts, nil
+}
+
+// Message renders the template into a message of the role.
+func (t *Template) Message(role chat.MessageRole, values map[string]any) (chat.Message, error) {
+	parts, err := t.Parts(values)
+	if err != nil {
+		return chat.Message{}, err
+	}
+	return chat.Message{Role: role, Content: parts}, nil
+}
+
+func (t *Template) fenceOf(name string) Fence {
+	if fence, ok := t.vars[name]; ok {
+		return fence
+	}
+	return t.fence
+}
+
+func (t *Template) fenceValue(name string, value any) (string, error) {
+	switch t.fenceOf(name) {
+	case FenceQuote:
+		return Quote(fmt.Sprint(value)), nil
+	case FenceJSON:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("encode variable %s: %w", name, err)
+		}
+		// backticks cannot close the code block.
+		return "```json\n" + strings.ReplaceAll(string(b), "`", `\u0060`) + "\n```", nil
+	case FenceRaw:
+		return fmt.Sprint(value), nil
+	}
+	return XML(name, fmt.Sprint(value)), nil
+}
+
+// mediaPart returns the image or file content part of the value.
+func (t *Template) mediaPart(name string, fence Fence, value any) (chat.ContentPart, error) {
+	partType := string(fence)
+	switch v := value.(type) {
+	case chat.ContentPart:
+		return v, nil
+	case []byte:
+		return chat.ContentPart{Type: partType, DataURL: chat.EncodeDataURL(http.DetectContentType(v), v)}, nil
+	case string:
+		if chat.IsDataURL(v) {
+			return chat.ContentPart{Type: partType, DataURL: v}, nil
+		}
+		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			return chat.NewURLPart(partType, v), nil
+		}
+		if t.fsys == nil {
+			return chat.ContentPart{}, fmt.Errorf("read variable %s: %w", name, ErrNoFS)
+		}
+		data, err := fs.ReadFile(t.fsys, v)
+		if err != nil {
+			return chat.ContentPart{}, fmt.Errorf("read variable %s: %w", name, err)
+		}
+		mimeType := mime.TypeByExtension(path.Ext(v))
+		if mimeType == "" {
+			mimeType = http.DetectContentType(data)
+		}
+		return chat.ContentPart{Type: partType, DataURL: chat.EncodeDataURL(mimeType, data)}, nil
+	}
+	return chat.ContentPart{}, fmt.Errorf("unsupported value of %s variable %s: %T", fence, name, value)
+}
+
+// xmlEscaper escapes only the markup characters, so quotes and newlines stay readable to the model.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// XML wraps the escaped text in the tag.
+func XML(tag, text string) string {
+	return "<" + tag + ">" + xmlEscaper.Replace(text) + "</" + tag + ">"
+}
+
+// Quote quotes the text as a JSON string.
+func Quote(text string) string {
+	b, _ := json.Marshal(text)
+	return string(b)
+}
+
+func validFence(fence Fence) bool {
+	switch fence {
+	case FenceXML, FenceQuote, FenceJSON, FenceRaw, FenceImage, FenceFile:
+		return true
+	}
+	return false
+}
+
+func isMedia(fence Fence) bool {
+	return fence == FenceImage || fence == FenceFile
+}
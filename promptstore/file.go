@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package promptstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// currentFile pins the current version of a prompt.
+const currentFile = "current"
+
+// FileStore stores prompts as files, <dir>/<name>/<version>.<ext>, eg. prompts/support/v2.md.
+// The current version is pinned by <dir>/<name>/current, or is the latest version if not pinned.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in the directory.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Get(_ context.Context, name, version string) (*Prompt, error) {
+	if !filepath.IsLocal(name) || (version != "" && !filepath.IsLocal(version)) {
+		return nil, fmt.Errorf("invalid prompt name or version: %s@%s", name, version)
+	}
+	if version == "" {
+		v, err := s.Current(name)
+		if err != nil {
+			return nil, err
+		}
+		version = v
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.dir, name, globEscape(version)+".*"))
+	if err != nil {
+		return nil, fmt.Errorf("find prompt: %w", err)
+	}
+	// v1.* also matches v1.2.md, so only the files of the exact version are kept.
+	matches = slices.DeleteFunc(matches, func(m string) bool {
+		base := filepath.Base(m)
+		return strings.TrimSuffix(base, filepath.Ext(base)) != version
+	})
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("ambiguous prompt version %s@%s: %d files", name, version, len(matches))
+	}
+	text, err := os.ReadFile(matches[0])
+	if err != nil {
+		return nil, fmt.Errorf("read prompt: %w", err)
+	}
+	return &Prompt{Name: name, Version: version, Text: string(text)}, nil
+}
+
+// Versions returns the versions of the prompt.
+func (s *FileStore) Versions(name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read prompt dir: %w", err)
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == currentFile {
+			continue
+		}
+		versions = append(versions, strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())))
+	}
+	return versions, nil
+}
+
+// Current returns the pinned version of the prompt, or the latest version if not pinned.
+func (s *FileStore) Current(name string) (string, error) {
+	pinned, err := os.ReadFile(filepath.Join(s.dir, name, currentFile))
+	if err == nil {
+		return strings.TrimSpace(string(pinned)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("read current version: %w", err)
+	}
+	versions, err := s.Versions(name)
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return LatestVersion(versions), nil
+}
+
+// SetCurrent pins the current version of the prompt, eg. to roll out or roll back.
+func (s *FileStore) SetCurrent(name, version string) error {
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid prompt name: %s", name)
+	}
+	versions, err := s.Versions(name)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(versions, version) {
+		return fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, name, currentFile), []byte(version+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write current version: %w", err)
+	}
+	return nil
+}
+
+func globEscape(s string) string {
+	r := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return r.Replace(s)
+}
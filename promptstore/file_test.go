@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package promptstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "support"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for v, text := range map[string]string{"v1": "first", "v1.2": "patched", "v2": "second"} {
+		if err := os.WriteFile(filepath.Join(dir, "support", v+".md"), []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	store := NewFileStore(dir)
+	p, err := store.Get(ctx, "support", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != "v2" || p.Text != "second" {
+		t.Errorf("current should be the latest version: %+v", p)
+	}
+
+	// roll back
+	if err := store.SetCurrent("support", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	p, err = store.Get(ctx, "support", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Version != "v1" || p.Text != "first" {
+		t.Errorf("current should be pinned: %+v", p)
+	}
+
+	p, err = store.Get(ctx, "support", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Text != "first" {
+		t.Errorf("v1 should not match v1.2: %+v", p)
+	}
+	if _, err := store.Get(ctx, "support", "v3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.SetCurrent("support", "v3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Get(ctx, "../support", ""); err == nil {
+		t.Error("expected error for non-local name")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "support", "v2.txt"), []byte("other"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "support", "v2"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ambiguous version error, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package promptstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPStore gets prompts from an HTTP service.
+// GET <baseURL>/<name>/<version> returns the version and GET <baseURL>/<name> the current version,
+// as a JSON Prompt. Not found is status 404.
+type HTTPStore struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPStore creates an HTTPStore. http.DefaultClient is used if client is nil.
+func NewHTTPStore(baseURL string, client *http.Client) *HTTPStore {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPStore{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (s *HTTPStore) Get(ctx context.Context, name, version string) (*Prompt, error) {
+	u := s.baseURL + "/" + url.PathEscape(name)
+	if version != "" {
+		u += "/" + url.PathEscape(version)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get prompt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get prompt: status %d", resp.StatusCode)
+	}
+	var prompt Prompt
+	if err := json.NewDecoder(resp.Body).Decode(&prompt); err != nil {
+		return nil, fmt.Errorf("decode prompt: %w", err)
+	}
+	if prompt.Name == "" {
+		prompt.Name = name
+	}
+	return &prompt, nil
+}
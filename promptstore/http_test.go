@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package promptstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/prompts/support":
+			_ = json.NewEncoder(w).Encode(Prompt{Version: "v2", Text: "current"})
+		case "/prompts/support/v1":
+			_ = json.NewEncoder(w).Encode(Prompt{Name: "support", Version: "v1", Text: "first"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := NewHTTPStore(srv.URL+"/prompts/", nil)
+	p, err := store.Get(ctx, "support", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "support" || p.Version != "v2" || p.Text != "current" {
+		t.Errorf("prompt mismatch: %+v", p)
+	}
+	p, err = store.Get(ctx, "support", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Text != "first" {
+		t.Errorf("prompt mismatch: %+v", p)
+	}
+	if _, err := store.Get(ctx, "missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
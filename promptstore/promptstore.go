@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package promptstore resolves versioned prompts referenced by Request.PromptRef,
+// so prompts can be rolled out and rolled back independently of binaries.
+//
+//	store := promptstore.NewFileStore("prompts")
+//	req.PromptRef = &chat.PromptRef{Name: "support"}
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(promptstore.Middleware(store)))
+//	version := resp.Metadata[promptstore.MetadataVersion]
+package promptstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+const (
+	// MetadataName is the metadata key of the resolved prompt name.
+	MetadataName = "prompt_name"
+	// MetadataVersion is the metadata key of the resolved prompt version.
+	MetadataVersion = "prompt_version"
+)
+
+// ErrNotFound is returned when the prompt or the version does not exist.
+var ErrNotFound = errors.New("prompt not found")
+
+// Prompt is a version of a named prompt.
+type Prompt struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Text    string `json:"text"`
+}
+
+// Store gets prompts. An empty version gets the current version.
+type Store interface {
+	Get(ctx context.Context, name, version string) (*Prompt, error)
+}
+
+// Resolve returns a copy of the request with the referenced prompt prepended as a system message
+// and the resolved name and version in the metadata.
+// The request is returned as is if it has no PromptRef.
+func Resolve(ctx context.Context, store Store, req *chat.Request) (*chat.Request, error) {
+	if req.PromptRef == nil {
+		return req, nil
+	}
+	prompt, err := store.Get(ctx, req.PromptRef.Name, req.PromptRef.Version)
+	if err != nil {
+		return nil, fmt.Errorf("get prompt %s: %w", req.PromptRef.Name, err)
+	}
+
+	r := *req
+	r.PromptRef = nil
+	r.Messages = append([]chat.Message{chat.NewTextMessage(chat.MessageRoleSystem, prompt.Text)}, req.Messages...)
+	r.Metadata = maps.Clone(req.Metadata)
+	if r.Metadata == nil {
+		r.Metadata = chat.Metadata{}
+	}
+	r.Metadata[MetadataName] = prompt.Name
+	r.Metadata[MetadataVersion] = prompt.Version
+	return &r, nil
+}
+
+// Middleware resolves Request.PromptRef and records the resolved version in the response metadata.
+func Middleware(store Store) chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			resolved, err := Resolve(ctx, store, req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next(ctx, resolved)
+			if err != nil || req.PromptRef == nil {
+				return resp, err
+			}
+			if resp.Metadata == nil {
+				resp.Metadata = chat.Metadata{}
+			}
+			resp.Metadata[MetadataName] = resolved.Metadata[MetadataName]
+			resp.Metadata[MetadataVersion] = resolved.Metadata[MetadataVersion]
+			return resp, nil
+		}
+	}
+}
+
+// LatestVersion returns the highest version, comparing dot separated numbers, eg. "v1.10" > "v1.9".
+func LatestVersion(versions []string) string {
+	if len(versions) == 0 {
+		return ""
+	}
+	return slices.MaxFunc(versions, compareVersions)
+}
+
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := range min(len(as), len(bs)) {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr != nil || berr != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if an != bn {
+			return an - bn
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package promptstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+type mapStore map[string]*Prompt
+
+func (s mapStore) Get(_ context.Context, name, version string) (*Prompt, error) {
+	p, ok := s[name+"@"+version]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return p, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	store := mapStore{"support@": {Name: "support", Version: "v2", Text: "You are a support agent."}}
+
+	var got *chat.Request
+	generate := Middleware(store)(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{}, nil
+	})
+
+	req := &chat.Request{
+		PromptRef: &chat.PromptRef{Name: "support"},
+		Metadata:  chat.Metadata{"tenant_id": "acme"},
+		Messages:  []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "hello")},
+	}
+	resp, err := generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != chat.MessageRoleSystem || got.PromptRef != nil {
+		t.Errorf("prompt not resolved: %+v", got)
+	}
+	if got.Metadata[MetadataVersion] != "v2" || got.Metadata["tenant_id"] != "acme" {
+		t.Errorf("metadata mismatch: %v", got.Metadata)
+	}
+	if resp.Metadata[MetadataName] != "support" || resp.Metadata[MetadataVersion] != "v2" {
+		t.Errorf("response metadata mismatch: %v", resp.Metadata)
+	}
+	if len(req.Messages) != 1 || len(req.Metadata) != 1 {
+		t.Error("original request should not be modified")
+	}
+
+	req.PromptRef = &chat.PromptRef{Name: "missing"}
+	if _, err := generate(context.Background(), req); err == nil {
+		t.Error("expected error for missing prompt")
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	if v := LatestVersion([]string{"v1.9", "v1.10", "v1.2"}); v != "v1.10" {
+		t.Errorf("latest = %s", v)
+	}
+	if v := LatestVersion([]string{"1", "2", "10"}); v != "10" {
+		t.Errorf("latest = %s", v)
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package provenance stamps responses with signed provenance metadata, so downstream systems
+// can verify which model produced a text. Stamps are signed with Ed25519,
+// so verifiers only need the public key.
+//
+//	signer := provenance.NewSigner(privateKey, provenance.WithKeyID("2025-01"))
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(signer.Middleware()))
+//
+//	// downstream
+//	stamp, err := provenance.Verify(text, resp.Metadata[provenance.MetadataKey], publicKey)
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// MetadataKey is the response metadata key of the signed stamp.
+const MetadataKey = "provenance"
+
+var (
+	// ErrInvalidSignature is returned by Verify for malformed stamps or signatures not of the key.
+	ErrInvalidSignature = errors.New("invalid provenance signature")
+	// ErrTextMismatch is returned by Verify when the text is not the stamped text.
+	ErrTextMismatch = errors.New("text does not match provenance")
+)
+
+// Stamp is the provenance of a response text.
+type Stamp struct {
+	Model    string `json:"model"`
+	Provider string `json:"provider,omitempty"`
+	// Timestamp is the time the response was stamped.
+	Timestamp time.Time `json:"timestamp"`
+	// RequestFingerprint is the SHA-256 of the JSON request.
+	RequestFingerprint string `json:"request_fingerprint"`
+	// TextHash is the SHA-256 of the response text.
+	TextHash string `json:"text_hash"`
+	// KeyID identifies the signing key, eg. for key rotation.
+	KeyID string `json:"kid,omitempty"`
+}
+
+// Signer signs the provenance of responses.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+	clock func() time.Time
+}
+
+// Option configures Signer.
+type Option func(s *Signer)
+
+// WithKeyID sets the key ID of the stamps.
+func WithKeyID(id string) Option {
+	return func(s *Signer) {
+		s.keyID = id
+	}
+}
+
+// WithClock sets the clock of the stamp timestamps. time.Now by default.
+func WithClock(clock func() time.Time) Option {
+	return func(s *Signer) {
+		s.clock = clock
+	}
+}
+
+// NewSigner creates a signer with the Ed25519 private key.
+func NewSigner(key ed25519.PrivateKey, opts ...Option) *Signer {
+	s := &Signer{key: key, clock: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Sign returns the signed stamp of the response text to the request.
+// The stamp is "<base64url JSON stamp>.<base64url signature>".
+func (s *Signer) Sign(req *chat.Request, resp *chat.Response) (string, error) {
+	model := resp.Model
+	if model == "" {
+		model = req.Model
+	}
+	stamp := Stamp{
+		Model:     model,
+		Timestamp: s.clock().UTC(),
+		TextHash:  hash([]byte(resp.Text())),
+		KeyID:     s.keyID,
+	}
+	if info, err := chat.NewOptions().LookupModel(model); err == nil {
+		stamp.Provider = info.Provider
+	}
+	fingerprint, err := Fingerprint(req)
+	if err != nil {
+		return "", err
+	}
+	stamp.RequestFingerprint = fingerprint
+
+	payload, err := json.Marshal(stamp)
+	if err != nil {
+		return "", fmt.Errorf("marshal stamp: %w", err)
+	}
+	signature := ed25519.Sign(s.key, payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Middleware stamps the responses in the response metadata with MetadataKey.
+// The stamp is of the response returned by the provider, so later rewrites of the text fail Verify.
+func (s *Signer) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			resp, err := next(ctx, req)
+			if err != nil {
+				return resp, err
+			}
+			stamp, err := s.Sign(req, resp)
+			if err != nil {
+				return nil, fmt.Errorf("provenance: %w", err)
+			}
+			if resp.Metadata == nil {
+				resp.Metadata = chat.Metadata{}
+			}
+			resp.Metadata[MetadataKey] = stamp
+			return resp, nil
+		}
+	}
+}
+
+// Verify verifies the signed stamp with the Ed25519 public key and that it is of the text.
+// Returns ErrInvalidSignature or ErrTextMismatch if not.
+func Verify(text, signed string, key ed25519.PublicKey) (*Stamp, error) {
+	encoded, encodedSig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return nil, ErrInvalidSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !ed25519.Verify(key, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+	var stamp Stamp
+	if err := json.Unmarshal(payload, &stamp); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if stamp.TextHash != hash([]byte(text)) {
+		return &stamp, ErrTextMismatch
+	}
+	return &stamp, nil
+}
+
+// Fingerprint returns the SHA-256 of the JSON request, eg. to match a stamp with a logged request.
+func Fingerprint(req *chat.Request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+	return hash(data), nil
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestMiddleware(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewSigner(private, WithKeyID("k1"), WithClock(func() time.Time { return now }))
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Hello")}}
+	generate := signer.Middleware()(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		return &chat.Response{Model: req.Model, Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Hi there")}}, nil
+	})
+	resp, err := generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := resp.Metadata[MetadataKey]
+	stamp, err := Verify("Hi there", signed, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, _ := Fingerprint(req)
+	if stamp.Model != "gpt-4o-mini" || stamp.Provider != "openai" || !stamp.Timestamp.Equal(now) ||
+		stamp.KeyID != "k1" || stamp.RequestFingerprint != fingerprint {
+		t.Errorf("stamp mismatch: %+v", stamp)
+	}
+
+	if _, err := Verify("Hi there!", signed, public); !errors.Is(err, ErrTextMismatch) {
+		t.Errorf("expected text mismatch: %v", err)
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	if _, err := Verify("Hi there", signed, other); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature with another key: %v", err)
+	}
+	if _, err := Verify("Hi there", "x"+signed, public); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected invalid signature of a tampered stamp: %v", err)
+	}
+}
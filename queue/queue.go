@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package queue runs long generations asynchronously in a worker pool, eg. for serverless
+// frontends with short request timeouts. Submit returns a job ID immediately, and the final job
+// is delivered to the webhook or callback channel, and can be queried by ID until it expires.
+//
+//	q := queue.New(queue.WithWorkers(4), queue.WithWebhook("https://example.com/hooks/gengo", secret))
+//	defer q.Close(context.Background())
+//	id, err := q.Submit(req, chat.WithTimeout(5*time.Minute))
+//	// later, eg. in a polling handler
+//	job, err := q.Job(id)
+package queue
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+const (
+	// DefaultWorkers is the number of jobs processed concurrently.
+	DefaultWorkers = 4
+	// DefaultCapacity is the number of jobs waiting for a worker.
+	DefaultCapacity = 100
+	// DefaultRetention is how long finished jobs can be queried.
+	DefaultRetention = time.Hour
+	// SignatureHeader is the webhook header of the hex HMAC-SHA256 of the body with the secret.
+	SignatureHeader = "X-Gengo-Signature"
+)
+
+var (
+	// ErrJobNotFound is returned for unknown or expired job IDs.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrQueueFull is returned when the jobs waiting for a worker reach the capacity.
+	ErrQueueFull = errors.New("queue is full")
+	// ErrClosed is returned when submitting to a closed queue.
+	ErrClosed = errors.New("queue is closed")
+)
+
+// Status is the status of a job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Done reports whether the job is finished.
+func (s Status) Done() bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// Job is a snapshot of a submitted generation, sent as the webhook body.
+type Job struct {
+	ID       string         `json:"id"`
+	Status   Status         `json:"status"`
+	Response *chat.Response `json:"response,omitempty"`
+	// Error is the generation error of a failed job.
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeliveryError is the webhook delivery error, only set in queried jobs.
+	DeliveryError string `json:"delivery_error,omitempty"`
+}
+
+// Queue processes submitted requests in a worker pool.
+type Queue struct {
+	workers    int
+	capacity   int
+	retention  time.Duration
+	generate   gengo.GenerateFunc
+	webhook    string
+	secret     []byte
+	httpClient *http.Client
+	callback   chan<- Job
+	now        func() time.Time
+
+	mu     sync.Mutex
+	jobs   map[string]*entry
+	closed bool
+	tasks  chan *task
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type entry struct {
+	job  Job
+	done chan struct{}
+}
+
+type task struct {
+	id   string
+	req  *chat.Request
+	opts []chat.Option
+}
+
+// Option configures Queue.
+type Option func(q *Queue)
+
+// WithWorkers sets the number of jobs processed concurrently. DefaultWorkers by default.
+func WithWorkers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// WithCapacity sets the number of jobs waiting for a worker. DefaultCapacity by default.
+func WithCapacity(n int) Option {
+	return func(q *Queue) {
+		q.capacity = n
+	}
+}
+
+// WithRetention sets how long finished jobs can be queried. DefaultRetention by default.
+func WithRetention(d time.Duration) Option {
+	return func(q *Queue) {
+		q.retention = d
+	}
+}
+
+// WithGenerator replaces the generate function of the jobs.
+func WithGenerator(fn gengo.GenerateFunc) Option {
+	return func(q *Queue) {
+		q.generate = fn
+	}
+}
+
+// WithWebhook posts finished jobs as JSON to the URL, signed with the secret in SignatureHeader if not empty.
+func WithWebhook(url string, secret []byte) Option {
+	return func(q *Queue) {
+		q.webhook = url
+		q.secret = secret
+	}
+}
+
+// WithHTTPClient sets the client of the webhook. http.DefaultClient by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(q *Queue) {
+		q.httpClient = client
+	}
+}
+
+// WithCallback sends finished jobs to the channel. The worker blocks until the job is received
+// or the queue is closed, so the channel should be buffered or drained.
+func WithCallback(ch chan<- Job) Option {
+	return func(q *Queue) {
+		q.callback = ch
+	}
+}
+
+// WithClock sets the clock of the job timestamps and expiration, eg. for tests.
+func WithClock(now func() time.Time) Option {
+	return func(q *Queue) {
+		q.now = now
+	}
+}
+
+// New creates a queue and starts the workers.
+func New(opts ...Option) *Queue {
+	q := &Queue{
+		workers:    DefaultWorkers,
+		capacity:   DefaultCapacity,
+		retention:  DefaultRetention,
+		generate:   gengo.Generate,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+		jobs:       map[string]*entry{},
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.tasks = make(chan *task, q.capacity)
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for range q.workers {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues the request generated with the options, and returns the job ID immediately.
+func (q *Queue) Submit(req *chat.Request, opts ...chat.Option) (string, error) {
+	id := rand.Text()
+	now := q.now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return "", ErrClosed
+	}
+	q.expire(now)
+	select {
+	case q.tasks <- &task{id: id, req: req, opts: opts}:
+	default:
+		return "", ErrQueueFull
+	}
+	q.jobs[id] = &entry{
+		job:  Job{ID: id, Status: StatusQueued, CreatedAt: now, UpdatedAt: now},
+		done: make(chan struct{}),
+	}
+	return id, nil
+}
+
+// Job returns the job, or ErrJobNotFound if the ID is unknown or expired.
+func (q *Queue) Job(id string) (Job, error) {
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, ok := q.lookup(id, now)
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return e.job, nil
+}
+
+// Wait blocks until the job is finished or the context is done.
+func (q *Queue) Wait(ctx context.Context, id string) (Job, error) {
+	now := q.now()
+	q.mu.Lock()
+	e, ok := q.lookup(id, now)
+	q.mu.Unlock()
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	select {
+	case <-e.done:
+		return q.Job(id)
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+// Close stops accepting jobs and waits for the queued jobs to finish.
+// Running jobs are canceled when the context is done.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.tasks)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for t := range q.tasks {
+		q.update(t.id, func(job *Job) {
+			job.Status = StatusRunning
+		})
+		resp, err := q.generate(q.ctx, t.req, t.opts...)
+		job := q.update(t.id, func(job *Job) {
+			if err != nil {
+				job.Status = StatusFailed
+				job.Error = err.Error()
+				return
+			}
+			job.Status = StatusSucceeded
+			job.Response = resp
+		})
+		q.deliver(job)
+	}
+}
+
+// update updates the job and returns the updated snapshot. Finished jobs are marked done.
+func (q *Queue) update(id string, fn func(job *Job)) Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := q.jobs[id]
+	fn(&e.job)
+	e.job.UpdatedAt = q.now()
+	if e.job.Status.Done() {
+		close(e.done)
+	}
+	return e.job
+}
+
+// deliver sends the finished job to the callback and webhook.
+func (q *Queue) deliver(job Job) {
+	if q.callback != nil {
+		select {
+		case q.callback <- job:
+		case <-q.ctx.Done():
+		}
+	}
+	if q.webhook == "" {
+		return
+	}
+	if err := q.post(job); err != nil {
+		q.mu.Lock()
+		if e, ok := q.jobs[job.ID]; ok {
+			e.job.DeliveryError = err.Error()
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *Queue) post(job Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+	req, err := http.NewRequestWithContext(q.ctx, http.MethodPost, q.webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(q.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(q.secret, body))
+	}
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("post webhook: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// lookup returns the entry of the job, deleting it if expired. q.mu must be held.
+func (q *Queue) lookup(id string, now time.Time) (*entry, bool) {
+	e, ok := q.jobs[id]
+	if ok && q.expired(e, now) {
+		delete(q.jobs, id)
+		return nil, false
+	}
+	return e, ok
+}
+
+// expire deletes the jobs finished before the retention. q.mu must be held.
+func (q *Queue) expire(now time.Time) {
+	for id, e := range q.jobs {
+		if q.expired(e, now) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (q *Queue) expired(e *entry, now time.Time) bool {
+	return e.job.Status.Done() && now.Sub(e.job.UpdatedAt) > q.retention
+}
+
+// Sign returns the hex HMAC-SHA256 of the webhook body with the secret.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the signature of SignatureHeader matches the webhook body.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
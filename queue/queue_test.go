@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func echo(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	if req.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	return &chat.Response{Model: req.Model, Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "done")}}, nil
+}
+
+func TestQueueCallback(t *testing.T) {
+	callback := make(chan Job, 2)
+	q := New(WithGenerator(echo), WithCallback(callback))
+	defer q.Close(context.Background())
+
+	ok, err := q.Submit(&chat.Request{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := q.Submit(&chat.Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]Job{}
+	for range 2 {
+		job := <-callback
+		got[job.ID] = job
+	}
+	if job := got[ok]; job.Status != StatusSucceeded || job.Response.Text() != "done" {
+		t.Errorf("succeeded job mismatch: %+v", job)
+	}
+	if job := got[failed]; job.Status != StatusFailed || job.Error != "model is required" {
+		t.Errorf("failed job mismatch: %+v", job)
+	}
+
+	job, err := q.Job(ok)
+	if err != nil || job.Status != StatusSucceeded {
+		t.Errorf("queried job mismatch: %+v %v", job, err)
+	}
+	if _, err := q.Job("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestQueueWebhook(t *testing.T) {
+	secret := []byte("secret")
+	received := make(chan Job, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, body, r.Header.Get(SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var job Job
+		if err := json.Unmarshal(body, &job); err != nil {
+			t.Error(err)
+		}
+		received <- job
+	}))
+	defer server.Close()
+
+	q := New(WithGenerator(echo), WithWebhook(server.URL, secret))
+	id, err := q.Submit(&chat.Request{Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := <-received
+	if job.ID != id || job.Status != StatusSucceeded || job.Response.Text() != "done" {
+		t.Errorf("webhook job mismatch: %+v", job)
+	}
+	if err := q.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ := q.Job(id); job.DeliveryError != "" {
+		t.Errorf("unexpected delivery error: %s", job.DeliveryError)
+	}
+
+	// an invalid signature is rejected and recorded as the delivery error.
+	q = New(WithGenerator(echo), WithWebhook(server.URL, []byte("wrong")))
+	id, _ = q.Submit(&chat.Request{Model: "m"})
+	q.Close(context.Background())
+	if job, _ := q.Job(id); job.DeliveryError != "post webhook: status 401" {
+		t.Errorf("delivery error mismatch: %q", job.DeliveryError)
+	}
+}
+
+func TestQueueCapacityAndClose(t *testing.T) {
+	release := make(chan struct{})
+	blocking := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		select {
+		case <-release:
+			return &chat.Response{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	q := New(WithGenerator(blocking), WithWorkers(1), WithCapacity(1))
+
+	running, _ := q.Submit(&chat.Request{})
+	// wait until the worker takes the first job, so the second fills the capacity.
+	for job, _ := q.Job(running); job.Status != StatusRunning; job, _ = q.Job(running) {
+		time.Sleep(time.Millisecond)
+	}
+	queued, err := q.Submit(&chat.Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Submit(&chat.Request{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	for _, id := range []string{running, queued} {
+		job, err := q.Wait(context.Background(), id)
+		if err != nil || job.Status != StatusFailed {
+			t.Errorf("canceled job mismatch: %+v %v", job, err)
+		}
+	}
+	if _, err := q.Submit(&chat.Request{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestQueueRetention(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := New(WithGenerator(echo), WithRetention(time.Minute), WithClock(func() time.Time { return now }))
+	defer q.Close(context.Background())
+
+	id, _ := q.Submit(&chat.Request{Model: "m"})
+	if _, err := q.Wait(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := q.Job(id); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expired job should not be returned: %v", err)
+	}
+	if _, err := q.Wait(context.Background(), id); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expired job should not be waited: %v", err)
+	}
+
+	id, _ = q.Submit(&chat.Request{Model: "m"})
+	if _, err := q.Wait(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	q.Submit(&chat.Request{Model: "m"})
+	q.mu.Lock()
+	_, ok := q.jobs[id]
+	q.mu.Unlock()
+	if ok {
+		t.Errorf("expired job should be deleted on submit")
+	}
+}
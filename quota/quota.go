@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package quota enforces per-tenant daily and monthly token and cost limits.
+//
+//	q := quota.New(quota.NewMemoryStore(), quota.WithDefaultLimits(quota.Limits{DailyTokens: 100000}))
+//	req.Metadata = chat.Metadata{"tenant_id": "acme"}
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(q.Middleware()))
+//	if errors.Is(err, quota.ErrQuotaExceeded) { ... }
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultTenantKey is the metadata key of the tenant ID.
+const DefaultTenantKey = "tenant_id"
+
+// ErrQuotaExceeded is matched by errors.Is for all ExceededError.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ExceededError is returned when a tenant exceeds a limit.
+type ExceededError struct {
+	Tenant string
+	// Period is "day" or "month".
+	Period string
+	// Resource is "tokens" or "cost".
+	Resource string
+	Used     float64
+	Limit    float64
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: tenant %s %s %s %g/%g", e.Tenant, e.Period, e.Resource, e.Used, e.Limit)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Limits of a tenant. Zero means unlimited.
+type Limits struct {
+	DailyTokens   int
+	MonthlyTokens int
+	DailyCost     float64
+	MonthlyCost   float64
+}
+
+// Usage is the usage of a tenant in a period.
+type Usage struct {
+	Tokens int     `json:"tokens"`
+	Cost   float64 `json:"cost"`
+}
+
+// Store stores tenant usage by period key, eg. "day:2025-01-02" or "month:2025-01".
+type Store interface {
+	Get(ctx context.Context, tenant, period string) (Usage, error)
+	Add(ctx context.Context, tenant, period string, usage Usage) error
+}
+
+// Quota checks and records tenant usage.
+type Quota struct {
+	store         Store
+	tenantKey     string
+	defaultLimits Limits
+	limits        map[string]Limits
+	clock         func() time.Time
+	handlers      []chat.EventHandler
+}
+
+// Option configures Quota.
+type Option func(q *Quota)
+
+// WithLimits sets the limits of the tenant.
+func WithLimits(tenant string, limits Limits) Option {
+	return func(q *Quota) {
+		q.limits[tenant] = limits
+	}
+}
+
+// WithDefaultLimits sets the limits of tenants without WithLimits.
+func WithDefaultLimits(limits Limits) Option {
+	return func(q *Quota) {
+		q.defaultLimits = limits
+	}
+}
+
+// WithTenantKey sets the metadata key of the tenant ID. DefaultTenantKey by default.
+func WithTenantKey(key string) Option {
+	return func(q *Quota) {
+		q.tenantKey = key
+	}
+}
+
+// WithClock sets the clock used for periods. time.Now by default.
+func WithClock(clock func() time.Time) Option {
+	return func(q *Quota) {
+		q.clock = clock
+	}
+}
+
+// WithEventHandler adds a handler of chat.EventMiddlewareError events of the usage the middleware
+// fails to record, eg. for logging or reconciling the usage.
+func WithEventHandler(handler chat.EventHandler) Option {
+	return func(q *Quota) {
+		q.handlers = append(q.handlers, handler)
+	}
+}
+
+// New creates a Quota with the store.
+func New(store Store, opts ...Option) *Quota {
+	q := &Quota{
+		store:     store,
+		tenantKey: DefaultTenantKey,
+		limits:    map[string]Limits{},
+		clock:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+func (q *Quota) periods() (day, month string) {
+	now := q.clock().UTC()
+	return "day:" + now.Format(time.DateOnly), "month:" + now.Format("2006-01")
+}
+
+func (q *Quota) limitsOf(tenant string) Limits {
+	if limits, ok := q.limits[tenant]; ok {
+		return limits
+	}
+	return q.defaultLimits
+}
+
+// Check returns ExceededError if the tenant has reached a limit.
+func (q *Quota) Check(ctx context.Context, tenant string) error {
+	limits := q.limitsOf(tenant)
+	day, month := q.periods()
+
+	checks := []struct {
+		period     string
+		name       string
+		tokenLimit int
+		costLimit  float64
+	}{
+		{day, "day", limits.DailyTokens, limits.DailyCost},
+		{month, "month", limits.MonthlyTokens, limits.MonthlyCost},
+	}
+	for _, c := range checks {
+		if c.tokenLimit == 0 && c.costLimit == 0 {
+			continue
+		}
+		used, err := q.store.Get(ctx, tenant, c.period)
+		if err != nil {
+			return fmt.Errorf("get quota usage: %w", err)
+		}
+		if c.tokenLimit > 0 && used.Tokens >= c.tokenLimit {
+			return &ExceededError{Tenant: tenant, Period: c.name, Resource: "tokens", Used: float64(used.Tokens), Limit: float64(c.tokenLimit)}
+		}
+		if c.costLimit > 0 && used.Cost >= c.costLimit {
+			return &ExceededError{Tenant: tenant, Period: c.name, Resource: "cost", Used: used.Cost, Limit: c.costLimit}
+		}
+	}
+	return nil
+}
+
+// Record adds the usage to the tenant daily and monthly usage.
+func (q *Quota) Record(ctx context.Context, tenant string, usage *chat.Usage) error {
+	if usage == nil {
+		return nil
+	}
+	u := Usage{Tokens: usage.TotalTokens, Cost: usage.Cost}
+	day, month := q.periods()
+	for _, period := range []string{day, month} {
+		if err := q.store.Add(ctx, tenant, period, u); err != nil {
+			return fmt.Errorf("add quota usage: %w", err)
+		}
+	}
+	return nil
+}
+
+// Middleware checks the quota before and records usage after generation.
+// Requests without the tenant metadata are not limited.
+// The response is returned even if the usage is not recorded, as the tokens are consumed anyway,
+// and the error is emitted to the event handlers.
+func (q *Quota) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			tenant := req.Metadata[q.tenantKey]
+			if tenant == "" {
+				return next(ctx, req)
+			}
+			if err := q.Check(ctx, tenant); err != nil {
+				return nil, err
+			}
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			if err := q.Record(ctx, tenant, resp.Usage); err != nil {
+				q.emitError(req, err)
+			}
+			return resp, nil
+		}
+	}
+}
+
+func (q *Quota) emitError(req *chat.Request, err error) {
+	ev := &chat.Event{Type: chat.EventMiddlewareError, Time: q.clock(), Model: req.Model, Error: err.Error()}
+	for _, h := range q.handlers {
+		h(ev)
+	}
+}
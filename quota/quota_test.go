@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestMiddleware(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	q := New(NewMemoryStore(),
+		WithDefaultLimits(Limits{DailyTokens: 100}),
+		WithLimits("vip", Limits{}),
+		WithClock(func() time.Time { return now }),
+	)
+
+	calls := 0
+	generate := q.Middleware()(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		calls++
+		return &chat.Response{Usage: &chat.Usage{TotalTokens: 60}}, nil
+	})
+	call := func(tenant string) error {
+		_, err := generate(t.Context(), &chat.Request{Metadata: chat.Metadata{DefaultTenantKey: tenant}})
+		return err
+	}
+
+	for range 2 {
+		if err := call("acme"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := call("acme")
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Period != "day" || exceeded.Used != 120 {
+		t.Errorf("exceeded error mismatch: %+v", exceeded)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 provider calls, got %d", calls)
+	}
+
+	for range 3 {
+		if err := call("vip"); err != nil {
+			t.Errorf("unlimited tenant: %v", err)
+		}
+	}
+
+	now = now.Add(24 * time.Hour)
+	if err := call("acme"); err != nil {
+		t.Errorf("next day: %v", err)
+	}
+}
+
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Add(ctx context.Context, tenant, period string, usage Usage) error {
+	return errors.New("store unavailable")
+}
+
+func TestMiddlewareRecordError(t *testing.T) {
+	events := []*chat.Event{}
+	q := New(failingStore{NewMemoryStore()}, WithEventHandler(func(ev *chat.Event) { events = append(events, ev) }))
+	generate := q.Middleware()(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		return &chat.Response{Usage: &chat.Usage{TotalTokens: 60}}, nil
+	})
+	resp, err := generate(t.Context(), &chat.Request{Metadata: chat.Metadata{DefaultTenantKey: "acme"}})
+	if err != nil || resp == nil {
+		t.Fatalf("response should be returned: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != chat.EventMiddlewareError {
+		t.Errorf("record error should be emitted: %+v", events)
+	}
+}
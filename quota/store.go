@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package quota
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is an in-memory Store for a single process.
+type MemoryStore struct {
+	mu    sync.Mutex
+	usage map[string]Usage
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{usage: map[string]Usage{}}
+}
+
+func (s *MemoryStore) Get(_ context.Context, tenant, period string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[tenant+"/"+period], nil
+}
+
+func (s *MemoryStore) Add(_ context.Context, tenant, period string, usage Usage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.usage[tenant+"/"+period]
+	u.Tokens += usage.Tokens
+	u.Cost += usage.Cost
+	s.usage[tenant+"/"+period] = u
+	return nil
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultCacheTTL is how long responses are cached.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache caches responses in Redis by the request, shared by the replicas.
+type Cache struct {
+	client   Client
+	prefix   string
+	ttl      time.Duration
+	handlers []chat.EventHandler
+}
+
+// CacheOption configures Cache.
+type CacheOption func(c *Cache)
+
+// WithCachePrefix sets the key prefix. DefaultPrefix by default.
+func WithCachePrefix(prefix string) CacheOption {
+	return func(c *Cache) {
+		c.prefix = prefix
+	}
+}
+
+// WithTTL sets how long responses are cached. DefaultCacheTTL by default.
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithCacheEventHandler adds a handler of chat.EventMiddlewareError events of the Redis errors
+// the middleware ignores, eg. for logging.
+func WithCacheEventHandler(handler chat.EventHandler) CacheOption {
+	return func(c *Cache) {
+		c.handlers = append(c.handlers, handler)
+	}
+}
+
+// NewCache creates a Cache with the client.
+func NewCache(client Client, opts ...CacheOption) *Cache {
+	c := &Cache{client: client, prefix: DefaultPrefix, ttl: DefaultCacheTTL}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Key returns the cache key of the request, the hash of the request JSON.
+func (c *Cache) Key(req *chat.Request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return c.prefix + "cache:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Get returns the cached response of the request, or nil if not cached.
+func (c *Cache) Get(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+	key, err := c.Key(req)
+	if err != nil {
+		return nil, err
+	}
+	v, err := c.client.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, fmt.Errorf("get cached response: %w", err)
+	}
+	data, err := replyBytes(v)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var resp chat.Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal cached response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Set caches the response of the request.
+func (c *Cache) Set(ctx context.Context, req *chat.Request, resp *chat.Response) error {
+	key, err := c.Key(req)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("marshal response: %w", err)
+	}
+	if _, err := c.client.Do(ctx, "SET", key, string(data), "PX", c.ttl.Milliseconds()); err != nil {
+		return fmt.Errorf("cache response: %w", err)
+	}
+	return nil
+}
+
+// Middleware returns cached responses of identical requests, and caches completed responses.
+// Cached responses are not streamed, and have no usage as no tokens are consumed.
+// The cache fails open: Redis errors are emitted to the event handlers and the request is generated as usual.
+func (c *Cache) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			cached, err := c.Get(ctx, req)
+			if err != nil {
+				c.emitError(req, err)
+			}
+			if cached != nil {
+				cached.Usage = nil
+				return cached, nil
+			}
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			if resp.FinishReason == chat.FinishReasonStop || resp.FinishReason == chat.FinishReasonToolUse {
+				if err := c.Set(ctx, req, resp); err != nil {
+					c.emitError(req, err)
+				}
+			}
+			return resp, nil
+		}
+	}
+}
+
+func (c *Cache) emitError(req *chat.Request, err error) {
+	ev := &chat.Event{Type: chat.EventMiddlewareError, Time: time.Now(), Model: req.Model, Error: err.Error()}
+	for _, h := range c.handlers {
+		h(ev)
+	}
+}
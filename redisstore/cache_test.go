@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	redis := newFakeRedis()
+	calls := 0
+	next := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		calls++
+		return &chat.Response{
+			Model:        req.Model,
+			FinishReason: chat.FinishReasonStop,
+			Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Tokyo")},
+			Usage:        &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+	req := &chat.Request{Model: "m", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "The capital of Japan?")}}
+
+	// replicas share the cached responses through Redis.
+	first := NewCache(redis, WithTTL(time.Hour)).Middleware()(next)
+	second := NewCache(redis).Middleware()(next)
+	if _, err := first(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := second(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || resp.Text() != "Tokyo" || resp.Usage != nil {
+		t.Errorf("cached response mismatch: calls = %d, %+v", calls, resp)
+	}
+
+	other := *req
+	other.Model = "other"
+	if _, err := second(context.Background(), &other); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("different request should not be cached: calls = %d", calls)
+	}
+	key, _ := NewCache(redis).Key(req)
+	if got := redis.expires[key]; got != time.Hour.Milliseconds() {
+		t.Errorf("expiration mismatch: %d", got)
+	}
+}
+
+func TestCacheMiddlewareFailOpen(t *testing.T) {
+	down := ClientFunc(func(ctx context.Context, args ...any) (any, error) {
+		return nil, errors.New("connection refused")
+	})
+	var events []*chat.Event
+	cache := NewCache(down, WithCacheEventHandler(func(ev *chat.Event) { events = append(events, ev) }))
+	next := func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		return &chat.Response{FinishReason: chat.FinishReasonStop, Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Tokyo")}}, nil
+	}
+	resp, err := cache.Middleware()(next)(context.Background(), &chat.Request{Model: "m"})
+	if err != nil || resp.Text() != "Tokyo" {
+		t.Fatalf("cache errors should not fail the generation: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != chat.EventMiddlewareError || !strings.Contains(events[1].Error, "connection refused") {
+		t.Errorf("get and set errors should be emitted: %+v", events)
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultLimiterKey is the metadata key of the rate limit key, eg. the tenant ID.
+const DefaultLimiterKey = "tenant_id"
+
+// Limiter limits the requests per minute across replicas with a fixed window counter in Redis.
+// Unlike schedule.Scheduler, waiting requests are not ordered by fairness or priority.
+type Limiter struct {
+	client   Client
+	prefix   string
+	limit    int64
+	window   time.Duration
+	keyField string
+	now      func() time.Time
+}
+
+// LimiterOption configures Limiter.
+type LimiterOption func(l *Limiter)
+
+// WithLimiterPrefix sets the key prefix. DefaultPrefix by default.
+func WithLimiterPrefix(prefix string) LimiterOption {
+	return func(l *Limiter) {
+		l.prefix = prefix
+	}
+}
+
+// WithLimiterKey sets the metadata key of the rate limit key, so each key is limited separately.
+// DefaultLimiterKey by default. Requests without the metadata share the "" key.
+func WithLimiterKey(key string) LimiterOption {
+	return func(l *Limiter) {
+		l.keyField = key
+	}
+}
+
+// WithLimiterClock sets the clock of the windows, eg. for tests.
+func WithLimiterClock(now func() time.Time) LimiterOption {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+// NewLimiter creates a limiter of requestsPerMinute requests, eg. the provider rate limit.
+func NewLimiter(client Client, requestsPerMinute int, opts ...LimiterOption) *Limiter {
+	l := &Limiter{
+		client:   client,
+		prefix:   DefaultPrefix,
+		limit:    int64(requestsPerMinute),
+		window:   time.Minute,
+		keyField: DefaultLimiterKey,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// incrScript increments the counter and sets the expiration of a new counter atomically,
+// so a counter is not left without expiration if the client fails between the commands.
+const incrScript = `local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`
+
+// Allow counts a request of the key and reports whether it is within the limit of the current window.
+// If not, it returns the wait until the next window.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	now := l.now()
+	window := now.Truncate(l.window)
+	redisKey := l.prefix + "ratelimit:" + key + ":" + strconv.FormatInt(window.Unix(), 10)
+	// the counter outlives the window slightly to tolerate clock skew between replicas.
+	v, err := l.client.Do(ctx, "EVAL", incrScript, 1, redisKey, (2 * l.window).Milliseconds())
+	if err != nil {
+		return false, 0, fmt.Errorf("count request: %w", err)
+	}
+	count, err := replyInt(v)
+	if err != nil {
+		return false, 0, fmt.Errorf("count request: %w", err)
+	}
+	if count <= l.limit {
+		return true, 0, nil
+	}
+	return false, window.Add(l.window).Sub(now), nil
+}
+
+// Wait blocks until a request of the key is allowed or the context is done.
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	for {
+		ok, wait, err := l.Allow(ctx, key)
+		if err != nil || ok {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Middleware waits for the rate limit of the key in the request metadata.
+func (l *Limiter) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if err := l.Wait(ctx, req.Metadata[l.keyField]); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
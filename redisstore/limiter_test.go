@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	now := time.Date(2025, 1, 2, 12, 0, 15, 0, time.UTC)
+	clock := WithLimiterClock(func() time.Time { return now })
+	replicas := []*Limiter{NewLimiter(redis, 3, clock), NewLimiter(redis, 3, clock)}
+
+	for i := range 3 {
+		ok, _, err := replicas[i%2].Allow(ctx, "acme")
+		if err != nil || !ok {
+			t.Fatalf("request %d should be allowed: %v", i, err)
+		}
+	}
+	ok, wait, err := replicas[1].Allow(ctx, "acme")
+	if err != nil || ok || wait != 45*time.Second {
+		t.Errorf("request over the limit: ok = %v, wait = %s, err = %v", ok, wait, err)
+	}
+	if ok, _, _ := replicas[0].Allow(ctx, "other"); !ok {
+		t.Error("other key should be limited separately")
+	}
+
+	now = now.Add(time.Minute)
+	if ok, _, _ := replicas[0].Allow(ctx, "acme"); !ok {
+		t.Error("request in the next window should be allowed")
+	}
+	if got := redis.expires["gengo:ratelimit:acme:1735819200"]; got != (2 * time.Minute).Milliseconds() {
+		t.Errorf("expiration mismatch: %d", got)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 15, 0, time.UTC)
+	l := NewLimiter(newFakeRedis(), 1, WithLimiterClock(func() time.Time { return now }))
+	if err := l.Wait(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, ""); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
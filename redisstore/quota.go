@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jumonmd/gengo/quota"
+)
+
+// DefaultQuotaRetention is how long the usage of a period is kept after the last update,
+// longer than the longest period of a month.
+const DefaultQuotaRetention = 32 * 24 * time.Hour
+
+// QuotaStore is a quota.Store of the usage in Redis hashes.
+type QuotaStore struct {
+	client    Client
+	prefix    string
+	retention time.Duration
+}
+
+// QuotaOption configures QuotaStore.
+type QuotaOption func(s *QuotaStore)
+
+// WithQuotaPrefix sets the key prefix. DefaultPrefix by default.
+func WithQuotaPrefix(prefix string) QuotaOption {
+	return func(s *QuotaStore) {
+		s.prefix = prefix
+	}
+}
+
+// WithQuotaRetention sets how long the usage is kept after the last update. DefaultQuotaRetention by default.
+func WithQuotaRetention(d time.Duration) QuotaOption {
+	return func(s *QuotaStore) {
+		s.retention = d
+	}
+}
+
+// NewQuotaStore creates a QuotaStore with the client.
+func NewQuotaStore(client Client, opts ...QuotaOption) *QuotaStore {
+	s := &QuotaStore{client: client, prefix: DefaultPrefix, retention: DefaultQuotaRetention}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var _ quota.Store = (*QuotaStore)(nil)
+
+func (s *QuotaStore) key(tenant, period string) string {
+	return s.prefix + "quota:" + tenant + ":" + period
+}
+
+func (s *QuotaStore) Get(ctx context.Context, tenant, period string) (quota.Usage, error) {
+	v, err := s.client.Do(ctx, "HMGET", s.key(tenant, period), "tokens", "cost")
+	if err != nil {
+		return quota.Usage{}, fmt.Errorf("get usage: %w", err)
+	}
+	fields, ok := v.([]any)
+	if !ok || len(fields) != 2 {
+		return quota.Usage{}, fmt.Errorf("get usage: unexpected reply %T", v)
+	}
+	tokens, err := replyInt(fields[0])
+	if err != nil {
+		return quota.Usage{}, fmt.Errorf("get usage tokens: %w", err)
+	}
+	cost, err := replyFloat(fields[1])
+	if err != nil {
+		return quota.Usage{}, fmt.Errorf("get usage cost: %w", err)
+	}
+	return quota.Usage{Tokens: int(tokens), Cost: cost}, nil
+}
+
+// Add increments the usage atomically per field, so concurrent replicas do not lose updates.
+func (s *QuotaStore) Add(ctx context.Context, tenant, period string, usage quota.Usage) error {
+	key := s.key(tenant, period)
+	if _, err := s.client.Do(ctx, "HINCRBY", key, "tokens", usage.Tokens); err != nil {
+		return fmt.Errorf("add usage tokens: %w", err)
+	}
+	if _, err := s.client.Do(ctx, "HINCRBYFLOAT", key, "cost", strconv.FormatFloat(usage.Cost, 'g', -1, 64)); err != nil {
+		return fmt.Errorf("add usage cost: %w", err)
+	}
+	if _, err := s.client.Do(ctx, "PEXPIRE", key, s.retention.Milliseconds()); err != nil {
+		return fmt.Errorf("expire usage: %w", err)
+	}
+	return nil
+}
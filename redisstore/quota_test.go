@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/quota"
+)
+
+func TestQuotaStore(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	store := NewQuotaStore(redis)
+
+	usage, err := store.Get(ctx, "acme", "day:2025-01-02")
+	if err != nil || usage != (quota.Usage{}) {
+		t.Fatalf("empty usage mismatch: %+v %v", usage, err)
+	}
+	// replicas share the usage through Redis.
+	for _, s := range []*QuotaStore{store, NewQuotaStore(redis)} {
+		if err := s.Add(ctx, "acme", "day:2025-01-02", quota.Usage{Tokens: 100, Cost: 0.25}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err = store.Get(ctx, "acme", "day:2025-01-02")
+	if err != nil || usage != (quota.Usage{Tokens: 200, Cost: 0.5}) {
+		t.Errorf("usage mismatch: %+v %v", usage, err)
+	}
+	if got := redis.expires["gengo:quota:acme:day:2025-01-02"]; got != DefaultQuotaRetention.Milliseconds() {
+		t.Errorf("expiration mismatch: %d", got)
+	}
+
+	q := quota.New(store, quota.WithDefaultLimits(quota.Limits{DailyTokens: 200}),
+		quota.WithClock(func() time.Time { return time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC) }))
+	if err := q.Check(ctx, "acme"); !errors.Is(err, quota.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := q.Record(ctx, "other", &chat.Usage{TotalTokens: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Check(ctx, "other"); err != nil {
+		t.Error(err)
+	}
+}
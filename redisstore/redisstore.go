@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package redisstore provides Redis-backed quota store, rate limiter and response cache,
+// so the replicas of multi-instance deployments share limits and cached responses.
+// Redis commands are sent through Client, so any Redis client can be used, eg. go-redis:
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	client := redisstore.ClientFunc(func(ctx context.Context, args ...any) (any, error) {
+//		v, err := rdb.Do(ctx, args...).Result()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, nil
+//		}
+//		return v, err
+//	})
+//	q := quota.New(redisstore.NewQuotaStore(client), quota.WithDefaultLimits(limits))
+//	limiter := redisstore.NewLimiter(client, 500)
+//	cache := redisstore.NewCache(client, redisstore.WithTTL(time.Hour))
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(cache.Middleware(), limiter.Middleware(), q.Middleware()))
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// DefaultPrefix is the prefix of the keys.
+const DefaultPrefix = "gengo:"
+
+// Client sends a Redis command, eg. "INCR", "key", and returns the reply:
+// int64, string, []byte, []any or nil for missing keys without error.
+type Client interface {
+	Do(ctx context.Context, args ...any) (any, error)
+}
+
+// ClientFunc is a function adapter for Client.
+type ClientFunc func(ctx context.Context, args ...any) (any, error)
+
+func (f ClientFunc) Do(ctx context.Context, args ...any) (any, error) {
+	return f(ctx, args...)
+}
+
+func replyInt(v any) (int64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	}
+	return 0, fmt.Errorf("unexpected reply %T", v)
+}
+
+func replyFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	}
+	return 0, fmt.Errorf("unexpected reply %T", v)
+}
+
+func replyBytes(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %T", v)
+}
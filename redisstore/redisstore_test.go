@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// fakeRedis implements the commands used by the package in memory. Expiration is recorded but not applied.
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	expires map[string]int64
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{strings: map[string]string{}, hashes: map[string]map[string]string{}, expires: map[string]int64{}}
+}
+
+func (r *fakeRedis) Do(_ context.Context, args ...any) (any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	arg := func(i int) string { return fmt.Sprint(args[i]) }
+	key := arg(1)
+	switch arg(0) {
+	case "GET":
+		if v, ok := r.strings[key]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case "SET":
+		r.strings[key] = arg(2)
+		if len(args) == 5 && arg(3) == "PX" {
+			r.expires[key], _ = strconv.ParseInt(arg(4), 10, 64)
+		}
+		return "OK", nil
+	case "EVAL":
+		// only the incrScript of the limiter, with the key and the expiration.
+		if arg(1) != incrScript {
+			return nil, fmt.Errorf("unknown script")
+		}
+		key = arg(3)
+		n, _ := strconv.ParseInt(r.strings[key], 10, 64)
+		n++
+		r.strings[key] = strconv.FormatInt(n, 10)
+		if n == 1 {
+			r.expires[key], _ = strconv.ParseInt(arg(4), 10, 64)
+		}
+		return n, nil
+	case "PEXPIRE":
+		r.expires[key], _ = strconv.ParseInt(arg(2), 10, 64)
+		return int64(1), nil
+	case "HMGET":
+		var values []any
+		for i := 2; i < len(args); i++ {
+			if v, ok := r.hashes[key][arg(i)]; ok {
+				values = append(values, v)
+			} else {
+				values = append(values, nil)
+			}
+		}
+		return values, nil
+	case "HINCRBY", "HINCRBYFLOAT":
+		if r.hashes[key] == nil {
+			r.hashes[key] = map[string]string{}
+		}
+		n, _ := strconv.ParseFloat(r.hashes[key][arg(2)], 64)
+		d, _ := strconv.ParseFloat(arg(3), 64)
+		r.hashes[key][arg(2)] = strconv.FormatFloat(n+d, 'g', -1, 64)
+		return r.hashes[key][arg(2)], nil
+	}
+	return nil, fmt.Errorf("unknown command %s", arg(0))
+}
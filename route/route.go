@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package route routes requests to targets, pinning conversations to the same target
+// to maximize provider prompt-cache hits.
+//
+//	router := route.NewRouter([]route.Target{
+//		{Name: "primary", Model: "claude-3-5-haiku-latest", Options: []chat.Option{chat.WithAPIKey(key1)}},
+//		{Name: "secondary", Model: "claude-3-5-haiku-latest", Options: []chat.Option{chat.WithAPIKey(key2)}},
+//	})
+//	req.Metadata = chat.Metadata{"session_id": conversationID}
+//	resp, err := router.Generate(ctx, req)
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+const (
+	// DefaultSessionKey is the metadata key of the conversation or session ID.
+	DefaultSessionKey = "session_id"
+	// DefaultCooldown is the duration a failed target is considered unhealthy.
+	DefaultCooldown = 30 * time.Second
+	// DefaultPinTTL is the duration a session stays pinned after its last request,
+	// the longest prompt-cache TTL of the providers.
+	DefaultPinTTL = time.Hour
+)
+
+// ErrNoTarget is returned when there are no targets.
+var ErrNoTarget = errors.New("no route target")
+
+// Target is a provider, API key or deployment that requests are routed to.
+type Target struct {
+	Name string
+	// Model overrides the request model if set.
+	Model   string
+	Options []chat.Option
+}
+
+// Router routes requests with the same session ID to the same target
+// and fails over to the next healthy target on transient, rate limit and server errors.
+type Router struct {
+	targets    []Target
+	sessionKey string
+	cooldown   time.Duration
+	pinTTL     time.Duration
+	clock      func() time.Time
+	generate   gengo.GenerateFunc
+	failover   func(err error) bool
+
+	mu        sync.Mutex
+	pins      map[string]pin
+	unhealthy map[int]time.Time
+	swept     time.Time
+}
+
+// pin is the target of a session and the time of the last request.
+type pin struct {
+	target int
+	used   time.Time
+}
+
+// Option configures Router.
+type Option func(r *Router)
+
+// WithSessionKey sets the metadata key of the session ID. DefaultSessionKey by default.
+func WithSessionKey(key string) Option {
+	return func(r *Router) {
+		r.sessionKey = key
+	}
+}
+
+// WithCooldown sets the duration a failed target is skipped.
+func WithCooldown(d time.Duration) Option {
+	return func(r *Router) {
+		r.cooldown = d
+	}
+}
+
+// WithPinTTL sets the duration a session stays pinned after its last request. DefaultPinTTL by default.
+func WithPinTTL(d time.Duration) Option {
+	return func(r *Router) {
+		r.pinTTL = d
+	}
+}
+
+// WithClock sets the clock used for health checks and pin expiry. time.Now by default.
+func WithClock(clock func() time.Time) Option {
+	return func(r *Router) {
+		r.clock = clock
+	}
+}
+
+// WithGenerator replaces the generate function.
+func WithGenerator(fn gengo.GenerateFunc) Option {
+	return func(r *Router) {
+		r.generate = fn
+	}
+}
+
+// WithFailover sets whether an error fails over to the next target and marks the target unhealthy.
+// Retryable errors of gengo.ClassifyError by default, so invalid requests are not sent to every target.
+func WithFailover(failover func(err error) bool) Option {
+	return func(r *Router) {
+		r.failover = failover
+	}
+}
+
+// NewRouter creates a router with the targets.
+func NewRouter(targets []Target, opts ...Option) *Router {
+	r := &Router{
+		targets:    targets,
+		sessionKey: DefaultSessionKey,
+		cooldown:   DefaultCooldown,
+		pinTTL:     DefaultPinTTL,
+		clock:      time.Now,
+		generate:   gengo.Generate,
+		failover:   func(err error) bool { return gengo.ClassifyError(err).Retryable() },
+		pins:       map[string]pin{},
+		unhealthy:  map[int]time.Time{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Generate generates a response with the pinned target of the session.
+// Requests without session ID are routed to the first healthy target.
+func (r *Router) Generate(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	if len(r.targets) == 0 {
+		return nil, ErrNoTarget
+	}
+	session := req.Metadata[r.sessionKey]
+
+	var errs []error
+	for _, i := range r.order(session) {
+		target := r.targets[i]
+		treq := *req
+		if target.Model != "" {
+			treq.Model = target.Model
+		}
+		resp, err := r.generate(ctx, &treq, append(append([]chat.Option{}, opts...), target.Options...)...)
+		if err != nil {
+			if ctx.Err() != nil || !r.failover(err) {
+				return nil, err
+			}
+			r.markUnhealthy(i)
+			errs = append(errs, fmt.Errorf("target %s: %w", target.Name, err))
+			continue
+		}
+		r.pin(session, i)
+		return resp, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Target returns the target name pinned to the session, or empty if not pinned or expired.
+func (r *Router) Target(session string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pinned(session, r.clock())
+	if !ok {
+		return ""
+	}
+	return r.targets[p.target].Name
+}
+
+// Unpin removes the pin of the session, eg. when the conversation ends.
+func (r *Router) Unpin(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pins, session)
+}
+
+// pinned returns the pin of the session unless expired. The caller must hold the lock.
+func (r *Router) pinned(session string, now time.Time) (pin, bool) {
+	p, ok := r.pins[session]
+	if ok && now.Sub(p.used) >= r.pinTTL {
+		delete(r.pins, session)
+		return pin{}, false
+	}
+	return p, ok
+}
+
+// order returns the target indexes to try: pinned or hashed target first,
+// healthy targets before unhealthy ones.
+func (r *Router) order(session string) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock()
+	start := 0
+	if p, ok := r.pinned(session, now); ok {
+		start = p.target
+	} else if session != "" {
+		h := fnv.New32a()
+		h.Write([]byte(session))
+		start = int(h.Sum32() % uint32(len(r.targets)))
+	}
+
+	healthy, unhealthy := []int{}, []int{}
+	for n := range r.targets {
+		i := (start + n) % len(r.targets)
+		if until, ok := r.unhealthy[i]; ok && now.Before(until) {
+			unhealthy = append(unhealthy, i)
+			continue
+		}
+		healthy = append(healthy, i)
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (r *Router) markUnhealthy(i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unhealthy[i] = r.clock().Add(r.cooldown)
+}
+
+func (r *Router) pin(session string, i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.unhealthy, i)
+	if session == "" {
+		return
+	}
+	now := r.clock()
+	r.pins[session] = pin{target: i, used: now}
+	// expired pins of the sessions without further requests are swept once per TTL.
+	if now.Sub(r.swept) >= r.pinTTL {
+		for s, p := range r.pins {
+			if now.Sub(p.used) >= r.pinTTL {
+				delete(r.pins, s)
+			}
+		}
+		r.swept = now
+	}
+}
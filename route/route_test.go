@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package route
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestRouterSticky(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	failing := map[string]bool{}
+	var used []string
+
+	generate := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		o := chat.NewOptions(opts...)
+		used = append(used, o.APIKey)
+		if failing[o.APIKey] {
+			return nil, &openai.APIError{HTTPStatusCode: 503, Message: "unavailable"}
+		}
+		return &chat.Response{Model: req.Model}, nil
+	}
+	router := NewRouter([]Target{
+		{Name: "a", Options: []chat.Option{chat.WithAPIKey("a")}},
+		{Name: "b", Options: []chat.Option{chat.WithAPIKey("b")}},
+		{Name: "c", Options: []chat.Option{chat.WithAPIKey("c")}},
+	}, WithGenerator(generate), WithClock(func() time.Time { return now }))
+
+	req := &chat.Request{Model: "gpt-4o", Metadata: chat.Metadata{DefaultSessionKey: "conversation-1"}}
+	for range 3 {
+		if _, err := router.Generate(t.Context(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pinned := router.Target("conversation-1")
+	if pinned == "" || used[0] != pinned || used[1] != pinned || used[2] != pinned {
+		t.Fatalf("session should be pinned: %v", used)
+	}
+
+	// failover
+	failing[pinned] = true
+	used = nil
+	if _, err := router.Generate(t.Context(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 2 || used[1] == pinned || router.Target("conversation-1") != used[1] {
+		t.Errorf("failover mismatch: %v, pinned %s", used, router.Target("conversation-1"))
+	}
+
+	// all targets failing
+	failing = map[string]bool{"a": true, "b": true, "c": true}
+	if _, err := router.Generate(t.Context(), req); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestRouterNoFailover(t *testing.T) {
+	calls := 0
+	generate := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		calls++
+		return nil, &openai.APIError{HTTPStatusCode: 400, Message: "invalid request"}
+	}
+	router := NewRouter([]Target{{Name: "a"}, {Name: "b"}}, WithGenerator(generate))
+	if _, err := router.Generate(t.Context(), &chat.Request{Model: "gpt-4o"}); err == nil || calls != 1 {
+		t.Errorf("invalid requests should not fail over: %d calls, %v", calls, err)
+	}
+	if order := router.order(""); order[0] != 0 {
+		t.Errorf("target should stay healthy: %v", order)
+	}
+}
+
+func TestRouterPinExpiry(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	generate := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		return &chat.Response{Model: req.Model}, nil
+	}
+	router := NewRouter([]Target{{Name: "a"}, {Name: "b"}},
+		WithGenerator(generate), WithClock(func() time.Time { return now }), WithPinTTL(time.Minute))
+
+	for _, session := range []string{"s1", "s2"} {
+		req := &chat.Request{Model: "gpt-4o", Metadata: chat.Metadata{DefaultSessionKey: session}}
+		if _, err := router.Generate(t.Context(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if router.Target("s1") == "" || len(router.pins) != 2 {
+		t.Fatalf("sessions should be pinned: %v", router.pins)
+	}
+
+	now = now.Add(time.Minute)
+	if router.Target("s1") != "" {
+		t.Error("expired pin should not be returned")
+	}
+	req := &chat.Request{Model: "gpt-4o", Metadata: chat.Metadata{DefaultSessionKey: "s3"}}
+	if _, err := router.Generate(t.Context(), req); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := router.pins["s2"]; ok || len(router.pins) != 1 {
+		t.Errorf("expired pins should be evicted: %v", router.pins)
+	}
+
+	router.Unpin("s3")
+	if router.Target("s3") != "" {
+		t.Error("unpinned session should not be pinned")
+	}
+}
@@ -0,0 +1,305 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultMaxIterations is the default maximum number of Generate calls in Run.
+const DefaultMaxIterations = 10
+
+// ErrMaxIterations is returned when the agent run exceeds the maximum iterations.
+var ErrMaxIterations = errors.New("max iterations exceeded")
+
+// ErrRunSuspended is matched by errors.Is for all SuspendedError.
+var ErrRunSuspended = errors.New("run suspended")
+
+// SuspendedError is returned when the agent run stops because the context deadline is near.
+// State is the resumable state, also saved in the run store if set.
+// Continue the run out-of-band with ResumeState, or with Resume and the run ID.
+type SuspendedError struct {
+	State *chat.RunState
+}
+
+func (e *SuspendedError) Error() string {
+	return fmt.Sprintf("run suspended before deadline: iteration %d, %d pending tool calls",
+		e.State.Iteration, len(e.State.PendingToolCalls))
+}
+
+func (e *SuspendedError) Unwrap() error {
+	return ErrRunSuspended
+}
+
+// ErrClarificationNeeded is matched by errors.Is for all ClarificationError.
+var ErrClarificationNeeded = errors.New("clarification needed")
+
+// ClarificationError is returned when the model asks the user a question with the ask_user tool.
+// Set the answer with State.AnswerClarification and continue the run with ResumeState,
+// or save the state to the run store and continue with Resume.
+type ClarificationError struct {
+	chat.Clarification
+	State *chat.RunState
+}
+
+func (e *ClarificationError) Error() string {
+	return "clarification needed: " + e.Question
+}
+
+func (e *ClarificationError) Unwrap() error {
+	return ErrClarificationNeeded
+}
+
+// ToolHandler executes a tool call and returns the result(stringified json).
+type ToolHandler func(ctx context.Context, call *chat.ToolCall) (string, error)
+
+// generate is replaced in tests.
+var generate GenerateFunc = Generate
+
+// unstreamed returns the options without the streamer of the caller,
+// for internal calls whose output is not the response, eg. verifiers and judges.
+func unstreamed(opts []chat.Option) []chat.Option {
+	return append(slices.Clone(opts), chat.WithStream(nil))
+}
+
+// Run generates a response and executes the tool calls returned by the model with the handlers,
+// until the model stops calling tools or the maximum iterations is reached.
+// The returned response contains all messages added during the run and the total usage.
+//
+// With chat.WithRunStore, the run state is saved after each Generate call and each tool execution,
+// so an interrupted run can be continued with Resume. The state is deleted when the run finishes.
+func Run(ctx context.Context, req *chat.Request, tools map[string]ToolHandler, opts ...chat.Option) (*chat.Response, error) {
+	r := *req
+	r.Messages = slices.Clone(req.Messages)
+	state := &chat.RunState{
+		Request: &r,
+		Start:   len(r.Messages),
+	}
+	return runLoop(ctx, state, tools, opts)
+}
+
+// Resume continues the agent run saved in the run store.
+// Completed tool calls are not executed again, and finished runs are not in the store.
+func Resume(ctx context.Context, runID string, tools map[string]ToolHandler, opts ...chat.Option) (*chat.Response, error) {
+	o := chat.NewOptions(chat.MergeContextOptions(ctx, opts)...)
+	if o.RunStore == nil {
+		return nil, fmt.Errorf("run store is required to resume")
+	}
+	state, err := o.RunStore.Load(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("load run state: %w", err)
+	}
+	if state.Done {
+		return runResponse(state), nil
+	}
+	return runLoop(ctx, state, tools, opts)
+}
+
+// ResumeState continues the agent run from the state, eg. of SuspendedError.
+func ResumeState(ctx context.Context, state *chat.RunState, tools map[string]ToolHandler, opts ...chat.Option) (*chat.Response, error) {
+	if state.Done {
+		return runResponse(state), nil
+	}
+	return runLoop(ctx, state, tools, opts)
+}
+
+func runLoop(ctx context.Context, state *chat.RunState, tools map[string]ToolHandler, opts []chat.Option) (*chat.Response, error) {
+	// opts are passed to Generate as is, which merges the context options itself.
+	o := chat.NewOptions(chat.MergeContextOptions(ctx, opts)...)
+	if o.RunID != "" {
+		state.ID = o.RunID
+	}
+	maxIterations := o.MaxIterations
+	if maxIterations == 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	if o.Clarification {
+		if !slices.ContainsFunc(state.Request.Tools, func(t chat.Tool) bool { return t.Name == chat.AskUserToolName }) {
+			state.Request.Tools = append(slices.Clone(state.Request.Tools), chat.AskUserTool())
+		}
+		// the tool is kept in the saved state, so it is exempted on resume too.
+		ctx = contextWithBuiltinTools(ctx, chat.AskUserToolName)
+	}
+
+	for {
+		if err := executeToolCalls(ctx, state, tools, o); err != nil {
+			if errors.Is(err, ErrRunSuspended) || errors.Is(err, ErrClarificationNeeded) {
+				return runResponse(state), err
+			}
+			return nil, err
+		}
+
+		if state.Iteration >= maxIterations {
+			return runResponse(state), ErrMaxIterations
+		}
+		if deadlineNear(ctx, o.DeadlineMargin) {
+			// the state is not saved yet if the deadline is near before the first Generate call.
+			if err := saveRunState(ctx, state, o); err != nil {
+				return nil, err
+			}
+			return runResponse(state), &SuspendedError{State: state}
+		}
+
+		resp, err := generate(ctx, state.Request, opts...)
+		if err != nil {
+			return nil, err
+		}
+		state.Iteration++
+		state.Usage.Add(resp.Usage)
+		state.Request.Messages = append(state.Request.Messages, resp.Messages...)
+		state.PendingToolCalls = resp.ToolCalls()
+		state.Done = len(state.PendingToolCalls) == 0
+		if state.Done {
+			if err := deleteRunState(ctx, state, o); err != nil {
+				return nil, err
+			}
+		} else if err := saveRunState(ctx, state, o); err != nil {
+			return nil, err
+		}
+
+		if state.Done {
+			final := runResponse(state)
+			final.Model = resp.Model
+			final.FinishReason = resp.FinishReason
+			final.Metadata = resp.Metadata
+			return final, nil
+		}
+	}
+}
+
+func executeToolCalls(ctx context.Context, state *chat.RunState, tools map[string]ToolHandler, o *chat.Options) error {
+	for len(state.PendingToolCalls) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if deadlineNear(ctx, o.DeadlineMargin) {
+			return &SuspendedError{State: state}
+		}
+		call := state.PendingToolCalls[0].ToolCall
+		if o.Clarification && call.Name == chat.AskUserToolName {
+			_, clarification := state.PendingClarification()
+			return &ClarificationError{Clarification: *clarification, State: state}
+		}
+		o.Emit(&chat.Event{Type: chat.EventToolCall, Model: state.Request.Model, ToolCall: call})
+		msg, looping := loopToolResponse(state, call, o)
+		if !looping {
+			msg = ExecuteToolCall(ctx, call, tools)
+		}
+		msg.ToolResponse.Result = chat.TruncateToolResult(msg.ToolResponse.Result, o.ToolResultLimitFor(call.Name))
+		o.Emit(&chat.Event{Type: chat.EventToolResult, Model: state.Request.Model, ToolResponse: msg.ToolResponse})
+		state.Request.Messages = append(state.Request.Messages, msg)
+		state.PendingToolCalls = state.PendingToolCalls[1:]
+		if err := saveRunState(ctx, state, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteToolCall executes the tool call with the handlers and returns the tool response message.
+// Unknown tools and handler errors are returned to the model as an error result.
+func ExecuteToolCall(ctx context.Context, call *chat.ToolCall, tools map[string]ToolHandler) chat.Message {
+	handler, ok := tools[call.Name]
+	if !ok {
+		return chat.NewToolResponseMessage(call.Name, call.ID, chat.ToolErrorResult("tool not found: "+call.Name))
+	}
+	result, err := handler(ctx, call)
+	if err != nil {
+		result = chat.ToolErrorResult(err.Error())
+	}
+	return chat.NewToolResponseMessage(call.Name, call.ID, result)
+}
+
+// loopToolResponse returns the response by the tool loop action if the call repeats
+// the same name and arguments as the threshold or more previous calls in the run.
+func loopToolResponse(state *chat.RunState, call *chat.ToolCall, o *chat.Options) (chat.Message, bool) {
+	if o.ToolLoopThreshold <= 0 {
+		return chat.Message{}, false
+	}
+	key := toolCallKey(call)
+	count := 0
+	last := ""
+	pending := map[string]string{}
+	for _, msg := range state.Request.Messages[state.Start:] {
+		switch {
+		case msg.IsToolCall():
+			pending[msg.ToolCall.ID] = toolCallKey(msg.ToolCall)
+		case msg.IsToolResponse():
+			if k, ok := pending[msg.ToolResponse.ID]; ok && k == key {
+				count++
+				last = msg.ToolResponse.Result
+			}
+			delete(pending, msg.ToolResponse.ID)
+		}
+	}
+	if count < o.ToolLoopThreshold {
+		return chat.Message{}, false
+	}
+	if o.ToolLoopAction == chat.ToolLoopCache {
+		return chat.NewToolResponseMessage(call.Name, call.ID, last), true
+	}
+	note := fmt.Sprintf(chat.ToolLoopNoteText, count)
+	return chat.NewToolResponseMessage(call.Name, call.ID, chat.ToolErrorResult(note)), true
+}
+
+// toolCallKey identifies the tool call by name and arguments, ignoring the JSON formatting.
+func toolCallKey(call *chat.ToolCall) string {
+	var args any
+	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+		return call.Name + "\x00" + call.Arguments
+	}
+	normalized, err := json.Marshal(args)
+	if err != nil {
+		return call.Name + "\x00" + call.Arguments
+	}
+	return call.Name + "\x00" + string(normalized)
+}
+
+// deadlineNear reports whether the context deadline is within the margin.
+func deadlineNear(ctx context.Context, margin time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && margin > 0 && time.Until(deadline) < margin
+}
+
+func saveRunState(ctx context.Context, state *chat.RunState, o *chat.Options) error {
+	if o.RunStore == nil {
+		return nil
+	}
+	if err := o.RunStore.Save(ctx, state); err != nil {
+		return fmt.Errorf("save run state: %w", err)
+	}
+	return nil
+}
+
+// deleteRunState deletes the state of the finished run, so the store does not grow with completed runs.
+func deleteRunState(ctx context.Context, state *chat.RunState, o *chat.Options) error {
+	if o.RunStore == nil || state.ID == "" {
+		return nil
+	}
+	if err := o.RunStore.Delete(ctx, state.ID); err != nil {
+		return fmt.Errorf("delete run state: %w", err)
+	}
+	return nil
+}
+
+func runResponse(state *chat.RunState) *chat.Response {
+	usage := state.Usage
+	finishReason := chat.FinishReasonStop
+	if !state.Done {
+		finishReason = chat.FinishReasonToolUse
+	}
+	return &chat.Response{
+		Model:        state.Request.Model,
+		FinishReason: finishReason,
+		Messages:     slices.Clone(state.Request.Messages[state.Start:]),
+		Usage:        &usage,
+	}
+}
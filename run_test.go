@@ -0,0 +1,274 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// fakeWeatherModel calls the weather tool until it gets a tool response.
+func fakeWeatherModel(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	last := req.Messages[len(req.Messages)-1]
+	if last.IsToolResponse() {
+		return &chat.Response{
+			Model:        req.Model,
+			FinishReason: chat.FinishReasonStop,
+			Messages:     []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "It is "+last.ToolResponse.Result)},
+			Usage:        &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+	return &chat.Response{
+		Model:        req.Model,
+		FinishReason: chat.FinishReasonToolUse,
+		Messages:     []chat.Message{chat.NewToolCallMessage("get_weather", "call-1", `{"location": "Tokyo"}`)},
+		Usage:        &chat.Usage{TotalTokens: 10},
+	}, nil
+}
+
+func setGenerate(t *testing.T, fn GenerateFunc) {
+	t.Helper()
+	orig := generate
+	generate = fn
+	t.Cleanup(func() { generate = orig })
+}
+
+func TestRun(t *testing.T) {
+	setGenerate(t, fakeWeatherModel)
+
+	calls := 0
+	tools := map[string]ToolHandler{
+		"get_weather": func(ctx context.Context, call *chat.ToolCall) (string, error) {
+			calls++
+			return "Rainy", nil
+		},
+	}
+
+	req := &chat.Request{
+		Model:    "test-model",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+	}
+	trace := chat.NewTrace()
+	resp, err := Run(t.Context(), req, tools, trace.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Events) != 2 || trace.Events[0].Type != chat.EventToolCall || trace.Events[1].Type != chat.EventToolResult {
+		t.Errorf("tool events mismatch: %v", trace.Events)
+	}
+	if calls != 1 {
+		t.Errorf("tool calls mismatch: %d", calls)
+	}
+	if len(resp.Messages) != 3 {
+		t.Fatalf("messages mismatch: %d", len(resp.Messages))
+	}
+	if resp.Messages[2].ContentString() != "It is Rainy" {
+		t.Errorf("content mismatch: %s", resp.Messages[2].ContentString())
+	}
+	if resp.Usage.TotalTokens != 20 {
+		t.Errorf("usage mismatch: %d", resp.Usage.TotalTokens)
+	}
+	if len(req.Messages) != 1 {
+		t.Errorf("request messages should not be modified: %d", len(req.Messages))
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	errCrash := errors.New("crash")
+	calls := 0
+	store := chat.NewMemoryRunStore()
+	tools := map[string]ToolHandler{
+		"get_weather": func(ctx context.Context, call *chat.ToolCall) (string, error) {
+			calls++
+			return "Sunny", nil
+		},
+	}
+
+	// crash on the second Generate call after the tool is executed.
+	n := 0
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		n++
+		if n == 2 {
+			return nil, errCrash
+		}
+		return fakeWeatherModel(ctx, req, opts...)
+	})
+
+	req := &chat.Request{
+		Model:    "test-model",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+	}
+	if _, err := Run(t.Context(), req, tools, chat.WithRunStore(store, "run-1")); !errors.Is(err, errCrash) {
+		t.Fatalf("expected crash, got %v", err)
+	}
+
+	resp, err := Resume(t.Context(), "run-1", tools, chat.WithRunStore(store, "run-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("tool should not be executed again: %d", calls)
+	}
+	if resp.Messages[len(resp.Messages)-1].ContentString() != "It is Sunny" {
+		t.Errorf("content mismatch: %s", resp.Messages[len(resp.Messages)-1].ContentString())
+	}
+}
+
+func TestRunMaxIterations(t *testing.T) {
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewToolCallMessage("loop", "call", `{}`)},
+		}, nil
+	})
+
+	_, err := Run(t.Context(), &chat.Request{Model: "test-model"}, nil, chat.WithMaxIterations(2))
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+}
+
+func TestRunSuspend(t *testing.T) {
+	setGenerate(t, fakeWeatherModel)
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Minute)
+	defer cancel()
+	tools := map[string]ToolHandler{
+		"get_weather": func(ctx context.Context, call *chat.ToolCall) (string, error) {
+			return "Cloudy", nil
+		},
+	}
+	req := &chat.Request{
+		Model:    "test-model",
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+	}
+
+	store := chat.NewMemoryRunStore()
+	resp, err := Run(ctx, req, tools, chat.WithDeadlineMargin(2*time.Minute), chat.WithRunStore(store, "run-1"))
+	var suspended *SuspendedError
+	if !errors.Is(err, ErrRunSuspended) || !errors.As(err, &suspended) {
+		t.Fatalf("expected SuspendedError, got %v", err)
+	}
+	if resp == nil || suspended.State.Iteration != 0 {
+		t.Fatalf("run should be suspended before the first Generate: %+v", suspended.State)
+	}
+	if saved, err := store.Load(t.Context(), "run-1"); err != nil || saved.ID != "run-1" {
+		t.Fatalf("suspended state should be saved: %+v, %v", saved, err)
+	}
+
+	// the state is serializable and resumed out-of-band without the deadline.
+	data, err := json.Marshal(suspended.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &chat.RunState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ResumeState(t.Context(), state, tools, chat.WithRunStore(store, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ID != "run-1" {
+		t.Errorf("run ID should be kept: %q", state.ID)
+	}
+	if resp.Messages[len(resp.Messages)-1].ContentString() != "It is Cloudy" {
+		t.Errorf("content mismatch: %s", resp.Messages[len(resp.Messages)-1].ContentString())
+	}
+	if _, err := store.Load(t.Context(), "run-1"); err == nil {
+		t.Error("finished run state should be deleted")
+	}
+}
+
+func TestRunToolLoopDetection(t *testing.T) {
+	// the model repeats the same call until the result is a note.
+	loopModel := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.IsToolResponse() && last.ToolResponse.Result != "Rainy" {
+			return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, last.ToolResponse.Result)}}, nil
+		}
+		id := fmt.Sprintf("call-%d", len(req.Messages))
+		return &chat.Response{Messages: []chat.Message{chat.NewToolCallMessage("get_weather", id, `{"location":  "Tokyo"}`)}}, nil
+	}
+	setGenerate(t, loopModel)
+
+	calls := 0
+	tools := map[string]ToolHandler{
+		"get_weather": func(ctx context.Context, call *chat.ToolCall) (string, error) {
+			calls++
+			return "Rainy", nil
+		},
+	}
+	req := &chat.Request{Model: "test-model", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather?")}}
+
+	resp, err := Run(t.Context(), req, tools, chat.WithToolLoopDetection(2, chat.ToolLoopNote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("tool calls mismatch: %d", calls)
+	}
+	if !strings.Contains(resp.Text(), "already called 2 times") {
+		t.Errorf("note mismatch: %s", resp.Text())
+	}
+
+	calls = 0
+	_, err = Run(t.Context(), req, tools, chat.WithToolLoopDetection(1, chat.ToolLoopCache), chat.WithMaxIterations(4))
+	if !errors.Is(err, ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("repeated calls should use the cached result: %d", calls)
+	}
+}
+
+func TestRunClarification(t *testing.T) {
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		last := req.Messages[len(req.Messages)-1]
+		if last.IsToolResponse() {
+			return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, "Booked: "+last.ToolResponse.Result)}}, nil
+		}
+		if !strings.Contains(fmt.Sprint(req.Tools), chat.AskUserToolName) {
+			t.Errorf("ask_user tool should be added: %+v", req.Tools)
+		}
+		return &chat.Response{
+			FinishReason: chat.FinishReasonToolUse,
+			Messages: []chat.Message{chat.NewToolCallMessage(chat.AskUserToolName, "call-1",
+				`{"question": "Which date?", "options": ["today", "tomorrow"]}`)},
+		}, nil
+	})
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "Book a table.")}}
+	resp, err := Run(context.Background(), req, nil, chat.WithClarification())
+	var clarification *ClarificationError
+	if !errors.As(err, &clarification) || !errors.Is(err, ErrClarificationNeeded) {
+		t.Fatalf("expected clarification error: %v", err)
+	}
+	if clarification.Question != "Which date?" || len(clarification.Options) != 2 || resp.FinishReason != chat.FinishReasonToolUse {
+		t.Errorf("clarification mismatch: %+v", clarification)
+	}
+	if len(req.Tools) != 0 {
+		t.Error("request should not be modified")
+	}
+
+	if err := clarification.State.AnswerClarification("tomorrow"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = ResumeState(context.Background(), clarification.State, nil, chat.WithClarification())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != `Booked: {"answer":"tomorrow"}` {
+		t.Errorf("response mismatch: %s", resp.Text())
+	}
+	if err := clarification.State.AnswerClarification("again"); err == nil {
+		t.Error("expected no pending clarification error")
+	}
+}
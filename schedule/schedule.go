@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package schedule dispatches the requests of tenants subject to a global rate limit
+// with weighted fairness, so heavy tenants do not starve others in shared services.
+// Requests are queued per tenant and dispatched by a token bucket in start-time fair queuing order.
+// Requests of chat.PriorityHigh are dispatched before queued lower priority requests,
+// and chat.PriorityBatch requests after the others.
+//
+//	s := schedule.New(500, schedule.WithWeight("acme", 2))
+//	req.Metadata = chat.Metadata{"tenant_id": "acme"}
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(s.Middleware()))
+package schedule
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// DefaultTenantKey is the metadata key of the tenant ID.
+const DefaultTenantKey = "tenant_id"
+
+// Scheduler queues requests per tenant and dispatches them within the rate limit.
+type Scheduler struct {
+	rate      float64 // requests per second
+	burst     float64
+	tenantKey string
+	weights   map[string]float64
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	queues  map[queueKey][]*waiter
+	finish  map[string]float64 // virtual finish time of the last dispatched request of the tenant
+	virtual float64
+	timer   *time.Timer
+}
+
+// queueKey is the queue of the tenant requests with the priority.
+type queueKey struct {
+	priority chat.Priority
+	tenant   string
+}
+
+// priorities are the priorities in dispatch order.
+var priorities = []chat.Priority{chat.PriorityHigh, chat.PriorityNormal, chat.PriorityBatch}
+
+type waiter struct {
+	key     queueKey
+	ready   chan struct{}
+	granted bool
+}
+
+// Option configures Scheduler.
+type Option func(s *Scheduler)
+
+// WithWeight sets the share of the tenant relative to the others. 1 by default.
+func WithWeight(tenant string, weight float64) Option {
+	return func(s *Scheduler) {
+		s.weights[tenant] = weight
+	}
+}
+
+// WithBurst sets the number of requests dispatched at once after an idle period. 1 by default.
+func WithBurst(burst int) Option {
+	return func(s *Scheduler) {
+		s.burst = float64(burst)
+	}
+}
+
+// WithTenantKey sets the metadata key of the tenant ID. DefaultTenantKey by default.
+func WithTenantKey(key string) Option {
+	return func(s *Scheduler) {
+		s.tenantKey = key
+	}
+}
+
+// New creates a scheduler dispatching up to requestsPerMinute requests, eg. the provider rate limit.
+func New(requestsPerMinute float64, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		rate:      requestsPerMinute / 60,
+		burst:     1,
+		tenantKey: DefaultTenantKey,
+		weights:   map[string]float64{},
+		queues:    map[queueKey][]*waiter{},
+		finish:    map[string]float64{},
+		last:      time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.tokens = s.burst
+	return s
+}
+
+// Wait blocks until a request of the tenant is dispatched or the context is done.
+// The priority is chat.PriorityFromContext of the context.
+func (s *Scheduler) Wait(ctx context.Context, tenant string) error {
+	key := queueKey{priority: chat.PriorityFromContext(ctx), tenant: tenant}
+	if !slices.Contains(priorities, key.priority) {
+		key.priority = chat.PriorityNormal
+	}
+	w := &waiter{key: key, ready: make(chan struct{})}
+	s.mu.Lock()
+	s.queues[key] = append(s.queues[key], w)
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.granted {
+			// dispatched concurrently; return the token for the next request.
+			s.tokens = math.Min(s.tokens+1, s.burst)
+			s.dispatch()
+			return ctx.Err()
+		}
+		s.remove(w)
+		return ctx.Err()
+	}
+}
+
+// QueueDepth returns the number of queued requests of the tenant.
+func (s *Scheduler) QueueDepth(tenant string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for key, q := range s.queues {
+		if key.tenant == tenant {
+			n += len(q)
+		}
+	}
+	return n
+}
+
+// QueueDepths returns the number of queued requests by tenant, eg. for metrics.
+func (s *Scheduler) QueueDepths() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	depths := map[string]int{}
+	for key, q := range s.queues {
+		depths[key.tenant] += len(q)
+	}
+	return depths
+}
+
+// Middleware waits for the dispatch of the request by the tenant in the request metadata.
+// Requests without the tenant metadata share the "" tenant.
+func (s *Scheduler) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if err := s.Wait(ctx, req.Metadata[s.tenantKey]); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// dispatch grants the queued requests while tokens are available, and schedules
+// the next dispatch when a token will be refilled. s.mu must be held.
+func (s *Scheduler) dispatch() {
+	now := time.Now()
+	s.tokens = math.Min(s.tokens+now.Sub(s.last).Seconds()*s.rate, s.burst)
+	s.last = now
+
+	for s.tokens >= 1 {
+		key, ok := s.next()
+		if !ok {
+			return
+		}
+		w := s.queues[key][0]
+		s.queues[key] = s.queues[key][1:]
+		if len(s.queues[key]) == 0 {
+			delete(s.queues, key)
+		}
+		start := math.Max(s.finish[key.tenant], s.virtual)
+		s.virtual = start
+		s.finish[key.tenant] = start + 1/s.weight(key.tenant)
+		s.tokens--
+		w.granted = true
+		close(w.ready)
+	}
+	if len(s.queues) > 0 && s.timer == nil && s.rate > 0 {
+		wait := time.Duration((1 - s.tokens) / s.rate * float64(time.Second))
+		s.timer = time.AfterFunc(wait, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.timer = nil
+			s.dispatch()
+		})
+	}
+}
+
+// next returns the queue of the highest priority with the earliest virtual start time of the tenant.
+func (s *Scheduler) next() (queueKey, bool) {
+	for _, priority := range priorities {
+		best, bestStart, found := queueKey{}, 0.0, false
+		for key := range s.queues {
+			if key.priority != priority {
+				continue
+			}
+			start := math.Max(s.finish[key.tenant], s.virtual)
+			if !found || start < bestStart || start == bestStart && key.tenant < best.tenant {
+				best, bestStart, found = key, start, true
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return queueKey{}, false
+}
+
+func (s *Scheduler) weight(tenant string) float64 {
+	if w, ok := s.weights[tenant]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+func (s *Scheduler) remove(w *waiter) {
+	q := s.queues[w.key]
+	for i, queued := range q {
+		if queued == w {
+			s.queues[w.key] = append(q[:i:i], q[i+1:]...)
+			break
+		}
+	}
+	if len(s.queues[w.key]) == 0 {
+		delete(s.queues, w.key)
+	}
+}
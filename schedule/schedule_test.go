@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package schedule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// enqueue starts waiting for the tenant and returns after the request is queued.
+func enqueue(t *testing.T, ctx context.Context, s *Scheduler, tenant string, wg *sync.WaitGroup, mu *sync.Mutex, order *[]string) {
+	t.Helper()
+	depth := s.QueueDepth(tenant)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := s.Wait(ctx, tenant); err != nil {
+			t.Error(err)
+			return
+		}
+		mu.Lock()
+		*order = append(*order, tenant)
+		mu.Unlock()
+	}()
+	for s.QueueDepth(tenant) == depth {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerFairness(t *testing.T) {
+	s := New(3000, WithWeight("b", 2))
+	if err := s.Wait(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	order := []string{}
+	for range 4 {
+		enqueue(t, t.Context(), s, "a", &wg, &mu, &order)
+	}
+	for range 4 {
+		enqueue(t, t.Context(), s, "b", &wg, &mu, &order)
+	}
+	if depths := s.QueueDepths(); depths["a"] != 4 || depths["b"] != 4 {
+		t.Errorf("queue depths mismatch: %v", depths)
+	}
+	wg.Wait()
+
+	// b has twice the share of a, which already used a request.
+	if got := strings.Join(order, ""); got != "bbabbaaa" {
+		t.Errorf("dispatch order mismatch: %s", got)
+	}
+	if len(s.QueueDepths()) != 0 {
+		t.Errorf("queues should be empty: %v", s.QueueDepths())
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	s := New(3000)
+	if err := s.Wait(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	order := []string{}
+	batch := chat.ContextWithPriority(t.Context(), chat.PriorityBatch)
+	high := chat.ContextWithPriority(t.Context(), chat.PriorityHigh)
+	enqueue(t, batch, s, "batch", &wg, &mu, &order)
+	enqueue(t, t.Context(), s, "normal", &wg, &mu, &order)
+	enqueue(t, high, s, "high", &wg, &mu, &order)
+	wg.Wait()
+
+	if got := strings.Join(order, " "); got != "high normal batch" {
+		t.Errorf("dispatch order mismatch: %s", got)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := New(1)
+	if err := s.Wait(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded: %v", err)
+	}
+	if s.QueueDepth("a") != 0 {
+		t.Error("canceled request should be removed from the queue")
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package sqlgen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// token is a lexical token of SQL. Strings and comments are skipped.
+type token struct {
+	text  string
+	ident bool
+}
+
+// Check runs dialect independent checks of the SQL statement:
+// balanced parentheses and quotes, a single statement, SELECT or WITH without
+// data-modifying keywords anywhere, eg. in a CTE, if readOnly,
+// and tables after FROM and JOIN existing in the schema.
+func Check(sql string, schema *Schema, readOnly bool) error {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return errors.New("empty statement")
+	}
+	if err := checkStatement(tokens, readOnly); err != nil {
+		return err
+	}
+	return checkTables(tokens, schema)
+}
+
+func checkStatement(tokens []token, readOnly bool) error {
+	depth := 0
+	for i, t := range tokens {
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth < 0 {
+				return errors.New("unbalanced parentheses")
+			}
+		case ";":
+			if i != len(tokens)-1 {
+				return errors.New("multiple statements are not allowed")
+			}
+		}
+	}
+	if depth != 0 {
+		return errors.New("unbalanced parentheses")
+	}
+
+	first := strings.ToUpper(tokens[0].text)
+	if readOnly && first != "SELECT" && first != "WITH" {
+		return fmt.Errorf("only SELECT queries are allowed: %s", first)
+	}
+	if readOnly {
+		for _, t := range tokens {
+			if kw := strings.ToUpper(t.text); t.ident && writeKeywords[kw] {
+				return fmt.Errorf("only SELECT queries are allowed: %s", kw)
+			}
+		}
+	}
+	return nil
+}
+
+// writeKeywords are the keywords of data or schema modifying statements.
+// Quoted identifiers and string literals are not keywords.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "UPSERT": true, "REPLACE": true,
+	"TRUNCATE": true, "DROP": true, "ALTER": true, "CREATE": true, "GRANT": true, "REVOKE": true,
+	"COPY": true, "INTO": true,
+}
+
+func checkTables(tokens []token, schema *Schema) error {
+	if schema == nil || len(schema.Tables) == 0 {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, t := range schema.Tables {
+		known[normalizeName(t.Name)] = true
+	}
+	// CTE names: WITH name AS (...), name AS (...)
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].ident && strings.EqualFold(tokens[i+1].text, "AS") && i+2 < len(tokens) && tokens[i+2].text == "(" {
+			known[normalizeName(tokens[i].text)] = true
+		}
+	}
+
+	for i := 0; i+1 < len(tokens); i++ {
+		kw := strings.ToUpper(tokens[i].text)
+		if kw != "FROM" && kw != "JOIN" {
+			continue
+		}
+		next := tokens[i+1]
+		if !next.ident {
+			continue
+		}
+		if !known[normalizeName(next.text)] {
+			return fmt.Errorf("unknown table: %s", next.text)
+		}
+	}
+	return nil
+}
+
+// normalizeName removes quotes and schema qualifier, eg. "public"."Users" -> users.
+func normalizeName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(strings.Trim(name, "\"`[]"))
+}
+
+func tokenize(sql string) ([]token, error) {
+	tokens := []token{}
+	rs := []rune(sql)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			end := i + 2
+			for end+1 < len(rs) && (rs[end] != '*' || rs[end+1] != '/') {
+				end++
+			}
+			if end+1 >= len(rs) {
+				return nil, errors.New("unterminated comment")
+			}
+			i = end + 2
+		case r == '\'':
+			end, err := skipQuoted(rs, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{text: "'"})
+			i = end
+		case isIdentRune(r) || r == '"' || r == '`' || r == '[':
+			end, err := scanIdent(rs, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{text: string(rs[i:end]), ident: true})
+			i = end
+		default:
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// skipQuoted returns the index after the closing quote. Doubled quotes are escapes.
+func skipQuoted(rs []rune, start int, quote rune) (int, error) {
+	for i := start + 1; i < len(rs); i++ {
+		if rs[i] != quote {
+			continue
+		}
+		if i+1 < len(rs) && rs[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1, nil
+	}
+	return 0, errors.New("unterminated quoted string")
+}
+
+// scanIdent scans a possibly qualified and quoted identifier, eg. public."Users".
+func scanIdent(rs []rune, start int) (int, error) {
+	i := start
+	for i < len(rs) {
+		switch {
+		case rs[i] == '"' || rs[i] == '`':
+			end, err := skipQuoted(rs, i, rs[i])
+			if err != nil {
+				return 0, err
+			}
+			i = end
+		case rs[i] == '[':
+			end := i + 1
+			for end < len(rs) && rs[end] != ']' {
+				end++
+			}
+			if end == len(rs) {
+				return 0, errors.New("unterminated quoted identifier")
+			}
+			i = end + 1
+		case isIdentRune(rs[i]):
+			for i < len(rs) && isIdentRune(rs[i]) {
+				i++
+			}
+		default:
+			return i, nil
+		}
+		if i < len(rs) && rs[i] == '.' {
+			i++
+			continue
+		}
+		return i, nil
+	}
+	return i, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package sqlgen
+
+import (
+	"testing"
+)
+
+var testSchema = &Schema{
+	Dialect: "PostgreSQL",
+	Tables: []Table{
+		{Name: "users", Columns: []Column{{Name: "id", Type: "bigint"}, {Name: "created_at", Type: "timestamp"}}},
+		{Name: "orders", Columns: []Column{{Name: "id", Type: "bigint"}, {Name: "user_id", Type: "bigint"}}},
+	},
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		wantErr bool
+	}{
+		{"select", "SELECT count(*) FROM users WHERE created_at > now() - interval '7 days';", false},
+		{"join", `SELECT u.id FROM public."users" u JOIN orders o ON o.user_id = u.id`, false},
+		{"cte", "WITH recent AS (SELECT * FROM users) SELECT * FROM recent", false},
+		{"comment", "SELECT id /* ; DROP TABLE users */ FROM users -- trailing", false},
+		{"string with semicolon", "SELECT 'a;b' FROM users", false},
+		{"unknown table", "SELECT * FROM accounts", true},
+		{"write", "DELETE FROM users", true},
+		{"data-modifying cte", "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", true},
+		{"select into", "SELECT * INTO backup FROM users", true},
+		{"keyword in string", "SELECT id FROM users WHERE note = 'please update'", false},
+		{"quoted keyword column", `SELECT "update" FROM users`, false},
+		{"multiple statements", "SELECT 1 FROM users; DROP TABLE users", true},
+		{"unbalanced", "SELECT count(* FROM users", true},
+		{"unterminated string", "SELECT 'abc FROM users", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.sql, testSchema, true)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
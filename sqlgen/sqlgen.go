@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package sqlgen generates validated SQL from natural language questions with a database schema.
+//
+//	g := sqlgen.New("gpt-4o-mini", &sqlgen.Schema{
+//		Dialect: "PostgreSQL",
+//		Tables: []sqlgen.Table{{Name: "users", Columns: []sqlgen.Column{{Name: "id", Type: "bigint"}}}},
+//	}, sqlgen.WithExplain(func(ctx context.Context, sql string) error {
+//		_, err := db.ExecContext(ctx, "EXPLAIN "+sql)
+//		return err
+//	}))
+//	result, err := g.Generate(ctx, "How many users signed up last week?")
+package sqlgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// DefaultMaxAttempts is the default number of generations including retries after validation errors.
+const DefaultMaxAttempts = 3
+
+// ErrInvalidSQL is returned when no valid SQL is generated within the maximum attempts.
+var ErrInvalidSQL = errors.New("invalid SQL")
+
+// ValidateFunc validates a generated SQL statement, eg. with a SQL parser of the dialect.
+type ValidateFunc func(sql string) error
+
+// ExplainFunc checks a generated SQL statement against the database, eg. with EXPLAIN.
+type ExplainFunc func(ctx context.Context, sql string) error
+
+// Column is a table column.
+type Column struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Table is a database table.
+type Table struct {
+	Name        string
+	Description string
+	Columns     []Column
+}
+
+// Schema is the database schema description given to the model.
+type Schema struct {
+	// Dialect is the SQL dialect, eg. "PostgreSQL", "MySQL", "SQLite".
+	Dialect string
+	Tables  []Table
+}
+
+// String returns the schema as CREATE TABLE statements with comments.
+func (s *Schema) String() string {
+	var b strings.Builder
+	for _, t := range s.Tables {
+		if t.Description != "" {
+			fmt.Fprintf(&b, "-- %s\n", t.Description)
+		}
+		fmt.Fprintf(&b, "CREATE TABLE %s (\n", t.Name)
+		for i, c := range t.Columns {
+			fmt.Fprintf(&b, "  %s %s", c.Name, c.Type)
+			if i < len(t.Columns)-1 {
+				b.WriteString(",")
+			}
+			if c.Description != "" {
+				fmt.Fprintf(&b, " -- %s", c.Description)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString(");\n")
+	}
+	return b.String()
+}
+
+// Result is a generated SQL statement.
+type Result struct {
+	SQL         string     `json:"sql"`
+	Explanation string     `json:"explanation"`
+	Attempts    int        `json:"attempts"`
+	Usage       chat.Usage `json:"usage"`
+}
+
+// Generator generates SQL with the model.
+type Generator struct {
+	model       string
+	schema      *Schema
+	readOnly    bool
+	maxAttempts int
+	validate    ValidateFunc
+	explain     ExplainFunc
+	generate    gengo.GenerateFunc
+	options     []chat.Option
+}
+
+// Option configures Generator.
+type Option func(g *Generator)
+
+// WithWrite allows statements other than SELECT. Only SELECT and WITH queries are allowed by default.
+func WithWrite() Option {
+	return func(g *Generator) {
+		g.readOnly = false
+	}
+}
+
+// WithMaxAttempts sets the number of generations including retries after validation errors, at least 1.
+func WithMaxAttempts(n int) Option {
+	return func(g *Generator) {
+		g.maxAttempts = n
+	}
+}
+
+// WithValidator adds a SQL validator run after the built-in checks.
+func WithValidator(fn ValidateFunc) Option {
+	return func(g *Generator) {
+		g.validate = fn
+	}
+}
+
+// WithExplain sets a callback checking the SQL against the database.
+func WithExplain(fn ExplainFunc) Option {
+	return func(g *Generator) {
+		g.explain = fn
+	}
+}
+
+// WithGenerator replaces the generate function.
+func WithGenerator(fn gengo.GenerateFunc) Option {
+	return func(g *Generator) {
+		g.generate = fn
+	}
+}
+
+// WithOptions sets the options passed to generate.
+func WithOptions(opts ...chat.Option) Option {
+	return func(g *Generator) {
+		g.options = opts
+	}
+}
+
+// New creates a Generator of the model and the schema.
+func New(model string, schema *Schema, opts ...Option) *Generator {
+	g := &Generator{
+		model:       model,
+		schema:      schema,
+		readOnly:    true,
+		maxAttempts: DefaultMaxAttempts,
+		generate:    gengo.Generate,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+var resultSchema = jsonschema.MustParseJSONString(`{
+	"type": "object",
+	"properties": {
+		"sql": {"type": "string", "description": "single SQL statement"},
+		"explanation": {"type": "string", "description": "short explanation of the query"}
+	},
+	"required": ["sql", "explanation"],
+	"additionalProperties": false
+}`)
+
+const systemPrompt = `You are an expert %s developer. Write a single SQL statement answering the question with the database schema below.
+Use only the tables and columns in the schema.%s
+
+%s`
+
+// Generate generates a validated SQL statement answering the question.
+// Validation errors are sent back to the model until the maximum attempts.
+func (g *Generator) Generate(ctx context.Context, question string) (*Result, error) {
+	dialect := g.schema.Dialect
+	if dialect == "" {
+		dialect = "SQL"
+	}
+	rule := ""
+	if g.readOnly {
+		rule = " Only SELECT queries are allowed."
+	}
+	req := &chat.Request{
+		Model: g.model,
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleSystem, fmt.Sprintf(systemPrompt, dialect, rule, g.schema.String())),
+			chat.NewTextMessage(chat.MessageRoleHuman, question),
+		},
+		ResponseSchema: resultSchema,
+	}
+
+	result := &Result{}
+	var lastErr error
+	for result.Attempts < max(g.maxAttempts, 1) {
+		result.Attempts++
+		resp, err := g.generate(ctx, req, g.options...)
+		if err != nil {
+			return nil, err
+		}
+		result.Usage.Add(resp.Usage)
+		if err := resp.JSON(result); err != nil {
+			return nil, fmt.Errorf("decode result: %w", err)
+		}
+		result.SQL = strings.TrimSpace(result.SQL)
+
+		lastErr = g.check(ctx, result.SQL)
+		if lastErr == nil {
+			return result, nil
+		}
+		req.Messages = append(req.Messages, resp.Messages...)
+		req.Messages = append(req.Messages, chat.NewTextMessage(chat.MessageRoleHuman,
+			fmt.Sprintf("The SQL is invalid: %v\nFix the SQL.", lastErr)))
+	}
+	return result, fmt.Errorf("%w: %w", ErrInvalidSQL, lastErr)
+}
+
+func (g *Generator) check(ctx context.Context, sql string) error {
+	if err := Check(sql, g.schema, g.readOnly); err != nil {
+		return err
+	}
+	if g.validate != nil {
+		if err := g.validate(sql); err != nil {
+			return err
+		}
+	}
+	if g.explain != nil {
+		if err := g.explain(ctx, sql); err != nil {
+			return fmt.Errorf("explain: %w", err)
+		}
+	}
+	return nil
+}
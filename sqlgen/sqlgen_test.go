@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package sqlgen
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerate(t *testing.T) {
+	answers := []string{
+		`{"sql": "SELECT count(*) FROM accounts", "explanation": "count accounts"}`,
+		`{"sql": "SELECT count(*) FROM users", "explanation": "count users"}`,
+	}
+	var requests []*chat.Request
+	generate := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		requests = append(requests, req)
+		answer := answers[len(requests)-1]
+		return &chat.Response{
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, answer)},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+
+	explained := ""
+	g := New("gpt-4o-mini", testSchema, WithGenerator(generate), WithExplain(func(ctx context.Context, sql string) error {
+		explained = sql
+		return nil
+	}))
+	result, err := g.Generate(t.Context(), "How many users?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SQL != "SELECT count(*) FROM users" || result.Attempts != 2 || result.Usage.TotalTokens != 20 {
+		t.Errorf("result mismatch: %+v", result)
+	}
+	if explained != result.SQL {
+		t.Errorf("explain should be called with the SQL: %s", explained)
+	}
+	if !strings.Contains(requests[0].Messages[0].ContentString(), "CREATE TABLE users") {
+		t.Errorf("schema should be in the system prompt: %s", requests[0].Messages[0].ContentString())
+	}
+	last := requests[1].Messages[len(requests[1].Messages)-1].ContentString()
+	if !strings.Contains(last, "unknown table: accounts") {
+		t.Errorf("validation error should be sent back: %s", last)
+	}
+
+	g = New("gpt-4o-mini", testSchema, WithGenerator(generate), WithMaxAttempts(1))
+	requests = nil
+	if _, err := g.Generate(t.Context(), "How many users?"); !errors.Is(err, ErrInvalidSQL) {
+		t.Errorf("expected ErrInvalidSQL, got %v", err)
+	}
+
+	g = New("gpt-4o-mini", testSchema, WithGenerator(generate), WithMaxAttempts(0))
+	requests = nil
+	if _, err := g.Generate(t.Context(), "How many users?"); !errors.Is(err, ErrInvalidSQL) || len(requests) != 1 {
+		t.Errorf("zero attempts should generate once: %d requests, %v", len(requests), err)
+	}
+}
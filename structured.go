@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// DefaultStructRetries is the number of regenerations of GenerateStruct on schema mismatch.
+const DefaultStructRetries = 2
+
+// GenerateStruct generates a JSON response and unmarshals it into T.
+// ResponseSchema is derived from T with jsonschema.For unless the request has one.
+// The response is validated against the schema and regenerated with the validation error
+// up to DefaultStructRetries times, overridden by chat.WithSchemaValidation.
+// If the response still does not match, *chat.SchemaMismatchError is returned.
+func GenerateStruct[T any](ctx context.Context, req *chat.Request, opts ...chat.Option) (T, *chat.Response, error) {
+	var v T
+	r := *req
+	if r.ResponseSchema == nil {
+		schema, err := jsonschema.For[T]()
+		if err != nil {
+			return v, nil, fmt.Errorf("response schema: %w", err)
+		}
+		r.ResponseSchema = schema
+	}
+	opts = append([]chat.Option{chat.WithSchemaValidation(DefaultStructRetries)}, opts...)
+	resp, err := generate(ctx, &r, opts...)
+	if err != nil {
+		return v, nil, err
+	}
+	if err := json.Unmarshal([]byte(resp.Text()), &v); err != nil {
+		return v, resp, fmt.Errorf("unmarshal response: %w", err)
+	}
+	return v, resp, nil
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	type city struct {
+		Name       string `json:"name"`
+		Population int    `json:"population" description:"population in thousands"`
+	}
+	var got *chat.Request
+	setGenerate(t, fakeJSONModel(`{"name": "Tokyo", "population": 14000}`, &got))
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "The capital of Japan?")}}
+	c, resp, err := GenerateStruct[city](context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Tokyo" || c.Population != 14000 || resp.Usage.TotalTokens != 10 {
+		t.Errorf("result mismatch: %+v", c)
+	}
+	if got.ResponseSchema["type"] != "object" || req.ResponseSchema != nil {
+		t.Errorf("schema should be set on a copy of the request: %v", got.ResponseSchema)
+	}
+
+	setGenerate(t, fakeJSONModel(`{"name": 1}`, &got))
+	if _, _, err := GenerateStruct[city](context.Background(), req); err == nil {
+		t.Error("expected unmarshal error")
+	}
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package tokenizer counts, truncates and splits text by the tokens of a model.
+// Tokenizers are registered by model family, eg. a tiktoken encoding for "gpt-4o"
+// or a sentencepiece model for a local model, and used by the token estimation of requests.
+// Models without a registered tokenizer use the Heuristic estimate.
+//
+//	enc, _ := tiktoken.GetEncoding("o200k_base")
+//	tokenizer.Register("gpt-4o", tokenizer.Func(func(s string) int { return len(enc.Encode(s, nil, nil)) }))
+//	n := tokenizer.For("gpt-4o-mini").Count(text)
+package tokenizer
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens of text.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Func is a function Tokenizer.
+type Func func(text string) int
+
+func (f Func) Count(text string) int {
+	return f(text)
+}
+
+const (
+	// charsPerToken is the approximate number of Latin characters per token.
+	charsPerToken = 4
+	// otherCharsPerToken is the approximate number of non-Latin, non-CJK characters per token, eg. Cyrillic.
+	otherCharsPerToken = 2
+)
+
+// Heuristic estimates tokens by script: Latin text is about 4 characters per token,
+// while CJK characters (Japanese, Chinese, Korean) are about one token each.
+var Heuristic Tokenizer = Func(func(s string) int {
+	latin, cjk, other := 0, 0, 0
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf || unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+		default:
+			other++
+		}
+	}
+	return (latin+charsPerToken-1)/charsPerToken + cjk + (other+otherCharsPerToken-1)/otherCharsPerToken
+})
+
+// Scaled returns the tokenizer with the counts multiplied by the factor,
+// eg. Heuristic calibrated for a model family.
+func Scaled(t Tokenizer, factor float64) Tokenizer {
+	return Func(func(s string) int {
+		return int(math.Ceil(float64(t.Count(s)) * factor))
+	})
+}
+
+var (
+	mu       sync.RWMutex
+	families = map[string]Tokenizer{}
+)
+
+// Register sets the tokenizer of the model family, matched as a model name prefix, eg. "gpt-4o" or "llama-3".
+func Register(family string, t Tokenizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	families[family] = t
+}
+
+// Unregister removes the tokenizer of the model family.
+func Unregister(family string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(families, family)
+}
+
+// Lookup returns the tokenizer of the longest family matching the model, eg. "gpt-4o" for "gpt-4o-mini".
+// A provider prefix of the model, eg. "gemini/", is ignored.
+func Lookup(model string) (Tokenizer, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	model = model[strings.LastIndex(model, "/")+1:]
+	best := ""
+	for family := range families {
+		if strings.HasPrefix(model, family) && len(family) > len(best) {
+			best = family
+		}
+	}
+	if best == "" {
+		return nil, false
+	}
+	return families[best], true
+}
+
+// For returns the tokenizer of the model, or Heuristic if none is registered.
+func For(model string) Tokenizer {
+	if t, ok := Lookup(model); ok {
+		return t
+	}
+	return Heuristic
+}
+
+// Truncate returns the longest prefix of the text with at most limit tokens.
+func Truncate(t Tokenizer, text string, limit int) string {
+	if t.Count(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	n := sort.Search(len(runes)+1, func(i int) bool {
+		return t.Count(string(runes[:i])) > limit
+	})
+	return string(runes[:max(n-1, 0)])
+}
+
+// Split splits the text into chunks of at most limit tokens, preferring line and word boundaries.
+func Split(t Tokenizer, text string, limit int) []string {
+	chunks := []string{}
+	for text != "" {
+		chunk := Truncate(t, text, limit)
+		if chunk == "" {
+			// a single character exceeds the limit.
+			_, size := utf8.DecodeRuneInString(text)
+			chunk = text[:size]
+		} else if len(chunk) < len(text) {
+			if i := strings.LastIndexAny(chunk, "\n"); i > 0 {
+				chunk = chunk[:i+1]
+			} else if i := strings.LastIndexFunc(chunk, unicode.IsSpace); i > 0 {
+				chunk = chunk[:i+1]
+			}
+		}
+		chunks = append(chunks, chunk)
+		text = text[len(chunk):]
+	}
+	return chunks
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+)
+
+// words counts space separated words as tokens.
+var words = Func(func(s string) int { return len(strings.Fields(s)) })
+
+func TestRegistry(t *testing.T) {
+	Register("llama-3", words)
+	Register("llama-3.3", Scaled(words, 2))
+	t.Cleanup(func() {
+		Unregister("llama-3")
+		Unregister("llama-3.3")
+	})
+
+	if got := For("llama-3.1-8b").Count("a b c"); got != 3 {
+		t.Errorf("family tokenizer mismatch: %d", got)
+	}
+	if got := For("groq/llama-3.3-70b").Count("a b c"); got != 6 {
+		t.Errorf("longest family should match: %d", got)
+	}
+	if _, ok := Lookup("gpt-4o"); ok {
+		t.Error("unregistered model should not match")
+	}
+	if got := For("gpt-4o").Count("abcdefgh"); got != 2 {
+		t.Errorf("heuristic should be the default: %d", got)
+	}
+}
+
+func TestTruncateSplit(t *testing.T) {
+	text := "one two three\nfour five six seven"
+	if got := Truncate(words, text, 4); got != "one two three\nfour " {
+		t.Errorf("truncate mismatch: %q", got)
+	}
+	if got := Truncate(words, text, 10); got != text {
+		t.Errorf("text within the limit should be kept: %q", got)
+	}
+
+	chunks := Split(words, text, 4)
+	want := []string{"one two three\n", "four five six seven"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("split mismatch: %q", chunks)
+	}
+	if got := Split(Heuristic, "日本語", 0); len(got) != 3 {
+		t.Errorf("characters exceeding the limit should be split: %q", got)
+	}
+}
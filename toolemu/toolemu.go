@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package toolemu emulates tool calling with prompts for models without native tool support.
+// Tool calls in the model output are returned as ToolCall messages, so gengo.Run works uniformly.
+//
+//	resp, err := gengo.Run(ctx, req, tools, chat.WithMiddleware(toolemu.Middleware()))
+package toolemu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// Mode is the emulation mode.
+type Mode string
+
+const (
+	// ModePrompt describes the tools and the output format in the system prompt.
+	ModePrompt Mode = "prompt"
+	// ModeSchema also forces the output format with the response schema.
+	ModeSchema Mode = "schema"
+)
+
+// Option configures the middleware.
+type Option func(c *config)
+
+type config struct {
+	mode Mode
+}
+
+// WithMode sets the emulation mode. ModePrompt by default.
+func WithMode(mode Mode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// output is the model output of the emulation.
+type output struct {
+	Tool      string          `json:"tool,omitempty"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Answer    string          `json:"answer,omitempty"`
+}
+
+const systemPrompt = `You can call the following tools.
+
+%s
+To call a tool, respond only with the JSON: {"tool": "<tool name>", "arguments": {<arguments>}}
+Tool results are given in messages starting with "Tool result".
+To answer without calling a tool, respond only with the JSON: {"answer": "<answer>"}`
+
+// Middleware converts the request tools to a system prompt and
+// the tool call JSON in the model output to ToolCall messages.
+func Middleware(opts ...Option) chat.Middleware {
+	c := &config{mode: ModePrompt}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if len(req.Tools) == 0 {
+				return next(ctx, req)
+			}
+			resp, err := next(ctx, c.convertRequest(req))
+			if err != nil {
+				return nil, err
+			}
+			return convertResponse(resp, len(req.Messages))
+		}
+	}
+}
+
+func (c *config) convertRequest(req *chat.Request) *chat.Request {
+	r := *req
+	r.Tools = nil
+	r.MustCallTool = false
+	r.Messages = []chat.Message{chat.NewTextMessage(chat.MessageRoleSystem, fmt.Sprintf(systemPrompt, describeTools(req.Tools)))}
+	for _, msg := range req.Messages {
+		r.Messages = append(r.Messages, convertMessage(msg))
+	}
+	if c.mode == ModeSchema && r.ResponseSchema == nil {
+		r.ResponseSchema = outputSchema(req.Tools)
+	}
+	return &r
+}
+
+func describeTools(tools []chat.Tool) string {
+	var b strings.Builder
+	for _, tool := range tools {
+		fmt.Fprintf(&b, "- %s: %s\n", tool.Name, tool.Description)
+		if tool.InputSchema != nil {
+			fmt.Fprintf(&b, "  arguments JSON schema: %s\n", tool.InputSchema.JSON())
+		}
+	}
+	return b.String()
+}
+
+// convertMessage converts tool call and response messages to text messages.
+func convertMessage(msg chat.Message) chat.Message {
+	switch {
+	case msg.IsToolCall():
+		data, _ := json.Marshal(output{Tool: msg.ToolCall.Name, Arguments: json.RawMessage(msg.ToolCall.Arguments)})
+		return chat.NewTextMessage(chat.MessageRoleAI, string(data))
+	case msg.IsToolResponse():
+		return chat.NewTextMessage(chat.MessageRoleHuman,
+			fmt.Sprintf("Tool result of %s (%s): %s", msg.ToolResponse.Name, msg.ToolResponse.ID, msg.ToolResponse.Result))
+	}
+	return msg
+}
+
+func outputSchema(tools []chat.Tool) jsonschema.Schema {
+	names := make([]any, len(tools))
+	for i, tool := range tools {
+		names[i] = tool.Name
+	}
+	return jsonschema.Schema{
+		"type": "object",
+		"properties": map[string]any{
+			"tool":      map[string]any{"type": "string", "enum": names},
+			"arguments": map[string]any{"type": "object"},
+			"answer":    map[string]any{"type": "string"},
+		},
+	}
+}
+
+// convertResponse converts the output JSON to a ToolCall message or an answer text message.
+// Output that is not JSON is returned as the answer.
+func convertResponse(resp *chat.Response, turn int) (*chat.Response, error) {
+	out, ok := parseOutput(resp.Text())
+	if !ok {
+		return resp, nil
+	}
+
+	r := *resp
+	if out.Tool == "" {
+		r.Messages = []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, out.Answer)}
+		return &r, nil
+	}
+	args := string(out.Arguments)
+	if args == "" {
+		args = "{}"
+	}
+	r.Messages = []chat.Message{chat.NewToolCallMessage(out.Tool, fmt.Sprintf("call_%d", turn), args)}
+	r.FinishReason = chat.FinishReasonToolUse
+	return &r, nil
+}
+
+// parseOutput parses the output JSON, ignoring text around it such as code fences.
+func parseOutput(text string) (*output, bool) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, false
+	}
+	out := &output{}
+	if err := json.Unmarshal([]byte(text[start:end+1]), out); err != nil {
+		return nil, false
+	}
+	if out.Tool == "" && out.Answer == "" {
+		return nil, false
+	}
+	return out, true
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolemu
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got *chat.Request
+	answer := "```json\n{\"tool\": \"weather\", \"arguments\": {\"city\": \"Tokyo\"}}\n```"
+	generate := Middleware(WithMode(ModeSchema))(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, answer)}}, nil
+	})
+
+	req := &chat.Request{
+		Tools: []chat.Tool{{
+			Name:        "weather",
+			Description: "Get the weather of the city.",
+			InputSchema: jsonschema.MustParseJSONString(`{"type": "object", "properties": {"city": {"type": "string"}}}`),
+		}},
+		Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")},
+	}
+	resp, err := generate(t.Context(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Tools != nil || got.ResponseSchema == nil {
+		t.Errorf("tools should be converted to prompt and schema: %+v", got)
+	}
+	if !strings.Contains(got.Messages[0].ContentString(), "- weather: Get the weather of the city.") {
+		t.Errorf("system prompt mismatch: %s", got.Messages[0].ContentString())
+	}
+	call := resp.FirstToolCall()
+	if call == nil || call.Name != "weather" || call.Arguments != `{"city": "Tokyo"}` || resp.FinishReason != chat.FinishReasonToolUse {
+		t.Fatalf("tool call mismatch: %+v", resp)
+	}
+
+	// second turn with the tool result
+	req.Messages = append(req.Messages,
+		chat.NewToolCallMessage(call.Name, call.ID, call.Arguments),
+		chat.NewToolResponseMessage(call.Name, call.ID, `{"weather": "sunny"}`),
+	)
+	answer = `{"answer": "It is sunny in Tokyo."}`
+	resp, err = generate(t.Context(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got.Messages[3].ContentString(), "Tool result of weather") || got.Messages[3].Role != chat.MessageRoleHuman {
+		t.Errorf("tool response should be converted to text: %+v", got.Messages[3])
+	}
+	if resp.Text() != "It is sunny in Tokyo." || resp.FirstToolCall() != nil {
+		t.Errorf("answer mismatch: %+v", resp)
+	}
+}
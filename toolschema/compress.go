@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// MetadataTokensSaved is the response metadata key of the estimated input tokens saved per request.
+const MetadataTokensSaved = "tool_schema_tokens_saved"
+
+// minRefBytes is the minimum size of a repeated sub-schema moved to $defs.
+const minRefBytes = 64
+
+// Level is the level of tool schema compression.
+type Level int
+
+const (
+	// LevelNone keeps the schemas as is.
+	LevelNone Level = iota
+	// LevelAnnotations removes annotations not used for calling, eg. title, examples and $comment.
+	LevelAnnotations
+	// LevelDescriptions also removes the property descriptions.
+	LevelDescriptions
+	// LevelTerse also shortens the tool descriptions to the first sentence.
+	LevelTerse
+)
+
+var annotationKeys = []string{"title", "examples", "$comment", "$schema", "default", "deprecated", "readOnly", "writeOnly"}
+
+// Compress returns the tools with the schemas compressed to the level.
+func Compress(tools []chat.Tool, level Level) []chat.Tool {
+	out := make([]chat.Tool, len(tools))
+	for i, tool := range tools {
+		out[i] = tool
+		if level >= LevelTerse {
+			out[i].Description = firstSentence(tool.Description)
+		}
+		if tool.InputSchema != nil && level > LevelNone {
+			out[i].InputSchema = jsonschema.Schema(stripSchema(tool.InputSchema.Clone(), level))
+		}
+	}
+	return out
+}
+
+func stripSchema(node map[string]any, level Level) map[string]any {
+	for _, key := range annotationKeys {
+		delete(node, key)
+	}
+	if level >= LevelDescriptions {
+		delete(node, "description")
+	}
+	for key, v := range node {
+		switch key {
+		case "properties", "$defs", "definitions", "patternProperties":
+			if props, ok := v.(map[string]any); ok {
+				for name, p := range props {
+					if m, ok := p.(map[string]any); ok {
+						props[name] = stripSchema(m, level)
+					}
+				}
+			}
+		case "items", "additionalProperties", "not":
+			if m, ok := v.(map[string]any); ok {
+				node[key] = stripSchema(m, level)
+			}
+		case "anyOf", "oneOf", "allOf":
+			if list, ok := v.([]any); ok {
+				for i, item := range list {
+					if m, ok := item.(map[string]any); ok {
+						list[i] = stripSchema(m, level)
+					}
+				}
+			}
+		}
+	}
+	return node
+}
+
+func firstSentence(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, ". "); i >= 0 {
+		return s[:i+1]
+	}
+	return s
+}
+
+// Dedupe returns the schema with sub-schemas repeated in the schema moved to $defs
+// and referenced by $ref, for providers resolving local references, eg. OpenAI and Anthropic.
+func Dedupe(schema jsonschema.Schema) jsonschema.Schema {
+	counts := map[string]int{}
+	walkSubschemas(schema, func(m map[string]any) {
+		if key, ok := refKey(m); ok {
+			counts[key]++
+		}
+	})
+
+	clone := schema.Clone()
+	// the definitions are added to the existing $defs with names not used by them.
+	defs, _ := clone["$defs"].(map[string]any)
+	if defs == nil {
+		defs = map[string]any{}
+	}
+	names := map[string]string{}
+	next := 1
+	rewriteSubschemas(clone, func(m map[string]any) (map[string]any, bool) {
+		key, ok := refKey(m)
+		if !ok || counts[key] < 2 {
+			return m, false
+		}
+		name, ok := names[key]
+		if !ok {
+			for {
+				name = "def" + strconv.Itoa(next)
+				next++
+				if _, used := defs[name]; !used {
+					break
+				}
+			}
+			names[key] = name
+			defs[name] = m
+		}
+		return map[string]any{"$ref": "#/$defs/" + name}, true
+	})
+	if len(defs) > 0 {
+		clone["$defs"] = defs
+	}
+	return clone
+}
+
+func refKey(m map[string]any) (string, bool) {
+	if _, ok := m["properties"]; !ok {
+		return "", false
+	}
+	js, err := json.Marshal(m)
+	if err != nil || len(js) < minRefBytes {
+		return "", false
+	}
+	return string(js), true
+}
+
+// walkSubschemas calls fn for the sub-schemas of the properties and items, in key order.
+func walkSubschemas(node map[string]any, fn func(map[string]any)) {
+	for _, child := range children(node) {
+		fn(child)
+		walkSubschemas(child, fn)
+	}
+}
+
+// rewriteSubschemas replaces the sub-schemas with the result of fn, descending into the kept ones.
+func rewriteSubschemas(node map[string]any, fn func(map[string]any) (map[string]any, bool)) {
+	if props, ok := node["properties"].(map[string]any); ok {
+		for _, name := range sortedKeys(props) {
+			if m, ok := props[name].(map[string]any); ok {
+				replaced, done := fn(m)
+				props[name] = replaced
+				if !done {
+					rewriteSubschemas(m, fn)
+				}
+			}
+		}
+	}
+	if m, ok := node["items"].(map[string]any); ok {
+		replaced, done := fn(m)
+		node["items"] = replaced
+		if !done {
+			rewriteSubschemas(m, fn)
+		}
+	}
+}
+
+func children(node map[string]any) []map[string]any {
+	var out []map[string]any
+	if props, ok := node["properties"].(map[string]any); ok {
+		for _, name := range sortedKeys(props) {
+			if m, ok := props[name].(map[string]any); ok {
+				out = append(out, m)
+			}
+		}
+	}
+	if m, ok := node["items"].(map[string]any); ok {
+		out = append(out, m)
+	}
+	return out
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type compressor struct {
+	level     Level
+	maxTokens int
+	refs      bool
+}
+
+// CompressOption configures CompressMiddleware.
+type CompressOption func(c *compressor)
+
+// WithLevel sets the maximum compression level. LevelDescriptions by default.
+func WithLevel(level Level) CompressOption {
+	return func(c *compressor) {
+		c.level = level
+	}
+}
+
+// WithMaxToolTokens compresses adaptively: the lowest level bringing the estimated tool tokens
+// under n is used, up to the maximum level. 0 always uses the maximum level.
+func WithMaxToolTokens(n int) CompressOption {
+	return func(c *compressor) {
+		c.maxTokens = n
+	}
+}
+
+// WithRefs moves repeated sub-schemas to $defs. Use it only with providers resolving $ref.
+func WithRefs() CompressOption {
+	return func(c *compressor) {
+		c.refs = true
+	}
+}
+
+// CompressMiddleware compresses the tool schemas of requests and reports
+// the estimated input tokens saved in the response metadata MetadataTokensSaved.
+func CompressMiddleware(opts ...CompressOption) chat.Middleware {
+	c := &compressor{level: LevelDescriptions}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if len(req.Tools) == 0 {
+				return next(ctx, req)
+			}
+			tools, saved := c.compress(req.Tools)
+			r := *req
+			r.Tools = tools
+			resp, err := next(ctx, &r)
+			if err != nil {
+				return nil, err
+			}
+			if resp.Metadata == nil {
+				resp.Metadata = chat.Metadata{}
+			}
+			resp.Metadata[MetadataTokensSaved] = fmt.Sprint(saved)
+			return resp, nil
+		}
+	}
+}
+
+func (c *compressor) compress(tools []chat.Tool) ([]chat.Tool, int) {
+	before := toolTokens(tools)
+	out := tools
+	for level := LevelAnnotations; level <= c.level; level++ {
+		out = Compress(tools, level)
+		if c.refs {
+			for i := range out {
+				if out[i].InputSchema != nil {
+					out[i].InputSchema = Dedupe(out[i].InputSchema)
+				}
+			}
+		}
+		if c.maxTokens > 0 && toolTokens(out) <= c.maxTokens {
+			break
+		}
+	}
+	return out, before - toolTokens(out)
+}
+
+func toolTokens(tools []chat.Tool) int {
+	return chat.EstimateTokens(&chat.Request{Tools: tools})
+}
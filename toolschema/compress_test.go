@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolschema
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+const addressSchema = `{"type": "object", "description": "A postal address.", "properties": {
+	"street": {"type": "string", "description": "Street and number."},
+	"city": {"type": "string", "description": "City name."}
+}}`
+
+func testTools() []chat.Tool {
+	return []chat.Tool{{
+		Name:        "ship",
+		Description: "Ship an order. Use it after payment.\nReturns the tracking number.",
+		InputSchema: jsonschema.MustParseJSONString(`{"type": "object", "title": "Ship", "properties": {
+			"from": ` + addressSchema + `,
+			"to": ` + addressSchema + `,
+			"order_id": {"type": "string", "description": "Order ID.", "examples": ["o-1"]}
+		}}`),
+	}}
+}
+
+func TestCompress(t *testing.T) {
+	tools := testTools()
+
+	annotations := Compress(tools, LevelAnnotations)
+	props := annotations[0].InputSchema["properties"].(map[string]any)
+	if _, ok := annotations[0].InputSchema["title"]; ok {
+		t.Error("title should be removed")
+	}
+	if _, ok := props["order_id"].(map[string]any)["examples"]; ok {
+		t.Error("examples should be removed")
+	}
+	if _, ok := props["order_id"].(map[string]any)["description"]; !ok {
+		t.Error("description should be kept")
+	}
+
+	terse := Compress(tools, LevelTerse)
+	props = terse[0].InputSchema["properties"].(map[string]any)
+	if _, ok := props["order_id"].(map[string]any)["description"]; ok {
+		t.Error("description should be removed")
+	}
+	if terse[0].Description != "Ship an order." {
+		t.Errorf("description mismatch: %s", terse[0].Description)
+	}
+	if _, ok := tools[0].InputSchema["title"]; !ok {
+		t.Error("original tools should not be modified")
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	schema := Dedupe(testTools()[0].InputSchema)
+	props := schema["properties"].(map[string]any)
+	if props["from"].(map[string]any)["$ref"] != "#/$defs/def1" || props["to"].(map[string]any)["$ref"] != "#/$defs/def1" {
+		t.Errorf("repeated schema should be referenced: %v", props)
+	}
+	if _, ok := schema["$defs"].(map[string]any)["def1"]; !ok {
+		t.Errorf("defs mismatch: %v", schema["$defs"])
+	}
+	if !schema.IsValid() {
+		t.Error("deduped schema should be valid")
+	}
+
+	// existing definitions are kept and not overwritten.
+	input := testTools()[0].InputSchema.Clone()
+	input["$defs"] = map[string]any{"def1": map[string]any{"type": "string"}}
+	schema = Dedupe(input)
+	defs := schema["$defs"].(map[string]any)
+	if defs["def1"].(map[string]any)["type"] != "string" || defs["def2"] == nil {
+		t.Errorf("defs should be merged: %v", defs)
+	}
+	props = schema["properties"].(map[string]any)
+	if props["from"].(map[string]any)["$ref"] != "#/$defs/def2" {
+		t.Errorf("repeated schema should be referenced by a new name: %v", props)
+	}
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	var got *chat.Request
+	generate := CompressMiddleware(WithRefs())(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{}, nil
+	})
+	req := &chat.Request{Tools: testTools()}
+	resp, err := generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := strconv.Atoi(resp.Metadata[MetadataTokensSaved])
+	if err != nil || saved <= 0 {
+		t.Errorf("saved tokens mismatch: %v", resp.Metadata)
+	}
+	if chat.EstimateTokens(got) >= chat.EstimateTokens(req) {
+		t.Error("tools should be compressed")
+	}
+
+	// adaptive: annotations only are enough under a loose limit.
+	generate = CompressMiddleware(WithMaxToolTokens(1000), WithLevel(LevelTerse))(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req
+		return &chat.Response{}, nil
+	})
+	if _, err := generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got.Tools[0].Description != req.Tools[0].Description {
+		t.Errorf("lowest level fitting the limit should be used: %s", got.Tools[0].Description)
+	}
+}
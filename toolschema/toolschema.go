@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package toolschema provides tool input schemas built at runtime, eg. with the current valid IDs as enum.
+//
+//	schemas := toolschema.NewRegistry()
+//	schemas.Register("open_project", time.Minute, func(ctx context.Context) (jsonschema.Schema, error) {
+//		ids, err := listProjectIDs(ctx)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return baseSchema.WithEnum("project_id", ids...)
+//	})
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(schemas.Middleware()))
+package toolschema
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// Factory builds the input schema of a tool.
+type Factory func(ctx context.Context) (jsonschema.Schema, error)
+
+type entry struct {
+	factory Factory
+	ttl     time.Duration
+	schema  jsonschema.Schema
+	expires time.Time
+}
+
+// Registry holds schema factories keyed by tool name and caches the built schemas.
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	clock   func() time.Time
+}
+
+// Option configures Registry.
+type Option func(r *Registry)
+
+// WithClock sets the clock used for cache expiration. time.Now by default.
+func WithClock(clock func() time.Time) Option {
+	return func(r *Registry) {
+		r.clock = clock
+	}
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry(opts ...Option) *Registry {
+	r := &Registry{
+		entries: map[string]*entry{},
+		clock:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register registers the schema factory of the tool.
+// The built schema is cached for ttl. ttl 0 builds the schema for every request.
+func (r *Registry) Register(tool string, ttl time.Duration, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[tool] = &entry{factory: factory, ttl: ttl}
+}
+
+// Invalidate clears the cached schemas of the tools, or all tools if none are given.
+func (r *Registry) Invalidate(tools ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, e := range r.entries {
+		if len(tools) == 0 || slices.Contains(tools, name) {
+			e.schema = nil
+		}
+	}
+}
+
+// Schema returns the schema of the tool, building it if the cache is empty or expired.
+// ok is false if no factory is registered for the tool.
+func (r *Registry) Schema(ctx context.Context, tool string) (schema jsonschema.Schema, ok bool, err error) {
+	r.mu.Lock()
+	e, ok := r.entries[tool]
+	if !ok {
+		r.mu.Unlock()
+		return nil, false, nil
+	}
+	now := r.clock()
+	if e.schema != nil && now.Before(e.expires) {
+		schema = e.schema
+		r.mu.Unlock()
+		return schema, true, nil
+	}
+	factory := e.factory
+	r.mu.Unlock()
+
+	schema, err = factory(ctx)
+	if err != nil {
+		return nil, true, fmt.Errorf("build schema of tool %s: %w", tool, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.schema = schema
+	e.expires = now.Add(e.ttl)
+	return schema, true, nil
+}
+
+// Apply returns a copy of the tools with the registered schemas.
+func (r *Registry) Apply(ctx context.Context, tools []chat.Tool) ([]chat.Tool, error) {
+	result := make([]chat.Tool, len(tools))
+	for i, tool := range tools {
+		schema, ok, err := r.Schema(ctx, tool.Name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tool.InputSchema = schema
+		}
+		result[i] = tool
+	}
+	return result, nil
+}
+
+// Middleware replaces the input schemas of the request tools with the registered schemas.
+func (r *Registry) Middleware() chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if len(req.Tools) == 0 {
+				return next(ctx, req)
+			}
+			tools, err := r.Apply(ctx, req.Tools)
+			if err != nil {
+				return nil, err
+			}
+			rq := *req
+			rq.Tools = tools
+			return next(ctx, &rq)
+		}
+	}
+}
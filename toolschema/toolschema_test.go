@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolschema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	base := jsonschema.MustParseJSONString(`{"type": "object", "properties": {"project_id": {"type": "string"}}}`)
+	projects := []any{"p1"}
+	builds := 0
+
+	r := NewRegistry(WithClock(func() time.Time { return now }))
+	r.Register("open_project", time.Minute, func(ctx context.Context) (jsonschema.Schema, error) {
+		builds++
+		return base.WithEnum("project_id", projects...)
+	})
+
+	var got []chat.Tool
+	generate := r.Middleware()(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req.Tools
+		return &chat.Response{}, nil
+	})
+	req := &chat.Request{Tools: []chat.Tool{{Name: "open_project", InputSchema: base}, {Name: "search"}}}
+	call := func() {
+		t.Helper()
+		if _, err := generate(t.Context(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	enum := func() []any {
+		return got[0].InputSchema["properties"].(map[string]any)["project_id"].(map[string]any)["enum"].([]any)
+	}
+
+	call()
+	call()
+	if builds != 1 || len(enum()) != 1 {
+		t.Errorf("schema should be cached: %d builds, enum %v", builds, enum())
+	}
+	if got[1].InputSchema != nil {
+		t.Errorf("tool without factory should not be changed")
+	}
+
+	projects = append(projects, "p2")
+	r.Invalidate("open_project")
+	call()
+	if builds != 2 || len(enum()) != 2 {
+		t.Errorf("schema should be rebuilt after invalidation: %d builds, enum %v", builds, enum())
+	}
+
+	now = now.Add(2 * time.Minute)
+	call()
+	if builds != 3 {
+		t.Errorf("schema should be rebuilt after expiration: %d builds", builds)
+	}
+}
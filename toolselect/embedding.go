@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolselect
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// EmbedFunc returns the embedding vectors of the texts.
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float64, error)
+
+// EmbeddingSelector scores tools by the cosine similarity of the query and tool embeddings.
+// Tool embeddings are cached by tool name and description.
+type EmbeddingSelector struct {
+	embed EmbedFunc
+	mu    sync.Mutex
+	cache map[string][]float64
+}
+
+// NewEmbeddingSelector creates an EmbeddingSelector with the embedding function.
+func NewEmbeddingSelector(embed EmbedFunc) *EmbeddingSelector {
+	return &EmbeddingSelector{embed: embed, cache: map[string][]float64{}}
+}
+
+func (s *EmbeddingSelector) Select(ctx context.Context, req *chat.Request, tools []chat.Tool, k int) ([]chat.Tool, error) {
+	if err := s.embedTools(ctx, tools); err != nil {
+		return nil, err
+	}
+	vectors, err := s.embed(ctx, []string{query(req)})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed query: expected 1 vector, got %d", len(vectors))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scores := make([]float64, len(tools))
+	for i, tool := range tools {
+		scores[i] = cosine(vectors[0], s.cache[toolText(tool)])
+	}
+	return topK(tools, scores, k), nil
+}
+
+func (s *EmbeddingSelector) embedTools(ctx context.Context, tools []chat.Tool) error {
+	s.mu.Lock()
+	missing := []string{}
+	for _, tool := range tools {
+		if _, ok := s.cache[toolText(tool)]; !ok {
+			missing = append(missing, toolText(tool))
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	vectors, err := s.embed(ctx, missing)
+	if err != nil {
+		return fmt.Errorf("embed tools: %w", err)
+	}
+	if len(vectors) != len(missing) {
+		return fmt.Errorf("embed tools: expected %d vectors, got %d", len(missing), len(vectors))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, text := range missing {
+		s.cache[text] = vectors[i]
+	}
+	return nil
+}
+
+func toolText(tool chat.Tool) string {
+	return tool.Name + ": " + tool.Description
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
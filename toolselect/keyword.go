@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolselect
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// KeywordSelector scores tools by the query words matching the tool name and description,
+// weighted by inverse document frequency.
+type KeywordSelector struct{}
+
+// NewKeywordSelector creates a KeywordSelector.
+func NewKeywordSelector() *KeywordSelector {
+	return &KeywordSelector{}
+}
+
+func (s *KeywordSelector) Select(_ context.Context, req *chat.Request, tools []chat.Tool, k int) ([]chat.Tool, error) {
+	docs := make([]map[string]bool, len(tools))
+	df := map[string]int{}
+	for i, tool := range tools {
+		docs[i] = map[string]bool{}
+		for _, w := range words(tool.Name + " " + tool.Description) {
+			if !docs[i][w] {
+				docs[i][w] = true
+				df[w]++
+			}
+		}
+	}
+
+	queryWords := words(query(req))
+	scores := make([]float64, len(tools))
+	for i := range tools {
+		for _, w := range queryWords {
+			if docs[i][w] {
+				scores[i] += math.Log(1 + float64(len(tools))/float64(df[w]))
+			}
+		}
+	}
+	return topK(tools, scores, k), nil
+}
+
+// words splits text into lower case words, splitting snake case names.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
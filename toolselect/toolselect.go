@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package toolselect sends only the tools relevant to the request when many tools are registered.
+//
+//	tools := append(toolselect.Namespace("github", githubTools...), toolselect.Namespace("jira", jiraTools...)...)
+//	req.Tools = tools
+//	resp, err := gengo.Generate(ctx, req, chat.WithMiddleware(toolselect.Middleware(toolselect.NewKeywordSelector(), 8)))
+package toolselect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// NamespaceSeparator separates the namespace and the tool name.
+// Provider tool names allow only letters, digits, "_" and "-".
+const NamespaceSeparator = "__"
+
+// Selector selects up to k tools relevant to the request.
+type Selector interface {
+	Select(ctx context.Context, req *chat.Request, tools []chat.Tool, k int) ([]chat.Tool, error)
+}
+
+// Namespace prefixes the tool names with the namespace, eg. "github__create_issue".
+func Namespace(namespace string, tools ...chat.Tool) []chat.Tool {
+	result := make([]chat.Tool, len(tools))
+	for i, tool := range tools {
+		tool.Name = namespace + NamespaceSeparator + tool.Name
+		result[i] = tool
+	}
+	return result
+}
+
+// SplitName splits a namespaced tool name into the namespace and the name.
+// The namespace is empty if the name is not namespaced.
+func SplitName(name string) (namespace, tool string) {
+	ns, tool, ok := strings.Cut(name, NamespaceSeparator)
+	if !ok {
+		return "", name
+	}
+	return ns, tool
+}
+
+// Middleware replaces the request tools with the top k selected tools when there are more than k tools.
+// Tools already called in the conversation are always kept.
+func Middleware(selector Selector, k int) chat.Middleware {
+	return func(next chat.GenerateFunc) chat.GenerateFunc {
+		return func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+			if len(req.Tools) <= k {
+				return next(ctx, req)
+			}
+			selected, err := selector.Select(ctx, req, req.Tools, k)
+			if err != nil {
+				return nil, fmt.Errorf("select tools: %w", err)
+			}
+			r := *req
+			r.Tools = keepCalledTools(req, selected)
+			return next(ctx, &r)
+		}
+	}
+}
+
+func keepCalledTools(req *chat.Request, selected []chat.Tool) []chat.Tool {
+	names := map[string]bool{}
+	for _, tool := range selected {
+		names[tool.Name] = true
+	}
+	for _, msg := range req.Messages {
+		if !msg.IsToolCall() || names[msg.ToolCall.Name] {
+			continue
+		}
+		for _, tool := range req.Tools {
+			if tool.Name == msg.ToolCall.Name {
+				selected = append(selected, tool)
+				names[tool.Name] = true
+			}
+		}
+	}
+	return selected
+}
+
+// query returns the text of the last human message.
+func query(req *chat.Request) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		msg := req.Messages[i]
+		if msg.Role != chat.MessageRoleHuman {
+			continue
+		}
+		texts := []string{}
+		for _, part := range msg.Content {
+			if part.Type == "text" {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// topK returns the k tools with the highest scores, keeping the original order for ties.
+func topK(tools []chat.Tool, scores []float64, k int) []chat.Tool {
+	idx := make([]int, len(tools))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	if k > len(idx) {
+		k = len(idx)
+	}
+	selected := make([]chat.Tool, 0, k)
+	for _, i := range idx[:k] {
+		selected = append(selected, tools[i])
+	}
+	return selected
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package toolselect
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func testTools() []chat.Tool {
+	tools := Namespace("github",
+		chat.Tool{Name: "create_issue", Description: "Create a GitHub issue in a repository."},
+		chat.Tool{Name: "list_pull_requests", Description: "List pull requests of a repository."},
+	)
+	return append(tools, Namespace("calendar",
+		chat.Tool{Name: "create_event", Description: "Create a calendar event."},
+		chat.Tool{Name: "list_events", Description: "List calendar events of a day."},
+	)...)
+}
+
+func TestNamespace(t *testing.T) {
+	tools := testTools()
+	if tools[0].Name != "github__create_issue" {
+		t.Errorf("name mismatch: %s", tools[0].Name)
+	}
+	ns, name := SplitName(tools[0].Name)
+	if ns != "github" || name != "create_issue" {
+		t.Errorf("split mismatch: %s, %s", ns, name)
+	}
+}
+
+func TestKeywordSelector(t *testing.T) {
+	req := &chat.Request{Messages: []chat.Message{
+		chat.NewTextMessage(chat.MessageRoleHuman, "What events are on my calendar tomorrow?"),
+	}}
+	selected, err := NewKeywordSelector().Select(t.Context(), req, testTools(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || selected[0].Name != "calendar__list_events" || selected[1].Name != "calendar__create_event" {
+		t.Errorf("selection mismatch: %v", selected)
+	}
+}
+
+func TestEmbeddingSelector(t *testing.T) {
+	calls := 0
+	// 2 dimensional embedding: [github, calendar]
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		calls++
+		vectors := make([][]float64, len(texts))
+		for i, text := range texts {
+			text = strings.ToLower(text)
+			vectors[i] = []float64{float64(strings.Count(text, "github") + strings.Count(text, "issue")), float64(strings.Count(text, "calendar"))}
+		}
+		return vectors, nil
+	}
+	selector := NewEmbeddingSelector(embed)
+	req := &chat.Request{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "open an issue")}}
+
+	for range 2 {
+		selected, err := selector.Select(t.Context(), req, testTools(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if selected[0].Name != "github__create_issue" {
+			t.Errorf("selection mismatch: %v", selected)
+		}
+	}
+	// tools once and query twice
+	if calls != 3 {
+		t.Errorf("tool embeddings should be cached: %d calls", calls)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got []chat.Tool
+	generate := Middleware(NewKeywordSelector(), 1)(func(ctx context.Context, req *chat.Request) (*chat.Response, error) {
+		got = req.Tools
+		return &chat.Response{}, nil
+	})
+
+	req := &chat.Request{
+		Tools: testTools(),
+		Messages: []chat.Message{
+			chat.NewTextMessage(chat.MessageRoleHuman, "create an issue"),
+			chat.NewToolCallMessage("github__create_issue", "call_1", "{}"),
+			chat.NewToolResponseMessage("github__create_issue", "call_1", "{}"),
+			chat.NewTextMessage(chat.MessageRoleHuman, "and list calendar events"),
+		},
+	}
+	if _, err := generate(t.Context(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Name != "calendar__list_events" || got[1].Name != "github__create_issue" {
+		t.Errorf("tools mismatch: %v", got)
+	}
+	if len(req.Tools) != 4 {
+		t.Error("request tools should not be modified")
+	}
+}
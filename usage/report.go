@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package usage aggregates token usage and cost into reports.
+//
+//	report := usage.NewReport()
+//	resp, err := gengo.Generate(ctx, req, chat.WithEventHandler(report.Handler()))
+//	groups := report.Group(usage.GroupBy{MetadataKeys: []string{"team"}, Period: usage.Month})
+//	err = usage.WriteCSV(os.Stdout, groups)
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// Period is a time bucket of a report group.
+type Period string
+
+const (
+	Hour  Period = "hour"
+	Day   Period = "day"
+	Month Period = "month"
+)
+
+// Record is the usage of a single generation.
+type Record struct {
+	Time     time.Time     `json:"time"`
+	Model    string        `json:"model"`
+	Provider string        `json:"provider"`
+	Metadata chat.Metadata `json:"metadata,omitempty"`
+	Usage    chat.Usage    `json:"usage"`
+}
+
+// Report accumulates usage records. It is safe for concurrent use.
+type Report struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{}
+}
+
+// Add adds a usage record.
+func (r *Report) Add(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// Records returns a copy of the records.
+func (r *Report) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.records)
+}
+
+// Handler returns an event handler adding a record for each final response.
+func (r *Report) Handler() chat.EventHandler {
+	return func(ev *chat.Event) {
+		if ev.Type != chat.EventFinal || ev.Response == nil || ev.Response.Usage == nil {
+			return
+		}
+		rec := Record{
+			Time:     ev.Time,
+			Model:    ev.Model,
+			Provider: ev.Provider,
+			Usage:    *ev.Response.Usage,
+		}
+		if ev.Request != nil {
+			rec.Metadata = maps.Clone(ev.Request.Metadata)
+		}
+		r.Add(rec)
+	}
+}
+
+// GroupBy specifies the keys of report groups.
+type GroupBy struct {
+	Model        bool
+	Provider     bool
+	MetadataKeys []string
+	// Period buckets records by time in UTC. Empty means no time bucket.
+	Period Period
+}
+
+// Group is the aggregated usage of the records with the same keys.
+type Group struct {
+	Period   string        `json:"period,omitempty"`
+	Model    string        `json:"model,omitempty"`
+	Provider string        `json:"provider,omitempty"`
+	Metadata chat.Metadata `json:"metadata,omitempty"`
+	Requests int           `json:"requests"`
+	Usage    chat.Usage    `json:"usage"`
+}
+
+// Group aggregates the records by the keys. Groups are sorted by period and keys.
+func (r *Report) Group(by GroupBy) []*Group {
+	groups := map[string]*Group{}
+	for _, rec := range r.Records() {
+		g := &Group{Period: formatPeriod(rec.Time, by.Period)}
+		if by.Model {
+			g.Model = rec.Model
+		}
+		if by.Provider {
+			g.Provider = rec.Provider
+		}
+		if len(by.MetadataKeys) > 0 {
+			g.Metadata = chat.Metadata{}
+			for _, k := range by.MetadataKeys {
+				g.Metadata[k] = rec.Metadata[k]
+			}
+		}
+
+		key := g.key(by.MetadataKeys)
+		if existing, ok := groups[key]; ok {
+			g = existing
+		} else {
+			groups[key] = g
+		}
+		g.Requests++
+		g.Usage.Add(&rec.Usage)
+	}
+
+	keys := slices.Collect(maps.Keys(groups))
+	sort.Strings(keys)
+	result := make([]*Group, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, groups[k])
+	}
+	return result
+}
+
+func (g *Group) key(metadataKeys []string) string {
+	parts := []string{g.Period, g.Model, g.Provider}
+	for _, k := range metadataKeys {
+		parts = append(parts, g.Metadata[k])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func formatPeriod(t time.Time, period Period) string {
+	t = t.UTC()
+	switch period {
+	case Hour:
+		return t.Format("2006-01-02T15")
+	case Day:
+		return t.Format(time.DateOnly)
+	case Month:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// WriteJSON writes the groups as JSON.
+func WriteJSON(w io.Writer, groups []*Group) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(groups); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
+
+// WriteCSV writes the groups as CSV with a header row.
+// Metadata columns are prefixed with "metadata.".
+// Costs of groups in several currencies are joined by ";" in the cost and currency columns.
+func WriteCSV(w io.Writer, groups []*Group) error {
+	metadataKeys := []string{}
+	for _, g := range groups {
+		for k := range g.Metadata {
+			if !slices.Contains(metadataKeys, k) {
+				metadataKeys = append(metadataKeys, k)
+			}
+		}
+	}
+	sort.Strings(metadataKeys)
+
+	header := []string{"period", "model", "provider"}
+	for _, k := range metadataKeys {
+		header = append(header, "metadata."+k)
+	}
+	header = append(header, "requests", "input_tokens", "output_tokens", "cached_tokens", "total_tokens", "cost", "currency")
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+	for _, g := range groups {
+		row := []string{g.Period, g.Model, g.Provider}
+		for _, k := range metadataKeys {
+			row = append(row, g.Metadata[k])
+		}
+		row = append(row,
+			strconv.Itoa(g.Requests),
+			strconv.Itoa(g.Usage.InputTokens),
+			strconv.Itoa(g.Usage.OutputTokens),
+			strconv.Itoa(g.Usage.CachedTokens),
+			strconv.Itoa(g.Usage.TotalTokens),
+		)
+		row = append(row, costColumns(&g.Usage)...)
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("write csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// costColumns returns the cost and currency columns of the usage.
+func costColumns(u *chat.Usage) []string {
+	if len(u.Costs) == 0 {
+		return []string{strconv.FormatFloat(u.Cost, 'f', -1, 64), u.Currency}
+	}
+	costs, currencies := []string{}, slices.Sorted(maps.Keys(u.Costs))
+	for _, currency := range currencies {
+		costs = append(costs, strconv.FormatFloat(u.Costs[currency], 'f', -1, 64))
+	}
+	return []string{strings.Join(costs, ";"), strings.Join(currencies, ";")}
+}
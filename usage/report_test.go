@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package usage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestReportGroup(t *testing.T) {
+	report := NewReport()
+	handler := report.Handler()
+
+	emit := func(day int, model, team string, cost float64) {
+		handler(&chat.Event{
+			Type:     chat.EventFinal,
+			Time:     time.Date(2025, time.January, day, 12, 0, 0, 0, time.UTC),
+			Model:    model,
+			Provider: "openai",
+			Request:  &chat.Request{Model: model, Metadata: chat.Metadata{"team": team}},
+			Response: &chat.Response{Usage: &chat.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, Cost: cost}},
+		})
+	}
+	emit(1, "gpt-4o", "search", 0.5)
+	emit(2, "gpt-4o", "search", 0.25)
+	emit(2, "gpt-4o-mini", "support", 0.125)
+	handler(&chat.Event{Type: chat.EventRequest})
+
+	groups := report.Group(GroupBy{MetadataKeys: []string{"team"}, Period: Month})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Metadata["team"] != "search" || groups[0].Requests != 2 || groups[0].Usage.Cost != 0.75 {
+		t.Errorf("search group mismatch: %+v", groups[0])
+	}
+	if groups[0].Period != "2025-01" {
+		t.Errorf("period mismatch: %s", groups[0].Period)
+	}
+
+	groups = report.Group(GroupBy{Model: true, Period: Day})
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[2].Model != "gpt-4o-mini" || groups[2].Period != "2025-01-02" {
+		t.Errorf("group order mismatch: %+v", groups[2])
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	groups := []*Group{
+		{Period: "2025-01", Metadata: chat.Metadata{"team": "search"}, Requests: 2, Usage: chat.Usage{InputTokens: 20, Cost: 0.75}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteCSV(buf, groups); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "period,model,provider,metadata.team,requests,input_tokens,output_tokens,cached_tokens,total_tokens,cost,currency" {
+		t.Errorf("header mismatch: %s", lines[0])
+	}
+	if lines[1] != "2025-01,,,search,2,20,0,0,0,0.75," {
+		t.Errorf("row mismatch: %s", lines[1])
+	}
+}
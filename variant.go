@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+// GenerateVariant generates a response with one of the variant schemas, eg. an answer or a clarification request.
+// Models with native structured output answer with chat.VariantSchema, and other models choose
+// a variant by calling one of chat.VariantTools. The response text is the JSON of the value
+// and Response.Variant is the chosen variant name.
+func GenerateVariant(ctx context.Context, req *chat.Request, variants []chat.ResponseVariant, opts ...chat.Option,
+) (*chat.Response, error) {
+	if err := chat.ValidateResponseVariants(variants); err != nil {
+		return nil, err
+	}
+	info, err := chat.NewOptions(opts...).LookupModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	r := *req
+	r.ResponseSchema = nil
+	if chat.ModelCapabilities(info).StructuredOutput {
+		r.ResponseSchema = chat.VariantSchema(variants)
+	} else {
+		if len(req.Tools) > 0 {
+			return nil, fmt.Errorf("response variants cannot be combined with tools on %s", req.Model)
+		}
+		r.Tools = chat.VariantTools(variants)
+		r.MustCallTool = true
+		for _, v := range variants {
+			ctx = contextWithBuiltinTools(ctx, v.Name)
+		}
+	}
+
+	resp, err := generate(ctx, &r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := chat.ResolveResponseVariant(variants, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+var testVariants = []chat.ResponseVariant{
+	{Name: "answer", Schema: jsonschema.MustParseJSONString(
+		`{"type": "object", "properties": {"text": {"type": "string"}}, "required": ["text"]}`)},
+	{Name: "clarification_request", Description: "Ask the user a question.", Schema: jsonschema.MustParseJSONString(
+		`{"type": "object", "properties": {"question": {"type": "string"}}, "required": ["question"]}`)},
+}
+
+func TestGenerateVariant(t *testing.T) {
+	var got *chat.Request
+	setGenerate(t, fakeJSONModel(`{"variant": "clarification_request", "value": {"question": "Which city?"}}`, &got))
+
+	req := &chat.Request{Model: "gpt-4o-mini", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather?")}}
+	resp, err := GenerateVariant(context.Background(), req, testVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ResponseSchema == nil || got.Tools != nil {
+		t.Errorf("native structured output should use the variant schema: %+v", got)
+	}
+	if resp.Variant != "clarification_request" || resp.Text() != `{"question": "Which city?"}` {
+		t.Errorf("response mismatch: %s %s", resp.Variant, resp.Text())
+	}
+}
+
+func TestGenerateVariantWithTools(t *testing.T) {
+	var got *chat.Request
+	setGenerate(t, func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		got = req
+		return &chat.Response{
+			FinishReason: chat.FinishReasonToolUse,
+			Messages:     []chat.Message{chat.NewToolCallMessage("answer", "call_1", `{"text": "Sunny."}`)},
+		}, nil
+	})
+
+	req := &chat.Request{Model: "claude-3-5-haiku-latest", Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleHuman, "weather in Tokyo?")}}
+	resp, err := GenerateVariant(context.Background(), req, testVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Tools) != 2 || !got.MustCallTool || got.ResponseSchema != nil {
+		t.Errorf("variants should be emulated with tools: %+v", got)
+	}
+	if resp.Variant != "answer" || resp.Text() != `{"text": "Sunny."}` || resp.FirstToolCall() != nil || resp.FinishReason != chat.FinishReasonStop {
+		t.Errorf("response mismatch: %+v", resp)
+	}
+}
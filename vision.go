@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// ErrVisionNotSupported is returned when the model does not support image input.
+var ErrVisionNotSupported = errors.New("model does not support vision")
+
+// BoundingBox is a box normalized to 0-1 of the image width and height.
+type BoundingBox struct {
+	XMin float64 `json:"x_min"`
+	YMin float64 `json:"y_min"`
+	XMax float64 `json:"x_max"`
+	YMax float64 `json:"y_max"`
+}
+
+// Box2D converts a Gemini box_2d, [ymin, xmin, ymax, xmax] normalized to 0-1000, to a BoundingBox.
+// Returns nil if the box is not 4 values.
+func Box2D(box []float64) *BoundingBox {
+	if len(box) != 4 {
+		return nil
+	}
+	return &BoundingBox{XMin: box[1] / 1000, YMin: box[0] / 1000, XMax: box[3] / 1000, YMax: box[2] / 1000}
+}
+
+// TextBlock is a block of text recognized in an image.
+type TextBlock struct {
+	Text string `json:"text"`
+	// Box is nil if the provider does not supply bounding boxes.
+	Box *BoundingBox `json:"box,omitempty"`
+}
+
+// OCRResult is the text recognized in an image.
+type OCRResult struct {
+	Text   string      `json:"text"`
+	Blocks []TextBlock `json:"blocks"`
+	Usage  *chat.Usage `json:"usage,omitempty"`
+}
+
+// CaptionResult is a description of an image.
+type CaptionResult struct {
+	Caption string      `json:"caption"`
+	Tags    []string    `json:"tags"`
+	Usage   *chat.Usage `json:"usage,omitempty"`
+}
+
+const ocrPrompt = `Extract all text in the image as blocks in reading order, keeping line breaks.
+Return the full text and each block.`
+
+const ocrBoxPrompt = ocrPrompt + `
+Include box_2d of each block as [ymin, xmin, ymax, xmax] normalized to 0-1000.`
+
+const captionPrompt = `Describe the image in one or two sentences and list a few tags of its content.`
+
+// OCR recognizes the text in the image file with a vision-capable model.
+// Blocks have bounding boxes with Gemini models, which are trained to return them.
+func OCR(ctx context.Context, imagePath, model string, opts ...chat.Option) (*OCRResult, error) {
+	info, err := visionModel(model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	prompt, blockSchema := ocrPrompt, `{"type": "object", "properties": {"text": {"type": "string"}}, "required": ["text"]}`
+	if info.Provider == "gemini" {
+		prompt = ocrBoxPrompt
+		blockSchema = `{"type": "object", "properties": {"text": {"type": "string"},
+			"box_2d": {"type": "array", "items": {"type": "number"}}}, "required": ["text"]}`
+	}
+	schema := jsonschema.MustParseJSONString(`{"type": "object", "properties": {
+		"text": {"type": "string"},
+		"blocks": {"type": "array", "items": ` + blockSchema + `}
+	}, "required": ["text", "blocks"]}`)
+
+	var out struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Text  string    `json:"text"`
+			Box2D []float64 `json:"box_2d"`
+		} `json:"blocks"`
+	}
+	usage, err := generateImageJSON(ctx, model, imagePath, prompt, schema, &out, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ocr: %w", err)
+	}
+
+	result := &OCRResult{Text: out.Text, Blocks: make([]TextBlock, len(out.Blocks)), Usage: usage}
+	for i, b := range out.Blocks {
+		result.Blocks[i] = TextBlock{Text: b.Text, Box: Box2D(b.Box2D)}
+	}
+	return result, nil
+}
+
+// Caption describes the image file with a vision-capable model.
+func Caption(ctx context.Context, imagePath, model string, opts ...chat.Option) (*CaptionResult, error) {
+	if _, err := visionModel(model, opts...); err != nil {
+		return nil, err
+	}
+	schema := jsonschema.MustParseJSONString(`{"type": "object", "properties": {
+		"caption": {"type": "string"},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}, "required": ["caption", "tags"]}`)
+
+	result := &CaptionResult{}
+	usage, err := generateImageJSON(ctx, model, imagePath, captionPrompt, schema, result, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("caption: %w", err)
+	}
+	result.Usage = usage
+	return result, nil
+}
+
+func visionModel(model string, opts ...chat.Option) (*chat.ModelInfo, error) {
+	info, err := chat.NewOptions(opts...).LookupModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if !info.SupportsVision {
+		return nil, fmt.Errorf("%w: %s", ErrVisionNotSupported, model)
+	}
+	return info, nil
+}
+
+func generateImageJSON(ctx context.Context, model, imagePath, prompt string, schema jsonschema.Schema,
+	out any, opts ...chat.Option,
+) (*chat.Usage, error) {
+	msg, err := chat.NewTextImageMessage(chat.MessageRoleHuman, prompt, imagePath)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := generate(ctx, &chat.Request{
+		Model:          model,
+		Messages:       []chat.Message{msg},
+		ResponseSchema: schema,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.JSON(out); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	return resp.Usage, nil
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+// Package vision detects objects in images with Gemini bounding-box detection.
+//
+//	detections, err := vision.Detect(ctx, "photo.jpg", []string{"cat", "dog"})
+//	for _, d := range detections {
+//		fmt.Println(d.Label, d.Box.XMin, d.Box.YMin)
+//	}
+package vision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+	"github.com/jumonmd/gengo/jsonschema"
+)
+
+// DefaultModel is the default detection model.
+const DefaultModel = "gemini-2.0-flash"
+
+// ErrUnsupportedModel is returned when the model is not a Gemini vision model.
+var ErrUnsupportedModel = errors.New("model does not support bounding-box detection")
+
+const detectPrompt = `Detect the objects of the following classes in the image: %s.
+Return each object with its label and box_2d as [ymin, xmin, ymax, xmax] normalized to 0-1000.
+Return an empty list if there are none.`
+
+// Detection is a detected object.
+type Detection struct {
+	Label string `json:"label"`
+	// Box is normalized to 0-1 of the image width and height.
+	Box gengo.BoundingBox `json:"box"`
+}
+
+type detector struct {
+	model      string
+	maxObjects int
+	generate   gengo.GenerateFunc
+	opts       []chat.Option
+}
+
+// Option configures Detect.
+type Option func(d *detector)
+
+// WithModel sets the Gemini model. DefaultModel by default.
+func WithModel(model string) Option {
+	return func(d *detector) {
+		d.model = model
+	}
+}
+
+// WithMaxObjects limits the number of detections.
+func WithMaxObjects(n int) Option {
+	return func(d *detector) {
+		d.maxObjects = n
+	}
+}
+
+// WithGenerator sets the generate function.
+func WithGenerator(generate gengo.GenerateFunc) Option {
+	return func(d *detector) {
+		d.generate = generate
+	}
+}
+
+// WithOptions sets the options of the request.
+func WithOptions(opts ...chat.Option) Option {
+	return func(d *detector) {
+		d.opts = opts
+	}
+}
+
+// Detect detects the objects of the classes in the image file.
+// Detections with labels other than the classes are dropped.
+func Detect(ctx context.Context, imagePath string, classes []string, opts ...Option) ([]Detection, error) {
+	d := &detector{model: DefaultModel, generate: gengo.Generate}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if len(classes) == 0 {
+		return nil, errors.New("detect: no classes")
+	}
+	info := chat.NewOptions(d.opts...).ModelCatalog.GetModel(d.model)
+	if info == nil || info.Provider != "gemini" || !info.SupportsVision {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedModel, d.model)
+	}
+
+	msg, err := chat.NewTextImageMessage(chat.MessageRoleHuman, fmt.Sprintf(detectPrompt, strings.Join(classes, ", ")), imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("detect: %w", err)
+	}
+	resp, err := d.generate(ctx, &chat.Request{
+		Model:          d.model,
+		Messages:       []chat.Message{msg},
+		ResponseSchema: detectSchema(classes),
+	}, d.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("detect: %w", err)
+	}
+
+	var out []struct {
+		Label string    `json:"label"`
+		Box2D []float64 `json:"box_2d"`
+	}
+	if err := resp.JSON(&out); err != nil {
+		return nil, fmt.Errorf("detect: %w", err)
+	}
+	detections := []Detection{}
+	for _, o := range out {
+		box := gengo.Box2D(o.Box2D)
+		if box == nil || !slices.Contains(classes, o.Label) {
+			continue
+		}
+		detections = append(detections, Detection{Label: o.Label, Box: normalizeBox(*box)})
+	}
+	if d.maxObjects > 0 && len(detections) > d.maxObjects {
+		detections = detections[:d.maxObjects]
+	}
+	return detections, nil
+}
+
+func detectSchema(classes []string) jsonschema.Schema {
+	labels := make([]any, len(classes))
+	for i, c := range classes {
+		labels[i] = c
+	}
+	return jsonschema.Schema{
+		"type": "array",
+		"items": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"label":  map[string]any{"type": "string", "enum": labels},
+				"box_2d": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			},
+			"required": []any{"label", "box_2d"},
+		},
+	}
+}
+
+// normalizeBox orders the corners and clamps them to 0-1.
+func normalizeBox(b gengo.BoundingBox) gengo.BoundingBox {
+	clamp := func(v float64) float64 { return min(max(v, 0), 1) }
+	return gengo.BoundingBox{
+		XMin: clamp(min(b.XMin, b.XMax)),
+		YMin: clamp(min(b.YMin, b.YMax)),
+		XMax: clamp(max(b.XMin, b.XMax)),
+		YMax: clamp(max(b.YMin, b.YMax)),
+	}
+}
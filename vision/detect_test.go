@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package vision
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jumonmd/gengo"
+	"github.com/jumonmd/gengo/chat"
+)
+
+func TestDetect(t *testing.T) {
+	var got *chat.Request
+	fake := func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		got = req
+		return &chat.Response{Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, `[
+			{"label": "logo", "box_2d": [100, 200, 300, 800]},
+			{"label": "text", "box_2d": [500, 900, 400, 1200]},
+			{"label": "cat", "box_2d": [0, 0, 10, 10]},
+			{"label": "logo", "box_2d": [1, 2]}
+		]`)}}, nil
+	}
+
+	detections, err := Detect(context.Background(), "../testdata/image.png", []string{"logo", "text"}, WithGenerator(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Detection{
+		{Label: "logo", Box: gengo.BoundingBox{XMin: 0.2, YMin: 0.1, XMax: 0.8, YMax: 0.3}},
+		{Label: "text", Box: gengo.BoundingBox{XMin: 0.9, YMin: 0.4, XMax: 1, YMax: 0.5}},
+	}
+	if len(detections) != len(want) {
+		t.Fatalf("detections mismatch: %+v", detections)
+	}
+	for i := range want {
+		if detections[i] != want[i] {
+			t.Errorf("detection %d = %+v, want %+v", i, detections[i], want[i])
+		}
+	}
+	if got.Model != DefaultModel || got.ResponseSchema == nil {
+		t.Errorf("request mismatch: %+v", got)
+	}
+
+	detections, err = Detect(context.Background(), "../testdata/image.png", []string{"logo", "text"}, WithGenerator(fake), WithMaxObjects(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detections) != 1 {
+		t.Errorf("detections should be limited: %+v", detections)
+	}
+}
+
+func TestDetectUnsupportedModel(t *testing.T) {
+	_, err := Detect(context.Background(), "../testdata/image.png", []string{"logo"}, WithModel("gpt-4o-mini"))
+	if !errors.Is(err, ErrUnsupportedModel) {
+		t.Errorf("expected ErrUnsupportedModel, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 Masa Cento
+// SPDX-License-Identifier: MIT
+
+package gengo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jumonmd/gengo/chat"
+)
+
+func fakeJSONModel(text string, got **chat.Request) func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+	return func(ctx context.Context, req *chat.Request, opts ...chat.Option) (*chat.Response, error) {
+		*got = req
+		return &chat.Response{
+			Model:    req.Model,
+			Messages: []chat.Message{chat.NewTextMessage(chat.MessageRoleAI, text)},
+			Usage:    &chat.Usage{TotalTokens: 10},
+		}, nil
+	}
+}
+
+func TestOCR(t *testing.T) {
+	var got *chat.Request
+	setGenerate(t, fakeJSONModel(`{"text": "GENGO", "blocks": [{"text": "GENGO", "box_2d": [100, 200, 300, 800]}]}`, &got))
+
+	result, err := OCR(context.Background(), "testdata/image.png", "gemini-2.0-flash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "GENGO" || len(result.Blocks) != 1 || result.Usage.TotalTokens != 10 {
+		t.Fatalf("result mismatch: %+v", result)
+	}
+	want := BoundingBox{XMin: 0.2, YMin: 0.1, XMax: 0.8, YMax: 0.3}
+	if box := result.Blocks[0].Box; box == nil || *box != want {
+		t.Errorf("box mismatch: %+v", box)
+	}
+	if !strings.Contains(got.Messages[0].Content[0].Text, "box_2d") || got.ResponseSchema == nil {
+		t.Errorf("request should ask for boxes with a schema: %+v", got)
+	}
+}
+
+func TestOCRWithoutBoxes(t *testing.T) {
+	var got *chat.Request
+	setGenerate(t, fakeJSONModel(`{"text": "GENGO", "blocks": [{"text": "GENGO"}]}`, &got))
+
+	result, err := OCR(context.Background(), "testdata/image.png", "gpt-4o-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Blocks[0].Box != nil {
+		t.Errorf("box should be nil: %+v", result.Blocks[0].Box)
+	}
+	if strings.Contains(got.Messages[0].Content[0].Text, "box_2d") {
+		t.Error("request should not ask for boxes")
+	}
+}
+
+func TestCaption(t *testing.T) {
+	var got *chat.Request
+	setGenerate(t, fakeJSONModel(`{"caption": "A logo.", "tags": ["logo", "text"]}`, &got))
+
+	result, err := Caption(context.Background(), "testdata/image.png", "gpt-4o-mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Caption != "A logo." || len(result.Tags) != 2 {
+		t.Errorf("result mismatch: %+v", result)
+	}
+
+	if _, err := Caption(context.Background(), "testdata/image.png", "gpt-3.5-turbo"); !errors.Is(err, ErrVisionNotSupported) {
+		t.Errorf("expected ErrVisionNotSupported, got %v", err)
+	}
+}